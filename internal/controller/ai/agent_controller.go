@@ -6,8 +6,6 @@ import (
 	"log"
 	"net/http"
 
-	service "lumenslate/internal/grpc_service"
-
 	"github.com/gin-gonic/gin"
 )
 
@@ -17,15 +15,20 @@ import (
 // @Tags         AI Agent
 // @Accept       multipart/form-data
 // @Produce      json
-// @Param        teacherId  formData  string  true   "Teacher ID for context and personalization"
+// @Param        X-User-ID  header    string  true   "ID of the calling teacher"
+// @Param        teacherId  formData  string  true   "Teacher ID for context and personalization; must match X-User-ID"
 // @Param        role       formData  string  true   "Role/context for the AI agent processing"
 // @Param        message    formData  string  true   "Message or prompt for the AI agent"
 // @Param        file       formData  file    false  "Optional file upload for processing"
 // @Param        fileType   formData  string  false  "Type of the uploaded file (if file is provided)"
 // @Param        createdAt  formData  string  false  "Creation timestamp (ISO format)"
 // @Param        updatedAt  formData  string  false  "Update timestamp (ISO format)"
+// @Param        sessionId  formData  string  false  "Session to continue; a new session is started when omitted"
 // @Success      200        {object}  map[string]interface{}  "AI agent response with processed data and metadata"
 // @Failure      400        {object}  map[string]interface{}  "Invalid request body, missing required fields, or file processing error"
+// @Failure      401        {object}  map[string]interface{}  "Missing or unknown X-User-ID header"
+// @Failure      403        {object}  map[string]interface{}  "Caller is not a teacher, teacherId is not the caller, or the session belongs to another teacher"
+// @Failure      404        {object}  map[string]interface{}  "Session not found"
 // @Failure      500        {object}  map[string]interface{}  "Internal server error during AI processing"
 // @Router       /ai/agent [post]
 func AgentHandler(c *gin.Context) {
@@ -38,40 +41,25 @@ func AgentHandler(c *gin.Context) {
 	}
 	log.Printf("[AI] Agent Request: %+v", req)
 
-	// Process file upload if present
-	var fileContent string
-	if req.File != nil {
-		// Open the uploaded file
-		file, err := req.File.Open()
-		if err != nil {
-			log.Printf("[AI] Error opening uploaded file: %v", err)
-			c.JSON(http.StatusBadRequest, gin.H{"error": "Failed to open uploaded file"})
-			return
-		}
-		defer file.Close()
-
-		// Read file content
-		fileBytes, err := io.ReadAll(file)
-		if err != nil {
-			log.Printf("[AI] Error reading file content: %v", err)
-			c.JSON(http.StatusBadRequest, gin.H{"error": "Failed to read file content"})
-			c.JSON(http.StatusBadRequest, gin.H{"error": "Failed to read file content"})
-			return
-		}
+	caller, session, ok := resolveAgentRequestSession(c, &req)
+	if !ok {
+		return
+	}
 
-		// Convert to base64 string for service layer
-		fileContent = base64.StdEncoding.EncodeToString(fileBytes)
-		log.Printf("[AI] File processed: %s, size: %d bytes", req.File.Filename, len(fileBytes))
+	fileContent, ok := readAgentFile(c, &req)
+	if !ok {
+		return
 	}
 
-	resp, err := service.LumenAgent(
+	resp, err := aiServiceFor(c).LumenAgent(
 		fileContent,
 		req.FileType,
-		req.TeacherId,
+		caller.ID,
 		req.Role,
 		req.Message,
 		req.CreatedAt,
 		req.UpdatedAt,
+		serviceSessionID(session),
 	)
 	if err != nil {
 		log.Printf("[AI] Agent error: %v", err)
@@ -79,5 +67,33 @@ func AgentHandler(c *gin.Context) {
 		return
 	}
 	log.Printf("[AI] Agent success")
-	c.JSON(http.StatusOK, resp)
+	c.JSON(http.StatusOK, recordAgentExchange(c.Request.Context(), caller.ID, session, &req, resp))
+}
+
+// readAgentFile returns the uploaded file base64 encoded for the service layer, or "" when none was sent.
+// It writes the error response and returns false when the file cannot be read.
+func readAgentFile(c *gin.Context, req *AgentRequest) (string, bool) {
+	if req.File == nil {
+		return "", true
+	}
+
+	// Open the uploaded file
+	file, err := req.File.Open()
+	if err != nil {
+		log.Printf("[AI] Error opening uploaded file: %v", err)
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Failed to open uploaded file"})
+		return "", false
+	}
+	defer file.Close()
+
+	// Read file content
+	fileBytes, err := io.ReadAll(file)
+	if err != nil {
+		log.Printf("[AI] Error reading file content: %v", err)
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Failed to read file content"})
+		return "", false
+	}
+
+	log.Printf("[AI] File processed: %s, size: %d bytes", req.File.Filename, len(fileBytes))
+	return base64.StdEncoding.EncodeToString(fileBytes), true
 }
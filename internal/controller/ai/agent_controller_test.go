@@ -0,0 +1,170 @@
+package ai_test
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"lumenslate/internal/middleware"
+	pb "lumenslate/internal/proto/ai_service"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// agentForm is an /ai/agent request from teacherID
+func agentForm(teacherID, message string) map[string]string {
+	return map[string]string{
+		"teacherId": teacherID,
+		"role":      "teacher",
+		"message":   message,
+	}
+}
+
+func TestAgentHandler(t *testing.T) {
+	resetFake(t)
+	teacherID := createTeacher(t)
+	headers := callerHeaders(teacherID)
+
+	body := decodeBody(t, postForm(t, "/api/v1/ai/agent", agentForm(teacherID, "Hello"), headers), http.StatusOK)
+
+	if body["payloadType"] != "text" {
+		t.Errorf("payloadType = %v, want text", body["payloadType"])
+	}
+	if body["agentName"] != "general_chat_agent" {
+		t.Errorf("agentName = %v, want the text payload's agent", body["agentName"])
+	}
+	sessionID, _ := body["sessionId"].(string)
+	if _, err := primitive.ObjectIDFromHex(sessionID); err != nil {
+		t.Fatalf("sessionId = %q, want the stored session instead of the AI service session", sessionID)
+	}
+	if _, ok := body["messageId"].(string); !ok {
+		t.Errorf("response %v has no messageId", body)
+	}
+
+	req := fake.Calls("LumenAgent")[0].Request.(*pb.AgentRequest)
+	if req.GetTeacherId() != teacherID || req.GetMessage() != "Hello" {
+		t.Errorf("request = %v, want the teacher and message sent", req)
+	}
+
+	// Continuing the conversation keeps the stored session
+	form := agentForm(teacherID, "And another thing")
+	form["sessionId"] = sessionID
+	body = decodeBody(t, postForm(t, "/api/v1/ai/agent", form, headers), http.StatusOK)
+	if body["sessionId"] != sessionID {
+		t.Errorf("sessionId = %v, want the continued session %s", body["sessionId"], sessionID)
+	}
+}
+
+func TestAgentHandlerReadsLegacyTextResponses(t *testing.T) {
+	resetFake(t)
+	teacherID := createTeacher(t)
+	fake.SetResponse("LumenAgent", &pb.AgentResponse{
+		Message:       "success",
+		AgentName:     "root_agent",
+		AgentResponse: "Plain text from an agent predating schema versions",
+	})
+
+	body := decodeBody(t, postForm(t, "/api/v1/ai/agent", agentForm(teacherID, "Hello"), callerHeaders(teacherID)), http.StatusOK)
+
+	if body["payloadType"] != "text" {
+		t.Errorf("payloadType = %v, want text", body["payloadType"])
+	}
+}
+
+func TestAgentHandlerRejectsAnotherTeachersSession(t *testing.T) {
+	resetFake(t)
+	ownerID := createTeacher(t)
+	otherID := createTeacher(t)
+
+	body := decodeBody(t, postForm(t, "/api/v1/ai/agent", agentForm(ownerID, "Hello"), callerHeaders(ownerID)), http.StatusOK)
+	sessionID := body["sessionId"].(string)
+
+	form := agentForm(otherID, "Let me in")
+	form["sessionId"] = sessionID
+	decodeBody(t, postForm(t, "/api/v1/ai/agent", form, callerHeaders(otherID)), http.StatusForbidden)
+
+	if calls := fake.Calls("LumenAgent"); len(calls) != 1 {
+		t.Errorf("LumenAgent called %d times, want only the owner's call", len(calls))
+	}
+}
+
+func TestAgentHandlerRejectsImpersonation(t *testing.T) {
+	resetFake(t)
+	callerID := createTeacher(t)
+	otherID := createTeacher(t)
+
+	decodeBody(t, postForm(t, "/api/v1/ai/agent", agentForm(otherID, "Hello"), callerHeaders(callerID)), http.StatusForbidden)
+	decodeBody(t, postForm(t, "/api/v1/ai/agent", agentForm(otherID, "Hello"), nil), http.StatusUnauthorized)
+
+	if calls := fake.Calls("LumenAgent"); len(calls) != 0 {
+		t.Errorf("LumenAgent called %d times for a request on behalf of another teacher", len(calls))
+	}
+}
+
+func TestAgentHandlerRejectsUnsignedCaller(t *testing.T) {
+	resetFake(t)
+	teacherID := createTeacher(t)
+
+	decodeBody(t, postForm(t, "/api/v1/ai/agent", agentForm(teacherID, "Hello"), map[string]string{"X-User-ID": teacherID}), http.StatusUnauthorized)
+
+	forged := callerHeaders(teacherID)
+	forged["X-User-Signature"] = middleware.SignCallerID("another-key", teacherID, time.Now().Add(time.Minute))
+	decodeBody(t, postForm(t, "/api/v1/ai/agent", agentForm(teacherID, "Hello"), forged), http.StatusUnauthorized)
+
+	expired := callerHeaders(teacherID)
+	expired["X-User-Signature"] = middleware.SignCallerID(callerSigningKey, teacherID, time.Now().Add(-time.Minute))
+	decodeBody(t, postForm(t, "/api/v1/ai/agent", agentForm(teacherID, "Hello"), expired), http.StatusUnauthorized)
+
+	if calls := fake.Calls("LumenAgent"); len(calls) != 0 {
+		t.Errorf("LumenAgent called %d times for an unverified caller", len(calls))
+	}
+}
+
+func TestAgentHandlerReportsAIServiceErrors(t *testing.T) {
+	resetFake(t)
+	teacherID := createTeacher(t)
+	fake.SetError("LumenAgent", status.Error(codes.InvalidArgument, "message too long"))
+
+	// Agent failures are answered as an agent reply carrying the error
+	body := decodeBody(t, postForm(t, "/api/v1/ai/agent", agentForm(teacherID, "Hello"), callerHeaders(teacherID)), http.StatusOK)
+	if message, _ := body["message"].(string); !strings.Contains(message, "message too long") {
+		t.Errorf("message = %v, want the AI service error", body["message"])
+	}
+}
+
+func TestAgentHandlerRequiresFields(t *testing.T) {
+	resetFake(t)
+
+	decodeBody(t, postForm(t, "/api/v1/ai/agent", map[string]string{"message": "Hello"}, nil), http.StatusBadRequest)
+	if calls := fake.Calls(""); len(calls) != 0 {
+		t.Errorf("AI service called %d times for an invalid request", len(calls))
+	}
+}
+
+func TestAgentStreamHandler(t *testing.T) {
+	resetFake(t)
+	teacherID := createTeacher(t)
+
+	events := readEvents(t, postForm(t, "/api/v1/ai/agent/stream", agentForm(teacherID, "Hello"), callerHeaders(teacherID)))
+
+	tokens := 0
+	for _, event := range events {
+		if event.Name == "token" {
+			tokens++
+		}
+	}
+	if tokens == 0 {
+		t.Errorf("no token events in %v", events)
+	}
+
+	result := lastEvent(t, events, "result")
+	if result["payloadType"] != "text" {
+		t.Errorf("payloadType = %v, want text", result["payloadType"])
+	}
+	if _, err := primitive.ObjectIDFromHex(result["sessionId"].(string)); err != nil {
+		t.Errorf("sessionId = %v, want the stored session", result["sessionId"])
+	}
+}
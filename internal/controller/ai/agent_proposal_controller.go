@@ -0,0 +1,268 @@
+package ai
+
+import (
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+
+	service "lumenslate/internal/grpc_service"
+	"lumenslate/internal/model"
+	"lumenslate/internal/repository"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// ListAgentProposalsHandler godoc
+// @Summary      List Agent Proposals
+// @Description  List the database writes the agent proposed to the calling teacher, newest first.
+// @Tags         AI Agent
+// @Produce      json
+// @Param        status  query  string  false  "Only proposals with this status (pending, approved, rejected)"
+// @Param        limit   query  int     false  "Maximum number of proposals to return (default 20)"
+// @Param        offset  query  int     false  "Number of proposals to skip"
+// @Success      200  {object}  map[string]interface{}  "Proposals and total count"
+// @Failure      401  {object}  map[string]interface{}  "Missing or unknown X-User-ID header"
+// @Failure      403  {object}  map[string]interface{}  "Caller is not a teacher"
+// @Failure      500  {object}  map[string]interface{}  "Failed to list proposals"
+// @Router       /ai/agent/proposals [get]
+func ListAgentProposalsHandler(c *gin.Context) {
+	caller, ok := requireTeacher(c)
+	if !ok {
+		return
+	}
+
+	limit, _ := strconv.ParseInt(c.DefaultQuery("limit", "20"), 10, 64)
+	offset, _ := strconv.ParseInt(c.DefaultQuery("offset", "0"), 10, 64)
+	if limit <= 0 || limit > 100 {
+		limit = 20
+	}
+	if offset < 0 {
+		offset = 0
+	}
+
+	proposals, total, err := repository.NewAgentProposalRepository().List(c.Request.Context(), caller.ID, c.Query("status"), limit, offset)
+	if err != nil {
+		log.Printf("[AI] Failed to list agent proposals for %s: %v", caller.ID, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to list proposals"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"proposals": proposals,
+		"total":     total,
+		"limit":     limit,
+		"offset":    offset,
+	})
+}
+
+// GetAgentProposalHandler godoc
+// @Summary      Get Agent Proposal
+// @Description  Get a proposal with the record it writes, a field-by-field preview and its history.
+// @Tags         AI Agent
+// @Produce      json
+// @Param        proposalId  path  string  true  "Proposal ID"
+// @Success      200  {object}  model.AgentProposal     "Proposal"
+// @Failure      400  {object}  map[string]interface{}  "Invalid proposal ID"
+// @Failure      401  {object}  map[string]interface{}  "Missing or unknown X-User-ID header"
+// @Failure      403  {object}  map[string]interface{}  "Proposal belongs to another teacher"
+// @Failure      404  {object}  map[string]interface{}  "Proposal not found"
+// @Router       /ai/agent/proposals/{proposalId} [get]
+func GetAgentProposalHandler(c *gin.Context) {
+	caller, ok := requireTeacher(c)
+	if !ok {
+		return
+	}
+	proposal, ok := loadAgentProposal(c, caller.ID)
+	if !ok {
+		return
+	}
+	c.JSON(http.StatusOK, proposal)
+}
+
+// ApproveAgentProposalHandler godoc
+// @Summary      Approve Agent Proposal
+// @Description  Write the record of a pending proposal. The approval is recorded in the proposal history together with the optional note.
+// @Tags         AI Agent
+// @Accept       json
+// @Produce      json
+// @Param        proposalId  path  string                           true   "Proposal ID"
+// @Param        body        body  ai.AgentProposalDecisionRequest  false  "Optional note"
+// @Success      200  {object}  map[string]interface{}  "Approved proposal and the written record"
+// @Failure      400  {object}  map[string]interface{}  "Invalid proposal ID or request body"
+// @Failure      401  {object}  map[string]interface{}  "Missing or unknown X-User-ID header"
+// @Failure      403  {object}  map[string]interface{}  "Proposal belongs to another teacher"
+// @Failure      404  {object}  map[string]interface{}  "Proposal not found"
+// @Failure      409  {object}  map[string]interface{}  "Proposal is no longer pending"
+// @Failure      500  {object}  map[string]interface{}  "Failed to write the proposal"
+// @Router       /ai/agent/proposals/{proposalId}/approve [post]
+func ApproveAgentProposalHandler(c *gin.Context) {
+	req, ok := bindAgentProposalDecision(c)
+	if !ok {
+		return
+	}
+	caller, ok := requireTeacher(c)
+	if !ok {
+		return
+	}
+	proposal, ok := loadAgentProposal(c, caller.ID)
+	if !ok {
+		return
+	}
+
+	approved, result, err := service.ApproveAgentProposal(c.Request.Context(), proposal.ID, caller.ID, req.Note)
+	if err != nil {
+		respondAgentProposalError(c, proposal.ID, "approve", err)
+		return
+	}
+
+	log.Printf("[AI] Teacher %s approved agent proposal %s (%s), wrote %s", caller.ID, proposal.ID.Hex(), proposal.PayloadType, approved.CommittedID)
+	c.JSON(http.StatusOK, gin.H{
+		"proposal": approved,
+		"result":   result,
+	})
+}
+
+// RejectAgentProposalHandler godoc
+// @Summary      Reject Agent Proposal
+// @Description  Close a pending proposal without writing it. The rejection is recorded in the proposal history together with the optional note.
+// @Tags         AI Agent
+// @Accept       json
+// @Produce      json
+// @Param        proposalId  path  string                           true   "Proposal ID"
+// @Param        body        body  ai.AgentProposalDecisionRequest  false  "Optional note"
+// @Success      200  {object}  model.AgentProposal     "Rejected proposal"
+// @Failure      400  {object}  map[string]interface{}  "Invalid proposal ID or request body"
+// @Failure      401  {object}  map[string]interface{}  "Missing or unknown X-User-ID header"
+// @Failure      403  {object}  map[string]interface{}  "Proposal belongs to another teacher"
+// @Failure      404  {object}  map[string]interface{}  "Proposal not found"
+// @Failure      409  {object}  map[string]interface{}  "Proposal is no longer pending"
+// @Failure      500  {object}  map[string]interface{}  "Failed to reject the proposal"
+// @Router       /ai/agent/proposals/{proposalId}/reject [post]
+func RejectAgentProposalHandler(c *gin.Context) {
+	req, ok := bindAgentProposalDecision(c)
+	if !ok {
+		return
+	}
+	caller, ok := requireTeacher(c)
+	if !ok {
+		return
+	}
+	proposal, ok := loadAgentProposal(c, caller.ID)
+	if !ok {
+		return
+	}
+
+	rejected, err := service.RejectAgentProposal(c.Request.Context(), proposal.ID, caller.ID, req.Note)
+	if err != nil {
+		respondAgentProposalError(c, proposal.ID, "reject", err)
+		return
+	}
+
+	log.Printf("[AI] Teacher %s rejected agent proposal %s (%s)", caller.ID, proposal.ID.Hex(), proposal.PayloadType)
+	c.JSON(http.StatusOK, rejected)
+}
+
+// EditAgentProposalHandler godoc
+// @Summary      Edit Agent Proposal
+// @Description  Change fields of the record a pending proposal writes, e.g. the title or due date of an assignment or the score of a report. Fields are named as in the proposal's record; identifiers and timestamps cannot be changed. The changed fields are recorded in the proposal history.
+// @Tags         AI Agent
+// @Accept       json
+// @Produce      json
+// @Param        proposalId  path  string                       true  "Proposal ID"
+// @Param        body        body  ai.EditAgentProposalRequest  true  "Fields to change and optional note"
+// @Success      200  {object}  model.AgentProposal     "Updated proposal"
+// @Failure      400  {object}  map[string]interface{}  "Invalid proposal ID, request body or edit"
+// @Failure      401  {object}  map[string]interface{}  "Missing or unknown X-User-ID header"
+// @Failure      403  {object}  map[string]interface{}  "Proposal belongs to another teacher"
+// @Failure      404  {object}  map[string]interface{}  "Proposal not found"
+// @Failure      409  {object}  map[string]interface{}  "Proposal is no longer pending"
+// @Failure      500  {object}  map[string]interface{}  "Failed to edit the proposal"
+// @Router       /ai/agent/proposals/{proposalId} [patch]
+func EditAgentProposalHandler(c *gin.Context) {
+	var req EditAgentProposalRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	caller, ok := requireTeacher(c)
+	if !ok {
+		return
+	}
+	proposal, ok := loadAgentProposal(c, caller.ID)
+	if !ok {
+		return
+	}
+	if proposal.Status != model.AgentProposalStatusPending {
+		c.JSON(http.StatusConflict, gin.H{"error": "Proposal is no longer pending", "status": proposal.Status})
+		return
+	}
+
+	edited, err := service.EditAgentProposal(c.Request.Context(), proposal, caller.ID, req.Changes, req.Note)
+	if err != nil {
+		respondAgentProposalError(c, proposal.ID, "edit", err)
+		return
+	}
+
+	log.Printf("[AI] Teacher %s edited agent proposal %s", caller.ID, proposal.ID.Hex())
+	c.JSON(http.StatusOK, edited)
+}
+
+// bindAgentProposalDecision binds the optional note sent with an approval or rejection
+func bindAgentProposalDecision(c *gin.Context) (*AgentProposalDecisionRequest, bool) {
+	var req AgentProposalDecisionRequest
+	if c.Request.ContentLength == 0 {
+		return &req, true
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return nil, false
+	}
+	return &req, true
+}
+
+// loadAgentProposal loads the proposal named in the path and checks that it was made to teacherID.
+// It writes the error response and returns false when the request must not proceed.
+func loadAgentProposal(c *gin.Context, teacherID string) (*model.AgentProposal, bool) {
+	proposalID, err := primitive.ObjectIDFromHex(c.Param("proposalId"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid proposal ID"})
+		return nil, false
+	}
+
+	proposal, err := repository.NewAgentProposalRepository().Get(c.Request.Context(), proposalID)
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Proposal not found"})
+			return nil, false
+		}
+		log.Printf("[AI] Failed to load agent proposal %s: %v", proposalID.Hex(), err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to load the proposal"})
+		return nil, false
+	}
+
+	if proposal.TeacherID != teacherID {
+		log.Printf("[AI] Teacher %s denied access to agent proposal %s", teacherID, proposalID.Hex())
+		c.JSON(http.StatusForbidden, gin.H{"error": "You do not have access to this proposal"})
+		return nil, false
+	}
+
+	return proposal, true
+}
+
+// respondAgentProposalError writes the response for a failed approval, rejection or edit
+func respondAgentProposalError(c *gin.Context, proposalID primitive.ObjectID, action string, err error) {
+	switch {
+	case errors.Is(err, repository.ErrAgentProposalNotPending):
+		c.JSON(http.StatusConflict, gin.H{"error": "Proposal is no longer pending"})
+	case errors.Is(err, service.ErrInvalidAgentProposalEdit):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	case errors.Is(err, mongo.ErrNoDocuments):
+		c.JSON(http.StatusNotFound, gin.H{"error": "Proposal not found"})
+	default:
+		log.Printf("[AI] Failed to %s agent proposal %s: %v", action, proposalID.Hex(), err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to " + action + " the proposal", "details": err.Error()})
+	}
+}
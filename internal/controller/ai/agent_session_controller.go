@@ -0,0 +1,370 @@
+package ai
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"lumenslate/internal/model"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// agentSessionTitleLength is the length of the opening message used as the title of a new session
+const agentSessionTitleLength = 60
+
+// ListAgentSessionsHandler godoc
+// @Summary      List Agent Sessions
+// @Description  List the calling teacher's conversations with the AI agent, most recently active first.
+// @Tags         AI Agent
+// @Produce      json
+// @Param        limit   query  int  false  "Maximum number of sessions to return (default 20)"
+// @Param        offset  query  int  false  "Number of sessions to skip"
+// @Success      200  {object}  map[string]interface{}  "Sessions and total count"
+// @Failure      401  {object}  map[string]interface{}  "Missing or unknown X-User-ID header"
+// @Failure      403  {object}  map[string]interface{}  "Caller is not a teacher"
+// @Failure      500  {object}  map[string]interface{}  "Failed to list sessions"
+// @Router       /ai/agent/sessions [get]
+func ListAgentSessionsHandler(c *gin.Context) {
+	caller, ok := requireTeacher(c)
+	if !ok {
+		return
+	}
+
+	limit, _ := strconv.ParseInt(c.DefaultQuery("limit", "20"), 10, 64)
+	offset, _ := strconv.ParseInt(c.DefaultQuery("offset", "0"), 10, 64)
+	if limit <= 0 || limit > 100 {
+		limit = 20
+	}
+	if offset < 0 {
+		offset = 0
+	}
+
+	sessions, total, err := agentSessions().ListSessions(c.Request.Context(), caller.ID, limit, offset)
+	if err != nil {
+		log.Printf("[AI] Failed to list agent sessions for %s: %v", caller.ID, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to list sessions"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"sessions": sessions,
+		"total":    total,
+		"limit":    limit,
+		"offset":   offset,
+	})
+}
+
+// GetAgentSessionHandler godoc
+// @Summary      Get Agent Session Transcript
+// @Description  Get a session with every teacher message and agent reply in order, including feedback.
+// @Tags         AI Agent
+// @Produce      json
+// @Param        sessionId  path  string  true  "Session ID"
+// @Success      200  {object}  map[string]interface{}  "Session and messages"
+// @Failure      400  {object}  map[string]interface{}  "Invalid session ID"
+// @Failure      401  {object}  map[string]interface{}  "Missing or unknown X-User-ID header"
+// @Failure      403  {object}  map[string]interface{}  "Session belongs to another teacher"
+// @Failure      404  {object}  map[string]interface{}  "Session not found"
+// @Failure      500  {object}  map[string]interface{}  "Failed to load the transcript"
+// @Router       /ai/agent/sessions/{sessionId} [get]
+func GetAgentSessionHandler(c *gin.Context) {
+	caller, ok := requireTeacher(c)
+	if !ok {
+		return
+	}
+	session, ok := loadAgentSession(c, c.Param("sessionId"), caller.ID)
+	if !ok {
+		return
+	}
+
+	messages, err := agentSessions().ListMessages(c.Request.Context(), session.ID)
+	if err != nil {
+		log.Printf("[AI] Failed to load transcript of agent session %s: %v", session.ID.Hex(), err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to load the transcript"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"session":  session,
+		"messages": messages,
+	})
+}
+
+// RenameAgentSessionHandler godoc
+// @Summary      Rename Agent Session
+// @Description  Change the title of a session.
+// @Tags         AI Agent
+// @Accept       json
+// @Produce      json
+// @Param        sessionId  path  string                        true  "Session ID"
+// @Param        body       body  ai.RenameAgentSessionRequest  true  "New title"
+// @Success      200  {object}  map[string]interface{}  "Updated session"
+// @Failure      400  {object}  map[string]interface{}  "Invalid session ID or request body"
+// @Failure      401  {object}  map[string]interface{}  "Missing or unknown X-User-ID header"
+// @Failure      403  {object}  map[string]interface{}  "Session belongs to another teacher"
+// @Failure      404  {object}  map[string]interface{}  "Session not found"
+// @Failure      500  {object}  map[string]interface{}  "Failed to rename the session"
+// @Router       /ai/agent/sessions/{sessionId} [patch]
+func RenameAgentSessionHandler(c *gin.Context) {
+	var req RenameAgentSessionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	title := strings.TrimSpace(req.Title)
+	if title == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "title must not be blank"})
+		return
+	}
+
+	caller, ok := requireTeacher(c)
+	if !ok {
+		return
+	}
+	session, ok := loadAgentSession(c, c.Param("sessionId"), caller.ID)
+	if !ok {
+		return
+	}
+
+	updated, err := agentSessions().RenameSession(c.Request.Context(), session.ID, title)
+	if err != nil {
+		log.Printf("[AI] Failed to rename agent session %s: %v", session.ID.Hex(), err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to rename the session"})
+		return
+	}
+
+	c.JSON(http.StatusOK, updated)
+}
+
+// DeleteAgentSessionHandler godoc
+// @Summary      Delete Agent Session
+// @Description  Delete a session and its transcript.
+// @Tags         AI Agent
+// @Produce      json
+// @Param        sessionId  path  string  true  "Session ID"
+// @Success      200  {object}  map[string]interface{}  "Session deleted"
+// @Failure      400  {object}  map[string]interface{}  "Invalid session ID"
+// @Failure      401  {object}  map[string]interface{}  "Missing or unknown X-User-ID header"
+// @Failure      403  {object}  map[string]interface{}  "Session belongs to another teacher"
+// @Failure      404  {object}  map[string]interface{}  "Session not found"
+// @Failure      500  {object}  map[string]interface{}  "Failed to delete the session"
+// @Router       /ai/agent/sessions/{sessionId} [delete]
+func DeleteAgentSessionHandler(c *gin.Context) {
+	caller, ok := requireTeacher(c)
+	if !ok {
+		return
+	}
+	session, ok := loadAgentSession(c, c.Param("sessionId"), caller.ID)
+	if !ok {
+		return
+	}
+
+	if err := agentSessions().DeleteSession(c.Request.Context(), session.ID); err != nil {
+		log.Printf("[AI] Failed to delete agent session %s: %v", session.ID.Hex(), err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete the session"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Session deleted", "sessionId": session.ID.Hex()})
+}
+
+// AgentMessageFeedbackHandler godoc
+// @Summary      Rate Agent Reply
+// @Description  Record thumbs-up ("positive") or thumbs-down ("negative") feedback on an agent reply. An empty feedback clears it.
+// @Tags         AI Agent
+// @Accept       json
+// @Produce      json
+// @Param        sessionId  path  string                   true  "Session ID"
+// @Param        messageId  path  string                   true  "Agent message ID"
+// @Param        body       body  ai.AgentFeedbackRequest  true  "Feedback"
+// @Success      200  {object}  map[string]interface{}  "Updated message"
+// @Failure      400  {object}  map[string]interface{}  "Invalid IDs or feedback value"
+// @Failure      401  {object}  map[string]interface{}  "Missing or unknown X-User-ID header"
+// @Failure      403  {object}  map[string]interface{}  "Session belongs to another teacher"
+// @Failure      404  {object}  map[string]interface{}  "Session or agent message not found"
+// @Failure      500  {object}  map[string]interface{}  "Failed to record the feedback"
+// @Router       /ai/agent/sessions/{sessionId}/messages/{messageId}/feedback [post]
+func AgentMessageFeedbackHandler(c *gin.Context) {
+	var req AgentFeedbackRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	messageID, err := primitive.ObjectIDFromHex(c.Param("messageId"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid message ID"})
+		return
+	}
+
+	caller, ok := requireTeacher(c)
+	if !ok {
+		return
+	}
+	session, ok := loadAgentSession(c, c.Param("sessionId"), caller.ID)
+	if !ok {
+		return
+	}
+
+	message, err := agentSessions().SetMessageFeedback(c.Request.Context(), session.ID, messageID, req.Feedback)
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Agent message not found in this session"})
+			return
+		}
+		log.Printf("[AI] Failed to record feedback on agent message %s: %v", messageID.Hex(), err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to record the feedback"})
+		return
+	}
+
+	c.JSON(http.StatusOK, message)
+}
+
+// requireTeacher resolves the caller and writes a 403 response when it is not a teacher
+func requireTeacher(c *gin.Context) (*corpusCaller, bool) {
+	caller, ok := requireCaller(c)
+	if !ok {
+		return nil, false
+	}
+	if !caller.isTeacher() {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Only teachers can use this endpoint"})
+		return nil, false
+	}
+	return caller, true
+}
+
+// loadAgentSession loads a session and checks that it belongs to teacherID.
+// It writes the error response and returns false when the request must not proceed.
+func loadAgentSession(c *gin.Context, sessionIDHex, teacherID string) (*model.AgentSession, bool) {
+	sessionID, err := primitive.ObjectIDFromHex(sessionIDHex)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid session ID"})
+		return nil, false
+	}
+
+	session, err := agentSessions().GetSession(c.Request.Context(), sessionID)
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Session not found"})
+			return nil, false
+		}
+		log.Printf("[AI] Failed to load agent session %s: %v", sessionIDHex, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to load the session"})
+		return nil, false
+	}
+
+	if session.TeacherID != teacherID {
+		log.Printf("[AI] Teacher %s denied access to agent session %s", teacherID, sessionIDHex)
+		c.JSON(http.StatusForbidden, gin.H{"error": "You do not have access to this session"})
+		return nil, false
+	}
+
+	return session, true
+}
+
+// resolveAgentRequestSession authenticates the teacher sending an agent request and loads the session
+// it continues, or returns a nil session for a new conversation. The teacherId in the request must be
+// the caller's. It writes the error response and returns false when the request must not proceed.
+func resolveAgentRequestSession(c *gin.Context, req *AgentRequest) (*corpusCaller, *model.AgentSession, bool) {
+	caller, ok := requireTeacher(c)
+	if !ok {
+		return nil, nil, false
+	}
+	if req.TeacherId != caller.ID {
+		log.Printf("[AI] Teacher %s sent an agent request as teacher %s", caller.ID, req.TeacherId)
+		c.JSON(http.StatusForbidden, gin.H{"error": "teacherId must be the authenticated teacher"})
+		return nil, nil, false
+	}
+
+	if req.SessionID == "" {
+		return caller, nil, true
+	}
+	session, ok := loadAgentSession(c, req.SessionID, caller.ID)
+	if !ok {
+		return nil, nil, false
+	}
+	return caller, session, true
+}
+
+// serviceSessionID returns the AI service session to continue, or "" for a new conversation
+func serviceSessionID(session *model.AgentSession) string {
+	if session == nil {
+		return ""
+	}
+	return session.ServiceSessionID
+}
+
+// recordAgentExchange stores the message of teacherID and the agent reply in the session, starting a new
+// session when there is none. The session and reply IDs replace the AI service's session ID in resp so
+// that clients continue and rate the stored conversation. Failing to record never fails the request.
+func recordAgentExchange(ctx context.Context, teacherID string, session *model.AgentSession, req *AgentRequest, resp map[string]interface{}) map[string]interface{} {
+	repo := agentSessions()
+
+	if session == nil {
+		session = model.NewAgentSession(teacherID, agentSessionTitle(req.Message))
+		if err := repo.CreateSession(ctx, session); err != nil {
+			log.Printf("[AI] Failed to start agent session for %s: %v", teacherID, err)
+			return resp
+		}
+	}
+
+	now := time.Now()
+	prompt := &model.AgentMessage{
+		TeacherID: teacherID,
+		Role:      model.AgentMessageRoleTeacher,
+		Content:   req.Message,
+		CreatedAt: now,
+	}
+	reply := &model.AgentMessage{
+		TeacherID:    teacherID,
+		Role:         model.AgentMessageRoleAgent,
+		Content:      responseString(resp, "message"),
+		AgentName:    responseString(resp, "agentName"),
+		PayloadType:  responseString(resp, "payloadType"),
+		Data:         responseData(resp),
+		ResponseTime: responseString(resp, "responseTime"),
+		Feedback:     responseString(resp, "feedback"),
+		CreatedAt:    now.Add(time.Millisecond), // Keep the reply after the prompt in the transcript
+	}
+
+	if err := repo.AppendMessages(ctx, session.ID, responseString(resp, "sessionId"), prompt, reply); err != nil {
+		log.Printf("[AI] Failed to record agent exchange in session %s: %v", session.ID.Hex(), err)
+		return resp
+	}
+
+	resp["sessionId"] = session.ID.Hex()
+	resp["messageId"] = reply.ID.Hex()
+	return resp
+}
+
+// agentSessionTitle derives a session title from the opening message
+func agentSessionTitle(message string) string {
+	title := strings.Join(strings.Fields(message), " ")
+	if runes := []rune(title); len(runes) > agentSessionTitleLength {
+		title = strings.TrimSpace(string(runes[:agentSessionTitleLength])) + "…"
+	}
+	if title == "" {
+		title = fmt.Sprintf("Conversation on %s", time.Now().Format("2 Jan 2006"))
+	}
+	return title
+}
+
+// responseData reads the structured data of an agent response
+func responseData(resp map[string]interface{}) map[string]interface{} {
+	data, _ := resp["data"].(map[string]interface{})
+	return data
+}
+
+// responseString reads a string field of an agent response
+func responseString(resp map[string]interface{}, key string) string {
+	value, _ := resp[key].(string)
+	return value
+}
@@ -0,0 +1,157 @@
+package ai
+
+import (
+	"context"
+	"log"
+	"net/http"
+
+	service "lumenslate/internal/grpc_service"
+
+	"github.com/gin-gonic/gin"
+)
+
+// SSE event names sent by the streaming agent endpoints, besides the token and event updates
+const (
+	agentStreamResultEvent = "result"
+	agentStreamErrorEvent  = "error"
+)
+
+// AgentStreamHandler godoc
+// @Summary      Stream AI Agent Response
+// @Description  Same request as /ai/agent, answered as Server-Sent Events: "token" events carry generated text as it is produced, "event" events carry structured progress, and a final "result" event carries the same response body /ai/agent returns. Failures after the stream has started are sent as an "error" event.
+// @Tags         AI Agent
+// @Accept       multipart/form-data
+// @Produce      text/event-stream
+// @Param        X-User-ID  header    string  true   "ID of the calling teacher"
+// @Param        teacherId  formData  string  true   "Teacher ID for context and personalization; must match X-User-ID"
+// @Param        role       formData  string  true   "Role/context for the AI agent processing"
+// @Param        message    formData  string  true   "Message or prompt for the AI agent"
+// @Param        file       formData  file    false  "Optional file upload for processing"
+// @Param        fileType   formData  string  false  "Type of the uploaded file (if file is provided)"
+// @Param        createdAt  formData  string  false  "Creation timestamp (ISO format)"
+// @Param        updatedAt  formData  string  false  "Update timestamp (ISO format)"
+// @Param        sessionId  formData  string  false  "Session to continue; a new session is started when omitted"
+// @Success      200        {string}  string  "Event stream"
+// @Failure      400        {object}  map[string]interface{}  "Invalid request body, missing required fields, or file processing error"
+// @Failure      401        {object}  map[string]interface{}  "Missing or unknown X-User-ID header"
+// @Failure      403        {object}  map[string]interface{}  "Caller is not a teacher, teacherId is not the caller, or the session belongs to another teacher"
+// @Failure      404        {object}  map[string]interface{}  "Session not found"
+// @Router       /ai/agent/stream [post]
+func AgentStreamHandler(c *gin.Context) {
+	log.Println("[AI] /ai/agent/stream called")
+	var req AgentRequest
+	if err := c.ShouldBind(&req); err != nil {
+		log.Printf("[AI] Invalid request: %v", err)
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	caller, session, ok := resolveAgentRequestSession(c, &req)
+	if !ok {
+		return
+	}
+
+	fileContent, ok := readAgentFile(c, &req)
+	if !ok {
+		return
+	}
+
+	beginAgentStream(c)
+	ctx := agentRunContext(c)
+	resp, err := aiService.LumenAgentStream(
+		ctx,
+		fileContent,
+		req.FileType,
+		caller.ID,
+		req.Role,
+		req.Message,
+		req.CreatedAt,
+		req.UpdatedAt,
+		serviceSessionID(session),
+		relayAgentUpdate(c),
+	)
+	if err != nil {
+		log.Printf("[AI] Agent stream error: %v", err)
+		sendAgentStreamEvent(c, agentStreamErrorEvent, gin.H{"error": err.Error()})
+		return
+	}
+	log.Printf("[AI] Agent stream success")
+	sendAgentStreamEvent(c, agentStreamResultEvent, recordAgentExchange(ctx, caller.ID, session, &req, resp))
+}
+
+// RAGAgentStreamHandler godoc
+// @Summary      Stream RAG Agent Response
+// @Description  Same request as /ai/rag-agent, answered as Server-Sent Events: "token" and "event" updates while the agent runs, then a final "result" event with the same body /ai/rag-agent returns. Failures after the stream has started are sent as an "error" event.
+// @Tags         AI RAG Agent
+// @Accept       json
+// @Produce      text/event-stream
+// @Param        body  body  ai.RAGAgentRequest  true  "RAG agent request with corpus name, role, and message"
+// @Success      200   {string}  string  "Event stream"
+// @Failure      400   {object}  gin.H  "Invalid request body or missing required fields"
+// @Failure      401   {object}  gin.H  "Missing or unknown X-User-ID header"
+// @Failure      403   {object}  gin.H  "Caller has no access to the corpus"
+// @Failure      409   {object}  gin.H  "Corpus is being deleted"
+// @Router       /ai/rag-agent/stream [post]
+func RAGAgentStreamHandler(c *gin.Context) {
+	var req RAGAgentRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		log.Printf("ERROR: Failed to bind JSON request: %v", err)
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if !authorizeRAGAgent(c, &req) {
+		return
+	}
+
+	beginAgentStream(c)
+	resp, err := aiService.RAGAgentStream(agentRunContext(c), req.CorpusName, req.Message, relayAgentUpdate(c))
+	if err != nil {
+		log.Printf("ERROR: Failed to stream RAG agent request: %v", err)
+		sendAgentStreamEvent(c, agentStreamErrorEvent, gin.H{"message": "Failed to process RAG agent request", "error": err.Error()})
+		return
+	}
+
+	standardizedResponse, err := buildRAGAgentResponse(resp, req.CorpusName)
+	if err != nil {
+		log.Printf("ERROR: Failed to process RAG agent response: %v", err)
+		sendAgentStreamEvent(c, agentStreamErrorEvent, gin.H{"message": "Failed to process RAG agent response", "error": err.Error()})
+		return
+	}
+
+	sendAgentStreamEvent(c, agentStreamResultEvent, standardizedResponse)
+}
+
+// beginAgentStream writes the Server-Sent Events headers
+func beginAgentStream(c *gin.Context) {
+	c.Header("Content-Type", "text/event-stream")
+	c.Header("Cache-Control", "no-cache")
+	c.Header("Connection", "keep-alive")
+	c.Header("X-Accel-Buffering", "no") // Disable proxy buffering (nginx)
+	c.Status(http.StatusOK)
+	c.Writer.Flush()
+}
+
+// agentRunContext detaches an agent run from the client's request, so that a client disconnecting
+// mid-stream does not abort the agent or lose its reply. The RPC still ends at the deadline configured
+// for the stream (GRPC_TIMEOUT_LUMENAGENTSTREAM, GRPC_TIMEOUT_RAGAGENTSTREAM).
+func agentRunContext(c *gin.Context) context.Context {
+	return context.WithoutCancel(c.Request.Context())
+}
+
+// relayAgentUpdate forwards agent updates to the client, failing once the client has gone
+func relayAgentUpdate(c *gin.Context) func(service.AgentStreamUpdate) error {
+	return func(update service.AgentStreamUpdate) error {
+		if err := c.Request.Context().Err(); err != nil {
+			return err
+		}
+		sendAgentStreamEvent(c, update.Kind, update)
+		return nil
+	}
+}
+
+// sendAgentStreamEvent writes a single event and flushes it to the client
+func sendAgentStreamEvent(c *gin.Context, event string, data interface{}) {
+	c.SSEvent(event, data)
+	c.Writer.Flush()
+}
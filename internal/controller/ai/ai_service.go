@@ -0,0 +1,32 @@
+package ai
+
+import (
+	"strconv"
+	"strings"
+
+	service "lumenslate/internal/grpc_service"
+
+	"github.com/gin-gonic/gin"
+)
+
+// aiCacheBypassHeader asks for a fresh AI response instead of a cached one; Cache-Control: no-cache works too
+const aiCacheBypassHeader = "X-AI-Cache-Bypass"
+
+// aiService is the AI microservice the handlers call
+var aiService service.AIService = service.NewAIService()
+
+// SetAIService replaces the AI microservice used by the handlers, e.g. with a fake
+func SetAIService(s service.AIService) {
+	aiService = s
+}
+
+// aiServiceFor returns the AI service for a request. Its calls join the trace of the request, and
+// bypass the response cache when the request asks to.
+func aiServiceFor(c *gin.Context) service.AIService {
+	s := service.WithContext(aiService, c.Request.Context())
+	bypass, _ := strconv.ParseBool(c.GetHeader(aiCacheBypassHeader))
+	if bypass || strings.Contains(strings.ToLower(c.GetHeader("Cache-Control")), "no-cache") {
+		return service.BypassCache(s)
+	}
+	return s
+}
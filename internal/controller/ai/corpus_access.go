@@ -0,0 +1,214 @@
+package ai
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+
+	"lumenslate/internal/middleware"
+	"lumenslate/internal/model"
+	"lumenslate/internal/repository"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// callerIDHeader carries the ID of the teacher or student making the request. The ID is only trusted
+// because the routes reading it verify its signature with middleware.CallerAuth.
+const callerIDHeader = middleware.CallerIDHeader
+
+const (
+	callerRoleTeacher = "teacher"
+	callerRoleStudent = "student"
+)
+
+// corpusAccess is the level of access an operation needs on a corpus
+type corpusAccess int
+
+const (
+	corpusRead  corpusAccess = iota // Query the corpus and view its documents
+	corpusWrite                     // Upload, delete and change settings
+)
+
+// corpusCaller is the teacher or student a request was made on behalf of
+type corpusCaller struct {
+	ID           string
+	Role         string
+	ClassroomIDs []string // Classrooms a student is enrolled in
+}
+
+// resolveCaller identifies the caller from the X-User-ID header and looks up whether it is a teacher or a student
+func resolveCaller(c *gin.Context) (*corpusCaller, error) {
+	callerID := strings.TrimSpace(c.GetHeader(callerIDHeader))
+	if callerID == "" {
+		return nil, fmt.Errorf("%s header is required", callerIDHeader)
+	}
+
+	if _, err := users().GetTeacherByID(callerID); err == nil {
+		return &corpusCaller{ID: callerID, Role: callerRoleTeacher}, nil
+	} else if !errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, fmt.Errorf("failed to look up teacher: %w", err)
+	}
+
+	student, err := users().GetStudentByID(callerID)
+	if err == nil {
+		return &corpusCaller{ID: callerID, Role: callerRoleStudent, ClassroomIDs: student.ClassIDs}, nil
+	} else if !errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, fmt.Errorf("failed to look up student: %w", err)
+	}
+
+	return nil, fmt.Errorf("unknown user '%s'", callerID)
+}
+
+// requireCaller resolves the caller and writes a 401 response when it cannot be identified
+func requireCaller(c *gin.Context) (*corpusCaller, bool) {
+	caller, err := resolveCaller(c)
+	if err != nil {
+		log.Printf("[AI] Unauthorized corpus request: %v", err)
+		c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		return nil, false
+	}
+	return caller, true
+}
+
+func (caller *corpusCaller) isTeacher() bool {
+	return caller.Role == callerRoleTeacher
+}
+
+// canAccess reports whether the caller may perform an operation needing access on corpus.
+// The owner teacher has full access; students enrolled in a classroom the corpus is shared
+// with, and the teachers of that classroom, have read access. Corpora without an owner,
+// created before ownership was tracked, are read-only to teachers until an admin assigns
+// an owner.
+func (caller *corpusCaller) canAccess(corpus *model.Corpus, access corpusAccess) bool {
+	if caller.isTeacher() {
+		if corpus.OwnerTeacher == caller.ID {
+			return true
+		}
+		if access != corpusRead {
+			return false
+		}
+		if corpus.OwnerTeacher == "" {
+			return true
+		}
+		for _, classroomID := range corpus.ClassroomIDs {
+			classroom, err := users().GetClassroomByID(classroomID)
+			if err != nil {
+				continue
+			}
+			for _, teacherID := range classroom.TeacherIDs {
+				if teacherID == caller.ID {
+					return true
+				}
+			}
+		}
+		return false
+	}
+
+	return access == corpusRead && corpus.IsSharedWith(caller.ClassroomIDs)
+}
+
+// authorizeCorpus loads a corpus and checks that the caller has the requested access to it.
+// It writes the error response and returns false when the request must not proceed.
+func authorizeCorpus(c *gin.Context, corpusName string, access corpusAccess) (*corpusCaller, *model.Corpus, bool) {
+	caller, ok := requireCaller(c)
+	if !ok {
+		return nil, nil, false
+	}
+
+	corpus, err := loadCorpus(c.Request.Context(), corpusName)
+	if err != nil {
+		respondCorpusLookupError(c, corpusName, err)
+		return nil, nil, false
+	}
+
+	if !caller.canAccess(corpus, access) {
+		respondCorpusForbidden(c, caller, corpusName)
+		return nil, nil, false
+	}
+
+	return caller, corpus, true
+}
+
+// authorizeDocument loads a document and checks that the caller has the requested access to its corpus
+func authorizeDocument(c *gin.Context, fileID string, access corpusAccess) (*model.Document, bool) {
+	caller, ok := requireCaller(c)
+	if !ok {
+		return nil, false
+	}
+	return authorizeCallerDocument(c, caller, fileID, access)
+}
+
+// authorizeCallerDocument loads a document and checks that an already resolved caller has the
+// requested access to its corpus
+func authorizeCallerDocument(c *gin.Context, caller *corpusCaller, fileID string, access corpusAccess) (*model.Document, bool) {
+	ctx := c.Request.Context()
+	document, err := repository.NewDocumentRepository().GetDocumentByFileID(ctx, fileID)
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			log.Printf("[AI] Document not found: %s", fileID)
+			c.JSON(http.StatusNotFound, gin.H{"error": "Document not found"})
+		} else {
+			log.Printf("[AI] Database error retrieving document: %v", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve document information"})
+		}
+		return nil, false
+	}
+
+	corpus, err := loadCorpus(ctx, document.CorpusName)
+	if err != nil {
+		respondCorpusLookupError(c, document.CorpusName, err)
+		return nil, false
+	}
+
+	if !caller.canAccess(corpus, access) {
+		respondCorpusForbidden(c, caller, document.CorpusName)
+		return nil, false
+	}
+
+	return document, true
+}
+
+// AuthorizeEventStream checks that the caller may follow the events of the given documents and user.
+// Callers may only follow their own user stream and documents of corpora they can read. It writes the
+// error response and returns false when the stream must not be opened; otherwise it returns the
+// requested documents.
+func AuthorizeEventStream(c *gin.Context, fileIDs []string, userID string) ([]*model.Document, bool) {
+	caller, ok := requireCaller(c)
+	if !ok {
+		return nil, false
+	}
+
+	if userID != "" && userID != caller.ID {
+		log.Printf("[AI] %s %s denied access to the events of user %s", caller.Role, caller.ID, userID)
+		c.JSON(http.StatusForbidden, gin.H{"error": "You can only follow your own events"})
+		return nil, false
+	}
+
+	documents := make([]*model.Document, 0, len(fileIDs))
+	for _, fileID := range fileIDs {
+		document, ok := authorizeCallerDocument(c, caller, fileID, corpusRead)
+		if !ok {
+			return nil, false
+		}
+		documents = append(documents, document)
+	}
+	return documents, true
+}
+
+// respondCorpusForbidden writes the response for a caller without access to a corpus
+func respondCorpusForbidden(c *gin.Context, caller *corpusCaller, corpusName string) {
+	log.Printf("[AI] %s %s denied access to corpus %s", caller.Role, caller.ID, corpusName)
+	c.JSON(http.StatusForbidden, gin.H{"error": fmt.Sprintf("You do not have access to corpus '%s'", corpusName)})
+}
+
+// legacyCorpusOwner returns the owner of a corpus created before ownership was tracked.
+// Those corpora were created per teacher and named after the teacher ID.
+func legacyCorpusOwner(corpusName string) string {
+	if _, err := users().GetTeacherByID(corpusName); err == nil {
+		return corpusName
+	}
+	return ""
+}
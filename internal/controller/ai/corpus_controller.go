@@ -2,29 +2,46 @@ package ai
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"log"
 	"net/http"
 	"os"
+	"strings"
+
+	"lumenslate/internal/model"
+	"lumenslate/internal/repository"
+	"lumenslate/internal/service"
+	"lumenslate/internal/tracing"
+	"lumenslate/internal/utils"
+	"lumenslate/tasks"
 
 	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
 	"google.golang.org/api/aiplatform/v1"
 	"google.golang.org/api/option"
 )
 
 // ListAllCorporaHandler godoc
 // @Summary      List All RAG Corpora
-// @Description  Retrieve a comprehensive list of all RAG corpora available in the Vertex AI project, including their display names, creation times, and update times.
+// @Description  Retrieve the RAG corpora in the Vertex AI project that the caller (X-User-ID header) can access, including their display names, creation times, update times and corpus records.
 // @Tags         AI RAG Management
 // @Accept       json
 // @Produce      json
 // @Success      200  {object}  map[string]interface{}  "List of all corpora with their metadata and count"
+// @Failure      401  {object}  map[string]interface{}  "Missing or unknown X-User-ID header"
 // @Failure      500  {object}  map[string]interface{}  "Internal server error during corpora retrieval from Vertex AI"
 // @Router       /ai/rag-agent/list-all-corpora [post]
 func ListAllCorporaHandler(c *gin.Context) {
 	log.Println("[AI] /ai/rag-agent/list-all-corpora called")
 
-	corporaResponse, err := listAllVertexAICorpora()
+	caller, ok := requireCaller(c)
+	if !ok {
+		return
+	}
+
+	corporaResponse, err := listAllVertexAICorpora(caller)
 	if err != nil {
 		log.Printf("[AI] List all corpora error: %v", err)
 		c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("failed to list corpora: %v", err)})
@@ -35,11 +52,21 @@ func ListAllCorporaHandler(c *gin.Context) {
 	c.JSON(http.StatusOK, corporaResponse)
 }
 
-// listAllVertexAICorpora lists all RAG corpora names
-func listAllVertexAICorpora() (map[string]interface{}, error) {
+// listAllVertexAICorpora lists the RAG corpora the caller can access
+func listAllVertexAICorpora(caller *corpusCaller) (map[string]interface{}, error) {
 	log.Printf("[AI] listAllVertexAICorpora called")
 	ctx := context.Background()
 
+	// Index corpus records by the display name they are registered under in Vertex AI
+	records := make(map[string]model.Corpus)
+	if storedCorpora, err := repository.NewCorpusRepository().ListCorpora(ctx); err != nil {
+		log.Printf("[AI] Warning: Failed to load corpus records: %v", err)
+	} else {
+		for _, record := range storedCorpora {
+			records[service.CorpusDisplayName(record.Name)] = record
+		}
+	}
+
 	// Project configuration - force RAG-compatible location
 	projectID := os.Getenv("GOOGLE_PROJECT_ID")
 	location := os.Getenv("GOOGLE_CLOUD_LOCATION")
@@ -75,12 +102,22 @@ func listAllVertexAICorpora() (map[string]interface{}, error) {
 	var corpora []map[string]interface{}
 	for _, corpus := range existingCorpora.RagCorpora {
 		log.Printf("[AI] Found corpus: %s (displayName: %s)", corpus.Name, corpus.DisplayName)
-		corpora = append(corpora, map[string]interface{}{
+		entry := map[string]interface{}{
 			"name":        corpus.Name,
 			"displayName": corpus.DisplayName,
 			"createTime":  corpus.CreateTime,
 			"updateTime":  corpus.UpdateTime,
-		})
+		}
+		if record, ok := records[corpus.DisplayName]; ok {
+			if !caller.canAccess(&record, corpusRead) {
+				continue
+			}
+			entry["record"] = record
+		} else if !caller.isTeacher() {
+			// Corpora without a record have no owner and are only visible to teachers
+			continue
+		}
+		corpora = append(corpora, entry)
 	}
 
 	log.Printf("[AI] Successfully listed %d corpora", len(corpora))
@@ -91,3 +128,371 @@ func listAllVertexAICorpora() (map[string]interface{}, error) {
 		"corpora":      corpora,
 	}, nil
 }
+
+// GetCorpusStatsHandler godoc
+// @Summary      Get RAG Corpus Stats
+// @Description  Retrieve the corpus record (owner, description, chunking settings, status) together with document counts and total bytes broken down by ingestion status. The stored counters are refreshed from the documents collection.
+// @Tags         AI RAG Management
+// @Accept       json
+// @Produce      json
+// @Param        corpusName  path    string  true  "Name of the corpus"
+// @Success      200         {object}  map[string]interface{}  "Corpus record and document statistics"
+// @Failure      401         {object}  map[string]interface{}  "Missing or unknown X-User-ID header"
+// @Failure      403         {object}  map[string]interface{}  "Caller has no access to the corpus"
+// @Failure      404         {object}  map[string]interface{}  "Corpus not found"
+// @Failure      500         {object}  map[string]interface{}  "Internal server error during stats retrieval"
+// @Router       /ai/corpora/{corpusName}/stats [get]
+func GetCorpusStatsHandler(c *gin.Context) {
+	corpusName := c.Param("corpusName")
+	log.Printf("[AI] /ai/corpora/%s/stats called", corpusName)
+	ctx := c.Request.Context()
+
+	_, corpus, ok := authorizeCorpus(c, corpusName, corpusRead)
+	if !ok {
+		return
+	}
+
+	stats, err := repository.NewDocumentRepository().GetCorpusStats(ctx, corpusName)
+	if err != nil {
+		log.Printf("[AI] Failed to compute corpus stats: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to compute corpus stats"})
+		return
+	}
+
+	if stats.DocumentCount != corpus.DocumentCount || stats.TotalBytes != corpus.TotalBytes {
+		if err := repository.NewCorpusRepository().SetUsage(ctx, corpusName, stats); err != nil {
+			log.Printf("[AI] Warning: Failed to refresh corpus usage: %v", err)
+		} else {
+			corpus.DocumentCount = stats.DocumentCount
+			corpus.TotalBytes = stats.TotalBytes
+		}
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"corpus": corpus,
+		"stats":  stats,
+	})
+}
+
+// UpdateCorpusHandler godoc
+// @Summary      Update RAG Corpus Settings
+// @Description  Rename the display name of a corpus or change its description and chunking settings. The corpus name used by documents and the RAG engine does not change. Chunking settings apply to documents imported afterwards.
+// @Tags         AI RAG Management
+// @Accept       json
+// @Produce      json
+// @Param        corpusName  path    string                   true  "Name of the corpus"
+// @Param        body        body    ai.UpdateCorpusRequest  true  "Fields to update"
+// @Success      200         {object}  map[string]interface{}  "Updated corpus record"
+// @Failure      400         {object}  map[string]interface{}  "Invalid request body or settings"
+// @Failure      401         {object}  map[string]interface{}  "Missing or unknown X-User-ID header"
+// @Failure      403         {object}  map[string]interface{}  "Caller has no access to the corpus"
+// @Failure      404         {object}  map[string]interface{}  "Corpus not found"
+// @Failure      409         {object}  map[string]interface{}  "Corpus is being deleted"
+// @Failure      500         {object}  map[string]interface{}  "Internal server error during update"
+// @Router       /ai/corpora/{corpusName} [patch]
+func UpdateCorpusHandler(c *gin.Context) {
+	corpusName := c.Param("corpusName")
+	log.Printf("[AI] PATCH /ai/corpora/%s called", corpusName)
+	ctx := c.Request.Context()
+
+	var req UpdateCorpusRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		log.Printf("[AI] Invalid request: %v", err)
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	_, corpus, ok := authorizeCorpus(c, corpusName, corpusWrite)
+	if !ok {
+		return
+	}
+	if corpus.Status != model.CorpusStatusActive {
+		c.JSON(http.StatusConflict, gin.H{"error": fmt.Sprintf("Corpus '%s' is %s", corpusName, corpus.Status)})
+		return
+	}
+
+	updates := bson.M{}
+	if req.DisplayName != nil {
+		displayName := strings.TrimSpace(*req.DisplayName)
+		if displayName == "" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "displayName cannot be empty"})
+			return
+		}
+		updates["displayName"] = displayName
+	}
+	if req.Description != nil {
+		updates["description"] = strings.TrimSpace(*req.Description)
+	}
+	if req.ChunkSize != nil || req.ChunkOverlap != nil {
+		chunking := corpus.Chunking
+		if req.ChunkSize != nil {
+			chunking.ChunkSize = *req.ChunkSize
+		}
+		if req.ChunkOverlap != nil {
+			chunking.ChunkOverlap = *req.ChunkOverlap
+		}
+		if err := validateCorpusChunking(chunking); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		updates["chunking"] = chunking
+	}
+	if len(updates) == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "No fields to update"})
+		return
+	}
+
+	updated, err := repository.NewCorpusRepository().UpdateFields(ctx, corpusName, updates)
+	if err != nil {
+		log.Printf("[AI] Failed to update corpus: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update corpus"})
+		return
+	}
+
+	log.Printf("[AI] Corpus updated: %s", corpusName)
+	c.JSON(http.StatusOK, updated)
+}
+
+// DeleteCorpusHandler godoc
+// @Summary      Delete RAG Corpus
+// @Description  Delete a corpus together with all of its documents, their GCS objects and RAG files. The deletion runs as a background task; the corpus reports status "deleting" until it is gone, or "delete_failed" with an error message, in which case the request may be repeated.
+// @Tags         AI RAG Management
+// @Accept       json
+// @Produce      json
+// @Param        corpusName  path    string  true  "Name of the corpus"
+// @Success      202         {object}  map[string]interface{}  "Corpus deletion queued"
+// @Failure      401         {object}  map[string]interface{}  "Missing or unknown X-User-ID header"
+// @Failure      403         {object}  map[string]interface{}  "Caller has no access to the corpus"
+// @Failure      404         {object}  map[string]interface{}  "Corpus not found"
+// @Failure      409         {object}  map[string]interface{}  "Corpus deletion already in progress"
+// @Failure      500         {object}  map[string]interface{}  "Internal server error while queueing deletion"
+// @Router       /ai/corpora/{corpusName} [delete]
+func DeleteCorpusHandler(c *gin.Context) {
+	corpusName := c.Param("corpusName")
+	log.Printf("[AI] DELETE /ai/corpora/%s called", corpusName)
+	ctx := utils.WithCorrelationID(c.Request.Context(), "")
+
+	caller, _, ok := authorizeCorpus(c, corpusName, corpusWrite)
+	if !ok {
+		return
+	}
+
+	corpusRepo := repository.NewCorpusRepository()
+	if _, err := corpusRepo.MarkDeleting(ctx, corpusName); err != nil {
+		if errors.Is(err, repository.ErrCorpusDeleting) {
+			c.JSON(http.StatusConflict, gin.H{"error": fmt.Sprintf("Corpus '%s' is already being deleted", corpusName)})
+			return
+		}
+		log.Printf("[AI] Failed to mark corpus as deleting: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to start corpus deletion"})
+		return
+	}
+
+	taskID, err := enqueueDeleteCorpusTask(ctx, tasks.DeleteCorpusPayload{
+		CorpusName:  corpusName,
+		RequestedBy: caller.ID,
+	})
+	if err != nil {
+		log.Printf("[AI] Failed to enqueue corpus deletion: %v", err)
+		if updateErr := corpusRepo.MarkDeleteFailed(ctx, corpusName, err.Error()); updateErr != nil {
+			log.Printf("[AI] Failed to record corpus deletion failure: %v", updateErr)
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to enqueue corpus deletion"})
+		return
+	}
+
+	log.Printf("[AI] Corpus deletion queued: %s (task %s)", corpusName, taskID)
+	c.JSON(http.StatusAccepted, gin.H{
+		"corpusName": corpusName,
+		"status":     model.CorpusStatusDeleting,
+		"taskId":     taskID,
+		"message":    "Corpus deletion queued",
+	})
+}
+
+// ShareCorpusHandler godoc
+// @Summary      Share RAG Corpus with Classroom
+// @Description  Give the students of a classroom read-only access to a corpus so they can query it through the RAG agent and view its documents. Only the corpus owner can share, and only with a classroom they teach.
+// @Tags         AI RAG Management
+// @Accept       json
+// @Produce      json
+// @Param        corpusName  path    string                 true  "Name of the corpus"
+// @Param        body        body    ai.ShareCorpusRequest  true  "Classroom to share the corpus with"
+// @Success      200         {object}  map[string]interface{}  "Updated corpus record"
+// @Failure      400         {object}  map[string]interface{}  "Invalid request body"
+// @Failure      401         {object}  map[string]interface{}  "Missing or unknown X-User-ID header"
+// @Failure      403         {object}  map[string]interface{}  "Caller does not own the corpus or teach the classroom"
+// @Failure      404         {object}  map[string]interface{}  "Corpus or classroom not found"
+// @Failure      500         {object}  map[string]interface{}  "Internal server error while sharing"
+// @Router       /ai/corpora/{corpusName}/classrooms [post]
+func ShareCorpusHandler(c *gin.Context) {
+	corpusName := c.Param("corpusName")
+	log.Printf("[AI] POST /ai/corpora/%s/classrooms called", corpusName)
+
+	var req ShareCorpusRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		log.Printf("[AI] Invalid request: %v", err)
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	caller, _, ok := authorizeCorpus(c, corpusName, corpusWrite)
+	if !ok {
+		return
+	}
+
+	classroom, err := users().GetClassroomByID(req.ClassroomID)
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			c.JSON(http.StatusNotFound, gin.H{"error": fmt.Sprintf("Classroom '%s' not found", req.ClassroomID)})
+			return
+		}
+		log.Printf("[AI] Failed to load classroom %s: %v", req.ClassroomID, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to load classroom"})
+		return
+	}
+
+	teachesClassroom := false
+	for _, teacherID := range classroom.TeacherIDs {
+		if teacherID == caller.ID {
+			teachesClassroom = true
+			break
+		}
+	}
+	if !teachesClassroom {
+		c.JSON(http.StatusForbidden, gin.H{"error": "You can only share a corpus with a classroom you teach"})
+		return
+	}
+
+	corpus, err := repository.NewCorpusRepository().ShareWithClassroom(c.Request.Context(), corpusName, req.ClassroomID)
+	if err != nil {
+		log.Printf("[AI] Failed to share corpus: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to share corpus"})
+		return
+	}
+
+	log.Printf("[AI] Corpus %s shared with classroom %s", corpusName, req.ClassroomID)
+	c.JSON(http.StatusOK, corpus)
+}
+
+// UnshareCorpusHandler godoc
+// @Summary      Stop Sharing RAG Corpus with Classroom
+// @Description  Revoke the read-only access a classroom was given to a corpus. Only the corpus owner can revoke access.
+// @Tags         AI RAG Management
+// @Accept       json
+// @Produce      json
+// @Param        corpusName   path    string  true  "Name of the corpus"
+// @Param        classroomId  path    string  true  "Classroom to revoke access from"
+// @Success      200          {object}  map[string]interface{}  "Updated corpus record"
+// @Failure      401          {object}  map[string]interface{}  "Missing or unknown X-User-ID header"
+// @Failure      403          {object}  map[string]interface{}  "Caller does not own the corpus"
+// @Failure      404          {object}  map[string]interface{}  "Corpus not found"
+// @Failure      500          {object}  map[string]interface{}  "Internal server error while revoking access"
+// @Router       /ai/corpora/{corpusName}/classrooms/{classroomId} [delete]
+func UnshareCorpusHandler(c *gin.Context) {
+	corpusName := c.Param("corpusName")
+	classroomID := c.Param("classroomId")
+	log.Printf("[AI] DELETE /ai/corpora/%s/classrooms/%s called", corpusName, classroomID)
+
+	if _, _, ok := authorizeCorpus(c, corpusName, corpusWrite); !ok {
+		return
+	}
+
+	corpus, err := repository.NewCorpusRepository().UnshareWithClassroom(c.Request.Context(), corpusName, classroomID)
+	if err != nil {
+		log.Printf("[AI] Failed to unshare corpus: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to stop sharing corpus"})
+		return
+	}
+
+	log.Printf("[AI] Corpus %s no longer shared with classroom %s", corpusName, classroomID)
+	c.JSON(http.StatusOK, corpus)
+}
+
+// loadCorpus returns the record of a corpus, adopting corpora created before the corpora
+// collection existed when they still have documents or exist in the RAG engine
+func loadCorpus(ctx context.Context, corpusName string) (*model.Corpus, error) {
+	records := corpusRecords()
+	corpus, err := records.GetCorpusByName(ctx, corpusName)
+	if err == nil || !errors.Is(err, mongo.ErrNoDocuments) {
+		return corpus, err
+	}
+
+	stats, statsErr := repository.NewDocumentRepository().GetCorpusStats(ctx, corpusName)
+	if statsErr != nil {
+		return nil, statsErr
+	}
+
+	var resourceName string
+	if stats.DocumentCount == 0 {
+		resourceName, statsErr = service.NewVertexAIService().FindCorpusResourceName(ctx, corpusName)
+		if statsErr != nil {
+			return nil, statsErr
+		}
+		if resourceName == "" {
+			return nil, err
+		}
+	}
+
+	legacy := model.NewCorpus(corpusName, legacyCorpusOwner(corpusName), "", model.CorpusChunking{})
+	legacy.ResourceName = resourceName
+	legacy.DocumentCount = stats.DocumentCount
+	legacy.TotalBytes = stats.TotalBytes
+	log.Printf("[AI] Adopting existing corpus without record: %s", corpusName)
+	return records.EnsureCorpus(ctx, legacy)
+}
+
+// respondCorpusLookupError writes the response for a failed loadCorpus call
+func respondCorpusLookupError(c *gin.Context, corpusName string, err error) {
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		c.JSON(http.StatusNotFound, gin.H{"error": fmt.Sprintf("Corpus '%s' not found", corpusName)})
+		return
+	}
+	log.Printf("[AI] Failed to load corpus %s: %v", corpusName, err)
+	c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to load corpus"})
+}
+
+// refreshCorpusUsage recomputes the document count and total bytes stored on a corpus record.
+// It is best effort; the stats endpoint refreshes the counters again on read.
+func refreshCorpusUsage(ctx context.Context, corpusName string) {
+	stats, err := repository.NewDocumentRepository().GetCorpusStats(ctx, corpusName)
+	if err != nil {
+		log.Printf("[AI] Warning: Failed to compute usage for corpus %s: %v", corpusName, err)
+		return
+	}
+	if err := repository.NewCorpusRepository().SetUsage(ctx, corpusName, stats); err != nil {
+		log.Printf("[AI] Warning: Failed to update usage for corpus %s: %v", corpusName, err)
+	}
+}
+
+// validateCorpusChunking checks chunking settings before they are stored
+func validateCorpusChunking(chunking model.CorpusChunking) error {
+	if chunking.ChunkSize < 0 || chunking.ChunkOverlap < 0 {
+		return fmt.Errorf("chunkSize and chunkOverlap must not be negative")
+	}
+	if chunking.ChunkOverlap > 0 && chunking.ChunkSize == 0 {
+		return fmt.Errorf("chunkOverlap requires chunkSize")
+	}
+	if chunking.ChunkSize > 0 && chunking.ChunkOverlap >= chunking.ChunkSize {
+		return fmt.Errorf("chunkOverlap must be smaller than chunkSize")
+	}
+	return nil
+}
+
+// enqueueDeleteCorpusTask enqueues the cascading delete of a corpus. A delete that is
+// already queued or retrying for the corpus is reused instead of enqueueing another.
+func enqueueDeleteCorpusTask(ctx context.Context, payload tasks.DeleteCorpusPayload) (string, error) {
+	// Carry the request's trace and correlation ID into the background run
+	payload.CorrelationID = utils.GetCorrelationID(ctx)
+	payload.TraceContext = tracing.InjectTaskContext(ctx)
+
+	task, err := tasks.NewDeleteCorpusTask(payload)
+	if err != nil {
+		return "", fmt.Errorf("task creation failed: %w", err)
+	}
+
+	return tasks.Enqueue(ctx, task, payload.CorpusName, map[string]string{
+		"corpus_name":  payload.CorpusName,
+		"requested_by": payload.RequestedBy,
+	})
+}
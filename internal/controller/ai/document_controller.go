@@ -2,6 +2,7 @@ package ai
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"log"
 	"net/http"
@@ -14,12 +15,12 @@ import (
 	"lumenslate/internal/model"
 	"lumenslate/internal/repository"
 	"lumenslate/internal/service"
+	"lumenslate/internal/tracing"
 	"lumenslate/internal/utils"
 	"lumenslate/tasks"
 
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
-	"github.com/hibiken/asynq"
 	"go.mongodb.org/mongo-driver/mongo"
 	"google.golang.org/api/aiplatform/v1"
 	"google.golang.org/api/option"
@@ -34,6 +35,8 @@ import (
 // @Param        body  body  ai.DeleteCorpusDocumentRequest  true  "Delete corpus document request containing corpus name and file identifier"
 // @Success      200   {object}  map[string]interface{}  "Document deleted successfully with deletion status for each component"
 // @Failure      400   {object}  map[string]interface{}  "Invalid request body or missing required fields"
+// @Failure      401   {object}  map[string]interface{}  "Missing or unknown X-User-ID header"
+// @Failure      403   {object}  map[string]interface{}  "Caller has no access to the corpus"
 // @Failure      404   {object}  map[string]interface{}  "Document or corpus not found"
 // @Failure      500   {object}  map[string]interface{}  "Internal server error during deletion process"
 // @Router       /ai/rag-agent/delete-corpus-document [post]
@@ -45,6 +48,10 @@ func DeleteCorpusDocumentHandler(c *gin.Context) {
 		return
 	}
 
+	if _, _, ok := authorizeCorpus(c, req.CorpusName, corpusWrite); !ok {
+		return
+	}
+
 	deleteResponse, err := deleteVertexAICorpusDocument(req.CorpusName, req.FileID)
 	if err != nil {
 		log.Printf("[AI] Delete corpus document error: %v", err)
@@ -64,6 +71,8 @@ func DeleteCorpusDocumentHandler(c *gin.Context) {
 // @Param        id   path    string  true  "Document ID (unique identifier for the document)"
 // @Success      200  {object}  map[string]interface{}  "Pre-signed URL generated successfully with document metadata"
 // @Failure      400  {object}  map[string]interface{}  "Invalid or missing document ID"
+// @Failure      401  {object}  map[string]interface{}  "Missing or unknown X-User-ID header"
+// @Failure      403  {object}  map[string]interface{}  "Caller has no access to the corpus"
 // @Failure      404  {object}  map[string]interface{}  "Document not found in database or storage"
 // @Failure      500  {object}  map[string]interface{}  "Internal server error during URL generation"
 // @Router       /ai/documents/view/{id} [get]
@@ -89,19 +98,11 @@ func ViewDocumentHandler(c *gin.Context) {
 	}
 	defer gcs.Close()
 
-	docRepo := repository.NewDocumentRepository()
 	ctx := context.Background()
 
-	// Get document metadata from database
-	document, err := docRepo.GetDocumentByFileID(ctx, documentID)
-	if err != nil {
-		if err == mongo.ErrNoDocuments {
-			log.Printf("[AI] Document not found: %s", documentID)
-			c.JSON(http.StatusNotFound, gin.H{"error": "Document not found"})
-		} else {
-			log.Printf("[AI] Database error retrieving document: %v", err)
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve document information"})
-		}
+	// Get document metadata from database and check the caller can read its corpus
+	document, ok := authorizeDocument(c, documentID, corpusRead)
+	if !ok {
 		return
 	}
 
@@ -151,6 +152,8 @@ func ViewDocumentHandler(c *gin.Context) {
 // @Param        id   path    string  true  "Document ID (unique identifier for the document to delete)"
 // @Success      200  {object}  map[string]interface{}  "Document deleted successfully from all systems"
 // @Failure      400  {object}  map[string]interface{}  "Invalid or missing document ID"
+// @Failure      401  {object}  map[string]interface{}  "Missing or unknown X-User-ID header"
+// @Failure      403  {object}  map[string]interface{}  "Caller has no access to the corpus"
 // @Failure      404  {object}  map[string]interface{}  "Document not found"
 // @Failure      500  {object}  map[string]interface{}  "Internal server error during deletion process"
 // @Router       /ai/documents/{id} [delete]
@@ -178,16 +181,9 @@ func DeleteCorpusDocumentByIDHandler(c *gin.Context) {
 	docRepo := repository.NewDocumentRepository()
 	ctx := context.Background()
 
-	// Get document metadata from database
-	document, err := docRepo.GetDocumentByFileID(ctx, documentID)
-	if err != nil {
-		if err == mongo.ErrNoDocuments {
-			log.Printf("[AI] Document not found: %s", documentID)
-			c.JSON(http.StatusNotFound, gin.H{"error": "Document not found"})
-		} else {
-			log.Printf("[AI] Database error retrieving document: %v", err)
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve document information"})
-		}
+	// Get document metadata from database and check the caller owns its corpus
+	document, ok := authorizeDocument(c, documentID, corpusWrite)
+	if !ok {
 		return
 	}
 
@@ -223,6 +219,7 @@ func DeleteCorpusDocumentByIDHandler(c *gin.Context) {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete document metadata"})
 		return
 	}
+	refreshCorpusUsage(ctx, document.CorpusName)
 
 	log.Printf("[AI] Document deleted successfully: %s", documentID)
 	c.JSON(http.StatusOK, gin.H{
@@ -241,6 +238,8 @@ func DeleteCorpusDocumentByIDHandler(c *gin.Context) {
 // @Param        file        formData  file    true   "Document file to upload (supported formats: PDF, TXT, DOCX, DOC, HTML, MD)"
 // @Success      200         {object}  map[string]interface{}  "Document uploaded successfully and queued for processing with pending status"
 // @Failure      400         {object}  map[string]interface{}  "Invalid request, unsupported file type, or missing required fields"
+// @Failure      401         {object}  map[string]interface{}  "Missing or unknown X-User-ID header"
+// @Failure      403         {object}  map[string]interface{}  "Caller has no access to the corpus"
 // @Failure      500         {object}  map[string]interface{}  "Internal server error during upload or task enqueue process"
 // @Router       /ai/rag-agent/add-corpus-document [post]
 func AddCorpusDocumentHandler(c *gin.Context) {
@@ -307,6 +306,16 @@ func AddCorpusDocumentHandler(c *gin.Context) {
 	docRepo := repository.NewDocumentRepository()
 	logger.InfoWithOperation(ctx, "service_init", "Services initialized successfully")
 
+	// Documents can only be added by the corpus owner to active corpora
+	caller, corpus, ok := authorizeCorpus(c, req.CorpusName, corpusWrite)
+	if !ok {
+		return
+	}
+	if corpus.Status != model.CorpusStatusActive {
+		c.JSON(http.StatusConflict, gin.H{"error": fmt.Sprintf("Corpus '%s' is %s", req.CorpusName, corpus.Status)})
+		return
+	}
+
 	// Open uploaded file
 	file, err := req.File.Open()
 	if err != nil {
@@ -379,7 +388,7 @@ func AddCorpusDocumentHandler(c *gin.Context) {
 	ctx = context.WithValue(ctx, "file_id", fileID)
 
 	// Generate final object name for when processing completes
-	finalObjectName := fmt.Sprintf("documents/%s%s", fileID, fileExtension)
+	finalObjectName := finalDocumentObjectName(fileID, req.File.Filename)
 
 	idMetadata := map[string]string{
 		"file_id":           fileID,
@@ -395,8 +404,8 @@ func AddCorpusDocumentHandler(c *gin.Context) {
 		tempObjectName, // Initially store with temp name
 		req.File.Header.Get("Content-Type"),
 		req.CorpusName,
-		"",       // RAG file ID will be set during background processing
-		"system", // TODO: Get from authentication context
+		"",        // RAG file ID will be set during background processing
+		caller.ID, // Status events are published to the uploader's stream
 		fileSize,
 	)
 
@@ -412,18 +421,11 @@ func AddCorpusDocumentHandler(c *gin.Context) {
 
 	dbMetadata := map[string]string{
 		"file_id": fileID,
-		"status":  document.Status,
+		"status":  string(document.Status),
 	}
 	logger.InfoWithMetrics(ctx, "database_store", "Document metadata stored successfully", 0, dbMetadata)
-
-	// Initialize Asynq client for task enqueuing
-	redisAddr := os.Getenv("REDIS_ADDR")
-	if redisAddr == "" {
-		redisAddr = "localhost:6379" // Default Redis address
-	}
-
-	asynqClient := asynq.NewClient(asynq.RedisClientOpt{Addr: redisAddr})
-	defer asynqClient.Close()
+	refreshCorpusUsage(ctx, req.CorpusName)
+	tasks.PublishDocumentStatus(ctx, document, document.Status, "", "Document uploaded")
 
 	// Create task payload
 	taskPayload := tasks.DocumentTaskPayload{
@@ -434,30 +436,10 @@ func AddCorpusDocumentHandler(c *gin.Context) {
 		DisplayName:     req.File.Filename,
 	}
 
-	// Create and enqueue the background task
-	task, err := tasks.NewAddDocumentToCorpusTask(taskPayload)
+	taskID, err := enqueueDocumentTask(ctx, logger, taskPayload)
 	if err != nil {
-		logger.ErrorWithOperation(ctx, "task_creation", "Failed to create background task", err)
 		// Update document status to failed
-		if updateErr := docRepo.UpdateStatus(ctx, fileID, "failed", fmt.Sprintf("Task creation failed: %v", err)); updateErr != nil {
-			logger.ErrorWithOperation(ctx, "status_update", "Failed to update document status after task creation error", updateErr)
-		}
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create background processing task"})
-		return
-	}
-
-	// Enqueue the task with logging
-	utils.LogTaskEnqueue(ctx, tasks.TypeAddDocumentToCorpus, fileID, map[string]string{
-		"corpus_name":       taskPayload.CorpusName,
-		"temp_object_name":  taskPayload.TempObjectName,
-		"final_object_name": taskPayload.FinalObjectName,
-	})
-
-	info, err := asynqClient.Enqueue(task)
-	if err != nil {
-		logger.ErrorWithOperation(ctx, "task_enqueue", "Failed to enqueue background task", err)
-		// Update document status to failed
-		if updateErr := docRepo.UpdateStatus(ctx, fileID, "failed", fmt.Sprintf("Task enqueue failed: %v", err)); updateErr != nil {
+		if updateErr := docRepo.MarkFailed(ctx, fileID, model.DocumentStatusImporting, err.Error()); updateErr != nil {
 			logger.ErrorWithOperation(ctx, "status_update", "Failed to update document status after task enqueue error", updateErr)
 		}
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to enqueue background processing task"})
@@ -465,7 +447,7 @@ func AddCorpusDocumentHandler(c *gin.Context) {
 	}
 
 	taskMetadata := map[string]string{
-		"task_id":   info.ID,
+		"task_id":   taskID,
 		"task_type": tasks.TypeAddDocumentToCorpus,
 		"file_id":   fileID,
 	}
@@ -476,17 +458,17 @@ func AddCorpusDocumentHandler(c *gin.Context) {
 
 	responseMetadata := map[string]string{
 		"file_id":       fileID,
-		"status":        "pending",
+		"status":        string(document.Status),
 		"response_time": responseTime.String(),
 		"corpus_name":   req.CorpusName,
 		"filename":      req.File.Filename,
 	}
 	logger.InfoWithMetrics(ctx, "upload_complete", "Document upload request completed successfully", responseTime, responseMetadata)
 
-	// Return immediate response with fileId and status="pending" for async processing
+	// Return immediate response with fileId and status="uploaded" for async processing
 	c.JSON(http.StatusOK, gin.H{
 		"fileId":       fileID,
-		"status":       "pending",
+		"status":       document.Status,
 		"message":      "Document uploaded successfully and queued for processing",
 		"responseTime": responseTime.String(),
 	})
@@ -494,13 +476,15 @@ func AddCorpusDocumentHandler(c *gin.Context) {
 
 // GetDocumentStatusHandler godoc
 // @Summary      Get Document Processing Status
-// @Description  Retrieve the current processing status of a document by its file ID. Returns status information including processing state, error messages (if any), and last update timestamp.
+// @Description  Retrieve the current processing status of a document by its file ID. Returns the ingestion state (uploaded, importing, indexing, finalizing, ready or failed), the failed step, attempt count, progress and the timestamped state history.
 // @Tags         AI Document Management
 // @Accept       json
 // @Produce      json
 // @Param        fileId   path    string  true  "Document file ID (unique identifier for the document)"
-// @Success      200      {object}  map[string]interface{}  "Document status retrieved successfully with fileId, status, errorMsg, and updatedAt"
+// @Success      200      {object}  map[string]interface{}  "Document status retrieved successfully with fileId, status, progress, attempts, history, errorMsg, and updatedAt"
 // @Failure      400      {object}  map[string]interface{}  "Invalid or missing file ID parameter"
+// @Failure      401      {object}  map[string]interface{}  "Missing or unknown X-User-ID header"
+// @Failure      403      {object}  map[string]interface{}  "Caller has no access to the corpus"
 // @Failure      404      {object}  map[string]interface{}  "Document not found"
 // @Failure      500      {object}  map[string]interface{}  "Internal server error during status retrieval"
 // @Router       /ai/rag-agent/document-status/{fileId} [get]
@@ -535,7 +519,7 @@ func GetDocumentStatusHandler(c *gin.Context) {
 	document, err := docRepo.GetDocumentByFileID(ctx, fileID)
 	if err != nil {
 		// Handle document not found cases with HTTP 404 responses
-		if err == mongo.ErrNoDocuments {
+		if errors.Is(err, mongo.ErrNoDocuments) {
 			logger.ErrorWithOperation(ctx, "document_query", "Document not found", err)
 			c.JSON(http.StatusNotFound, gin.H{
 				"error":   "Document not found",
@@ -555,30 +539,226 @@ func GetDocumentStatusHandler(c *gin.Context) {
 		return
 	}
 
+	if _, _, ok := authorizeCorpus(c, document.CorpusName, corpusRead); !ok {
+		return
+	}
+
+	status := document.Status.Normalize()
 	resultMetadata := map[string]string{
 		"file_id": fileID,
-		"status":  document.Status,
+		"status":  string(status),
 	}
 	if document.ErrorMsg != "" {
 		resultMetadata["error_msg"] = document.ErrorMsg
 	}
 	logger.InfoWithMetrics(ctx, "document_query", "Document status retrieved successfully", 0, resultMetadata)
 
-	// Return JSON response with fileId, status, errorMsg, and updatedAt
+	// Return JSON response with the state machine position and history
 	response := gin.H{
 		"fileId":    document.FileID,
-		"status":    document.Status,
+		"status":    status,
+		"progress":  document.Progress(),
+		"attempts":  document.Attempts,
+		"history":   document.StatusHistory,
 		"updatedAt": document.UpdatedAt,
 	}
 
-	// Include errorMsg only if it's not empty
+	// Include failure details only if the document failed
 	if document.ErrorMsg != "" {
 		response["errorMsg"] = document.ErrorMsg
 	}
+	if status == model.DocumentStatusFailed {
+		response["failedStep"] = document.FailedStep
+		response["canReprocess"] = true
+	}
 
 	c.JSON(http.StatusOK, response)
 }
 
+// ReprocessDocumentHandler godoc
+// @Summary      Reprocess Failed Document
+// @Description  Re-enqueue a failed document for ingestion, resuming from the step it failed at instead of requiring a fresh upload. An optional fromStep (importing, indexing or finalizing) restarts from an earlier step. Documents whose retries were exhausted have had their upload removed and must be uploaded again.
+// @Tags         AI Document Management
+// @Accept       json
+// @Produce      json
+// @Param        id    path    string                          true   "Document file ID"
+// @Param        body  body    ai.ReprocessDocumentRequest     false  "Optional step to resume from"
+// @Success      202   {object}  map[string]interface{}  "Document re-enqueued for processing"
+// @Failure      400   {object}  map[string]interface{}  "Invalid step"
+// @Failure      401   {object}  map[string]interface{}  "Missing or unknown X-User-ID header"
+// @Failure      403   {object}  map[string]interface{}  "Caller has no access to the corpus"
+// @Failure      404   {object}  map[string]interface{}  "Document not found"
+// @Failure      409   {object}  map[string]interface{}  "Document is not in the failed state or its upload no longer exists"
+// @Failure      500   {object}  map[string]interface{}  "Internal server error during task enqueue"
+// @Router       /ai/documents/{id}/reprocess [post]
+func ReprocessDocumentHandler(c *gin.Context) {
+	ctx := utils.WithCorrelationID(c.Request.Context(), "")
+	ctx = utils.WithRequestID(ctx, c.GetHeader("X-Request-ID"))
+	logger := utils.NewLogger("document_controller")
+
+	fileID := c.Param("id")
+	if fileID == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Document ID is required"})
+		return
+	}
+
+	var req ReprocessDocumentRequest
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+	}
+
+	docRepo := repository.NewDocumentRepository()
+	document, err := docRepo.GetDocumentByFileID(ctx, fileID)
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Document not found", "fileId": fileID})
+			return
+		}
+		logger.ErrorWithOperation(ctx, "document_query", "Database error retrieving document", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve document"})
+		return
+	}
+
+	if _, _, ok := authorizeCorpus(c, document.CorpusName, corpusWrite); !ok {
+		return
+	}
+
+	if document.Status.Normalize() != model.DocumentStatusFailed {
+		c.JSON(http.StatusConflict, gin.H{
+			"error":  "Only failed documents can be reprocessed",
+			"fileId": fileID,
+			"status": document.Status.Normalize(),
+		})
+		return
+	}
+
+	resumeFrom := document.ResumeStep()
+	if req.FromStep != "" {
+		requested := model.DocumentStatus(req.FromStep)
+		if !requested.IsProcessingStep() {
+			c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("fromStep must be one of %v", model.DocumentProcessingSteps)})
+			return
+		}
+		resumeFrom = requested
+	}
+	// Indexing needs the import operation; without it the import has to run again
+	if resumeFrom == model.DocumentStatusIndexing && document.ImportOperation == "" && document.RAGFileID == "" {
+		resumeFrom = model.DocumentStatusImporting
+	}
+
+	tempObjectName := document.TempObjectName
+	if tempObjectName == "" {
+		// Documents created before tempObjectName was tracked keep the temp name in gcsObject until finalized
+		tempObjectName = document.GCSObject
+	}
+	finalObjectName := finalDocumentObjectName(document.FileID, document.DisplayName)
+
+	// The upload is removed once retries are exhausted; reprocessing then needs a fresh upload
+	storedObject := tempObjectName
+	if document.GCSObject == finalObjectName {
+		storedObject = document.GCSObject
+	}
+	exists, err := documentObjectExists(ctx, storedObject)
+	if err != nil {
+		logger.ErrorWithOperation(ctx, "document_reprocess", "Failed to check the stored document object", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to verify document availability"})
+		return
+	}
+	if !exists {
+		c.JSON(http.StatusConflict, gin.H{
+			"error":  "The uploaded file no longer exists, upload the document again",
+			"fileId": fileID,
+		})
+		return
+	}
+
+	// Take the document over from the task that failed, whose pending retries would otherwise
+	// run the import alongside the new task
+	if err := docRepo.QueueAttempt(ctx, fileID, document.Attempts); err != nil {
+		if errors.Is(err, repository.ErrInvalidStatusTransition) {
+			c.JSON(http.StatusConflict, gin.H{"error": "Document changed while queuing it for reprocessing, try again", "fileId": fileID})
+			return
+		}
+		logger.ErrorWithOperation(ctx, "document_reprocess", "Failed to queue document for reprocessing", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to queue document for reprocessing"})
+		return
+	}
+
+	taskPayload := tasks.DocumentTaskPayload{
+		FileID:          document.FileID,
+		TempObjectName:  tempObjectName,
+		FinalObjectName: finalObjectName,
+		CorpusName:      document.CorpusName,
+		DisplayName:     document.DisplayName,
+		ResumeFrom:      resumeFrom,
+		Attempt:         document.Attempts,
+	}
+
+	taskID, err := enqueueDocumentTask(ctx, logger, taskPayload)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to enqueue background processing task"})
+		return
+	}
+
+	c.JSON(http.StatusAccepted, gin.H{
+		"fileId":     document.FileID,
+		"status":     document.Status.Normalize(),
+		"resumeFrom": resumeFrom,
+		"attempts":   document.Attempts,
+		"taskId":     taskID,
+		"message":    fmt.Sprintf("Document queued for reprocessing from the %s step", resumeFrom),
+	})
+}
+
+// documentObjectExists reports whether objectName is stored in GCS; an empty name never is
+func documentObjectExists(ctx context.Context, objectName string) (bool, error) {
+	if objectName == "" {
+		return false, nil
+	}
+	gcs, err := service.NewGCSService()
+	if err != nil {
+		return false, fmt.Errorf("failed to initialize GCS service: %w", err)
+	}
+	defer gcs.Close()
+	return gcs.ObjectExists(ctx, objectName)
+}
+
+// enqueueDocumentTask creates and enqueues the ingestion task for a document. An ingestion of the
+// same document and attempt that is already queued is not enqueued again; its task ID is returned.
+func enqueueDocumentTask(ctx context.Context, logger *utils.Logger, payload tasks.DocumentTaskPayload) (string, error) {
+	// Carry the request's trace and correlation ID into the background run
+	payload.CorrelationID = utils.GetCorrelationID(ctx)
+	payload.TraceContext = tracing.InjectTaskContext(ctx)
+
+	// Create the background task
+	task, err := tasks.NewAddDocumentToCorpusTask(payload)
+	if err != nil {
+		logger.ErrorWithOperation(ctx, "task_creation", "Failed to create background task", err)
+		return "", fmt.Errorf("task creation failed: %w", err)
+	}
+
+	taskID, err := tasks.Enqueue(ctx, task, payload.FileID, map[string]string{
+		"corpus_name":       payload.CorpusName,
+		"temp_object_name":  payload.TempObjectName,
+		"final_object_name": payload.FinalObjectName,
+		"resume_from":       string(payload.ResumeFrom),
+	})
+	if err != nil {
+		logger.ErrorWithOperation(ctx, "task_enqueue", "Failed to enqueue background task", err)
+		return "", fmt.Errorf("task enqueue failed: %w", err)
+	}
+
+	return taskID, nil
+}
+
+// finalDocumentObjectName returns the GCS object name a document is moved to once processed
+func finalDocumentObjectName(fileID, filename string) string {
+	return fmt.Sprintf("documents/%s%s", fileID, filepath.Ext(filename))
+}
+
 // addVertexAICorpusDocument adds a document from GCS or Google Drive to a RAG corpus
 func addVertexAICorpusDocument(corpusName, fileLink string) (map[string]interface{}, error) {
 	ctx := context.Background()
@@ -689,7 +869,8 @@ func deleteVertexAICorpusDocument(corpusName, fileIdentifier string) (map[string
 	// First, try to find document by fileIdentifier in database
 	var documentToDelete *model.Document
 	document, err := docRepo.GetDocumentByFileID(ctx, fileIdentifier)
-	if err == nil {
+	if err == nil && document.CorpusName == corpusName {
+		// Documents of other corpora are never matched so access checks on corpusName hold
 		documentToDelete = document
 		log.Printf("[AI] Found document by file ID match: %s", documentToDelete.FileID)
 	} else {
@@ -853,6 +1034,7 @@ func deleteVertexAICorpusDocument(corpusName, fileIdentifier string) (map[string
 		} else {
 			deletionResults["databaseDeleted"] = true
 			log.Printf("[AI] Successfully deleted from database")
+			refreshCorpusUsage(ctx, documentToDelete.CorpusName)
 		}
 	} else {
 		errorMsg := "document not found in database"
@@ -0,0 +1,229 @@
+package ai_test
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"log"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"lumenslate/internal/controller/ai"
+	service "lumenslate/internal/grpc_service"
+	"lumenslate/internal/grpc_service/aifake"
+	"lumenslate/internal/middleware"
+	"lumenslate/internal/model"
+	"lumenslate/internal/routes"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// fake answers every AI call made by the handlers under test. Tests share it, so they
+// reset it before scripting it and do not run in parallel.
+var fake *aifake.Server
+
+// The in-memory stores the handlers under test authorize against and record sessions in
+var (
+	users         = newMemoryUsers()
+	corpora       = newMemoryCorpora()
+	agentSessions = newMemoryAgentSessions()
+)
+
+// callerSigningKey signs the callers of test requests, as the gateway would
+const callerSigningKey = "test-caller-signing-key"
+
+func TestMain(m *testing.M) {
+	gin.SetMode(gin.TestMode)
+	os.Setenv("CALLER_SIGNING_KEY", callerSigningKey)
+
+	fake = aifake.NewServer()
+	manager, err := fake.Start(service.LoadClientConfig())
+	if err != nil {
+		log.Fatalf("failed to start AI service fake: %v", err)
+	}
+	service.SetClientManager(manager)
+	ai.SetAIService(service.NewAIService())
+	ai.SetUserDirectory(users)
+	ai.SetCorpusStore(corpora)
+	ai.SetAgentSessionStore(agentSessions)
+
+	code := m.Run()
+
+	service.CloseClientManager()
+	fake.Stop()
+	os.Exit(code)
+}
+
+// newRouter serves the AI routes the way the application does
+func newRouter() *gin.Engine {
+	router := gin.New()
+	routes.RegisterAIRoutes(router.Group("/api/v1"))
+	return router
+}
+
+// resetFake restores the fake's default responses now and after the test
+func resetFake(t *testing.T) {
+	t.Helper()
+	fake.Reset()
+	t.Cleanup(fake.Reset)
+}
+
+// callerHeaders returns the headers of a request made on behalf of userID
+func callerHeaders(userID string) map[string]string {
+	return map[string]string{
+		middleware.CallerIDHeader:        userID,
+		middleware.CallerSignatureHeader: middleware.SignCallerID(callerSigningKey, userID, time.Now().Add(time.Minute)),
+	}
+}
+
+// createTeacher stores a teacher for the test and removes it and its agent history afterwards
+func createTeacher(t *testing.T) string {
+	t.Helper()
+
+	teacherID := "test-teacher-" + uuid.New().String()
+	teacher := model.Teacher{ID: teacherID, Name: "Test Teacher", Email: teacherID + "@example.com", CreatedAt: time.Now(), UpdatedAt: time.Now(), IsActive: true}
+	users.SaveTeacher(teacher)
+
+	t.Cleanup(func() {
+		users.DeleteTeacher(teacherID)
+		agentSessions.DeleteTeacherSessions(teacherID)
+	})
+	return teacherID
+}
+
+// createCorpus stores an active corpus record for the test and removes it afterwards
+func createCorpus(t *testing.T, ownerTeacher string) string {
+	t.Helper()
+
+	corpusName := "test-corpus-" + uuid.New().String()
+	corpus := model.NewCorpus(corpusName, ownerTeacher, "", model.CorpusChunking{})
+	if _, err := corpora.EnsureCorpus(context.Background(), corpus); err != nil {
+		t.Fatalf("failed to create corpus: %v", err)
+	}
+
+	t.Cleanup(func() {
+		corpora.DeleteCorpus(corpusName)
+	})
+	return corpusName
+}
+
+// postJSON sends body as JSON to path, with the given headers
+func postJSON(t *testing.T, path string, body interface{}, headers map[string]string) *httptest.ResponseRecorder {
+	t.Helper()
+
+	payload, err := json.Marshal(body)
+	if err != nil {
+		t.Fatalf("failed to encode request: %v", err)
+	}
+	req := httptest.NewRequest(http.MethodPost, path, bytes.NewReader(payload))
+	req.Header.Set("Content-Type", "application/json")
+	for name, value := range headers {
+		req.Header.Set(name, value)
+	}
+
+	recorder := httptest.NewRecorder()
+	newRouter().ServeHTTP(recorder, req)
+	return recorder
+}
+
+// postForm sends fields as a multipart form to path, with the given headers
+func postForm(t *testing.T, path string, fields map[string]string, headers map[string]string) *httptest.ResponseRecorder {
+	t.Helper()
+
+	var body bytes.Buffer
+	writer := multipart.NewWriter(&body)
+	for name, value := range fields {
+		if err := writer.WriteField(name, value); err != nil {
+			t.Fatalf("failed to write form field %s: %v", name, err)
+		}
+	}
+	if err := writer.Close(); err != nil {
+		t.Fatalf("failed to close form: %v", err)
+	}
+
+	req := httptest.NewRequest(http.MethodPost, path, &body)
+	req.Header.Set("Content-Type", writer.FormDataContentType())
+	for name, value := range headers {
+		req.Header.Set(name, value)
+	}
+
+	recorder := httptest.NewRecorder()
+	newRouter().ServeHTTP(recorder, req)
+	return recorder
+}
+
+// decodeBody decodes a JSON response, failing the test when the status is not the expected one
+func decodeBody(t *testing.T, recorder *httptest.ResponseRecorder, status int) map[string]interface{} {
+	t.Helper()
+
+	if recorder.Code != status {
+		t.Fatalf("status = %d, want %d; body: %s", recorder.Code, status, recorder.Body.String())
+	}
+	var body map[string]interface{}
+	if err := json.Unmarshal(recorder.Body.Bytes(), &body); err != nil {
+		t.Fatalf("failed to decode response %q: %v", recorder.Body.String(), err)
+	}
+	return body
+}
+
+// sseEvent is one Server-Sent Event of a streamed response
+type sseEvent struct {
+	Name string
+	Data string
+}
+
+// readEvents parses a Server-Sent Events response body
+func readEvents(t *testing.T, recorder *httptest.ResponseRecorder) []sseEvent {
+	t.Helper()
+
+	if recorder.Code != http.StatusOK {
+		t.Fatalf("status = %d, want %d; body: %s", recorder.Code, http.StatusOK, recorder.Body.String())
+	}
+
+	var events []sseEvent
+	var current sseEvent
+	scanner := bufio.NewScanner(strings.NewReader(recorder.Body.String()))
+	for scanner.Scan() {
+		line := scanner.Text()
+		switch {
+		case line == "":
+			if current.Name != "" || current.Data != "" {
+				events = append(events, current)
+			}
+			current = sseEvent{}
+		case strings.HasPrefix(line, "event:"):
+			current.Name = strings.TrimSpace(strings.TrimPrefix(line, "event:"))
+		case strings.HasPrefix(line, "data:"):
+			current.Data += strings.TrimPrefix(line, "data:")
+		}
+	}
+	if current.Name != "" || current.Data != "" {
+		events = append(events, current)
+	}
+	return events
+}
+
+// lastEvent returns the last event of events, failing the test when it is not named name
+func lastEvent(t *testing.T, events []sseEvent, name string) map[string]interface{} {
+	t.Helper()
+
+	if len(events) == 0 {
+		t.Fatalf("no events received")
+	}
+	last := events[len(events)-1]
+	if last.Name != name {
+		t.Fatalf("last event = %q (%s), want %q", last.Name, last.Data, name)
+	}
+	var data map[string]interface{}
+	if err := json.Unmarshal([]byte(last.Data), &data); err != nil {
+		t.Fatalf("failed to decode %s event %q: %v", name, last.Data, err)
+	}
+	return data
+}
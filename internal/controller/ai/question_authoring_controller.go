@@ -0,0 +1,407 @@
+package ai
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"lumenslate/internal/model"
+	"lumenslate/internal/repository"
+	quest "lumenslate/internal/repository/questions"
+	"lumenslate/internal/tracing"
+	"lumenslate/internal/utils"
+	"lumenslate/tasks"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// AuthorQuestionHandler godoc
+// @Summary      Author Question
+// @Description  Queue a pasted question for authoring. A background job segments it, detects its variables and stores them, creates the question in the bank as inactive with the variables linked, and optionally generates a contextualized version. Poll the returned draft until it is ready, then accept or reject it.
+// @Tags         ai
+// @Accept       json
+// @Produce      json
+// @Param        body  body  ai.AuthorQuestionRequest  true  "Question to author"
+// @Success      202   {object}  map[string]interface{}  "Queued draft and task ID"
+// @Failure      400   {object}  map[string]interface{}  "Invalid request body or question"
+// @Failure      401   {object}  map[string]interface{}  "Missing or unknown X-User-ID header"
+// @Failure      403   {object}  map[string]interface{}  "Caller is not a teacher or does not own the question bank"
+// @Failure      404   {object}  map[string]interface{}  "Question bank not found"
+// @Failure      500   {object}  map[string]interface{}  "Failed to queue the authoring job"
+// @Router       /ai/author-question [post]
+func AuthorQuestionHandler(c *gin.Context) {
+	var req AuthorQuestionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	caller, ok := requireTeacher(c)
+	if !ok {
+		return
+	}
+
+	bank, err := repository.GetQuestionBankByID(req.BankID)
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Question bank not found"})
+			return
+		}
+		log.Printf("[AI] Failed to load question bank %s: %v", req.BankID, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to load the question bank"})
+		return
+	}
+	if bank.TeacherID != caller.ID {
+		c.JSON(http.StatusForbidden, gin.H{"error": "You do not own this question bank"})
+		return
+	}
+
+	authoring := model.NewQuestionAuthoring(caller.ID, bank.ID, req.QuestionType, strings.TrimSpace(req.Question))
+	authoring.Options = req.Options
+	authoring.AnswerIndex = req.AnswerIndex
+	authoring.AnswerIndices = req.AnswerIndices
+	authoring.Answer = req.Answer
+	authoring.IdealAnswer = req.IdealAnswer
+	authoring.GradingCriteria = req.GradingCriteria
+	authoring.Points = req.Points
+	authoring.Difficulty = strings.TrimSpace(req.Difficulty)
+	authoring.Subject = strings.TrimSpace(req.Subject)
+	authoring.GenerateContext = req.GenerateContext
+	authoring.Keywords = req.Keywords
+	authoring.Language = req.Language
+
+	// Check the question the pipeline will create before queueing it
+	if err := utils.Validate.Struct(authoring.BuildQuestion()); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err := validateAuthoredAnswers(authoring); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx := utils.WithCorrelationID(c.Request.Context(), "")
+	repo := repository.NewQuestionAuthoringRepository()
+	if err := repo.Create(ctx, authoring); err != nil {
+		log.Printf("[AI] Failed to create question authoring draft: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create the authoring draft"})
+		return
+	}
+
+	taskID, err := enqueueAuthorQuestionTask(ctx, tasks.AuthorQuestionPayload{AuthoringID: authoring.ID, TeacherID: caller.ID})
+	if err != nil {
+		log.Printf("[AI] Failed to queue question authoring %s: %v", authoring.ID, err)
+		if deleteErr := repo.Delete(ctx, authoring.ID); deleteErr != nil {
+			log.Printf("[AI] Warning: Failed to remove unqueued authoring draft %s: %v", authoring.ID, deleteErr)
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to queue the authoring job"})
+		return
+	}
+	if err := repo.SetTaskID(ctx, authoring.ID, taskID); err != nil {
+		log.Printf("[AI] Warning: %v", err)
+	}
+	authoring.TaskID = taskID
+
+	log.Printf("[AI] Queued question authoring %s for %s in bank %s", authoring.ID, caller.ID, bank.ID)
+	c.JSON(http.StatusAccepted, gin.H{"authoring": authoring, "taskId": taskID})
+}
+
+// ListQuestionAuthoringHandler godoc
+// @Summary      List Question Authoring Drafts
+// @Description  List the calling teacher's question authoring drafts, newest first.
+// @Tags         ai
+// @Produce      json
+// @Param        status  query  string  false  "Only drafts with this status (queued, processing, ready, failed, accepted, rejected)"
+// @Param        limit   query  int     false  "Maximum number of drafts to return (default 20)"
+// @Param        offset  query  int     false  "Number of drafts to skip"
+// @Success      200  {object}  map[string]interface{}  "Drafts and total count"
+// @Failure      401  {object}  map[string]interface{}  "Missing or unknown X-User-ID header"
+// @Failure      403  {object}  map[string]interface{}  "Caller is not a teacher"
+// @Failure      500  {object}  map[string]interface{}  "Failed to list drafts"
+// @Router       /ai/author-question [get]
+func ListQuestionAuthoringHandler(c *gin.Context) {
+	caller, ok := requireTeacher(c)
+	if !ok {
+		return
+	}
+
+	limit, _ := strconv.ParseInt(c.DefaultQuery("limit", "20"), 10, 64)
+	offset, _ := strconv.ParseInt(c.DefaultQuery("offset", "0"), 10, 64)
+	if limit <= 0 || limit > 100 {
+		limit = 20
+	}
+	if offset < 0 {
+		offset = 0
+	}
+
+	drafts, total, err := repository.NewQuestionAuthoringRepository().List(c.Request.Context(), caller.ID, c.Query("status"), limit, offset)
+	if err != nil {
+		log.Printf("[AI] Failed to list question authoring drafts for %s: %v", caller.ID, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to list drafts"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"drafts": drafts,
+		"total":  total,
+		"limit":  limit,
+		"offset": offset,
+	})
+}
+
+// GetQuestionAuthoringHandler godoc
+// @Summary      Get Question Authoring Draft
+// @Description  Get an authoring draft with its pipeline status, segmented question, detected variables and generated context.
+// @Tags         ai
+// @Produce      json
+// @Param        authoringId  path  string  true  "Authoring draft ID"
+// @Success      200  {object}  model.QuestionAuthoring  "Draft"
+// @Failure      401  {object}  map[string]interface{}   "Missing or unknown X-User-ID header"
+// @Failure      403  {object}  map[string]interface{}   "Draft belongs to another teacher"
+// @Failure      404  {object}  map[string]interface{}   "Draft not found"
+// @Router       /ai/author-question/{authoringId} [get]
+func GetQuestionAuthoringHandler(c *gin.Context) {
+	caller, ok := requireTeacher(c)
+	if !ok {
+		return
+	}
+	authoring, ok := loadQuestionAuthoring(c, caller.ID)
+	if !ok {
+		return
+	}
+	c.JSON(http.StatusOK, authoring)
+}
+
+// AcceptQuestionAuthoringHandler godoc
+// @Summary      Accept Question Authoring Draft
+// @Description  Activate the question created for a ready draft. With useContext the contextualized version becomes the question text and the variable positions are moved to match it.
+// @Tags         ai
+// @Accept       json
+// @Produce      json
+// @Param        authoringId  path  string                             true   "Authoring draft ID"
+// @Param        body         body  ai.AcceptQuestionAuthoringRequest  false  "Whether to use the contextualized version"
+// @Success      200  {object}  map[string]interface{}  "Accepted draft and the activated question"
+// @Failure      400  {object}  map[string]interface{}  "Invalid request body or no contextualized version"
+// @Failure      401  {object}  map[string]interface{}  "Missing or unknown X-User-ID header"
+// @Failure      403  {object}  map[string]interface{}  "Draft belongs to another teacher"
+// @Failure      404  {object}  map[string]interface{}  "Draft not found"
+// @Failure      409  {object}  map[string]interface{}  "Draft is not ready for review"
+// @Failure      500  {object}  map[string]interface{}  "Failed to activate the question"
+// @Router       /ai/author-question/{authoringId}/accept [post]
+func AcceptQuestionAuthoringHandler(c *gin.Context) {
+	var req AcceptQuestionAuthoringRequest
+	if c.Request.ContentLength != 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+	}
+	caller, ok := requireTeacher(c)
+	if !ok {
+		return
+	}
+	authoring, ok := loadQuestionAuthoring(c, caller.ID)
+	if !ok {
+		return
+	}
+	if authoring.Status != model.QuestionAuthoringStatusReady {
+		c.JSON(http.StatusConflict, gin.H{"error": "Draft is not ready for review", "status": authoring.Status})
+		return
+	}
+	if req.UseContext && authoring.ContextualizedQuestion == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Draft has no contextualized version"})
+		return
+	}
+
+	// Positions are set from the draft rather than shifted in place, so a retried accept stays correct
+	offset := 0
+	updates := map[string]interface{}{"isActive": true, "question": authoring.QuestionText()}
+	if req.UseContext {
+		offset = utf8.RuneCountInString(authoring.ContextualizedQuestion) - utf8.RuneCountInString(authoring.QuestionText())
+		updates["question"] = authoring.ContextualizedQuestion
+	}
+	for _, v := range authoring.Variables {
+		if _, err := repository.PatchVariable(v.ID, map[string]interface{}{
+			"namePositions":  shiftPositions(v.NamePositions, offset),
+			"valuePositions": shiftPositions(v.ValuePositions, offset),
+		}); err != nil {
+			log.Printf("[AI] Failed to update variable %s of authoring draft %s: %v", v.ID, authoring.ID, err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update the question variables"})
+			return
+		}
+	}
+
+	question, err := patchAuthoredQuestion(authoring, updates)
+	if err != nil {
+		log.Printf("[AI] Failed to activate question %s of authoring draft %s: %v", authoring.QuestionID, authoring.ID, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to activate the question"})
+		return
+	}
+
+	accepted, err := repository.NewQuestionAuthoringRepository().Accept(c.Request.Context(), authoring.ID, req.UseContext)
+	if err != nil {
+		respondQuestionAuthoringError(c, authoring.ID, "accept", err)
+		return
+	}
+
+	log.Printf("[AI] Teacher %s accepted authoring draft %s as %s %s", caller.ID, authoring.ID, authoring.QuestionType, authoring.QuestionID)
+	c.JSON(http.StatusOK, gin.H{"authoring": accepted, "question": question})
+}
+
+// RejectQuestionAuthoringHandler godoc
+// @Summary      Reject Question Authoring Draft
+// @Description  Discard a ready or failed draft and delete the inactive question and the variables the pipeline created for it.
+// @Tags         ai
+// @Produce      json
+// @Param        authoringId  path  string  true  "Authoring draft ID"
+// @Success      200  {object}  model.QuestionAuthoring  "Rejected draft"
+// @Failure      401  {object}  map[string]interface{}   "Missing or unknown X-User-ID header"
+// @Failure      403  {object}  map[string]interface{}   "Draft belongs to another teacher"
+// @Failure      404  {object}  map[string]interface{}   "Draft not found"
+// @Failure      409  {object}  map[string]interface{}   "Draft is still being processed or has already been reviewed"
+// @Router       /ai/author-question/{authoringId}/reject [post]
+func RejectQuestionAuthoringHandler(c *gin.Context) {
+	caller, ok := requireTeacher(c)
+	if !ok {
+		return
+	}
+	authoring, ok := loadQuestionAuthoring(c, caller.ID)
+	if !ok {
+		return
+	}
+
+	// Close the draft first so that a pending retry of the pipeline cannot recreate what is deleted
+	rejected, err := repository.NewQuestionAuthoringRepository().Reject(c.Request.Context(), authoring.ID)
+	if err != nil {
+		respondQuestionAuthoringError(c, authoring.ID, "reject", err)
+		return
+	}
+
+	if err := deleteAuthoredQuestion(rejected); err != nil {
+		log.Printf("[AI] Warning: Failed to delete question %s of rejected authoring draft %s: %v", rejected.QuestionID, rejected.ID, err)
+	}
+	for _, v := range rejected.Variables {
+		if err := repository.DeleteVariable(v.ID); err != nil {
+			log.Printf("[AI] Warning: Failed to delete variable %s of rejected authoring draft %s: %v", v.ID, rejected.ID, err)
+		}
+	}
+
+	log.Printf("[AI] Teacher %s rejected authoring draft %s", caller.ID, rejected.ID)
+	c.JSON(http.StatusOK, rejected)
+}
+
+// loadQuestionAuthoring loads the draft named in the path and checks that it belongs to teacherID.
+// It writes the error response and returns false when the request must not proceed.
+func loadQuestionAuthoring(c *gin.Context, teacherID string) (*model.QuestionAuthoring, bool) {
+	authoringID := c.Param("authoringId")
+	authoring, err := repository.NewQuestionAuthoringRepository().Get(c.Request.Context(), authoringID)
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Draft not found"})
+			return nil, false
+		}
+		log.Printf("[AI] Failed to load question authoring draft %s: %v", authoringID, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to load the draft"})
+		return nil, false
+	}
+
+	if authoring.TeacherID != teacherID {
+		log.Printf("[AI] Teacher %s denied access to question authoring draft %s", teacherID, authoringID)
+		c.JSON(http.StatusForbidden, gin.H{"error": "You do not have access to this draft"})
+		return nil, false
+	}
+
+	return authoring, true
+}
+
+// respondQuestionAuthoringError writes the response for a failed accept or reject
+func respondQuestionAuthoringError(c *gin.Context, authoringID, action string, err error) {
+	switch {
+	case errors.Is(err, repository.ErrQuestionAuthoringState):
+		c.JSON(http.StatusConflict, gin.H{"error": "Draft is still being processed or has already been reviewed"})
+	case errors.Is(err, mongo.ErrNoDocuments):
+		c.JSON(http.StatusNotFound, gin.H{"error": "Draft not found"})
+	default:
+		log.Printf("[AI] Failed to %s question authoring draft %s: %v", action, authoringID, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to " + action + " the draft"})
+	}
+}
+
+// patchAuthoredQuestion applies updates to the question created for a draft and returns it
+func patchAuthoredQuestion(authoring *model.QuestionAuthoring, updates map[string]interface{}) (interface{}, error) {
+	switch authoring.QuestionType {
+	case model.AuthoredQuestionMCQ:
+		return quest.PatchMCQ(authoring.QuestionID, updates)
+	case model.AuthoredQuestionMSQ:
+		return quest.PatchMSQ(authoring.QuestionID, updates)
+	case model.AuthoredQuestionNAT:
+		return quest.PatchNAT(authoring.QuestionID, updates)
+	case model.AuthoredQuestionSubjective:
+		return quest.PatchSubjective(authoring.QuestionID, updates)
+	}
+	return nil, fmt.Errorf("unknown question type %q", authoring.QuestionType)
+}
+
+// deleteAuthoredQuestion deletes the question created for a draft, if the pipeline got that far
+func deleteAuthoredQuestion(authoring *model.QuestionAuthoring) error {
+	switch authoring.QuestionType {
+	case model.AuthoredQuestionMCQ:
+		return quest.DeleteMCQ(authoring.QuestionID)
+	case model.AuthoredQuestionMSQ:
+		return quest.DeleteMSQ(authoring.QuestionID)
+	case model.AuthoredQuestionNAT:
+		return quest.DeleteNAT(authoring.QuestionID)
+	case model.AuthoredQuestionSubjective:
+		return quest.DeleteSubjective(authoring.QuestionID)
+	}
+	return fmt.Errorf("unknown question type %q", authoring.QuestionType)
+}
+
+// validateAuthoredAnswers checks that the answers of an MCQ or MSQ draft point at its options
+func validateAuthoredAnswers(authoring *model.QuestionAuthoring) error {
+	switch authoring.QuestionType {
+	case model.AuthoredQuestionMCQ:
+		if authoring.AnswerIndex >= len(authoring.Options) {
+			return fmt.Errorf("answerIndex %d is out of range for %d options", authoring.AnswerIndex, len(authoring.Options))
+		}
+	case model.AuthoredQuestionMSQ:
+		for _, index := range authoring.AnswerIndices {
+			if index < 0 || index >= len(authoring.Options) {
+				return fmt.Errorf("answerIndices entry %d is out of range for %d options", index, len(authoring.Options))
+			}
+		}
+	}
+	return nil
+}
+
+// shiftPositions moves every position by offset
+func shiftPositions(positions []int, offset int) []int {
+	shifted := make([]int, len(positions))
+	for i, p := range positions {
+		shifted[i] = p + offset
+	}
+	return shifted
+}
+
+// enqueueAuthorQuestionTask enqueues the authoring pipeline of a draft. A run that is
+// already queued or retrying for the draft is reused instead of enqueueing another.
+func enqueueAuthorQuestionTask(ctx context.Context, payload tasks.AuthorQuestionPayload) (string, error) {
+	// Carry the request's trace and correlation ID into the background run
+	payload.CorrelationID = utils.GetCorrelationID(ctx)
+	payload.TraceContext = tracing.InjectTaskContext(ctx)
+
+	task, err := tasks.NewAuthorQuestionTask(payload)
+	if err != nil {
+		return "", fmt.Errorf("task creation failed: %w", err)
+	}
+
+	return tasks.Enqueue(ctx, task, payload.AuthoringID, map[string]string{
+		"authoring_id": payload.AuthoringID,
+		"teacher_id":   payload.TeacherID,
+	})
+}
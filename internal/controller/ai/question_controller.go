@@ -4,8 +4,6 @@ import (
 	"log"
 	"net/http"
 
-	service "lumenslate/internal/grpc_service"
-
 	"github.com/gin-gonic/gin"
 )
 
@@ -16,6 +14,7 @@ import (
 // @Accept       json
 // @Produce      json
 // @Param        body  body  ai.GenerateContextRequest  true  "Request body"
+// @Param        X-AI-Cache-Bypass  header  bool  false  "Ignore the cached response and call the AI service"
 // @Success      200   {object}  map[string]interface{}
 // @Failure      400   {object}  map[string]interface{}
 // @Failure      500   {object}  map[string]interface{}
@@ -27,7 +26,7 @@ func GenerateContextHandler(c *gin.Context) {
 		return
 	}
 
-	content, err := service.GenerateContext(req.Question, req.Keywords, req.Language)
+	content, err := aiServiceFor(c).GenerateContext(req.Question, req.Keywords, req.Language)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
@@ -42,6 +41,7 @@ func GenerateContextHandler(c *gin.Context) {
 // @Accept       json
 // @Produce      json
 // @Param        body  body  ai.DetectVariablesRequest  true  "Request body"
+// @Param        X-AI-Cache-Bypass  header  bool  false  "Ignore the cached response and call the AI service"
 // @Success      200   {object}  map[string]interface{}
 // @Failure      400   {object}  map[string]interface{}
 // @Failure      500   {object}  map[string]interface{}
@@ -53,7 +53,7 @@ func DetectVariablesHandler(c *gin.Context) {
 		return
 	}
 
-	variables, err := service.DetectVariables(req.Question)
+	variables, err := aiServiceFor(c).DetectVariables(req.Question)
 	if err != nil {
 		log.Printf("[AI] DetectVariables error: %v", err)
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
@@ -72,6 +72,7 @@ func DetectVariablesHandler(c *gin.Context) {
 // @Accept       json
 // @Produce      json
 // @Param        body  body  ai.SegmentQuestionRequest  true  "Request body"
+// @Param        X-AI-Cache-Bypass  header  bool  false  "Ignore the cached response and call the AI service"
 // @Success      200   {object}  map[string]interface{}
 // @Failure      400   {object}  map[string]interface{}
 // @Failure      500   {object}  map[string]interface{}
@@ -82,7 +83,7 @@ func SegmentQuestionHandler(c *gin.Context) {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
-	segmented, err := service.SegmentQuestion(req.Question)
+	segmented, err := aiServiceFor(c).SegmentQuestion(req.Question)
 	if err != nil {
 		log.Printf("[AI] SegmentQuestion error: %v", err)
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
@@ -111,7 +112,7 @@ func GenerateMCQVariationsHandler(c *gin.Context) {
 		return
 	}
 	log.Printf("[AI] Request: %+v", req)
-	variations, err := service.GenerateMCQVariations(req.Question, req.Options, req.AnswerIndex)
+	variations, err := aiServiceFor(c).GenerateMCQVariations(req.Question, req.Options, req.AnswerIndex)
 	if err != nil {
 		log.Printf("[AI] GenerateMCQVariations error: %v", err)
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
@@ -141,7 +142,7 @@ func GenerateMSQVariationsHandler(c *gin.Context) {
 		return
 	}
 	log.Printf("[AI] Request: %+v", req)
-	variations, err := service.GenerateMSQVariations(req.Question, req.Options, req.AnswerIndices)
+	variations, err := aiServiceFor(c).GenerateMSQVariations(req.Question, req.Options, req.AnswerIndices)
 	if err != nil {
 		log.Printf("[AI] GenerateMSQVariations error: %v", err)
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
@@ -171,7 +172,7 @@ func FilterAndRandomizeHandler(c *gin.Context) {
 		return
 	}
 	log.Printf("[AI] Request: %+v", req)
-	vars, err := service.FilterAndRandomize(req.Question, req.UserPrompt)
+	vars, err := aiServiceFor(c).FilterAndRandomize(req.Question, req.UserPrompt)
 	if err != nil {
 		log.Printf("[AI] FilterAndRandomize error: %v", err)
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
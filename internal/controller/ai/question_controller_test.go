@@ -0,0 +1,172 @@
+package ai_test
+
+import (
+	"net/http"
+	"testing"
+
+	pb "lumenslate/internal/proto/ai_service"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestGenerateContextHandler(t *testing.T) {
+	resetFake(t)
+	fake.SetResponse("GenerateContext", &pb.GenerateContextResponse{Content: "A farmer has 12 apples."})
+
+	body := decodeBody(t, postJSON(t, "/api/v1/ai/generate-context", map[string]interface{}{
+		"question": "How many apples are left?",
+		"keywords": []string{"farm", "apples"},
+		"language": "English",
+	}, nil), http.StatusOK)
+
+	if body["content"] != "A farmer has 12 apples." {
+		t.Errorf("content = %v, want the fake's response", body["content"])
+	}
+
+	calls := fake.Calls("GenerateContext")
+	if len(calls) != 1 {
+		t.Fatalf("GenerateContext called %d times, want 1", len(calls))
+	}
+	req := calls[0].Request.(*pb.GenerateContextRequest)
+	if req.GetQuestion() != "How many apples are left?" || len(req.GetKeywords()) != 2 || req.GetLanguage() != "English" {
+		t.Errorf("request = %v, want the question, keywords and language sent", req)
+	}
+}
+
+func TestDetectVariablesHandler(t *testing.T) {
+	resetFake(t)
+
+	body := decodeBody(t, postJSON(t, "/api/v1/ai/detect-variables", map[string]string{
+		"question": "A train travels at 60 km/h.",
+	}, nil), http.StatusOK)
+
+	variables, ok := body["variables"].([]interface{})
+	if !ok || len(variables) != 1 {
+		t.Fatalf("variables = %v, want the fake's single variable", body["variables"])
+	}
+	if variable := variables[0].(map[string]interface{}); variable["name"] != "speed" || variable["value"] != "60" {
+		t.Errorf("variable = %v, want speed = 60", variable)
+	}
+	if req := fake.Calls("DetectVariables")[0].Request.(*pb.VariableDetectorRequest); req.GetQuestion() != "A train travels at 60 km/h." {
+		t.Errorf("question sent = %q", req.GetQuestion())
+	}
+}
+
+func TestSegmentQuestionHandler(t *testing.T) {
+	resetFake(t)
+
+	body := decodeBody(t, postJSON(t, "/api/v1/ai/segment-question", map[string]string{
+		"question": "A train travels at 60 km/h. How far does it travel in 2 hours?",
+	}, nil), http.StatusOK)
+
+	if body["segmentedQuestion"] != "How far does the train travel in 2 hours?" {
+		t.Errorf("segmentedQuestion = %v, want the fake's response", body["segmentedQuestion"])
+	}
+}
+
+func TestGenerateMCQVariationsHandler(t *testing.T) {
+	resetFake(t)
+
+	body := decodeBody(t, postJSON(t, "/api/v1/ai/generate-mcq", map[string]interface{}{
+		"question":    "What is 1 + 1?",
+		"options":     []string{"1", "2", "3", "4"},
+		"answerIndex": 1,
+	}, nil), http.StatusOK)
+
+	variations, ok := body["variations"].([]interface{})
+	if !ok || len(variations) != 1 {
+		t.Fatalf("variations = %v, want the fake's single variation", body["variations"])
+	}
+	if variation := variations[0].(map[string]interface{}); variation["question"] != "What is 2 + 3?" {
+		t.Errorf("variation = %v, want the fake's question", variation)
+	}
+
+	req := fake.Calls("GenerateMCQVariations")[0].Request.(*pb.MCQRequest)
+	if req.GetQuestion() != "What is 1 + 1?" || len(req.GetOptions()) != 4 || req.GetAnswerIndex() != 1 {
+		t.Errorf("request = %v, want the question, options and answer sent", req)
+	}
+}
+
+func TestGenerateMSQVariationsHandler(t *testing.T) {
+	resetFake(t)
+
+	body := decodeBody(t, postJSON(t, "/api/v1/ai/generate-msq", map[string]interface{}{
+		"question":      "Which are even?",
+		"options":       []string{"1", "2", "3", "4"},
+		"answerIndices": []int{1, 3},
+	}, nil), http.StatusOK)
+
+	variations, ok := body["variations"].([]interface{})
+	if !ok || len(variations) != 1 {
+		t.Fatalf("variations = %v, want the fake's single variation", body["variations"])
+	}
+	if variation := variations[0].(map[string]interface{}); variation["question"] != "Which of these are prime?" {
+		t.Errorf("variation = %v, want the fake's question", variation)
+	}
+
+	req := fake.Calls("GenerateMSQVariations")[0].Request.(*pb.MSQRequest)
+	if len(req.GetAnswerIndices()) != 2 {
+		t.Errorf("answer indices sent = %v, want 2", req.GetAnswerIndices())
+	}
+}
+
+func TestFilterAndRandomizeHandler(t *testing.T) {
+	resetFake(t)
+
+	body := decodeBody(t, postJSON(t, "/api/v1/ai/filter-and-randomize", map[string]string{
+		"question":   "A train travels at 60 km/h.",
+		"userPrompt": "Keep the speed between 50 and 100",
+	}, nil), http.StatusOK)
+
+	variables, ok := body["variables"].([]interface{})
+	if !ok || len(variables) != 1 {
+		t.Fatalf("variables = %v, want the fake's single variable", body["variables"])
+	}
+	if variable := variables[0].(map[string]interface{}); variable["value"] != "75" {
+		t.Errorf("variable = %v, want the randomized value", variable)
+	}
+
+	req := fake.Calls("FilterAndRandomize")[0].Request.(*pb.FilterAndRandomizerRequest)
+	if req.GetUserPrompt() != "Keep the speed between 50 and 100" {
+		t.Errorf("user prompt sent = %q", req.GetUserPrompt())
+	}
+}
+
+func TestQuestionHandlersReportAIServiceErrors(t *testing.T) {
+	tests := []struct {
+		method string
+		path   string
+		body   interface{}
+	}{
+		{"GenerateContext", "/api/v1/ai/generate-context", map[string]string{"question": "q"}},
+		{"DetectVariables", "/api/v1/ai/detect-variables", map[string]string{"question": "q"}},
+		{"SegmentQuestion", "/api/v1/ai/segment-question", map[string]string{"question": "q"}},
+		{"GenerateMCQVariations", "/api/v1/ai/generate-mcq", map[string]interface{}{"question": "q", "options": []string{"a", "b"}}},
+		{"GenerateMSQVariations", "/api/v1/ai/generate-msq", map[string]interface{}{"question": "q", "options": []string{"a", "b"}}},
+		{"FilterAndRandomize", "/api/v1/ai/filter-and-randomize", map[string]string{"question": "q", "userPrompt": "p"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.method, func(t *testing.T) {
+			resetFake(t)
+			fake.SetError(tt.method, status.Error(codes.InvalidArgument, "model rejected the prompt"))
+
+			body := decodeBody(t, postJSON(t, tt.path, tt.body, nil), http.StatusInternalServerError)
+			if body["error"] == nil {
+				t.Errorf("response %v has no error", body)
+			}
+		})
+	}
+}
+
+func TestQuestionHandlersRejectInvalidJSON(t *testing.T) {
+	resetFake(t)
+
+	recorder := postJSON(t, "/api/v1/ai/generate-mcq", map[string]interface{}{"answerIndex": "first"}, nil)
+	decodeBody(t, recorder, http.StatusBadRequest)
+
+	if calls := fake.Calls(""); len(calls) != 0 {
+		t.Errorf("AI service called %d times for an invalid request", len(calls))
+	}
+}
@@ -3,16 +3,19 @@ package ai
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"log"
 	"net/http"
 	"os"
 	"regexp"
 
-	service "lumenslate/internal/grpc_service"
+	"lumenslate/internal/model"
+	pb "lumenslate/internal/proto/ai_service"
 	"lumenslate/internal/repository"
 
 	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/mongo"
 	"google.golang.org/api/aiplatform/v1"
 	"google.golang.org/api/option"
 )
@@ -26,6 +29,8 @@ import (
 // @Param        body  body  ai.RAGAgentRequest  true  "RAG agent request with teacher ID, role, and message"
 // @Success      200   {object}  map[string]interface{}  "RAG agent response with message, data, and metadata"
 // @Failure      400   {object}  gin.H  "Invalid request body or missing required fields"
+// @Failure      401   {object}  gin.H  "Missing or unknown X-User-ID header"
+// @Failure      403   {object}  gin.H  "Caller has no access to the corpus"
 // @Failure      500   {object}  gin.H  "Internal server error during RAG processing"
 // @Router       /ai/rag-agent [post]
 func RAGAgentHandler(c *gin.Context) {
@@ -37,31 +42,81 @@ func RAGAgentHandler(c *gin.Context) {
 		return
 	}
 
-	// Create/verify corpus for the teacher before processing the request
-	_, err := createVertexAICorpus(req.CorpusName)
-	if err != nil {
-		log.Printf("WARNING: Could not create/verify corpus for teacher %s: %v", req.CorpusName, err)
-		// Continue processing even if corpus creation fails
+	if !authorizeRAGAgent(c, &req) {
+		return
 	}
 
 	// Call the gRPC microservice
-	resp, err := service.RAGAgentClient(req.CorpusName, req.Message)
+	resp, err := aiServiceFor(c).RAGAgentClient(req.CorpusName, req.Message)
 	if err != nil {
 		log.Printf("ERROR: Failed to process RAG agent request: %v", err)
 		c.JSON(http.StatusInternalServerError, gin.H{"message": "Failed to process RAG agent request", "error": err.Error()})
 		return
 	}
 
-	// Process the agent response to determine data content and message
-	responseData, responseMessage, err := processRAGAgentResponse(resp.GetAgentResponse(), req.CorpusName)
+	standardizedResponse, err := buildRAGAgentResponse(resp, req.CorpusName)
 	if err != nil {
 		log.Printf("ERROR: Failed to process RAG agent response: %v", err)
 		c.JSON(http.StatusInternalServerError, gin.H{"message": "Failed to process RAG agent response", "error": err.Error()})
 		return
 	}
 
-	// Build standardized response format (matching /ai/agent structure)
-	standardizedResponse := map[string]interface{}{
+	c.JSON(http.StatusOK, standardizedResponse)
+}
+
+// authorizeRAGAgent checks that the caller may query the requested corpus, creating the corpus record on a
+// teacher's first use. It writes the error response and returns false when the request must not proceed.
+func authorizeRAGAgent(c *gin.Context, req *RAGAgentRequest) bool {
+	caller, ok := requireCaller(c)
+	if !ok {
+		return false
+	}
+
+	ctx := c.Request.Context()
+	corpus, err := loadCorpus(ctx, req.CorpusName)
+	switch {
+	case errors.Is(err, mongo.ErrNoDocuments) && caller.isTeacher():
+		// First use of a new corpus: the calling teacher becomes its owner
+		corpus, err = corpusRecords().EnsureCorpus(ctx, model.NewCorpus(req.CorpusName, caller.ID, "", model.CorpusChunking{}))
+		if err != nil {
+			log.Printf("ERROR: Failed to store corpus record for %s: %v", req.CorpusName, err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create corpus"})
+			return false
+		}
+	case err != nil:
+		respondCorpusLookupError(c, req.CorpusName, err)
+		return false
+	}
+
+	if !caller.canAccess(corpus, corpusRead) {
+		respondCorpusForbidden(c, caller, req.CorpusName)
+		return false
+	}
+	if corpus.Status != model.CorpusStatusActive {
+		c.JSON(http.StatusConflict, gin.H{"error": fmt.Sprintf("Corpus '%s' is %s", req.CorpusName, corpus.Status)})
+		return false
+	}
+
+	// Create/verify corpus for the owner before processing the request
+	if caller.canAccess(corpus, corpusWrite) {
+		if _, err := createVertexAICorpus(req.CorpusName); err != nil {
+			log.Printf("WARNING: Could not create/verify corpus for teacher %s: %v", req.CorpusName, err)
+			// Continue processing even if corpus creation fails
+		}
+	}
+
+	return true
+}
+
+// buildRAGAgentResponse builds the standardized response (matching /ai/agent structure) for a RAG agent reply
+func buildRAGAgentResponse(resp *pb.RAGAgentResponse, corpusName string) (map[string]interface{}, error) {
+	// Process the agent response to determine data content and message
+	responseData, responseMessage, err := processRAGAgentResponse(resp.GetAgentResponse(), corpusName)
+	if err != nil {
+		return nil, err
+	}
+
+	return map[string]interface{}{
 		"message":      responseMessage,
 		"corpusName":   resp.GetCorpusName(),
 		"agentName":    resp.GetAgentName(),
@@ -72,9 +127,7 @@ func RAGAgentHandler(c *gin.Context) {
 		"responseTime": resp.GetResponseTime(),
 		"role":         resp.GetRole(),
 		"feedback":     resp.GetFeedback(),
-	}
-
-	c.JSON(http.StatusOK, standardizedResponse)
+	}, nil
 }
 
 // CreateCorpusHandler godoc
@@ -86,6 +139,8 @@ func RAGAgentHandler(c *gin.Context) {
 // @Param        body  body  ai.CreateCorpusRequest  true  "Corpus creation request containing the corpus name"
 // @Success      200   {object}  map[string]interface{}  "Corpus created or retrieved successfully with corpus details"
 // @Failure      400   {object}  map[string]interface{}  "Invalid request body or missing corpus name"
+// @Failure      401   {object}  map[string]interface{}  "Missing or unknown X-User-ID header"
+// @Failure      403   {object}  map[string]interface{}  "Caller has no access to the corpus"
 // @Failure      500   {object}  map[string]interface{}  "Internal server error during corpus creation"
 // @Router       /ai/rag-agent/create-corpus [post]
 func CreateCorpusHandler(c *gin.Context) {
@@ -98,6 +153,38 @@ func CreateCorpusHandler(c *gin.Context) {
 	}
 	log.Printf("[AI] Request: %+v", req)
 
+	chunking := model.CorpusChunking{ChunkSize: req.ChunkSize, ChunkOverlap: req.ChunkOverlap}
+	if err := validateCorpusChunking(chunking); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	// Only teachers create corpora, and only for themselves
+	caller, ok := requireCaller(c)
+	if !ok {
+		return
+	}
+	if !caller.isTeacher() || (req.TeacherID != "" && req.TeacherID != caller.ID) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Only a teacher can create a corpus, and only for themselves"})
+		return
+	}
+
+	ctx := c.Request.Context()
+	corpusRepo := repository.NewCorpusRepository()
+	if existing, err := loadCorpus(ctx, req.CorpusName); err == nil {
+		if !caller.canAccess(existing, corpusWrite) {
+			respondCorpusForbidden(c, caller, req.CorpusName)
+			return
+		}
+		if existing.Status != model.CorpusStatusActive {
+			c.JSON(http.StatusConflict, gin.H{"error": fmt.Sprintf("Corpus '%s' is %s", req.CorpusName, existing.Status)})
+			return
+		}
+	} else if !errors.Is(err, mongo.ErrNoDocuments) {
+		respondCorpusLookupError(c, req.CorpusName, err)
+		return
+	}
+
 	// Create corpus using Vertex AI
 	corpusResponse, err := createVertexAICorpus(req.CorpusName)
 	if err != nil {
@@ -106,6 +193,18 @@ func CreateCorpusHandler(c *gin.Context) {
 		return
 	}
 
+	// Mirror the corpus in the corpora collection; an existing record keeps its settings
+	corpus := model.NewCorpus(req.CorpusName, caller.ID, req.Description, chunking)
+	if existing, ok := corpusResponse["corpus"].(*aiplatform.GoogleCloudAiplatformV1RagCorpus); ok {
+		corpus.ResourceName = existing.Name
+	}
+	record, err := corpusRepo.EnsureCorpus(ctx, corpus)
+	if err != nil {
+		log.Printf("[AI] Warning: Failed to store corpus record: %v", err)
+	} else {
+		corpusResponse["record"] = record
+	}
+
 	log.Printf("[AI] CreateCorpus success")
 	c.JSON(http.StatusOK, corpusResponse)
 }
@@ -119,6 +218,8 @@ func CreateCorpusHandler(c *gin.Context) {
 // @Param        body  body  ai.CreateCorpusRequest  true  "Request body with corpus name to list content for"
 // @Success      200   {object}  map[string]interface{}  "List of documents in the corpus with metadata"
 // @Failure      400   {object}  map[string]interface{}  "Invalid request body or missing corpus name"
+// @Failure      401   {object}  map[string]interface{}  "Missing or unknown X-User-ID header"
+// @Failure      403   {object}  map[string]interface{}  "Caller has no access to the corpus"
 // @Failure      500   {object}  map[string]interface{}  "Internal server error during content retrieval"
 // @Router       /ai/rag-agent/list-corpus-content [post]
 func ListCorpusContentHandler(c *gin.Context) {
@@ -131,6 +232,10 @@ func ListCorpusContentHandler(c *gin.Context) {
 	}
 	log.Printf("[AI] Request: %+v", req)
 
+	if _, _, ok := authorizeCorpus(c, req.CorpusName, corpusRead); !ok {
+		return
+	}
+
 	// List corpus content using Vertex AI
 	contentResponse, err := listVertexAICorpusContent(req.CorpusName)
 	if err != nil {
@@ -152,6 +257,8 @@ func ListCorpusContentHandler(c *gin.Context) {
 // @Param        corpusName  path    string  true  "Name of the corpus to list documents for"
 // @Success      200         {object}  map[string]interface{}  "List of documents with unified information from database and RAG engine"
 // @Failure      400         {object}  map[string]interface{}  "Invalid or missing corpus name"
+// @Failure      401         {object}  map[string]interface{}  "Missing or unknown X-User-ID header"
+// @Failure      403         {object}  map[string]interface{}  "Caller has no access to the corpus"
 // @Failure      500         {object}  map[string]interface{}  "Internal server error during document retrieval"
 // @Router       /ai/rag-agent/corpus/{corpusName}/documents [get]
 func ListCorpusDocumentsHandler(c *gin.Context) {
@@ -161,6 +268,10 @@ func ListCorpusDocumentsHandler(c *gin.Context) {
 		return
 	}
 
+	if _, _, ok := authorizeCorpus(c, corpusName, corpusRead); !ok {
+		return
+	}
+
 	log.Printf("[AI] Listing documents for corpus: %s", corpusName)
 
 	docRepo := repository.NewDocumentRepository()
@@ -224,7 +335,7 @@ func ListCorpusDocumentsHandler(c *gin.Context) {
 			"inDatabase":    true,
 			"inRAGEngine":   false,
 			"ragEngineInfo": nil,
-			"status":        doc.Status,
+			"status":        doc.Status.Normalize(),
 		}
 
 		// Check if this document exists in RAG engine
@@ -0,0 +1,93 @@
+package ai_test
+
+import (
+	"net/http"
+	"testing"
+
+	pb "lumenslate/internal/proto/ai_service"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// ragAgentRequest is an /ai/rag-agent request on corpusName
+func ragAgentRequest(corpusName, message string) map[string]string {
+	return map[string]string{
+		"corpusName": corpusName,
+		"message":    message,
+		"role":       "teacher",
+	}
+}
+
+// Corpora without an owner are readable by every teacher, so querying them needs no Vertex AI
+// corpus check; the owner's first query would create the corpus in Vertex AI.
+
+func TestRAGAgentHandler(t *testing.T) {
+	resetFake(t)
+	teacherID := createTeacher(t)
+	corpusName := createCorpus(t, "")
+
+	body := decodeBody(t, postJSON(t, "/api/v1/ai/rag-agent", ragAgentRequest(corpusName, "What is the answer?"), callerHeaders(teacherID)), http.StatusOK)
+
+	if body["agentName"] != "rag_agent" || body["sessionId"] != "fake-rag-session" {
+		t.Errorf("response = %v, want the fake's agent and session", body)
+	}
+	// Plain text answers are returned as the message, without data
+	if body["message"] != "According to the uploaded documents, the answer is 42." || body["data"] != nil {
+		t.Errorf("response = %v, want the fake's answer as the message", body)
+	}
+
+	req := fake.Calls("RAGAgent")[0].Request.(*pb.RAGAgentRequest)
+	if req.GetCorpusName() != corpusName || req.GetMessage() != "What is the answer?" {
+		t.Errorf("request = %v, want the corpus and message sent", req)
+	}
+}
+
+func TestRAGAgentHandlerRequiresCaller(t *testing.T) {
+	resetFake(t)
+
+	decodeBody(t, postJSON(t, "/api/v1/ai/rag-agent", ragAgentRequest("any-corpus", "Hello"), nil), http.StatusUnauthorized)
+	if calls := fake.Calls(""); len(calls) != 0 {
+		t.Errorf("AI service called %d times for an unauthenticated request", len(calls))
+	}
+}
+
+func TestRAGAgentHandlerDeniesOtherTeachersCorpus(t *testing.T) {
+	resetFake(t)
+	ownerID := createTeacher(t)
+	otherID := createTeacher(t)
+	corpusName := createCorpus(t, ownerID)
+
+	decodeBody(t, postJSON(t, "/api/v1/ai/rag-agent", ragAgentRequest(corpusName, "Hello"), callerHeaders(otherID)), http.StatusForbidden)
+	if calls := fake.Calls("RAGAgent"); len(calls) != 0 {
+		t.Errorf("RAGAgent called %d times for a forbidden corpus", len(calls))
+	}
+}
+
+func TestRAGAgentHandlerReportsAIServiceErrors(t *testing.T) {
+	resetFake(t)
+	teacherID := createTeacher(t)
+	corpusName := createCorpus(t, "")
+	fake.SetError("RAGAgent", status.Error(codes.InvalidArgument, "corpus is empty"))
+
+	body := decodeBody(t, postJSON(t, "/api/v1/ai/rag-agent", ragAgentRequest(corpusName, "Hello"), callerHeaders(teacherID)), http.StatusInternalServerError)
+	if body["error"] == nil {
+		t.Errorf("response %v has no error", body)
+	}
+}
+
+func TestRAGAgentStreamHandler(t *testing.T) {
+	resetFake(t)
+	teacherID := createTeacher(t)
+	corpusName := createCorpus(t, "")
+
+	events := readEvents(t, postJSON(t, "/api/v1/ai/rag-agent/stream", ragAgentRequest(corpusName, "What is the answer?"), callerHeaders(teacherID)))
+
+	result := lastEvent(t, events, "result")
+	if result["agentName"] != "rag_agent" || result["corpusName"] == nil {
+		t.Errorf("result = %v, want the fake's response", result)
+	}
+	if len(events) < 2 {
+		t.Errorf("events = %v, want updates before the result", events)
+	}
+}
@@ -5,7 +5,6 @@ import (
 	"fmt"
 	"log"
 	"os"
-	"path/filepath"
 	"regexp"
 	"strings"
 
@@ -27,6 +26,8 @@ import (
 // @Param        corpusName  query  string  true  "Name of the RAG corpus to sync"
 // @Success      200         {object}  map[string]interface{}  "Sync completed successfully"
 // @Failure      400         {object}  map[string]interface{}  "Invalid request parameters"
+// @Failure      401         {object}  map[string]interface{}  "Missing or unknown X-User-ID header"
+// @Failure      403         {object}  map[string]interface{}  "Caller has no access to the corpus"
 // @Failure      500         {object}  map[string]interface{}  "Internal server error"
 // @Router       /ai/rag-agent/sync-file-ids [post]
 func SyncRAGFileIDsHandler(c *gin.Context) {
@@ -36,6 +37,10 @@ func SyncRAGFileIDsHandler(c *gin.Context) {
 		return
 	}
 
+	if _, _, ok := authorizeCorpus(c, corpusName, corpusWrite); !ok {
+		return
+	}
+
 	result, err := syncRAGFileIDs(corpusName)
 	if err != nil {
 		log.Printf("[AI] Failed to sync RAG file IDs: %v", err)
@@ -179,14 +184,7 @@ func isDocumentMatch(doc model.Document, ragFile map[string]interface{}) bool {
 	if !ok {
 		return false
 	}
-
-	// Try multiple matching strategies
-	return doc.DisplayName == ragDisplayName ||
-		strings.Contains(ragDisplayName, doc.DisplayName) ||
-		strings.Contains(doc.DisplayName, ragDisplayName) ||
-		// Handle case where one has extension and other doesn't
-		strings.HasPrefix(ragDisplayName, strings.TrimSuffix(doc.DisplayName, filepath.Ext(doc.DisplayName))) ||
-		strings.HasPrefix(doc.DisplayName, strings.TrimSuffix(ragDisplayName, filepath.Ext(ragDisplayName)))
+	return doc.MatchesRAGFile(ragDisplayName)
 }
 
 // extractRAGFileID extracts the RAG file ID from a full resource name
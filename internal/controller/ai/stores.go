@@ -0,0 +1,96 @@
+package ai
+
+import (
+	"context"
+
+	"lumenslate/internal/model"
+	"lumenslate/internal/repository"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// UserDirectory looks up the teachers, students and classrooms requests are authorized against
+type UserDirectory interface {
+	GetTeacherByID(id string) (*model.Teacher, error)
+	GetStudentByID(id string) (*model.Student, error)
+	GetClassroomByID(id string) (*model.Classroom, error)
+}
+
+// CorpusStore looks up and creates the corpus records requests are authorized against
+type CorpusStore interface {
+	GetCorpusByName(ctx context.Context, name string) (*model.Corpus, error)
+	EnsureCorpus(ctx context.Context, corpus *model.Corpus) (*model.Corpus, error)
+}
+
+// AgentSessionStore stores the conversations teachers have with the agent
+type AgentSessionStore interface {
+	CreateSession(ctx context.Context, session *model.AgentSession) error
+	GetSession(ctx context.Context, sessionID primitive.ObjectID) (*model.AgentSession, error)
+	ListSessions(ctx context.Context, teacherID string, limit, offset int64) ([]model.AgentSession, int64, error)
+	RenameSession(ctx context.Context, sessionID primitive.ObjectID, title string) (*model.AgentSession, error)
+	DeleteSession(ctx context.Context, sessionID primitive.ObjectID) error
+	AppendMessages(ctx context.Context, sessionID primitive.ObjectID, serviceSessionID string, messages ...*model.AgentMessage) error
+	ListMessages(ctx context.Context, sessionID primitive.ObjectID) ([]model.AgentMessage, error)
+	SetMessageFeedback(ctx context.Context, sessionID, messageID primitive.ObjectID, feedback string) (*model.AgentMessage, error)
+}
+
+// The stores replacing the MongoDB repositories; nil uses the repositories
+var (
+	userDirectory     UserDirectory
+	corpusStore       CorpusStore
+	agentSessionStore AgentSessionStore
+)
+
+// SetUserDirectory replaces the teacher, student and classroom lookups of the handlers, e.g. with a fake
+func SetUserDirectory(d UserDirectory) {
+	userDirectory = d
+}
+
+// SetCorpusStore replaces the corpus records the handlers authorize against, e.g. with a fake
+func SetCorpusStore(s CorpusStore) {
+	corpusStore = s
+}
+
+// SetAgentSessionStore replaces the store of agent conversations, e.g. with a fake
+func SetAgentSessionStore(s AgentSessionStore) {
+	agentSessionStore = s
+}
+
+// users returns the directory of teachers, students and classrooms
+func users() UserDirectory {
+	if userDirectory != nil {
+		return userDirectory
+	}
+	return repositoryUserDirectory{}
+}
+
+// corpusRecords returns the store of corpus records
+func corpusRecords() CorpusStore {
+	if corpusStore != nil {
+		return corpusStore
+	}
+	return repository.NewCorpusRepository()
+}
+
+// agentSessions returns the store of agent conversations
+func agentSessions() AgentSessionStore {
+	if agentSessionStore != nil {
+		return agentSessionStore
+	}
+	return repository.NewAgentSessionRepository()
+}
+
+// repositoryUserDirectory looks users up in MongoDB
+type repositoryUserDirectory struct{}
+
+func (repositoryUserDirectory) GetTeacherByID(id string) (*model.Teacher, error) {
+	return repository.GetTeacherByID(id)
+}
+
+func (repositoryUserDirectory) GetStudentByID(id string) (*model.Student, error) {
+	return repository.GetStudentByID(id)
+}
+
+func (repositoryUserDirectory) GetClassroomByID(id string) (*model.Classroom, error) {
+	return repository.GetClassroomByID(id)
+}
@@ -0,0 +1,240 @@
+package ai_test
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	"lumenslate/internal/model"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// memoryUsers is an in-memory ai.UserDirectory
+type memoryUsers struct {
+	mu         sync.Mutex
+	teachers   map[string]model.Teacher
+	students   map[string]model.Student
+	classrooms map[string]model.Classroom
+}
+
+func newMemoryUsers() *memoryUsers {
+	return &memoryUsers{
+		teachers:   map[string]model.Teacher{},
+		students:   map[string]model.Student{},
+		classrooms: map[string]model.Classroom{},
+	}
+}
+
+func (u *memoryUsers) SaveTeacher(teacher model.Teacher) {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	u.teachers[teacher.ID] = teacher
+}
+
+func (u *memoryUsers) DeleteTeacher(id string) {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	delete(u.teachers, id)
+}
+
+func (u *memoryUsers) GetTeacherByID(id string) (*model.Teacher, error) {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	teacher, ok := u.teachers[id]
+	if !ok {
+		return nil, mongo.ErrNoDocuments
+	}
+	return &teacher, nil
+}
+
+func (u *memoryUsers) GetStudentByID(id string) (*model.Student, error) {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	student, ok := u.students[id]
+	if !ok {
+		return nil, mongo.ErrNoDocuments
+	}
+	return &student, nil
+}
+
+func (u *memoryUsers) GetClassroomByID(id string) (*model.Classroom, error) {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	classroom, ok := u.classrooms[id]
+	if !ok {
+		return nil, mongo.ErrNoDocuments
+	}
+	return &classroom, nil
+}
+
+// memoryCorpora is an in-memory ai.CorpusStore
+type memoryCorpora struct {
+	mu      sync.Mutex
+	corpora map[string]model.Corpus
+}
+
+func newMemoryCorpora() *memoryCorpora {
+	return &memoryCorpora{corpora: map[string]model.Corpus{}}
+}
+
+func (s *memoryCorpora) DeleteCorpus(name string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.corpora, name)
+}
+
+func (s *memoryCorpora) GetCorpusByName(ctx context.Context, name string) (*model.Corpus, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	corpus, ok := s.corpora[name]
+	if !ok {
+		return nil, mongo.ErrNoDocuments
+	}
+	return &corpus, nil
+}
+
+func (s *memoryCorpora) EnsureCorpus(ctx context.Context, corpus *model.Corpus) (*model.Corpus, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if existing, ok := s.corpora[corpus.Name]; ok {
+		return &existing, nil
+	}
+	if corpus.Status == "" {
+		corpus.Status = model.CorpusStatusActive
+	}
+	s.corpora[corpus.Name] = *corpus
+	stored := *corpus
+	return &stored, nil
+}
+
+// memoryAgentSessions is an in-memory ai.AgentSessionStore
+type memoryAgentSessions struct {
+	mu       sync.Mutex
+	sessions map[primitive.ObjectID]model.AgentSession
+	messages map[primitive.ObjectID][]model.AgentMessage
+}
+
+func newMemoryAgentSessions() *memoryAgentSessions {
+	return &memoryAgentSessions{
+		sessions: map[primitive.ObjectID]model.AgentSession{},
+		messages: map[primitive.ObjectID][]model.AgentMessage{},
+	}
+}
+
+// DeleteTeacherSessions removes the sessions of a teacher
+func (s *memoryAgentSessions) DeleteTeacherSessions(teacherID string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for id, session := range s.sessions {
+		if session.TeacherID == teacherID {
+			delete(s.sessions, id)
+			delete(s.messages, id)
+		}
+	}
+}
+
+func (s *memoryAgentSessions) CreateSession(ctx context.Context, session *model.AgentSession) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	session.ID = primitive.NewObjectID()
+	s.sessions[session.ID] = *session
+	return nil
+}
+
+func (s *memoryAgentSessions) GetSession(ctx context.Context, sessionID primitive.ObjectID) (*model.AgentSession, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	session, ok := s.sessions[sessionID]
+	if !ok {
+		return nil, mongo.ErrNoDocuments
+	}
+	return &session, nil
+}
+
+func (s *memoryAgentSessions) ListSessions(ctx context.Context, teacherID string, limit, offset int64) ([]model.AgentSession, int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	sessions := []model.AgentSession{}
+	for _, session := range s.sessions {
+		if session.TeacherID == teacherID {
+			sessions = append(sessions, session)
+		}
+	}
+	sort.Slice(sessions, func(i, j int) bool { return sessions[i].LastMessageAt.After(sessions[j].LastMessageAt) })
+
+	total := int64(len(sessions))
+	sessions = sessions[min(offset, total):]
+	if limit > 0 && int64(len(sessions)) > limit {
+		sessions = sessions[:limit]
+	}
+	return sessions, total, nil
+}
+
+func (s *memoryAgentSessions) RenameSession(ctx context.Context, sessionID primitive.ObjectID, title string) (*model.AgentSession, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	session, ok := s.sessions[sessionID]
+	if !ok {
+		return nil, mongo.ErrNoDocuments
+	}
+	session.Title = title
+	session.UpdatedAt = time.Now()
+	s.sessions[sessionID] = session
+	return &session, nil
+}
+
+func (s *memoryAgentSessions) DeleteSession(ctx context.Context, sessionID primitive.ObjectID) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.sessions, sessionID)
+	delete(s.messages, sessionID)
+	return nil
+}
+
+func (s *memoryAgentSessions) AppendMessages(ctx context.Context, sessionID primitive.ObjectID, serviceSessionID string, messages ...*model.AgentMessage) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	session, ok := s.sessions[sessionID]
+	if !ok {
+		return mongo.ErrNoDocuments
+	}
+	for _, message := range messages {
+		message.ID = primitive.NewObjectID()
+		message.SessionID = sessionID
+		s.messages[sessionID] = append(s.messages[sessionID], *message)
+	}
+
+	now := time.Now()
+	session.LastMessageAt = now
+	session.UpdatedAt = now
+	session.MessageCount += int64(len(messages))
+	if serviceSessionID != "" {
+		session.ServiceSessionID = serviceSessionID
+	}
+	s.sessions[sessionID] = session
+	return nil
+}
+
+func (s *memoryAgentSessions) ListMessages(ctx context.Context, sessionID primitive.ObjectID) ([]model.AgentMessage, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]model.AgentMessage{}, s.messages[sessionID]...), nil
+}
+
+func (s *memoryAgentSessions) SetMessageFeedback(ctx context.Context, sessionID, messageID primitive.ObjectID, feedback string) (*model.AgentMessage, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for i, message := range s.messages[sessionID] {
+		if message.ID == messageID && message.Role == model.AgentMessageRoleAgent {
+			s.messages[sessionID][i].Feedback = feedback
+			updated := s.messages[sessionID][i]
+			return &updated, nil
+		}
+	}
+	return nil, mongo.ErrNoDocuments
+}
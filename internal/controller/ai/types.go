@@ -1,6 +1,9 @@
 package ai
 
-import "mime/multipart"
+import (
+	"encoding/json"
+	"mime/multipart"
+)
 
 // --- Request Structs for AI Operations ---
 
@@ -30,13 +33,80 @@ type GenerateMSQVariationsRequest struct {
 	AnswerIndices []int32  `json:"answerIndices"`
 }
 
+type GenerateVariationDraftsRequest struct {
+	QuestionType string `json:"questionType" binding:"required,oneof=mcq msq"`
+	QuestionID   string `json:"questionId" binding:"required"`
+}
+
+type EditVariationDraftRequest struct {
+	Question      *string  `json:"question"`
+	Options       []string `json:"options"`
+	AnswerIndex   *int     `json:"answerIndex"`   // MCQ drafts
+	AnswerIndices []int    `json:"answerIndices"` // MSQ drafts
+}
+
+type AuthorQuestionRequest struct {
+	BankID          string   `json:"bankId" binding:"required"`
+	QuestionType    string   `json:"questionType" binding:"required,oneof=mcq msq nat subjective"`
+	Question        string   `json:"question" binding:"required"`
+	Options         []string `json:"options"`         // MCQ and MSQ
+	AnswerIndex     int      `json:"answerIndex"`     // MCQ
+	AnswerIndices   []int    `json:"answerIndices"`   // MSQ
+	Answer          float64  `json:"answer"`          // NAT
+	IdealAnswer     *string  `json:"idealAnswer"`     // Subjective
+	GradingCriteria []string `json:"gradingCriteria"` // Subjective
+	Points          int      `json:"points"`
+	Difficulty      string   `json:"difficulty" binding:"required"`
+	Subject         string   `json:"subject" binding:"required"`
+	GenerateContext bool     `json:"generateContext"` // Also generate a contextualized version of the question
+	Keywords        []string `json:"keywords"`        // Passed to context generation
+	Language        string   `json:"language"`        // Passed to context generation
+}
+
+type AcceptQuestionAuthoringRequest struct {
+	UseContext bool `json:"useContext"` // Save the contextualized version as the question text
+}
+
 type FilterAndRandomizeRequest struct {
 	Question   string `json:"question"`
 	UserPrompt string `json:"userPrompt"`
 }
 
 type CreateCorpusRequest struct {
-	CorpusName string `json:"corpusName" binding:"required"`
+	CorpusName   string `json:"corpusName" binding:"required"`
+	TeacherID    string `json:"teacherId"`    // Optional: owner of the corpus
+	Description  string `json:"description"`  // Optional
+	ChunkSize    int64  `json:"chunkSize"`    // Optional: fixed length chunk size used on import
+	ChunkOverlap int64  `json:"chunkOverlap"` // Optional: overlap between chunks
+}
+
+// UpdateCorpusRequest updates the settings of a corpus; omitted fields are left unchanged
+type UpdateCorpusRequest struct {
+	DisplayName  *string `json:"displayName"`
+	Description  *string `json:"description"`
+	ChunkSize    *int64  `json:"chunkSize"`
+	ChunkOverlap *int64  `json:"chunkOverlap"`
+}
+
+type RenameAgentSessionRequest struct {
+	Title string `json:"title" binding:"required,max=200"`
+}
+
+type AgentFeedbackRequest struct {
+	Feedback string `json:"feedback" binding:"omitempty,oneof=positive negative"` // Empty clears the feedback
+}
+
+type AgentProposalDecisionRequest struct {
+	Note string `json:"note" binding:"max=1000"`
+}
+
+type EditAgentProposalRequest struct {
+	Changes json.RawMessage `json:"changes" binding:"required" swaggertype:"object"` // Fields of the proposed record to change
+	Note    string          `json:"note" binding:"max=1000"`
+}
+
+type ShareCorpusRequest struct {
+	ClassroomID string `json:"classroomId" binding:"required"`
 }
 
 type DeleteCorpusDocumentRequest struct {
@@ -49,6 +119,10 @@ type AddCorpusDocumentFormRequest struct {
 	File       *multipart.FileHeader `form:"file" binding:"required"`
 }
 
+type ReprocessDocumentRequest struct {
+	FromStep string `json:"fromStep"` // Optional: importing, indexing or finalizing
+}
+
 type ViewDocumentRequest struct {
 	DocumentID string `uri:"id" binding:"required"`
 }
@@ -61,6 +135,7 @@ type AgentRequest struct {
 	FileType  string                `form:"fileType"`
 	CreatedAt string                `form:"createdAt"`
 	UpdatedAt string                `form:"updatedAt"`
+	SessionID string                `form:"sessionId"` // Continue this conversation; a new one is started when empty
 }
 
 type RAGAgentRequest struct {
@@ -0,0 +1,476 @@
+package ai
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	service "lumenslate/internal/grpc_service"
+	"lumenslate/internal/model/questions"
+	"lumenslate/internal/repository"
+	quest "lumenslate/internal/repository/questions"
+	"lumenslate/internal/utils"
+
+	"github.com/gin-gonic/gin"
+	"github.com/go-playground/validator/v10"
+	"github.com/google/uuid"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// GenerateVariationDraftsHandler godoc
+// @Summary      Generate Variation Drafts
+// @Description  Generate AI variations of a stored MCQ or MSQ and keep them as drafts linked to it. Drafts are reviewed with the accept, edit and reject endpoints; only accepted drafts are added to the question bank.
+// @Tags         ai
+// @Accept       json
+// @Produce      json
+// @Param        body  body  ai.GenerateVariationDraftsRequest  true  "Question to vary"
+// @Success      201   {object}  map[string]interface{}  "Generated drafts"
+// @Failure      400   {object}  map[string]interface{}  "Invalid request body"
+// @Failure      401   {object}  map[string]interface{}  "Missing or unknown X-User-ID header"
+// @Failure      403   {object}  map[string]interface{}  "Caller is not a teacher or does not own the question's bank"
+// @Failure      404   {object}  map[string]interface{}  "Question not found"
+// @Failure      500   {object}  map[string]interface{}  "Failed to generate or store variations"
+// @Router       /ai/variations [post]
+func GenerateVariationDraftsHandler(c *gin.Context) {
+	var req GenerateVariationDraftsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	caller, ok := requireTeacher(c)
+	if !ok {
+		return
+	}
+
+	var drafts []questions.VariationDraft
+	var err error
+	switch req.QuestionType {
+	case questions.VariationTypeMCQ:
+		drafts, err = generateMCQDrafts(aiServiceFor(c), caller.ID, req.QuestionID)
+	case questions.VariationTypeMSQ:
+		drafts, err = generateMSQDrafts(aiServiceFor(c), caller.ID, req.QuestionID)
+	}
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			c.JSON(http.StatusNotFound, gin.H{"error": fmt.Sprintf("%s %s not found", strings.ToUpper(req.QuestionType), req.QuestionID)})
+			return
+		}
+		if errors.Is(err, errNotBankOwner) {
+			c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+			return
+		}
+		log.Printf("[AI] Failed to generate variations of %s %s: %v", req.QuestionType, req.QuestionID, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	if len(drafts) == 0 {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "The AI service returned no usable variations"})
+		return
+	}
+
+	if err := quest.SaveVariationDrafts(drafts); err != nil {
+		log.Printf("[AI] Failed to store variation drafts of %s: %v", req.QuestionID, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to store the variations"})
+		return
+	}
+
+	log.Printf("[AI] Generated %d variation drafts of %s %s for %s", len(drafts), req.QuestionType, req.QuestionID, caller.ID)
+	c.JSON(http.StatusCreated, gin.H{"drafts": drafts})
+}
+
+// ListVariationDraftsHandler godoc
+// @Summary      List Variation Drafts
+// @Description  List the calling teacher's variation drafts, newest first.
+// @Tags         ai
+// @Produce      json
+// @Param        parentQuestionId  query  string  false  "Only variations of this question"
+// @Param        status            query  string  false  "Only drafts with this status (draft, accepting, accepted, rejected)"
+// @Param        limit             query  int     false  "Maximum number of drafts to return (default 20)"
+// @Param        offset            query  int     false  "Number of drafts to skip"
+// @Success      200  {object}  map[string]interface{}  "Drafts"
+// @Failure      401  {object}  map[string]interface{}  "Missing or unknown X-User-ID header"
+// @Failure      403  {object}  map[string]interface{}  "Caller is not a teacher"
+// @Failure      500  {object}  map[string]interface{}  "Failed to list drafts"
+// @Router       /ai/variations [get]
+func ListVariationDraftsHandler(c *gin.Context) {
+	caller, ok := requireTeacher(c)
+	if !ok {
+		return
+	}
+
+	filters := map[string]string{
+		"parentQuestionId": c.Query("parentQuestionId"),
+		"status":           c.Query("status"),
+		"limit":            c.DefaultQuery("limit", "20"),
+		"offset":           c.DefaultQuery("offset", "0"),
+	}
+	drafts, err := quest.GetVariationDrafts(caller.ID, filters)
+	if err != nil {
+		log.Printf("[AI] Failed to list variation drafts for %s: %v", caller.ID, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to list drafts"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"drafts": drafts})
+}
+
+// EditVariationDraftHandler godoc
+// @Summary      Edit Variation Draft
+// @Description  Change the question text, options or answers of a draft before accepting it.
+// @Tags         ai
+// @Accept       json
+// @Produce      json
+// @Param        draftId  path  string                        true  "Draft ID"
+// @Param        body     body  ai.EditVariationDraftRequest  true  "Fields to change"
+// @Success      200  {object}  questions.VariationDraft  "Updated draft"
+// @Failure      400  {object}  map[string]interface{}    "Invalid request body or resulting question"
+// @Failure      401  {object}  map[string]interface{}    "Missing or unknown X-User-ID header"
+// @Failure      403  {object}  map[string]interface{}    "Draft belongs to another teacher"
+// @Failure      404  {object}  map[string]interface{}    "Draft not found"
+// @Failure      409  {object}  map[string]interface{}    "Draft has already been reviewed"
+// @Router       /ai/variations/{draftId} [patch]
+func EditVariationDraftHandler(c *gin.Context) {
+	var req EditVariationDraftRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	caller, ok := requireTeacher(c)
+	if !ok {
+		return
+	}
+	draft, ok := loadVariationDraft(c, caller.ID)
+	if !ok {
+		return
+	}
+
+	edited := *draft
+	if req.Question != nil {
+		edited.Question = strings.TrimSpace(*req.Question)
+	}
+	if req.Options != nil {
+		edited.Options = req.Options
+	}
+	if req.AnswerIndex != nil {
+		edited.AnswerIndex = *req.AnswerIndex
+	}
+	if req.AnswerIndices != nil {
+		edited.AnswerIndices = req.AnswerIndices
+	}
+	if err := validateVariationDraft(&edited); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	updated, err := quest.UpdateVariationDraft(draft.ID, bson.M{
+		"question":      edited.Question,
+		"options":       edited.Options,
+		"answerIndex":   edited.AnswerIndex,
+		"answerIndices": edited.AnswerIndices,
+		"edited":        true,
+	})
+	if err != nil {
+		respondVariationDraftError(c, draft.ID, "edit", err)
+		return
+	}
+	c.JSON(http.StatusOK, updated)
+}
+
+// AcceptVariationDraftHandler godoc
+// @Summary      Accept Variation Draft
+// @Description  Save a draft as a question in the bank of the question it was generated from, recording its lineage (parentQuestionId, generator and generationPrompt). Accepting a draft whose earlier accept did not finish completes it.
+// @Tags         ai
+// @Produce      json
+// @Param        draftId  path  string  true  "Draft ID"
+// @Success      200  {object}  map[string]interface{}  "Accepted draft and the saved question"
+// @Failure      400  {object}  map[string]interface{}  "Draft is not a valid question"
+// @Failure      401  {object}  map[string]interface{}  "Missing or unknown X-User-ID header"
+// @Failure      403  {object}  map[string]interface{}  "Draft belongs to another teacher"
+// @Failure      404  {object}  map[string]interface{}  "Draft not found"
+// @Failure      409  {object}  map[string]interface{}  "Draft has already been reviewed"
+// @Failure      500  {object}  map[string]interface{}  "Failed to save the question"
+// @Router       /ai/variations/{draftId}/accept [post]
+func AcceptVariationDraftHandler(c *gin.Context) {
+	caller, ok := requireTeacher(c)
+	if !ok {
+		return
+	}
+	draft, ok := loadVariationDraft(c, caller.ID)
+	if !ok {
+		return
+	}
+	var err error
+	switch draft.Status {
+	case questions.VariationDraftStatusDraft:
+		// Claim the draft before saving its question, so that an edit or reject racing the accept cannot
+		// leave a saved question behind a draft that was changed or rejected
+		draft, err = quest.ClaimVariationDraft(draft.ID)
+		if err != nil {
+			respondVariationDraftError(c, c.Param("draftId"), "accept", err)
+			return
+		}
+	case questions.VariationDraftStatusAccepting:
+		// An earlier accept claimed the draft but did not finish; saving again is safe, see below
+		log.Printf("[AI] Resuming the accept of variation draft %s", draft.ID)
+	default:
+		c.JSON(http.StatusConflict, gin.H{"error": "Draft has already been reviewed", "status": draft.Status})
+		return
+	}
+
+	var question interface{}
+	switch draft.QuestionType {
+	case questions.VariationTypeMCQ:
+		mcq := draft.ToMCQ()
+		if err = utils.Validate.Struct(mcq); err == nil {
+			err = quest.SaveMCQ(*mcq)
+		}
+		question = mcq
+	case questions.VariationTypeMSQ:
+		msq := draft.ToMSQ()
+		if err = utils.Validate.Struct(msq); err == nil {
+			err = quest.SaveMSQ(*msq)
+		}
+		question = msq
+	default:
+		err = fmt.Errorf("unknown question type %q", draft.QuestionType)
+	}
+	// The question takes the draft's ID, so a duplicate means an earlier accept saved it but could not
+	// mark the draft accepted
+	if err != nil && !mongo.IsDuplicateKeyError(err) {
+		if _, releaseErr := quest.ReleaseVariationDraft(draft.ID); releaseErr != nil {
+			log.Printf("[AI] Failed to release variation draft %s: %v", draft.ID, releaseErr)
+		}
+		var validationErr validator.ValidationErrors
+		if errors.As(err, &validationErr) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		log.Printf("[AI] Failed to save accepted variation draft %s: %v", draft.ID, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save the question"})
+		return
+	}
+
+	accepted, err := quest.MarkVariationDraftAccepted(draft.ID, caller.ID, time.Now())
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		// A concurrent accept of the same draft finished first
+		respondVariationDraftError(c, draft.ID, "accept", err)
+		return
+	}
+	if err != nil {
+		log.Printf("[AI] Variation draft %s was saved as a question but could not be marked accepted: %v", draft.ID, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "The question was saved but the draft could not be marked accepted, accept it again to finish"})
+		return
+	}
+
+	log.Printf("[AI] Teacher %s accepted variation draft %s of %s %s", caller.ID, draft.ID, draft.QuestionType, draft.ParentQuestionID)
+	c.JSON(http.StatusOK, gin.H{"draft": accepted, "question": question})
+}
+
+// RejectVariationDraftHandler godoc
+// @Summary      Reject Variation Draft
+// @Description  Discard a draft; it is kept with status rejected and never added to the question bank.
+// @Tags         ai
+// @Produce      json
+// @Param        draftId  path  string  true  "Draft ID"
+// @Success      200  {object}  questions.VariationDraft  "Rejected draft"
+// @Failure      401  {object}  map[string]interface{}    "Missing or unknown X-User-ID header"
+// @Failure      403  {object}  map[string]interface{}    "Draft belongs to another teacher"
+// @Failure      404  {object}  map[string]interface{}    "Draft not found"
+// @Failure      409  {object}  map[string]interface{}    "Draft has already been reviewed"
+// @Router       /ai/variations/{draftId}/reject [post]
+func RejectVariationDraftHandler(c *gin.Context) {
+	caller, ok := requireTeacher(c)
+	if !ok {
+		return
+	}
+	draft, ok := loadVariationDraft(c, caller.ID)
+	if !ok {
+		return
+	}
+
+	rejected, err := quest.UpdateVariationDraft(draft.ID, bson.M{
+		"status":     questions.VariationDraftStatusRejected,
+		"reviewedBy": caller.ID,
+		"reviewedAt": time.Now(),
+	})
+	if err != nil {
+		respondVariationDraftError(c, draft.ID, "reject", err)
+		return
+	}
+	c.JSON(http.StatusOK, rejected)
+}
+
+// errNotBankOwner is returned when a teacher asks for variations of a question in another teacher's bank
+var errNotBankOwner = errors.New("you do not own the question bank of this question")
+
+// requireBankOwner checks that teacherID owns the question bank bankID. A missing bank belongs to nobody.
+func requireBankOwner(bankID, teacherID string) error {
+	bank, err := repository.GetQuestionBankByID(bankID)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return errNotBankOwner
+	}
+	if err != nil {
+		return fmt.Errorf("failed to load question bank %s: %w", bankID, err)
+	}
+	if bank.TeacherID != teacherID {
+		return errNotBankOwner
+	}
+	return nil
+}
+
+// generateMCQDrafts generates variations of a stored MCQ as drafts in its bank, which teacherID must own
+func generateMCQDrafts(ai service.AIService, teacherID, questionID string) ([]questions.VariationDraft, error) {
+	parent, err := quest.GetMCQByID(questionID)
+	if err != nil {
+		return nil, err
+	}
+	if err := requireBankOwner(parent.BankID, teacherID); err != nil {
+		return nil, err
+	}
+	variations, err := ai.GenerateMCQVariations(parent.Question, parent.Options, int32(parent.AnswerIndex))
+	if err != nil {
+		return nil, err
+	}
+
+	prompt := questions.VariationPrompt(parent.Question, parent.Options)
+	drafts := make([]questions.VariationDraft, 0, len(variations))
+	for _, variation := range variations {
+		draft := newVariationDraft(teacherID, questions.VariationTypeMCQ, parent.ID, parent.BankID, questions.GeneratorMCQVariations, prompt)
+		draft.Question = variation.GetQuestion()
+		draft.Options = variation.GetOptions()
+		draft.AnswerIndex = int(variation.GetAnswerIndex())
+		draft.Points, draft.Difficulty, draft.Subject = parent.Points, parent.Difficulty, parent.Subject
+		if err := validateVariationDraft(draft); err != nil {
+			log.Printf("[AI] Dropping unusable variation of MCQ %s: %v", parent.ID, err)
+			continue
+		}
+		drafts = append(drafts, *draft)
+	}
+	return drafts, nil
+}
+
+// generateMSQDrafts generates variations of a stored MSQ as drafts in its bank, which teacherID must own
+func generateMSQDrafts(ai service.AIService, teacherID, questionID string) ([]questions.VariationDraft, error) {
+	parent, err := quest.GetMSQByID(questionID)
+	if err != nil {
+		return nil, err
+	}
+	if err := requireBankOwner(parent.BankID, teacherID); err != nil {
+		return nil, err
+	}
+	answerIndices := make([]int32, len(parent.AnswerIndices))
+	for i, index := range parent.AnswerIndices {
+		answerIndices[i] = int32(index)
+	}
+	variations, err := ai.GenerateMSQVariations(parent.Question, parent.Options, answerIndices)
+	if err != nil {
+		return nil, err
+	}
+
+	prompt := questions.VariationPrompt(parent.Question, parent.Options)
+	drafts := make([]questions.VariationDraft, 0, len(variations))
+	for _, variation := range variations {
+		draft := newVariationDraft(teacherID, questions.VariationTypeMSQ, parent.ID, parent.BankID, questions.GeneratorMSQVariations, prompt)
+		draft.Question = variation.GetQuestion()
+		draft.Options = variation.GetOptions()
+		for _, index := range variation.GetAnswerIndices() {
+			draft.AnswerIndices = append(draft.AnswerIndices, int(index))
+		}
+		draft.Points, draft.Difficulty, draft.Subject = parent.Points, parent.Difficulty, parent.Subject
+		if err := validateVariationDraft(draft); err != nil {
+			log.Printf("[AI] Dropping unusable variation of MSQ %s: %v", parent.ID, err)
+			continue
+		}
+		drafts = append(drafts, *draft)
+	}
+	return drafts, nil
+}
+
+func newVariationDraft(teacherID, questionType, parentID, bankID, generator, prompt string) *questions.VariationDraft {
+	now := time.Now()
+	return &questions.VariationDraft{
+		ID:               uuid.New().String(),
+		TeacherID:        teacherID,
+		QuestionType:     questionType,
+		ParentQuestionID: parentID,
+		BankID:           bankID,
+		Generator:        generator,
+		GenerationPrompt: prompt,
+		Status:           questions.VariationDraftStatusDraft,
+		CreatedAt:        now,
+		UpdatedAt:        now,
+	}
+}
+
+// validateVariationDraft checks that a draft is a well-formed question of its type
+func validateVariationDraft(draft *questions.VariationDraft) error {
+	if len(strings.TrimSpace(draft.Question)) < 3 {
+		return fmt.Errorf("question must be at least 3 characters")
+	}
+	if len(draft.Options) < 2 {
+		return fmt.Errorf("at least 2 options are required")
+	}
+	for i, option := range draft.Options {
+		if strings.TrimSpace(option) == "" {
+			return fmt.Errorf("option %d is empty", i)
+		}
+	}
+
+	switch draft.QuestionType {
+	case questions.VariationTypeMCQ:
+		if draft.AnswerIndex < 0 || draft.AnswerIndex >= len(draft.Options) {
+			return fmt.Errorf("answerIndex %d is out of range", draft.AnswerIndex)
+		}
+	case questions.VariationTypeMSQ:
+		if len(draft.AnswerIndices) == 0 {
+			return fmt.Errorf("at least one answer index is required")
+		}
+		seen := make(map[int]bool, len(draft.AnswerIndices))
+		for _, index := range draft.AnswerIndices {
+			if index < 0 || index >= len(draft.Options) {
+				return fmt.Errorf("answer index %d is out of range", index)
+			}
+			if seen[index] {
+				return fmt.Errorf("answer index %d is repeated", index)
+			}
+			seen[index] = true
+		}
+	}
+	return nil
+}
+
+// loadVariationDraft loads the draft named in the path and checks that it belongs to teacherID.
+// It writes the error response and returns false when the request must not proceed.
+func loadVariationDraft(c *gin.Context, teacherID string) (*questions.VariationDraft, bool) {
+	draftID := c.Param("draftId")
+	draft, err := quest.GetVariationDraftByID(draftID)
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Draft not found"})
+			return nil, false
+		}
+		log.Printf("[AI] Failed to load variation draft %s: %v", draftID, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to load the draft"})
+		return nil, false
+	}
+
+	if draft.TeacherID != teacherID {
+		c.JSON(http.StatusForbidden, gin.H{"error": "You do not have access to this draft"})
+		return nil, false
+	}
+	return draft, true
+}
+
+// respondVariationDraftError writes the response for a failed edit, accept or reject. The update only
+// matches drafts awaiting review, so a missing document means the draft was reviewed in the meantime.
+func respondVariationDraftError(c *gin.Context, draftID, action string, err error) {
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		c.JSON(http.StatusConflict, gin.H{"error": "Draft has already been reviewed"})
+		return
+	}
+	log.Printf("[AI] Failed to %s variation draft %s: %v", action, draftID, err)
+	c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to " + action + " the draft"})
+}
@@ -0,0 +1,86 @@
+package controller
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+
+	"lumenslate/internal/repository"
+	"lumenslate/internal/utils"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// CorpusAdminController lets operators manage corpora outside their owner's control
+type CorpusAdminController struct {
+	corpora *repository.CorpusRepository
+	logger  *utils.Logger
+}
+
+// NewCorpusAdminController creates a new corpus admin controller
+func NewCorpusAdminController() *CorpusAdminController {
+	return &CorpusAdminController{
+		corpora: repository.NewCorpusRepository(),
+		logger:  utils.NewLogger("corpus_admin_controller"),
+	}
+}
+
+// AssignCorpusOwnerRequest names the teacher who should own a corpus
+type AssignCorpusOwnerRequest struct {
+	TeacherID string `json:"teacherId" binding:"required"`
+}
+
+// AssignCorpusOwnerHandler godoc
+// @Summary      Assign Corpus Owner
+// @Description  Makes a teacher the owner of a corpus, giving them write and delete access. Corpora created before ownership was tracked have no owner and are read-only until one is assigned.
+// @Tags         Admin
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        corpusName  path  string                    true  "Corpus name"
+// @Param        request     body  AssignCorpusOwnerRequest  true  "Teacher to make the owner"
+// @Success      200  {object}  model.Corpus
+// @Failure      400  {object}  map[string]interface{}  "Invalid request or unknown teacher"
+// @Failure      401  {object}  map[string]interface{}  "Missing or invalid admin token"
+// @Failure      404  {object}  map[string]interface{}  "Corpus not found"
+// @Failure      500  {object}  map[string]interface{}  "Internal server error"
+// @Router       /admin/corpora/{corpusName}/owner [put]
+func (cc *CorpusAdminController) AssignCorpusOwnerHandler(c *gin.Context) {
+	ctx := c.Request.Context()
+	corpusName := c.Param("corpusName")
+
+	var req AssignCorpusOwnerRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body", "message": err.Error()})
+		return
+	}
+	teacherID := strings.TrimSpace(req.TeacherID)
+
+	if _, err := repository.GetTeacherByID(teacherID); err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Unknown teacher '" + teacherID + "'"})
+			return
+		}
+		cc.logger.ErrorWithOperation(ctx, "assign_corpus_owner", "Failed to look up teacher", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to look up teacher"})
+		return
+	}
+
+	corpus, err := cc.corpora.AssignOwner(ctx, corpusName, teacherID)
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Corpus '" + corpusName + "' not found"})
+			return
+		}
+		cc.logger.ErrorWithOperation(ctx, "assign_corpus_owner", "Failed to assign corpus owner", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to assign corpus owner", "message": err.Error()})
+		return
+	}
+
+	cc.logger.InfoWithMetrics(ctx, "assign_corpus_owner", "Corpus owner assigned", 0, map[string]string{
+		"corpus_name": corpusName,
+		"teacher_id":  teacherID,
+	})
+	c.JSON(http.StatusOK, corpus)
+}
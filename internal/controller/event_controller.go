@@ -0,0 +1,150 @@
+package controller
+
+import (
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"lumenslate/internal/controller/ai"
+	"lumenslate/internal/model"
+	"lumenslate/internal/repository"
+	"lumenslate/internal/service"
+	"lumenslate/internal/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+// eventHeartbeatInterval keeps idle SSE connections open through proxies and load balancers
+const eventHeartbeatInterval = 15 * time.Second
+
+// EventController streams background job events to clients over Server-Sent Events
+type EventController struct {
+	broker *service.EventBroker
+	logger *utils.Logger
+}
+
+// NewEventController creates a new event controller
+func NewEventController(broker *service.EventBroker) *EventController {
+	return &EventController{
+		broker: broker,
+		logger: utils.NewLogger("event_controller"),
+	}
+}
+
+// StreamEventsHandler godoc
+// @Summary      Stream Background Job Events
+// @Description  Opens a Server-Sent Events stream of state changes for the given documents and/or user. The current status of each requested document is sent first, followed by live events published by background workers on any replica. Callers (X-User-ID header) can only follow their own user stream and documents of corpora they can read.
+// @Tags         Events
+// @Produce      text/event-stream
+// @Param        X-User-ID  header  string    true   "ID of the teacher or student making the request"
+// @Param        fileId     query   []string  false  "Document file IDs to follow (repeatable)"
+// @Param        userId     query   string    false  "User whose documents and jobs to follow; must be the caller"
+// @Success      200  {string}  string  "Event stream"
+// @Failure      400  {object}  map[string]interface{}  "Neither fileId nor userId given"
+// @Failure      401  {object}  map[string]interface{}  "Missing or unknown X-User-ID header"
+// @Failure      403  {object}  map[string]interface{}  "Caller may not follow the user or a document"
+// @Failure      404  {object}  map[string]interface{}  "Document not found"
+// @Failure      503  {object}  map[string]interface{}  "Event broker unavailable"
+// @Router       /events [get]
+func (ec *EventController) StreamEventsHandler(c *gin.Context) {
+	ctx := utils.WithCorrelationID(c.Request.Context(), "")
+
+	fileIDs := c.QueryArray("fileId")
+	userID := c.Query("userId")
+	if len(fileIDs) == 0 && userID == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "fileId or userId query parameter is required"})
+		return
+	}
+
+	documents, ok := ai.AuthorizeEventStream(c, fileIDs, userID)
+	if !ok {
+		return
+	}
+
+	var channels []string
+	for _, fileID := range fileIDs {
+		channels = append(channels, service.FileChannel(fileID))
+	}
+	if userID != "" {
+		channels = append(channels, service.UserChannel(userID))
+	}
+
+	pubsub, err := ec.broker.Subscribe(ctx, channels...)
+	if err != nil {
+		ec.logger.ErrorWithOperation(ctx, "event_subscribe", "Failed to subscribe to events", err)
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "Event stream is unavailable"})
+		return
+	}
+	defer pubsub.Close()
+
+	ec.logger.InfoWithMetrics(ctx, "event_subscribe", "Client subscribed to events", 0, map[string]string{
+		"channels": strings.Join(channels, ","),
+	})
+
+	c.Header("Content-Type", "text/event-stream")
+	c.Header("Cache-Control", "no-cache")
+	c.Header("Connection", "keep-alive")
+	c.Header("X-Accel-Buffering", "no") // Disable proxy buffering (nginx)
+
+	// Send the current status first so clients don't miss transitions that happened before subscribing
+	docRepo := repository.NewDocumentRepository()
+	for _, document := range documents {
+		if current, err := docRepo.GetDocumentByFileID(ctx, document.FileID); err == nil {
+			document = current
+		}
+		c.SSEvent(service.EventTypeDocumentStatus, documentSnapshotEvent(document))
+	}
+	c.Writer.Flush()
+
+	heartbeat := time.NewTicker(eventHeartbeatInterval)
+	defer heartbeat.Stop()
+	messages := pubsub.Channel()
+
+	c.Stream(func(w io.Writer) bool {
+		select {
+		case <-ctx.Done():
+			return false
+		case msg, ok := <-messages:
+			if !ok {
+				return false
+			}
+			event, err := service.DecodeEvent(msg)
+			if err != nil {
+				ec.logger.ErrorWithOperation(ctx, "event_decode", "Dropping malformed event", err)
+				return true
+			}
+			c.SSEvent(event.Type, event)
+			return true
+		case <-heartbeat.C:
+			c.SSEvent("heartbeat", gin.H{"timestamp": time.Now().UTC()})
+			return true
+		}
+	})
+
+	ec.logger.InfoWithOperation(ctx, "event_unsubscribe", "Client disconnected from event stream")
+}
+
+// documentSnapshotEvent builds the initial event describing a document's current state
+func documentSnapshotEvent(document *model.Document) service.Event {
+	data := map[string]interface{}{
+		"corpusName":  document.CorpusName,
+		"displayName": document.DisplayName,
+		"attempt":     document.Attempts,
+		"progress":    document.Progress(),
+		"snapshot":    true,
+	}
+	if document.FailedStep != "" {
+		data["failedStep"] = document.FailedStep
+	}
+
+	return service.Event{
+		Type:      service.EventTypeDocumentStatus,
+		FileID:    document.FileID,
+		UserID:    document.UploadedBy,
+		Status:    string(document.Status.Normalize()),
+		Message:   document.ErrorMsg,
+		Data:      data,
+		Timestamp: document.UpdatedAt,
+	}
+}
@@ -3,6 +3,7 @@ package controller
 import (
 	"fmt"
 	"net/http"
+	"slices"
 	"time"
 
 	"lumenslate/internal/service"
@@ -14,14 +15,16 @@ import (
 // HealthController handles health check endpoints
 type HealthController struct {
 	metricsCollector *service.MetricsCollector
+	readiness        *service.ReadinessRegistry
 	startTime        time.Time
 	logger           *utils.Logger
 }
 
 // NewHealthController creates a new health controller
-func NewHealthController(metricsCollector *service.MetricsCollector, startTime time.Time) *HealthController {
+func NewHealthController(metricsCollector *service.MetricsCollector, readiness *service.ReadinessRegistry, startTime time.Time) *HealthController {
 	return &HealthController{
 		metricsCollector: metricsCollector,
+		readiness:        readiness,
 		startTime:        startTime,
 		logger:           utils.NewLogger("health_controller"),
 	}
@@ -29,18 +32,28 @@ func NewHealthController(metricsCollector *service.MetricsCollector, startTime t
 
 // BasicHealthHandler godoc
 // @Summary      Basic Health Check
-// @Description  Returns basic health status of the application
+// @Description  Returns the overall status of the application from the cached dependency checks, ok when every dependency is up
 // @Tags         Health
 // @Produce      json
-// @Success      200  {object}  map[string]interface{}  "Application is healthy"
+// @Success      200  {object}  map[string]interface{}  "Application is healthy or degraded"
+// @Failure      503  {object}  map[string]interface{}  "A critical dependency is down"
 // @Router       /health [get]
 func (hc *HealthController) BasicHealthHandler(c *gin.Context) {
 	ctx := utils.WithCorrelationID(c.Request.Context(), "")
 
-	hc.logger.InfoWithOperation(ctx, "basic_health", "Basic health check requested")
+	report := hc.readiness.Check(ctx)
 
-	c.JSON(http.StatusOK, gin.H{
-		"status":    "ok",
+	status := report.Status
+	statusCode := http.StatusOK
+	switch {
+	case !report.Ready:
+		statusCode = http.StatusServiceUnavailable
+	case status == service.ReadinessReady:
+		status = "ok"
+	}
+
+	c.JSON(statusCode, gin.H{
+		"status":    status,
 		"timestamp": time.Now().UTC(),
 		"uptime":    time.Since(hc.startTime).String(),
 	})
@@ -48,7 +61,7 @@ func (hc *HealthController) BasicHealthHandler(c *gin.Context) {
 
 // BackgroundProcessingHealthHandler godoc
 // @Summary      Background Processing Health Check
-// @Description  Returns detailed health status of the background processing system including metrics and alerts
+// @Description  Returns detailed health status of the background processing system including metrics and alerts raised by the configured alert rules
 // @Tags         Health
 // @Produce      json
 // @Success      200  {object}  service.HealthStatus  "Background processing system health status"
@@ -60,16 +73,8 @@ func (hc *HealthController) BackgroundProcessingHealthHandler(c *gin.Context) {
 
 	hc.logger.InfoWithOperation(ctx, "bg_health_check", "Background processing health check requested")
 
-	// Define alert thresholds
-	thresholds := service.AlertThresholds{
-		MaxErrorRate:     0.1,             // 10% max error rate
-		MaxQueueDepth:    100,             // Max 100 items in queue
-		MaxProcessingLag: 5 * time.Minute, // Max 5 minutes processing lag
-		MinSuccessRate:   0.9,             // Min 90% success rate
-	}
-
 	// Get health status
-	healthStatus := hc.metricsCollector.CheckHealth(ctx, thresholds, hc.startTime)
+	healthStatus := hc.metricsCollector.CheckHealthWithRules(ctx, hc.metricsCollector.AlertRules(), hc.startTime)
 
 	// Log health check results
 	metadata := map[string]string{
@@ -124,13 +129,15 @@ func (hc *HealthController) MetricsHandler(c *gin.Context) {
 
 // TaskMetricsHandler godoc
 // @Summary      Task-specific Metrics
-// @Description  Returns metrics for a specific task type
+// @Description  Returns the success rate and p50/p95/p99 latency of a task type over a window, from the outcomes recorded by every web and worker process. Windows longer than an hour are summarized from hourly counters, with latencies estimated from histograms.
 // @Tags         Health
 // @Produce      json
-// @Param        taskType  path    string  true  "Task type to get metrics for"
-// @Success      200       {object}  service.TaskMetrics  "Task metrics"
-// @Failure      400       {object}  map[string]interface{}  "Invalid task type"
+// @Param        taskType  path    string  true   "Task type to get metrics for"
+// @Param        window    query   string  false  "Window ending now, e.g. 1h, 24h, 7d or 1w (default 1h)"
+// @Success      200       {object}  service.TaskStats  "Task metrics"
+// @Failure      400       {object}  map[string]interface{}  "Invalid task type or window"
 // @Failure      404       {object}  map[string]interface{}  "Task type not found"
+// @Failure      500       {object}  map[string]interface{}  "Internal server error"
 // @Router       /health/metrics/task/{taskType} [get]
 func (hc *HealthController) TaskMetricsHandler(c *gin.Context) {
 	ctx := utils.WithCorrelationID(c.Request.Context(), "")
@@ -145,12 +152,24 @@ func (hc *HealthController) TaskMetricsHandler(c *gin.Context) {
 		return
 	}
 
+	history := hc.metricsCollector.TaskHistory()
+	window, err := taskMetricsWindow(c, history)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
 	hc.logger.InfoWithOperation(ctx, "task_metrics_request", "Task metrics requested for: "+taskType)
 
-	taskMetrics := hc.metricsCollector.GetTaskMetrics(taskType)
+	taskTypes, err := history.TaskTypes(ctx)
+	if err != nil {
+		hc.logger.ErrorWithOperation(ctx, "task_metrics_error", "Failed to list task types", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve task metrics", "message": err.Error()})
+		return
+	}
 
 	// Check if task type has any recorded metrics
-	if taskMetrics.TotalCount == 0 {
+	if !slices.Contains(taskTypes, taskType) {
 		hc.logger.ErrorWithOperation(ctx, "task_metrics_not_found", "No metrics found for task type: "+taskType, nil)
 		c.JSON(http.StatusNotFound, gin.H{
 			"error":     "Task type not found",
@@ -160,60 +179,101 @@ func (hc *HealthController) TaskMetricsHandler(c *gin.Context) {
 		return
 	}
 
+	stats, err := history.Stats(ctx, taskType, window)
+	if err != nil {
+		hc.logger.ErrorWithOperation(ctx, "task_metrics_error", "Failed to get task metrics for: "+taskType, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve task metrics", "message": err.Error()})
+		return
+	}
+
 	hc.logger.InfoWithOperation(ctx, "task_metrics_success", "Task metrics retrieved successfully for: "+taskType)
-	c.JSON(http.StatusOK, taskMetrics)
+	c.JSON(http.StatusOK, stats)
 }
 
-// ReadinessHandler godoc
-// @Summary      Readiness Check
-// @Description  Returns readiness status indicating if the application is ready to serve requests
+// AllTaskMetricsHandler godoc
+// @Summary      Metrics of Every Task Type
+// @Description  Returns the success rate and p50/p95/p99 latency of every task type with recorded outcomes over a window. Windows longer than an hour are summarized from hourly counters, with latencies estimated from histograms.
 // @Tags         Health
 // @Produce      json
-// @Success      200  {object}  map[string]interface{}  "Application is ready"
-// @Failure      503  {object}  map[string]interface{}  "Application is not ready"
-// @Router       /health/ready [get]
-func (hc *HealthController) ReadinessHandler(c *gin.Context) {
+// @Param        window  query   string  false  "Window ending now, e.g. 1h, 24h, 7d or 1w (default 1h)"
+// @Success      200     {array}   service.TaskStats  "Task metrics by task type"
+// @Failure      400     {object}  map[string]interface{}  "Invalid window"
+// @Failure      500     {object}  map[string]interface{}  "Internal server error"
+// @Router       /health/metrics/tasks [get]
+func (hc *HealthController) AllTaskMetricsHandler(c *gin.Context) {
 	ctx := utils.WithCorrelationID(c.Request.Context(), "")
+	ctx = utils.WithRequestID(ctx, c.GetHeader("X-Request-ID"))
+
+	history := hc.metricsCollector.TaskHistory()
+	window, err := taskMetricsWindow(c, history)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
 
-	hc.logger.InfoWithOperation(ctx, "readiness_check", "Readiness check requested")
+	taskTypes, err := history.TaskTypes(ctx)
+	if err != nil {
+		hc.logger.ErrorWithOperation(ctx, "task_metrics_error", "Failed to list task types", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve task metrics", "message": err.Error()})
+		return
+	}
 
-	// Check if background processing is healthy
-	thresholds := service.AlertThresholds{
-		MaxErrorRate:     0.2,              // More lenient for readiness
-		MaxQueueDepth:    200,              // More lenient for readiness
-		MaxProcessingLag: 10 * time.Minute, // More lenient for readiness
-		MinSuccessRate:   0.8,              // More lenient for readiness
+	all := make([]service.TaskStats, 0, len(taskTypes))
+	for _, taskType := range taskTypes {
+		stats, err := history.Stats(ctx, taskType, window)
+		if err != nil {
+			hc.logger.ErrorWithOperation(ctx, "task_metrics_error", "Failed to get task metrics for: "+taskType, err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve task metrics", "message": err.Error()})
+			return
+		}
+		all = append(all, stats)
 	}
 
-	healthStatus := hc.metricsCollector.CheckHealth(ctx, thresholds, hc.startTime)
+	c.JSON(http.StatusOK, all)
+}
 
-	// Consider ready if not critical
-	ready := healthStatus.Status != "critical"
+// taskMetricsWindow parses the window query parameter, defaulting to the last hour
+func taskMetricsWindow(c *gin.Context, history *service.TaskHistory) (time.Duration, error) {
+	window, err := service.ParseWindow(c.DefaultQuery("window", "1h"))
+	if err != nil {
+		return 0, err
+	}
+	if window <= 0 || window > history.Retention() {
+		return 0, fmt.Errorf("window must be positive and at most the retention of %s", history.Retention())
+	}
+	return window, nil
+}
 
-	if ready {
-		hc.logger.InfoWithOperation(ctx, "readiness_success", "Application is ready")
-		c.JSON(http.StatusOK, gin.H{
-			"status":    "ready",
-			"timestamp": time.Now().UTC(),
-			"uptime":    time.Since(hc.startTime).String(),
-			"background_processing": gin.H{
-				"status":      healthStatus.Status,
-				"queue_depth": healthStatus.SystemMetrics.QueueDepth,
-			},
-		})
-	} else {
+// CacheMetricsHandler godoc
+// @Summary      AI Response Cache Metrics
+// @Description  Returns hits, misses and hit rate of the AI response cache for each cached RPC
+// @Tags         Health
+// @Produce      json
+// @Success      200  {object}  map[string]service.CacheMetrics  "Cache metrics by RPC name"
+// @Router       /health/metrics/cache [get]
+func (hc *HealthController) CacheMetricsHandler(c *gin.Context) {
+	c.JSON(http.StatusOK, hc.metricsCollector.GetCacheMetrics())
+}
+
+// ReadinessHandler godoc
+// @Summary      Readiness Check
+// @Description  Checks MongoDB, Redis, the AI microservice and object storage, reporting the status and latency of each. Responds 503 only when a critical dependency is down; an outage of the others reports the application as degraded. Results are cached briefly.
+// @Tags         Health
+// @Produce      json
+// @Success      200  {object}  service.ReadinessReport  "Application is ready or degraded"
+// @Failure      503  {object}  service.ReadinessReport  "A critical dependency is down"
+// @Router       /health/ready [get]
+func (hc *HealthController) ReadinessHandler(c *gin.Context) {
+	ctx := utils.WithCorrelationID(c.Request.Context(), "")
+
+	report := hc.readiness.Check(ctx)
+	if !report.Ready {
 		hc.logger.ErrorWithOperation(ctx, "readiness_failure", "Application is not ready", nil)
-		c.JSON(http.StatusServiceUnavailable, gin.H{
-			"status":    "not_ready",
-			"timestamp": time.Now().UTC(),
-			"reason":    "Background processing is in critical state",
-			"background_processing": gin.H{
-				"status":      healthStatus.Status,
-				"queue_depth": healthStatus.SystemMetrics.QueueDepth,
-				"alerts":      len(healthStatus.Alerts),
-			},
-		})
+		c.JSON(http.StatusServiceUnavailable, report)
+		return
 	}
+
+	c.JSON(http.StatusOK, report)
 }
 
 // LivenessHandler godoc
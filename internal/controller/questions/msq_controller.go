@@ -76,15 +76,19 @@ func DeleteMSQ(c *gin.Context) {
 // @Tags MSQs
 // @Produce json
 // @Param bankId query string false "Filter by bank ID"
+// @Param parentQuestionId query string false "Only variations generated from this question"
+// @Param aiGenerated query bool false "Only AI-generated (true) or only authored (false) questions"
 // @Param limit query string false "Pagination limit"
 // @Param offset query string false "Pagination offset"
 // @Success 200 {array} questions.MSQ
 // @Router /msqs [get]
 func GetAllMSQs(c *gin.Context) {
 	filters := map[string]string{
-		"bankId": c.Query("bankId"),
-		"limit":  c.DefaultQuery("limit", "10"),
-		"offset": c.DefaultQuery("offset", "0"),
+		"bankId":           c.Query("bankId"),
+		"parentQuestionId": c.Query("parentQuestionId"),
+		"aiGenerated":      c.Query("aiGenerated"),
+		"limit":            c.DefaultQuery("limit", "10"),
+		"offset":           c.DefaultQuery("offset", "0"),
 	}
 	msqs, err := repo.GetAllMSQs(filters)
 	if err != nil {
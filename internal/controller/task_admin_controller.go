@@ -0,0 +1,474 @@
+package controller
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"lumenslate/internal/model"
+	"lumenslate/internal/repository"
+	"lumenslate/internal/service"
+	"lumenslate/internal/utils"
+	"lumenslate/tasks"
+
+	"github.com/gin-gonic/gin"
+	"github.com/hibiken/asynq"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// TaskAdminController exposes the asynq queues and tasks to operators
+type TaskAdminController struct {
+	admin       *service.TaskAdmin
+	documents   *repository.DocumentRepository
+	deadLetters *repository.DeadLetterRepository
+	reports     *repository.ReconciliationRepository
+	logger      *utils.Logger
+}
+
+// NewTaskAdminController creates a new task admin controller
+func NewTaskAdminController(admin *service.TaskAdmin) *TaskAdminController {
+	return &TaskAdminController{
+		admin:       admin,
+		documents:   repository.NewDocumentRepository(),
+		deadLetters: repository.NewDeadLetterRepository(),
+		reports:     repository.NewReconciliationRepository(),
+		logger:      utils.NewLogger("task_admin_controller"),
+	}
+}
+
+// AdminTaskDetail is a task together with the document it processes, if any
+type AdminTaskDetail struct {
+	service.AdminTask
+	Document *model.Document `json:"document,omitempty"`
+}
+
+// ListQueuesHandler godoc
+// @Summary      List Task Queues
+// @Description  Returns the size of every asynq queue by task state, its latency, whether it is paused, and today's processed and failed counts
+// @Tags         Admin
+// @Produce      json
+// @Security     BearerAuth
+// @Success      200  {array}   service.AdminQueue
+// @Failure      401  {object}  map[string]interface{}  "Missing or invalid admin token"
+// @Failure      500  {object}  map[string]interface{}  "Internal server error"
+// @Router       /admin/queues [get]
+func (tc *TaskAdminController) ListQueuesHandler(c *gin.Context) {
+	queues, err := tc.admin.Queues()
+	if err != nil {
+		tc.respondError(c, "list_queues", err)
+		return
+	}
+	c.JSON(http.StatusOK, queues)
+}
+
+// PauseQueueHandler godoc
+// @Summary      Pause Task Queue
+// @Description  Stops workers from picking up the tasks of a queue; tasks keep being enqueued
+// @Tags         Admin
+// @Produce      json
+// @Security     BearerAuth
+// @Param        queue  path  string  true  "Queue name"
+// @Success      200  {object}  map[string]interface{}
+// @Failure      401  {object}  map[string]interface{}  "Missing or invalid admin token"
+// @Failure      404  {object}  map[string]interface{}  "Queue not found"
+// @Failure      409  {object}  map[string]interface{}  "Queue already paused"
+// @Router       /admin/queues/{queue}/pause [post]
+func (tc *TaskAdminController) PauseQueueHandler(c *gin.Context) {
+	queue := c.Param("queue")
+	if err := tc.admin.PauseQueue(queue); err != nil {
+		tc.respondError(c, "pause_queue", err)
+		return
+	}
+	tc.logger.InfoWithOperation(c.Request.Context(), "pause_queue", "Paused queue "+queue)
+	c.JSON(http.StatusOK, gin.H{"queue": queue, "paused": true})
+}
+
+// UnpauseQueueHandler godoc
+// @Summary      Resume Task Queue
+// @Description  Resumes processing the tasks of a paused queue
+// @Tags         Admin
+// @Produce      json
+// @Security     BearerAuth
+// @Param        queue  path  string  true  "Queue name"
+// @Success      200  {object}  map[string]interface{}
+// @Failure      401  {object}  map[string]interface{}  "Missing or invalid admin token"
+// @Failure      404  {object}  map[string]interface{}  "Queue not found"
+// @Failure      409  {object}  map[string]interface{}  "Queue not paused"
+// @Router       /admin/queues/{queue}/unpause [post]
+func (tc *TaskAdminController) UnpauseQueueHandler(c *gin.Context) {
+	queue := c.Param("queue")
+	if err := tc.admin.UnpauseQueue(queue); err != nil {
+		tc.respondError(c, "unpause_queue", err)
+		return
+	}
+	tc.logger.InfoWithOperation(c.Request.Context(), "unpause_queue", "Resumed queue "+queue)
+	c.JSON(http.StatusOK, gin.H{"queue": queue, "paused": false})
+}
+
+// ListTasksHandler godoc
+// @Summary      List Tasks
+// @Description  Lists the tasks of a queue in a state with their payload, retries and last error
+// @Tags         Admin
+// @Produce      json
+// @Security     BearerAuth
+// @Param        queue  path   string  true   "Queue name"
+// @Param        state  query  string  false  "Task state: pending, active, scheduled, retry, archived or completed (default archived)"
+// @Param        page   query  int     false  "Page number, starting at 1 (default 1)"
+// @Param        size   query  int     false  "Page size, at most 100 (default 20)"
+// @Success      200  {array}   service.AdminTask
+// @Failure      400  {object}  map[string]interface{}  "Invalid state or paging"
+// @Failure      401  {object}  map[string]interface{}  "Missing or invalid admin token"
+// @Failure      404  {object}  map[string]interface{}  "Queue not found"
+// @Router       /admin/queues/{queue}/tasks [get]
+func (tc *TaskAdminController) ListTasksHandler(c *gin.Context) {
+	page, err := strconv.Atoi(c.DefaultQuery("page", "1"))
+	if err != nil || page < 1 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "page must be a positive integer"})
+		return
+	}
+	size, err := strconv.Atoi(c.DefaultQuery("size", "20"))
+	if err != nil || size < 1 || size > 100 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "size must be between 1 and 100"})
+		return
+	}
+
+	tasks, err := tc.admin.ListTasks(c.Param("queue"), c.DefaultQuery("state", "archived"), page, size)
+	if err != nil {
+		tc.respondError(c, "list_tasks", err)
+		return
+	}
+	c.JSON(http.StatusOK, tasks)
+}
+
+// GetTaskHandler godoc
+// @Summary      Get Task
+// @Description  Returns a task with its payload and last error, and the document it processes for document tasks
+// @Tags         Admin
+// @Produce      json
+// @Security     BearerAuth
+// @Param        queue   path  string  true  "Queue name"
+// @Param        taskId  path  string  true  "Task ID"
+// @Success      200  {object}  AdminTaskDetail
+// @Failure      401  {object}  map[string]interface{}  "Missing or invalid admin token"
+// @Failure      404  {object}  map[string]interface{}  "Queue or task not found"
+// @Router       /admin/queues/{queue}/tasks/{taskId} [get]
+func (tc *TaskAdminController) GetTaskHandler(c *gin.Context) {
+	task, err := tc.admin.GetTask(c.Param("queue"), c.Param("taskId"))
+	if err != nil {
+		tc.respondError(c, "get_task", err)
+		return
+	}
+
+	detail := AdminTaskDetail{AdminTask: task}
+	if task.FileID != "" {
+		document, err := tc.documents.GetDocumentByFileID(c.Request.Context(), task.FileID)
+		if err != nil && !errors.Is(err, mongo.ErrNoDocuments) {
+			tc.respondError(c, "get_task_document", err)
+			return
+		}
+		detail.Document = document
+	}
+	c.JSON(http.StatusOK, detail)
+}
+
+// RetryTaskHandler godoc
+// @Summary      Retry Task
+// @Description  Runs a scheduled, retry or archived task right away. A document ingestion resumes from the step that failed.
+// @Tags         Admin
+// @Produce      json
+// @Security     BearerAuth
+// @Param        queue   path  string  true  "Queue name"
+// @Param        taskId  path  string  true  "Task ID"
+// @Success      200  {object}  map[string]interface{}
+// @Failure      401  {object}  map[string]interface{}  "Missing or invalid admin token"
+// @Failure      404  {object}  map[string]interface{}  "Queue or task not found"
+// @Failure      409  {object}  map[string]interface{}  "Task is pending or active"
+// @Router       /admin/queues/{queue}/tasks/{taskId}/retry [post]
+func (tc *TaskAdminController) RetryTaskHandler(c *gin.Context) {
+	tc.taskAction(c, "retry_task", "retried", tc.admin.RunTask)
+}
+
+// ArchiveTaskHandler godoc
+// @Summary      Archive Task
+// @Description  Archives a pending, scheduled or retry task so it is not processed until retried
+// @Tags         Admin
+// @Produce      json
+// @Security     BearerAuth
+// @Param        queue   path  string  true  "Queue name"
+// @Param        taskId  path  string  true  "Task ID"
+// @Success      200  {object}  map[string]interface{}
+// @Failure      401  {object}  map[string]interface{}  "Missing or invalid admin token"
+// @Failure      404  {object}  map[string]interface{}  "Queue or task not found"
+// @Failure      409  {object}  map[string]interface{}  "Task is active or already archived"
+// @Router       /admin/queues/{queue}/tasks/{taskId}/archive [post]
+func (tc *TaskAdminController) ArchiveTaskHandler(c *gin.Context) {
+	tc.taskAction(c, "archive_task", "archived", tc.admin.ArchiveTask)
+}
+
+// CancelTaskHandler godoc
+// @Summary      Cancel Task
+// @Description  Cancels an active task. The canceled run counts as a failure and is retried while retries remain.
+// @Tags         Admin
+// @Produce      json
+// @Security     BearerAuth
+// @Param        queue   path  string  true  "Queue name"
+// @Param        taskId  path  string  true  "Task ID"
+// @Success      200  {object}  map[string]interface{}
+// @Failure      401  {object}  map[string]interface{}  "Missing or invalid admin token"
+// @Failure      404  {object}  map[string]interface{}  "Queue or task not found"
+// @Failure      409  {object}  map[string]interface{}  "Task is not active"
+// @Router       /admin/queues/{queue}/tasks/{taskId}/cancel [post]
+func (tc *TaskAdminController) CancelTaskHandler(c *gin.Context) {
+	tc.taskAction(c, "cancel_task", "canceled", tc.admin.CancelTask)
+}
+
+// DeleteTaskHandler godoc
+// @Summary      Delete Task
+// @Description  Deletes a task that is not active
+// @Tags         Admin
+// @Produce      json
+// @Security     BearerAuth
+// @Param        queue   path  string  true  "Queue name"
+// @Param        taskId  path  string  true  "Task ID"
+// @Success      200  {object}  map[string]interface{}
+// @Failure      401  {object}  map[string]interface{}  "Missing or invalid admin token"
+// @Failure      404  {object}  map[string]interface{}  "Queue or task not found"
+// @Failure      409  {object}  map[string]interface{}  "Task is active"
+// @Router       /admin/queues/{queue}/tasks/{taskId} [delete]
+func (tc *TaskAdminController) DeleteTaskHandler(c *gin.Context) {
+	tc.taskAction(c, "delete_task", "deleted", tc.admin.DeleteTask)
+}
+
+// DocumentTasksHandler godoc
+// @Summary      List Tasks of a Document
+// @Description  Returns a document together with every queued, running, failed or completed task processing it, e.g. to find the archived ingestion task to retry, and its dead letters
+// @Tags         Admin
+// @Produce      json
+// @Security     BearerAuth
+// @Param        fileId  path  string  true  "Document file ID"
+// @Success      200  {object}  map[string]interface{}
+// @Failure      401  {object}  map[string]interface{}  "Missing or invalid admin token"
+// @Failure      404  {object}  map[string]interface{}  "Document not found"
+// @Router       /admin/documents/{fileId}/tasks [get]
+func (tc *TaskAdminController) DocumentTasksHandler(c *gin.Context) {
+	fileID := c.Param("fileId")
+
+	document, err := tc.documents.GetDocumentByFileID(c.Request.Context(), fileID)
+	if err != nil {
+		tc.respondError(c, "document_tasks", err)
+		return
+	}
+
+	tasks, err := tc.admin.TasksForFile(fileID)
+	if err != nil {
+		tc.respondError(c, "document_tasks", err)
+		return
+	}
+	if tasks == nil {
+		tasks = []service.AdminTask{}
+	}
+
+	deadLetters, _, err := tc.deadLetters.List(c.Request.Context(), "", fileID, 0, 0)
+	if err != nil {
+		tc.respondError(c, "document_tasks", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"document": document, "tasks": tasks, "deadLetters": deadLetters})
+}
+
+// ListDeadLettersHandler godoc
+// @Summary      List Dead Letters
+// @Description  Lists tasks whose retries were exhausted, newest first, with their final error and the cleanup run for them. Entries whose cleanup failed need manual attention.
+// @Tags         Admin
+// @Produce      json
+// @Security     BearerAuth
+// @Param        taskType  query  string  false  "Only dead letters of this task type"
+// @Param        fileId    query  string  false  "Only dead letters of this document"
+// @Param        page      query  int     false  "Page number, starting at 1 (default 1)"
+// @Param        size      query  int     false  "Page size, at most 100 (default 20)"
+// @Success      200  {object}  map[string]interface{}
+// @Failure      400  {object}  map[string]interface{}  "Invalid paging"
+// @Failure      401  {object}  map[string]interface{}  "Missing or invalid admin token"
+// @Failure      500  {object}  map[string]interface{}  "Internal server error"
+// @Router       /admin/dead-letters [get]
+func (tc *TaskAdminController) ListDeadLettersHandler(c *gin.Context) {
+	page, err := strconv.Atoi(c.DefaultQuery("page", "1"))
+	if err != nil || page < 1 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "page must be a positive integer"})
+		return
+	}
+	size, err := strconv.Atoi(c.DefaultQuery("size", "20"))
+	if err != nil || size < 1 || size > 100 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "size must be between 1 and 100"})
+		return
+	}
+
+	deadLetters, total, err := tc.deadLetters.List(c.Request.Context(), c.Query("taskType"), c.Query("fileId"), int64(size), int64((page-1)*size))
+	if err != nil {
+		tc.respondError(c, "list_dead_letters", err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"deadLetters": deadLetters, "total": total, "page": page, "size": size})
+}
+
+// GetDeadLetterHandler godoc
+// @Summary      Get Dead Letter
+// @Description  Returns a dead letter with the payload of the task, its final error and the cleanup actions run for it
+// @Tags         Admin
+// @Produce      json
+// @Security     BearerAuth
+// @Param        id  path  string  true  "Dead letter ID"
+// @Success      200  {object}  model.DeadLetter
+// @Failure      401  {object}  map[string]interface{}  "Missing or invalid admin token"
+// @Failure      404  {object}  map[string]interface{}  "Dead letter not found"
+// @Router       /admin/dead-letters/{id} [get]
+func (tc *TaskAdminController) GetDeadLetterHandler(c *gin.Context) {
+	deadLetter, err := tc.deadLetters.Get(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		tc.respondError(c, "get_dead_letter", err)
+		return
+	}
+	c.JSON(http.StatusOK, deadLetter)
+}
+
+// StartReconciliationRequest selects what a manual reconciliation run checks and repairs
+type StartReconciliationRequest struct {
+	CorpusName string   `json:"corpusName"` // Only reconcile this corpus; every corpus when empty
+	Repair     []string `json:"repair"`     // Drift kinds to repair, or ["all"]; the RECONCILE_REPAIR policy when omitted
+}
+
+// StartReconciliationHandler godoc
+// @Summary      Start Reconciliation
+// @Description  Enqueues a run comparing documents with object storage and the RAG corpora. The drift report is stored once the run completes. Drift kinds: missing_object, orphaned_object, orphaned_rag_file, stale_status. Storage objects without documents are only checked when every corpus is reconciled.
+// @Tags         Admin
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        request  body  StartReconciliationRequest  false  "Corpus to reconcile and drift kinds to repair"
+// @Success      202  {object}  map[string]interface{}
+// @Failure      400  {object}  map[string]interface{}  "Invalid request or unknown drift kind"
+// @Failure      401  {object}  map[string]interface{}  "Missing or invalid admin token"
+// @Failure      409  {object}  map[string]interface{}  "The same run was started less than an hour ago"
+// @Failure      500  {object}  map[string]interface{}  "Internal server error"
+// @Router       /admin/reconciliation/runs [post]
+func (tc *TaskAdminController) StartReconciliationHandler(c *gin.Context) {
+	var req StartReconciliationRequest
+	if c.Request.ContentLength != 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body", "message": err.Error()})
+			return
+		}
+	}
+
+	payload := tasks.ReconcilePayload{CorpusName: req.CorpusName, RequestedBy: "admin_api"}
+	if req.Repair != nil {
+		kinds, err := tasks.ParseDriftKinds(strings.Join(req.Repair, ","))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		payload.Repair = append([]model.DriftKind{}, kinds...)
+	}
+
+	task, err := tasks.NewReconcileStorageTask(payload)
+	if err != nil {
+		tc.respondError(c, "start_reconciliation", err)
+		return
+	}
+	taskID, err := tasks.Enqueue(c.Request.Context(), task, req.CorpusName, map[string]string{"requested_by": payload.RequestedBy})
+	if errors.Is(err, asynq.ErrDuplicateTask) {
+		c.JSON(http.StatusConflict, gin.H{"error": "A reconciliation with the same options was started less than an hour ago"})
+		return
+	}
+	if err != nil {
+		tc.respondError(c, "start_reconciliation", err)
+		return
+	}
+
+	c.JSON(http.StatusAccepted, gin.H{"taskId": taskID, "corpusName": req.CorpusName, "repair": payload.Repair})
+}
+
+// ListReconciliationReportsHandler godoc
+// @Summary      List Reconciliation Reports
+// @Description  Lists the drift reports of reconciliation runs, newest first, with their drift counts. The drifts themselves are returned by the report endpoint.
+// @Tags         Admin
+// @Produce      json
+// @Security     BearerAuth
+// @Param        page  query  int  false  "Page number, starting at 1 (default 1)"
+// @Param        size  query  int  false  "Page size, at most 100 (default 20)"
+// @Success      200  {object}  map[string]interface{}
+// @Failure      400  {object}  map[string]interface{}  "Invalid paging"
+// @Failure      401  {object}  map[string]interface{}  "Missing or invalid admin token"
+// @Failure      500  {object}  map[string]interface{}  "Internal server error"
+// @Router       /admin/reconciliation/reports [get]
+func (tc *TaskAdminController) ListReconciliationReportsHandler(c *gin.Context) {
+	page, err := strconv.Atoi(c.DefaultQuery("page", "1"))
+	if err != nil || page < 1 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "page must be a positive integer"})
+		return
+	}
+	size, err := strconv.Atoi(c.DefaultQuery("size", "20"))
+	if err != nil || size < 1 || size > 100 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "size must be between 1 and 100"})
+		return
+	}
+
+	reports, total, err := tc.reports.List(c.Request.Context(), int64(size), int64((page-1)*size))
+	if err != nil {
+		tc.respondError(c, "list_reconciliation_reports", err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"reports": reports, "total": total, "page": page, "size": size})
+}
+
+// GetReconciliationReportHandler godoc
+// @Summary      Get Reconciliation Report
+// @Description  Returns the drift report of a reconciliation run with every drift found and the repair applied to it
+// @Tags         Admin
+// @Produce      json
+// @Security     BearerAuth
+// @Param        id  path  string  true  "Report ID"
+// @Success      200  {object}  model.ReconciliationReport
+// @Failure      401  {object}  map[string]interface{}  "Missing or invalid admin token"
+// @Failure      404  {object}  map[string]interface{}  "Report not found"
+// @Router       /admin/reconciliation/reports/{id} [get]
+func (tc *TaskAdminController) GetReconciliationReportHandler(c *gin.Context) {
+	report, err := tc.reports.Get(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		tc.respondError(c, "get_reconciliation_report", err)
+		return
+	}
+	c.JSON(http.StatusOK, report)
+}
+
+// taskAction applies action to the task in the path and reports it as done
+func (tc *TaskAdminController) taskAction(c *gin.Context, operation, done string, action func(queue, id string) error) {
+	queue, taskID := c.Param("queue"), c.Param("taskId")
+	if err := action(queue, taskID); err != nil {
+		tc.respondError(c, operation, err)
+		return
+	}
+
+	tc.logger.InfoWithMetrics(c.Request.Context(), operation, "Task "+done, 0, map[string]string{
+		"queue":   queue,
+		"task_id": taskID,
+	})
+	c.JSON(http.StatusOK, gin.H{"queue": queue, "task_id": taskID, "status": done})
+}
+
+// respondError maps task admin and repository errors to HTTP responses
+func (tc *TaskAdminController) respondError(c *gin.Context, operation string, err error) {
+	switch {
+	case errors.Is(err, asynq.ErrQueueNotFound), errors.Is(err, asynq.ErrTaskNotFound), errors.Is(err, mongo.ErrNoDocuments):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case errors.Is(err, service.ErrInvalidTaskState):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	case errors.Is(err, service.ErrTaskStateConflict), errors.Is(err, service.ErrTaskNotActive), errors.Is(err, service.ErrQueueAlreadyInState):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	default:
+		tc.logger.ErrorWithOperation(c.Request.Context(), operation, "Task admin operation failed", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Task admin operation failed", "message": err.Error()})
+	}
+}
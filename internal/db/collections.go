@@ -10,24 +10,32 @@ const (
 
 // Collection names
 const (
-	MCQCollection              = "mcqs"
-	MSQCollection              = "msqs"
-	QuestionBankCollection     = "questionBanks"
-	TeacherCollection          = "teachers"
-	NATCollection              = "nats"
-	SubjectiveCollection       = "subjectives"
-	AssignmentCollection       = "assignments"
-	ClassroomCollection        = "classrooms"
-	CommentCollection          = "comments"
-	PostCollection             = "posts"
-	StudentCollection          = "students"
-	SubmissionCollection       = "submissions"
-	VariableCollection         = "variables"
-	QuestionsCollection        = "questions"
-	SubjectReportCollection    = "subject_reports"
-	ReportCardCollection       = "report_cards"
-	DocumentCollection         = "documents"
-	AssignmentResultCollection = "assignment_results"
+	MCQCollection               = "mcqs"
+	MSQCollection               = "msqs"
+	QuestionBankCollection      = "questionBanks"
+	TeacherCollection           = "teachers"
+	NATCollection               = "nats"
+	SubjectiveCollection        = "subjectives"
+	AssignmentCollection        = "assignments"
+	ClassroomCollection         = "classrooms"
+	CommentCollection           = "comments"
+	PostCollection              = "posts"
+	StudentCollection           = "students"
+	SubmissionCollection        = "submissions"
+	VariableCollection          = "variables"
+	QuestionsCollection         = "questions"
+	SubjectReportCollection     = "subject_reports"
+	ReportCardCollection        = "report_cards"
+	DocumentCollection          = "documents"
+	AssignmentResultCollection  = "assignment_results"
+	CorpusCollection            = "corpora"
+	AgentSessionCollection      = "agent_sessions"
+	AgentMessageCollection      = "agent_messages"
+	AgentProposalCollection     = "agent_proposals"
+	VariationDraftCollection    = "variation_drafts"
+	QuestionAuthoringCollection = "question_authoring"
+	DeadLetterCollection        = "dead_letters"
+	ReconciliationCollection    = "reconciliation_reports"
 )
 
 // GetCollection returns a reference to the specified collection
@@ -5,6 +5,10 @@ import (
 	"log"
 	"time"
 
+	"lumenslate/internal/metrics"
+	"lumenslate/internal/tracing"
+
+	"go.mongodb.org/mongo-driver/event"
 	"go.mongodb.org/mongo-driver/mongo"
 	"go.mongodb.org/mongo-driver/mongo/options"
 )
@@ -19,7 +23,8 @@ func InitMongoDB(uri string) error {
 	defer cancel()
 
 	serverAPI := options.ServerAPI(options.ServerAPIVersion1)
-	opts := options.Client().ApplyURI(uri).SetServerAPIOptions(serverAPI)
+	opts := options.Client().ApplyURI(uri).SetServerAPIOptions(serverAPI).
+		SetMonitor(combineMonitors(metrics.MongoCommandMonitor(), tracing.MongoCommandMonitor()))
 
 	client, err := mongo.Connect(ctx, opts)
 	if err != nil {
@@ -45,3 +50,24 @@ func CloseMongoDB() error {
 	}
 	return nil
 }
+
+// combineMonitors returns a command monitor passing every event to each of monitors in order
+func combineMonitors(monitors ...*event.CommandMonitor) *event.CommandMonitor {
+	return &event.CommandMonitor{
+		Started: func(ctx context.Context, e *event.CommandStartedEvent) {
+			for _, m := range monitors {
+				m.Started(ctx, e)
+			}
+		},
+		Succeeded: func(ctx context.Context, e *event.CommandSucceededEvent) {
+			for _, m := range monitors {
+				m.Succeeded(ctx, e)
+			}
+		},
+		Failed: func(ctx context.Context, e *event.CommandFailedEvent) {
+			for _, m := range monitors {
+				m.Failed(ctx, e)
+			}
+		},
+	}
+}
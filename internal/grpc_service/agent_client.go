@@ -5,82 +5,18 @@ import (
 	"encoding/json"
 	"fmt"
 	"log"
-	"math/rand"
-	"strconv"
 	"strings"
 	"time"
 
 	"lumenslate/internal/model"
 	pb "lumenslate/internal/proto/ai_service"
 	"lumenslate/internal/repository"
-	quest "lumenslate/internal/repository/questions"
 
 	"github.com/google/uuid"
 	"go.mongodb.org/mongo-driver/bson/primitive"
+	"google.golang.org/grpc/metadata"
 )
 
-// QuestionRequest represents a question request from the agent
-type QuestionRequest struct {
-	Type              string `json:"type"`
-	Subject           string `json:"subject"`
-	NumberOfQuestions int    `json:"number_of_questions"`
-	Difficulty        string `json:"difficulty"`
-}
-
-// AgentResponse represents the parsed agent response structure
-type AgentResponse struct {
-	QuestionsRequested []QuestionRequest `json:"questions_requested"`
-	AssessmentData     interface{}       `json:"assessment_data"`
-	AssignmentResult   interface{}       `json:"assignment_result"`
-	AssessmentResult   interface{}       `json:"assessment_result"`
-	ReportCardData     interface{}       `json:"report_card_data"`
-}
-
-// AssessmentData represents the assessment data structure
-type AssessmentData struct {
-	StudentID   interface{} `json:"student_id"`
-	StudentName string      `json:"student_name"`
-	Subject     string      `json:"subject"`
-	Score       interface{} `json:"score"`
-
-	// Optional fields
-	GradeLetter    string `json:"grade_letter,omitempty"`
-	ClassName      string `json:"class_name,omitempty"`
-	InstructorName string `json:"instructor_name,omitempty"`
-	Term           string `json:"term,omitempty"`
-	Remarks        string `json:"remarks,omitempty"`
-
-	// Assessment component breakdown
-	MidtermScore          interface{} `json:"midterm_score,omitempty"`
-	FinalExamScore        interface{} `json:"final_exam_score,omitempty"`
-	QuizScore             interface{} `json:"quiz_score,omitempty"`
-	AssignmentScore       interface{} `json:"assignment_score,omitempty"`
-	PracticalScore        interface{} `json:"practical_score,omitempty"`
-	OralPresentationScore interface{} `json:"oral_presentation_score,omitempty"`
-
-	// Skill evaluation (0-10 scale or %)
-	ConceptualUnderstanding interface{} `json:"conceptual_understanding,omitempty"`
-	ProblemSolving          interface{} `json:"problem_solving,omitempty"`
-	KnowledgeApplication    interface{} `json:"knowledge_application,omitempty"`
-	AnalyticalThinking      interface{} `json:"analytical_thinking,omitempty"`
-	Creativity              interface{} `json:"creativity,omitempty"`
-	PracticalSkills         interface{} `json:"practical_skills,omitempty"`
-
-	// Behavioral metrics
-	Participation interface{} `json:"participation,omitempty"`
-	Discipline    interface{} `json:"discipline,omitempty"`
-	Punctuality   interface{} `json:"punctuality,omitempty"`
-	Teamwork      interface{} `json:"teamwork,omitempty"`
-	EffortLevel   interface{} `json:"effort_level,omitempty"`
-	Improvement   interface{} `json:"improvement,omitempty"`
-
-	// Advanced insights
-	LearningObjectivesMastered string `json:"learning_objectives_mastered,omitempty"`
-	AreasForImprovement        string `json:"areas_for_improvement,omitempty"`
-	RecommendedResources       string `json:"recommended_resources,omitempty"`
-	TargetGoals                string `json:"target_goals,omitempty"`
-}
-
 // toCamelCase converts snake_case string to camelCase
 func toCamelCase(s string) string {
 	parts := strings.Split(s, "_")
@@ -113,16 +49,15 @@ func convertKeysToCamelCase(data interface{}) interface{} {
 	}
 }
 
-func LumenAgent(file, fileType, teacherId, role, message, createdAt, updatedAt string) (map[string]interface{}, error) {
-	client, conn, err := DialGRPC()
+// LumenAgent sends a message to the agent. A non-empty sessionId continues that AI service session.
+func LumenAgent(parent context.Context, file, fileType, teacherId, role, message, createdAt, updatedAt, sessionId string) (map[string]interface{}, error) {
+	client, ctx, cancel, err := newCall(parent, pb.AIService_LumenAgent_FullMethodName)
 	if err != nil {
-		log.Printf("ERROR: Failed to establish gRPC connection: %v", err)
+		log.Printf("ERROR: Failed to get gRPC client: %v", err)
 		return nil, err
 	}
-	defer conn.Close()
-
-	ctx, cancel := context.WithTimeout(context.Background(), 60*time.Second)
 	defer cancel()
+	ctx = withAgentSession(ctx, sessionId)
 
 	req := &pb.AgentRequest{
 		File:      file,
@@ -141,117 +76,61 @@ func LumenAgent(file, fileType, teacherId, role, message, createdAt, updatedAt s
 		return errorResponse, nil
 	}
 
-	// Get the raw agent response
-	rawAgentResponse := res.GetAgentResponse()
+	return processAgentResponse(teacherId, res), nil
+}
 
-	// Try to parse the agent response as JSON to see if it contains database operations
-	var agentResponse AgentResponse
-	agentName := "root_agent"
-	responseMessage := "Agent response processed successfully"
-	var responseData interface{}
+// AgentSessionMetadataKey is the request metadata carrying the AI service session a message continues
+const AgentSessionMetadataKey = "x-session-id"
 
-	if err := json.Unmarshal([]byte(rawAgentResponse), &agentResponse); err == nil {
-		// Handle report card field name mismatch: microservice returns "report_card" but we expect "report_card_data"
-		if agentResponse.ReportCardData == nil {
-			var rawMap map[string]interface{}
-			if err := json.Unmarshal([]byte(rawAgentResponse), &rawMap); err == nil {
-				if reportCardData, exists := rawMap["report_card"]; exists {
-					agentResponse.ReportCardData = reportCardData
-				}
-			}
-		}
+// withAgentSession attaches the AI service session to an outgoing call
+func withAgentSession(ctx context.Context, sessionId string) context.Context {
+	if sessionId == "" {
+		return ctx
+	}
+	return metadata.AppendToOutgoingContext(ctx, AgentSessionMetadataKey, sessionId)
+}
 
-		// Check for assignment result FIRST (prioritize assessor agent)
-		// Handle both "assignment_result" and "assessment_result" field names
-		var assignmentResultData interface{}
-		if agentResponse.AssignmentResult != nil {
-			assignmentResultData = agentResponse.AssignmentResult
-		} else if agentResponse.AssessmentResult != nil {
-			assignmentResultData = agentResponse.AssessmentResult
-		}
+// processAgentResponse decodes the agent payload, runs its handler and builds the response returned to clients
+func processAgentResponse(teacherId string, res *pb.AgentResponse) map[string]interface{} {
+	payload, err := DecodeAgentResponse(res)
+	if err != nil {
+		log.Printf("ERROR: Rejected agent response: %v", err)
+		return createPayloadErrorResponse(teacherId, err, res)
+	}
 
-		if assignmentResultData != nil {
-			// Handle assignment result saving (from assessor agent)
-			if assignmentResult, err := handleAssignmentResultSaving(assignmentResultData); err == nil {
-				responseData = assignmentResult
-				agentName = "assessor_agent"
-				responseMessage = "Assignment assessment completed successfully"
-			} else {
-				log.Printf("ERROR: Failed to handle assignment result saving: %v", err)
-				errorResponse := createErrorResponse(teacherId, err.Error(), res)
-				return errorResponse, nil
-			}
-		} else if len(agentResponse.QuestionsRequested) > 0 {
-			// Handle question generation (both AGG and AGT)
-			if questionsData, err := handleQuestionGeneration(agentResponse.QuestionsRequested, rawAgentResponse); err == nil {
-				responseData = questionsData
-				agentName = "assignment_generator_general"
-				responseMessage = "Assignment generated successfully"
-			} else {
-				log.Printf("ERROR: Failed to handle question generation: %v", err)
-				errorResponse := createErrorResponse(teacherId, err.Error(), res)
-				return errorResponse, nil
-			}
-		} else if agentResponse.AssessmentData != nil {
-			// Handle legacy assessment data saving (for backward compatibility)
-			if assessmentData, err := handleAssessmentSaving(agentResponse.AssessmentData, teacherId); err == nil {
-				responseData = assessmentData
-				agentName = "assessor_agent"
-				responseMessage = "Subject assessment report processed successfully"
-			} else {
-				log.Printf("ERROR: Failed to handle assessment saving: %v", err)
-				errorResponse := createErrorResponse(teacherId, err.Error(), res)
-				return errorResponse, nil
-			}
-		} else if agentResponse.ReportCardData != nil {
-			// Handle report card generation and saving
-			if reportCardData, err := handleReportCardGeneration(agentResponse.ReportCardData, teacherId); err == nil {
-				responseData = reportCardData
-				agentName = "report_card_generator"
-				responseMessage = "Report card generated and saved successfully"
-			} else {
-				log.Printf("ERROR: Failed to handle report card generation: %v", err)
-				errorResponse := createErrorResponse(teacherId, err.Error(), res)
-				return errorResponse, nil
-			}
-		} else {
-			// Regular agent response without database operations
-			responseData = map[string]interface{}{
-				"agentResponse": rawAgentResponse,
-			}
-		}
-	} else {
-		// Couldn't parse as JSON, this is likely from general_chat_agent
-		responseMessage = rawAgentResponse
-		responseData = map[string]interface{}{}
-		agentName = "general_chat_agent"
+	result, err := dispatchAgentPayload(payload, teacherId)
+	if err != nil {
+		log.Printf("ERROR: Failed to handle %s agent payload: %v", payload.Type, err)
+		return createErrorResponse(teacherId, err.Error(), res)
 	}
 
 	// Prepare final response
 	finalResponse := map[string]interface{}{
-		"message":      responseMessage,
-		"teacherId":    res.GetTeacherId(),
-		"agentName":    agentName,
-		"data":         responseData,
-		"sessionId":    res.GetSessionId(),
-		"createdAt":    res.GetCreatedAt(),
-		"updatedAt":    res.GetUpdatedAt(),
-		"responseTime": res.GetResponseTime(),
-		"role":         res.GetRole(),
-		"feedback":     res.GetFeedback(),
+		"message":       result.message,
+		"teacherId":     res.GetTeacherId(),
+		"agentName":     result.agentName,
+		"payloadType":   payload.Type,
+		"schemaVersion": payload.SchemaVersion,
+		"data":          result.data,
+		"sessionId":     res.GetSessionId(),
+		"createdAt":     res.GetCreatedAt(),
+		"updatedAt":     res.GetUpdatedAt(),
+		"responseTime":  res.GetResponseTime(),
+		"role":          res.GetRole(),
+		"feedback":      res.GetFeedback(),
 	}
 
 	// Convert the entire response to camelCase (including nested structs)
 	jsonBytes, err := json.Marshal(finalResponse)
 	if err != nil {
 		log.Printf("ERROR: Failed to marshal response data to JSON: %v", err)
-		return finalResponse, nil
+		return finalResponse
 	}
 
 	var jsonMap map[string]interface{}
 	if err := json.Unmarshal(jsonBytes, &jsonMap); err != nil {
 		log.Printf("ERROR: Failed to unmarshal response data from JSON: %v", err)
-		return finalResponse, nil
+		return finalResponse
 	}
 
 	// Apply camelCase conversion to the properly converted data
@@ -261,10 +140,19 @@ func LumenAgent(file, fileType, teacherId, role, message, createdAt, updatedAt s
 	finalResponseData, ok := camelCaseResponseData.(map[string]interface{})
 	if !ok {
 		log.Printf("ERROR: Failed to convert response data to map[string]interface{}")
-		return finalResponse, nil
+		return finalResponse
 	}
 
-	return finalResponseData, nil
+	return finalResponseData
+}
+
+// optionalString returns the trimmed string, or nil when it is blank
+func optionalString(value string) *string {
+	trimmed := strings.TrimSpace(value)
+	if trimmed == "" {
+		return nil
+	}
+	return &trimmed
 }
 
 func createErrorResponse(teacherId, errorMessage string, res *pb.AgentResponse) map[string]interface{} {
@@ -291,456 +179,92 @@ func createErrorResponse(teacherId, errorMessage string, res *pb.AgentResponse)
 	}
 }
 
-func handleQuestionGeneration(questionsRequested []QuestionRequest, rawAgentResponse string) (map[string]interface{}, error) {
-	// Parse the raw agent response to extract title and body
-	var agentResponseData map[string]interface{}
-	var assignmentTitle, assignmentBody string
-
-	if err := json.Unmarshal([]byte(rawAgentResponse), &agentResponseData); err == nil {
-		if title, ok := agentResponseData["title"].(string); ok {
-			assignmentTitle = title
-		}
-		if body, ok := agentResponseData["body"].(string); ok {
-			assignmentBody = body
-		}
-	}
-
-	// Default values if not provided by agent
-	if assignmentTitle == "" {
-		assignmentTitle = "Generated Assignment"
+// createPayloadErrorResponse is createErrorResponse with the structured reason an agent response was rejected
+func createPayloadErrorResponse(teacherId string, err error, res *pb.AgentResponse) map[string]interface{} {
+	response := createErrorResponse(teacherId, "Agent response could not be processed: "+err.Error(), res)
+	if payloadErr, ok := err.(*AgentPayloadError); ok {
+		response["error"] = payloadErr
 	}
-	if assignmentBody == "" {
-		assignmentBody = "Assignment generated from agent request"
-	}
-
-	// Test database connectivity
-	if err := repository.DebugTestDatabaseConnection(); err != nil {
-		return nil, fmt.Errorf("database connectivity failed: %v", err)
-	}
-
-	// Group requests by subject to handle multiple difficulty levels for the same subject
-	subjectRequests := make(map[string][]QuestionRequest)
-
-	for _, request := range questionsRequested {
-		if request.Type == "assignment_generator_general" {
-			subject := strings.ToLower(strings.TrimSpace(request.Subject))
-			subjectRequests[subject] = append(subjectRequests[subject], request)
-		}
-	}
-
-	// Collect all selected questions and their IDs for assignment creation
-	var allSelectedQuestions []model.Questions
-	mcqCount := 0
-	msqCount := 0
-	natCount := 0
-	subjectiveCount := 0
-
-	// Track question IDs by type for assignment
-	var mcqIds []string
-	var msqIds []string
-	var natIds []string
-	var subjectiveIds []string
-
-	for subjectKey, requests := range subjectRequests {
-		// Convert subject string to Subject enum
-		subject, validSubject := model.GetSubjectFromString(subjectKey)
-
-		if !validSubject {
-			continue
-		}
-
-		// Process all requests for this subject
-		for _, req := range requests {
-			numQuestions := req.NumberOfQuestions
-			difficulty := strings.ToLower(strings.TrimSpace(req.Difficulty))
-
-			var availableQuestions []model.Questions
-			var err error
-
-			// Query database for questions from this subject with specific difficulty
-			if difficulty != "" {
-				// Convert difficulty string to Difficulty enum
-				var difficultyEnum model.Difficulty
-				switch difficulty {
-				case "easy":
-					difficultyEnum = model.DifficultyEasy
-				case "medium":
-					difficultyEnum = model.DifficultyMedium
-				case "hard":
-					difficultyEnum = model.DifficultyHard
-				default:
-					// Invalid difficulty, get all questions for this subject
-					availableQuestions, err = repository.GetQuestionsBySubject(subject)
-				}
-
-				if difficultyEnum != "" {
-					availableQuestions, err = repository.GetQuestionsBySubjectAndDifficulty(subject, difficultyEnum)
-				}
-			} else {
-				// No difficulty specified, get all questions for this subject
-				availableQuestions, err = repository.GetQuestionsBySubject(subject)
-			}
-
-			if err != nil {
-				continue
-			}
-
-			if len(availableQuestions) >= numQuestions {
-				// Randomly sample the requested number of questions
-				rng := rand.New(rand.NewSource(time.Now().UnixNano()))
-				selectedQuestions := make([]model.Questions, numQuestions)
-				perm := rng.Perm(len(availableQuestions))
-				for i := 0; i < numQuestions; i++ {
-					selectedQuestions[i] = availableQuestions[perm[i]]
-				}
-				allSelectedQuestions = append(allSelectedQuestions, selectedQuestions...)
-
-				// Count questions by type and collect IDs
-				for _, q := range selectedQuestions {
-					questionID := q.ID
-
-					// Check if this is an MCQ
-					if mcq, err := quest.GetMCQByID(questionID); err == nil && mcq != nil {
-						mcqCount++
-						mcqIds = append(mcqIds, questionID)
-						continue
-					}
-
-					// Check if this is an MSQ
-					if msq, err := quest.GetMSQByID(questionID); err == nil && msq != nil {
-						msqCount++
-						msqIds = append(msqIds, questionID)
-						continue
-					}
-
-					// Check if this is a NAT
-					if nat, err := quest.GetNATByID(questionID); err == nil && nat != nil {
-						natCount++
-						natIds = append(natIds, questionID)
-						continue
-					}
-
-					// Check if this is a Subjective
-					if subjective, err := quest.GetSubjectiveByID(questionID); err == nil && subjective != nil {
-						subjectiveCount++
-						subjectiveIds = append(subjectiveIds, questionID)
-						continue
-					}
-				}
-			}
-		}
-	}
-
-	// Create assignment if we have selected questions
-	if len(allSelectedQuestions) == 0 {
-		return nil, fmt.Errorf("no questions could be selected for the assignment")
-	}
-
-	// Create assignment using existing assignment repository
-	assignmentID := uuid.New().String()
-	dueDate := time.Now().AddDate(0, 0, 7) // Default due date 7 days from now
-	assignment := model.Assignment{
-		ID:            assignmentID,
-		Title:         assignmentTitle,
-		Body:          assignmentBody,
-		DueDate:       dueDate,
-		Points:        len(allSelectedQuestions), // 1 point per question
-		CreatedAt:     time.Now(),
-		UpdatedAt:     time.Now(),
-		IsActive:      true,
-		CommentIds:    []string{},
-		MCQIds:        mcqIds,
-		MSQIds:        msqIds,
-		NATIds:        natIds,
-		SubjectiveIds: subjectiveIds,
-	}
-
-	// Save assignment to database
-	if err := repository.SaveAssignment(assignment); err != nil {
-		return nil, fmt.Errorf("failed to save assignment: %v", err)
-	}
-
-	// Prepare response
-	responseData := map[string]interface{}{
-		"assignmentId":    assignment.ID,
-		"title":           assignment.Title,
-		"body":            assignment.Body,
-		"mcqCount":        mcqCount,
-		"msqCount":        msqCount,
-		"natCount":        natCount,
-		"subjectiveCount": subjectiveCount,
-	}
-
-	// Convert the entire response to camelCase (including nested structs)
-	camelCaseResponseData := convertKeysToCamelCase(responseData)
-
-	// Type assert back to map[string]interface{} for return
-	finalResponseData, ok := camelCaseResponseData.(map[string]interface{})
-	if !ok {
-		log.Printf("ERROR: Failed to convert response data to map[string]interface{}")
-		return responseData, nil // Fallback to original data
-	}
-
-	return finalResponseData, nil
+	return response
 }
 
-func handleAssessmentSaving(assessmentDataInterface interface{}, teacherId string) (map[string]interface{}, error) {
-	// Parse the assessment data
-	assessmentBytes, err := json.Marshal(assessmentDataInterface)
-	if err != nil {
-		return nil, fmt.Errorf("failed to marshal assessment data: %v", err)
-	}
-
-	var assessmentData AssessmentData
-	if err := json.Unmarshal(assessmentBytes, &assessmentData); err != nil {
-		return nil, fmt.Errorf("failed to unmarshal assessment data: %v", err)
-	}
-
-	// Check for mandatory fields
-	var missingFields []string
-
-	if assessmentData.StudentID == nil {
-		missingFields = append(missingFields, "student_id")
-	}
-
-	if assessmentData.StudentName == "" {
-		missingFields = append(missingFields, "student_name")
-	}
-
-	if assessmentData.Subject == "" {
-		missingFields = append(missingFields, "subject")
-	}
-
-	if assessmentData.Score == nil {
-		missingFields = append(missingFields, "score")
-	}
-
-	if len(missingFields) > 0 {
-		return nil, fmt.Errorf("missing mandatory fields: %s", strings.Join(missingFields, ", "))
-	}
-
-	// Validate and convert subject to enum
-	subjectString := strings.ToLower(strings.TrimSpace(assessmentData.Subject))
-
-	subject, validSubject := model.GetSubjectFromString(subjectString)
-	if !validSubject {
-		return nil, fmt.Errorf("invalid subject: %s. Available subjects are: math, science, english, history, geography", assessmentData.Subject)
-	}
+// agentPayloadResult is the outcome of handling an agent payload
+type agentPayloadResult struct {
+	agentName string
+	message   string
+	data      interface{}
+}
 
-	// Convert studentID to int
-	var studentID int
-	switch v := assessmentData.StudentID.(type) {
-	case float64:
-		studentID = int(v)
-	case int:
-		studentID = v
-	case string:
-		if id, err := strconv.Atoi(v); err == nil {
-			studentID = id
-		} else {
-			return nil, fmt.Errorf("invalid student_id format: %v", v)
-		}
+// dispatchAgentPayload runs the handler for the payload type. Assignments, subject reports, assignment
+// results and report cards are not written directly but proposed to the teacher, see proposeAgentWrite.
+func dispatchAgentPayload(payload *AgentPayload, teacherId string) (*agentPayloadResult, error) {
+	switch payload.Type {
+	case AgentPayloadAssignmentResult, AgentPayloadQuestionRequest, AgentPayloadAssessment, AgentPayloadReportCard:
+		return proposeAgentWrite(payload, teacherId)
+	case AgentPayloadText:
+		return &agentPayloadResult{"general_chat_agent", payload.Text.Text, map[string]interface{}{}}, nil
 	default:
-		return nil, fmt.Errorf("invalid student_id type: %T", v)
+		return nil, &AgentPayloadError{Code: AgentPayloadErrUnknownType, Message: fmt.Sprintf("no handler for payload type %q", payload.Type)}
 	}
+}
 
-	// Convert score to int
-	var score int
-	switch v := assessmentData.Score.(type) {
-	case float64:
-		score = int(v)
-	case int:
-		score = v
-	case string:
-		if s, err := strconv.Atoi(v); err == nil {
-			score = s
-		} else {
-			return nil, fmt.Errorf("invalid score format: %v", v)
-		}
-	default:
-		return nil, fmt.Errorf("invalid score type: %T", v)
-	}
+// planAssessment builds the subject report for an assessment without saving it
+func planAssessment(assessment *AssessmentPayload, teacherId string) *model.SubjectReport {
+	// The payload has been validated, so the subject is known and the required fields are set
+	subject, _ := model.GetSubjectFromString(strings.ToLower(strings.TrimSpace(assessment.Subject)))
 
 	// Create SubjectReport object
 	now := time.Now()
-	subjectReport := model.SubjectReport{
+	return &model.SubjectReport{
+		ID:          uuid.New().String(),
 		UserID:      teacherId,
-		StudentID:   studentID,
-		StudentName: assessmentData.StudentName,
+		StudentID:   *assessment.StudentID.IntPtr(),
+		StudentName: strings.TrimSpace(assessment.StudentName),
 		Subject:     subject,
-		Score:       score,
+		Score:       *assessment.Score.IntPtr(),
 		Timestamp:   now,
 		CreatedAt:   now,
 		UpdatedAt:   now,
-	}
-
-	// Helper function to convert interface{} to string pointer
-	toStringPtr := func(v interface{}) *string {
-		if v == nil {
-			return nil
-		}
-		if str, ok := v.(string); ok && strings.TrimSpace(str) != "" {
-			trimmed := strings.TrimSpace(str)
-			return &trimmed
-		}
-		return nil
-	}
-
-	// Helper function to convert interface{} to int pointer
-	toIntPtr := func(v interface{}) *int {
-		if v == nil {
-			return nil
-		}
-		switch val := v.(type) {
-		case float64:
-			intVal := int(val)
-			return &intVal
-		case int:
-			return &val
-		case string:
-			if intVal, err := strconv.Atoi(val); err == nil {
-				return &intVal
-			}
-		}
-		return nil
-	}
-
-	// Helper function to convert interface{} to float64 pointer
-	toFloat64Ptr := func(v interface{}) *float64 {
-		if v == nil {
-			return nil
-		}
-		switch val := v.(type) {
-		case float64:
-			return &val
-		case int:
-			floatVal := float64(val)
-			return &floatVal
-		case string:
-			if floatVal, err := strconv.ParseFloat(val, 64); err == nil {
-				return &floatVal
-			}
-		}
-		return nil
-	}
 
-	// Set optional string fields
-	fieldCount := 0
-	if ptr := toStringPtr(assessmentData.GradeLetter); ptr != nil {
-		subjectReport.GradeLetter = ptr
-		fieldCount++
-	}
-	if ptr := toStringPtr(assessmentData.ClassName); ptr != nil {
-		subjectReport.ClassName = ptr
-		fieldCount++
-	}
-	if ptr := toStringPtr(assessmentData.InstructorName); ptr != nil {
-		subjectReport.InstructorName = ptr
-		fieldCount++
-	}
-	if ptr := toStringPtr(assessmentData.Term); ptr != nil {
-		subjectReport.Term = ptr
-		fieldCount++
-	}
-	if ptr := toStringPtr(assessmentData.Remarks); ptr != nil {
-		subjectReport.Remarks = ptr
-		fieldCount++
-	}
-	if ptr := toStringPtr(assessmentData.LearningObjectivesMastered); ptr != nil {
-		subjectReport.LearningObjectivesMastered = ptr
-		fieldCount++
-	}
-	if ptr := toStringPtr(assessmentData.AreasForImprovement); ptr != nil {
-		subjectReport.AreasForImprovement = ptr
-		fieldCount++
-	}
-	if ptr := toStringPtr(assessmentData.RecommendedResources); ptr != nil {
-		subjectReport.RecommendedResources = ptr
-		fieldCount++
-	}
-	if ptr := toStringPtr(assessmentData.TargetGoals); ptr != nil {
-		subjectReport.TargetGoals = ptr
-		fieldCount++
-	}
-
-	// Set optional integer fields
-	if ptr := toIntPtr(assessmentData.MidtermScore); ptr != nil {
-		subjectReport.MidtermScore = ptr
-		fieldCount++
-	}
-	if ptr := toIntPtr(assessmentData.FinalExamScore); ptr != nil {
-		subjectReport.FinalExamScore = ptr
-		fieldCount++
-	}
-	if ptr := toIntPtr(assessmentData.QuizScore); ptr != nil {
-		subjectReport.QuizScore = ptr
-		fieldCount++
-	}
-	if ptr := toIntPtr(assessmentData.AssignmentScore); ptr != nil {
-		subjectReport.AssignmentScore = ptr
-		fieldCount++
-	}
-	if ptr := toIntPtr(assessmentData.PracticalScore); ptr != nil {
-		subjectReport.PracticalScore = ptr
-		fieldCount++
-	}
-	if ptr := toIntPtr(assessmentData.OralPresentationScore); ptr != nil {
-		subjectReport.OralPresentationScore = ptr
-		fieldCount++
-	}
-
-	// Set optional float fields
-	if ptr := toFloat64Ptr(assessmentData.ConceptualUnderstanding); ptr != nil {
-		subjectReport.ConceptualUnderstanding = ptr
-		fieldCount++
-	}
-	if ptr := toFloat64Ptr(assessmentData.ProblemSolving); ptr != nil {
-		subjectReport.ProblemSolving = ptr
-		fieldCount++
-	}
-	if ptr := toFloat64Ptr(assessmentData.KnowledgeApplication); ptr != nil {
-		subjectReport.KnowledgeApplication = ptr
-		fieldCount++
-	}
-	if ptr := toFloat64Ptr(assessmentData.AnalyticalThinking); ptr != nil {
-		subjectReport.AnalyticalThinking = ptr
-		fieldCount++
-	}
-	if ptr := toFloat64Ptr(assessmentData.Creativity); ptr != nil {
-		subjectReport.Creativity = ptr
-		fieldCount++
-	}
-	if ptr := toFloat64Ptr(assessmentData.PracticalSkills); ptr != nil {
-		subjectReport.PracticalSkills = ptr
-		fieldCount++
-	}
-	if ptr := toFloat64Ptr(assessmentData.Participation); ptr != nil {
-		subjectReport.Participation = ptr
-		fieldCount++
-	}
-	if ptr := toFloat64Ptr(assessmentData.Discipline); ptr != nil {
-		subjectReport.Discipline = ptr
-		fieldCount++
-	}
-	if ptr := toFloat64Ptr(assessmentData.Punctuality); ptr != nil {
-		subjectReport.Punctuality = ptr
-		fieldCount++
-	}
-	if ptr := toFloat64Ptr(assessmentData.Teamwork); ptr != nil {
-		subjectReport.Teamwork = ptr
-		fieldCount++
-	}
-	if ptr := toFloat64Ptr(assessmentData.EffortLevel); ptr != nil {
-		subjectReport.EffortLevel = ptr
-		fieldCount++
-	}
-	if ptr := toFloat64Ptr(assessmentData.Improvement); ptr != nil {
-		subjectReport.Improvement = ptr
-		fieldCount++
+		// Optional text fields
+		GradeLetter:                optionalString(assessment.GradeLetter),
+		ClassName:                  optionalString(assessment.ClassName),
+		InstructorName:             optionalString(assessment.InstructorName),
+		Term:                       optionalString(assessment.Term),
+		Remarks:                    optionalString(assessment.Remarks),
+		LearningObjectivesMastered: optionalString(assessment.LearningObjectivesMastered),
+		AreasForImprovement:        optionalString(assessment.AreasForImprovement),
+		RecommendedResources:       optionalString(assessment.RecommendedResources),
+		TargetGoals:                optionalString(assessment.TargetGoals),
+
+		// Optional component scores
+		MidtermScore:          assessment.MidtermScore.IntPtr(),
+		FinalExamScore:        assessment.FinalExamScore.IntPtr(),
+		QuizScore:             assessment.QuizScore.IntPtr(),
+		AssignmentScore:       assessment.AssignmentScore.IntPtr(),
+		PracticalScore:        assessment.PracticalScore.IntPtr(),
+		OralPresentationScore: assessment.OralPresentationScore.IntPtr(),
+
+		// Optional skill and behavioral ratings
+		ConceptualUnderstanding: assessment.ConceptualUnderstanding.FloatPtr(),
+		ProblemSolving:          assessment.ProblemSolving.FloatPtr(),
+		KnowledgeApplication:    assessment.KnowledgeApplication.FloatPtr(),
+		AnalyticalThinking:      assessment.AnalyticalThinking.FloatPtr(),
+		Creativity:              assessment.Creativity.FloatPtr(),
+		PracticalSkills:         assessment.PracticalSkills.FloatPtr(),
+		Participation:           assessment.Participation.FloatPtr(),
+		Discipline:              assessment.Discipline.FloatPtr(),
+		Punctuality:             assessment.Punctuality.FloatPtr(),
+		Teamwork:                assessment.Teamwork.FloatPtr(),
+		EffortLevel:             assessment.EffortLevel.FloatPtr(),
+		Improvement:             assessment.Improvement.FloatPtr(),
 	}
+}
 
+// saveAssessment saves a subject report planned by planAssessment
+func saveAssessment(subjectReport *model.SubjectReport) (map[string]interface{}, error) {
 	// Save to database
-	savedReport, err := repository.SaveSubjectReport(subjectReport)
+	savedReport, err := repository.SaveSubjectReport(*subjectReport)
 	if err != nil {
 		return nil, fmt.Errorf("failed to save subject report: %v", err)
 	}
@@ -880,129 +404,60 @@ func handleAssessmentSaving(assessmentDataInterface interface{}, teacherId strin
 	return finalResponseData, nil
 }
 
-func handleReportCardGeneration(reportCardDataInterface interface{}, teacherId string) (map[string]interface{}, error) {
-	// The report card data is now pre-extracted from the agent response
-	// We can directly use it to save to database and pass through to the frontend
-	reportCardData := reportCardDataInterface
-
-	// Convert the report card data to our model structure
-	reportCardBytes, err := json.Marshal(reportCardData)
-	if err != nil {
-		return nil, fmt.Errorf("failed to marshal report card data: %v", err)
-	}
-
-	var agentReportCardData model.AgentReportCardData
-	if err := json.Unmarshal(reportCardBytes, &agentReportCardData); err != nil {
-		return nil, fmt.Errorf("failed to unmarshal report card data: %v", err)
-	}
-
-	// Create the AgentReportCard with metadata
-	agentReportCard := model.AgentReportCard{
+// planReportCard builds the report card generated for teacherId without saving it
+func planReportCard(reportCard *ReportCardPayload, teacherId string) *model.AgentReportCard {
+	now := time.Now()
+	return &model.AgentReportCard{
+		ID:         primitive.NewObjectID(), // Fixed now so that the proposal names the record it writes
 		UserID:     teacherId,
-		ReportCard: agentReportCardData,
-		CreatedAt:  time.Now(),
-		UpdatedAt:  time.Now(),
+		ReportCard: *reportCard,
+		CreatedAt:  now,
+		UpdatedAt:  now,
 	}
+}
 
-	// Save to database
-	savedReportCard, err := repository.CreateAgentReportCard(agentReportCard)
+// saveReportCard saves a report card planned by planReportCard
+func saveReportCard(reportCard *model.AgentReportCard) (map[string]interface{}, error) {
+	savedReportCard, err := repository.CreateAgentReportCard(*reportCard)
 	if err != nil {
-		// Continue and return the data even if saving fails
-		savedReportCard = nil
+		return nil, fmt.Errorf("failed to save report card: %v", err)
 	}
 
-	// Prepare response data
 	// Convert all keys in the report card data to camelCase
-	camelCaseReportCard := convertKeysToCamelCase(reportCardData)
-
-	responseData := map[string]interface{}{
-		"reportCard": camelCaseReportCard,
+	reportCardBytes, err := json.Marshal(savedReportCard.ReportCard)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal report card data: %v", err)
 	}
-
-	// Add database metadata if save was successful
-	if savedReportCard != nil {
-		responseData["databaseId"] = savedReportCard.ID.Hex()
-		responseData["savedAt"] = savedReportCard.CreatedAt.Format("2006-01-02T15:04:05.000Z")
+	var reportCardMap map[string]interface{}
+	if err := json.Unmarshal(reportCardBytes, &reportCardMap); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal report card data: %v", err)
 	}
 
-	return responseData, nil
+	return map[string]interface{}{
+		"reportCard": convertKeysToCamelCase(reportCardMap),
+		"databaseId": savedReportCard.ID.Hex(),
+		"savedAt":    savedReportCard.CreatedAt.Format("2006-01-02T15:04:05.000Z"),
+	}, nil
 }
 
-// handleAssignmentResultSaving processes assignment result data from assessor agent
-func handleAssignmentResultSaving(assignmentResultData interface{}) (map[string]interface{}, error) {
-	// Convert interface{} to map[string]interface{}
-	resultMap, ok := assignmentResultData.(map[string]interface{})
-	if !ok {
-		return nil, fmt.Errorf("invalid assignment result data format")
+// planAssignmentResult builds the assignment result graded by the assessor agent without saving it
+func planAssignmentResult(result *AssignmentResultPayload) *model.AssignmentResult {
+	assignmentResult := &model.AssignmentResult{
+		AssignmentID:      strings.TrimSpace(result.AssignmentID),
+		StudentID:         strings.TrimSpace(result.StudentID),
+		MCQResults:        result.MCQResults,
+		MSQResults:        result.MSQResults,
+		NATResults:        result.NATResults,
+		SubjectiveResults: result.SubjectiveResults,
 	}
-
-	// Convert to AssignmentResult model
-	var assignmentResult model.AssignmentResult
-
-	// Map the fields from the response
-	if assignmentId, ok := resultMap["assignment_id"].(string); ok {
-		assignmentResult.AssignmentID = assignmentId
-	} else {
-		return nil, fmt.Errorf("assignment_id is required")
-	}
-
-	if studentId, ok := resultMap["student_id"].(string); ok {
-		assignmentResult.StudentID = studentId
-	} else {
-		return nil, fmt.Errorf("student_id is required")
-	}
-
-	if totalPoints, ok := resultMap["total_points_awarded"].(float64); ok {
-		assignmentResult.TotalPointsAwarded = int(totalPoints)
-	}
-
-	if maxPoints, ok := resultMap["total_max_points"].(float64); ok {
-		assignmentResult.TotalMaxPoints = int(maxPoints)
-	}
-
-	if percentage, ok := resultMap["percentage_score"].(float64); ok {
-		assignmentResult.PercentageScore = percentage
-	}
-
-	// Convert the results arrays into the proper structs
-	if mcqResults, ok := resultMap["mcq_results"]; ok {
-		mcqResultsBytes, err := json.Marshal(mcqResults)
-		if err == nil {
-			var mcqResultsList []model.MCQResult
-			if err := json.Unmarshal(mcqResultsBytes, &mcqResultsList); err == nil {
-				assignmentResult.MCQResults = mcqResultsList
-			}
-		}
-	}
-
-	if msqResults, ok := resultMap["msq_results"]; ok {
-		msqResultsBytes, err := json.Marshal(msqResults)
-		if err == nil {
-			var msqResultsList []model.MSQResult
-			if err := json.Unmarshal(msqResultsBytes, &msqResultsList); err == nil {
-				assignmentResult.MSQResults = msqResultsList
-			}
-		}
+	if points := result.TotalPointsAwarded.IntPtr(); points != nil {
+		assignmentResult.TotalPointsAwarded = *points
 	}
-
-	if natResults, ok := resultMap["nat_results"]; ok {
-		natResultsBytes, err := json.Marshal(natResults)
-		if err == nil {
-			var natResultsList []model.NATResult
-			if err := json.Unmarshal(natResultsBytes, &natResultsList); err == nil {
-				assignmentResult.NATResults = natResultsList
-			}
-		}
+	if maxPoints := result.TotalMaxPoints.IntPtr(); maxPoints != nil {
+		assignmentResult.TotalMaxPoints = *maxPoints
 	}
-
-	if subjectiveResults, ok := resultMap["subjective_results"]; ok {
-		subjectiveResultsBytes, err := json.Marshal(subjectiveResults)
-		if err == nil {
-			var subjectiveResultsList []model.SubjectiveResult
-			if err := json.Unmarshal(subjectiveResultsBytes, &subjectiveResultsList); err == nil {
-				assignmentResult.SubjectiveResults = subjectiveResultsList
-			}
-		}
+	if percentage := result.PercentageScore.FloatPtr(); percentage != nil {
+		assignmentResult.PercentageScore = *percentage
 	}
 
 	// Set metadata
@@ -1010,12 +465,17 @@ func handleAssignmentResultSaving(assignmentResultData interface{}) (map[string]
 	assignmentResult.CreatedAt = time.Now()
 	assignmentResult.UpdatedAt = time.Now()
 
+	return assignmentResult
+}
+
+// saveAssignmentResult saves an assignment result planned by planAssignmentResult
+func saveAssignmentResult(planned *model.AssignmentResult) (map[string]interface{}, error) {
 	// Save to database using repository
-	savedResult, err := repository.CreateAssignmentResult(assignmentResult)
+	savedResult, err := repository.CreateAssignmentResult(*planned)
 	if err != nil {
 		return nil, fmt.Errorf("failed to save assignment result: %v", err)
 	}
-	assignmentResult = *savedResult
+	assignmentResult := *savedResult
 
 	// Return the saved assignment result data directly (flattened)
 	responseData := map[string]interface{}{
@@ -1049,13 +509,10 @@ func handleAssignmentResultSaving(assignmentResultData interface{}) (map[string]
 // protoc --go_out=. --go-grpc_out=. internal/proto/ai_service.proto
 /*
 func RAGAgent(teacherId, role, message, file, createdAt, updatedAt string) (map[string]interface{}, error) {
-	client, conn, err := DialGRPC()
+	client, ctx, cancel, err := newCall(pb.AIService_RAGAgent_FullMethodName)
 	if err != nil {
 		return nil, err
 	}
-	defer conn.Close()
-
-	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
 	defer cancel()
 
 	req := &pb.RAGAgentRequest{
@@ -1111,15 +568,12 @@ func createRAGErrorResponse(teacherId, errorMessage string, res *pb.RAGAgentResp
 }
 */
 
-func RAGAgentClient(corpusName string, message string) (*pb.RAGAgentResponse, error) {
-	client, conn, err := DialGRPC()
+func RAGAgentClient(parent context.Context, corpusName string, message string) (*pb.RAGAgentResponse, error) {
+	client, ctx, cancel, err := newCall(parent, pb.AIService_RAGAgent_FullMethodName)
 	if err != nil {
-		log.Printf("ERROR: Failed to establish gRPC connection: %v", err)
+		log.Printf("ERROR: Failed to get gRPC client: %v", err)
 		return nil, err
 	}
-	defer conn.Close()
-
-	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
 	defer cancel()
 
 	req := &pb.RAGAgentRequest{
@@ -0,0 +1,517 @@
+package service
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+
+	"lumenslate/internal/model"
+)
+
+// AgentPayloadSchemaVersion is the newest agent_response envelope version this server understands.
+// Responses without a schema_version are the unversioned format sent before the envelope existed.
+const AgentPayloadSchemaVersion = 1
+
+// AgentPayloadType identifies which payload an agent response carries
+type AgentPayloadType string
+
+const (
+	AgentPayloadQuestionRequest  AgentPayloadType = "question_request"
+	AgentPayloadAssessment       AgentPayloadType = "assessment"
+	AgentPayloadAssignmentResult AgentPayloadType = "assignment_result"
+	AgentPayloadReportCard       AgentPayloadType = "report_card"
+	AgentPayloadText             AgentPayloadType = "text"
+)
+
+// Error codes reported in AgentPayloadError
+const (
+	AgentPayloadErrMalformed          = "malformed_payload"
+	AgentPayloadErrUnsupportedVersion = "unsupported_schema_version"
+	AgentPayloadErrUnknownType        = "unknown_payload_type"
+	AgentPayloadErrAmbiguous          = "ambiguous_payload"
+	AgentPayloadErrInvalid            = "invalid_payload"
+)
+
+// AgentPayloadError describes an agent response that does not match the payload contract
+type AgentPayloadError struct {
+	Code          string           `json:"code"`
+	Message       string           `json:"message"`
+	SchemaVersion int              `json:"schemaVersion"`
+	PayloadType   AgentPayloadType `json:"payloadType,omitempty"`
+	Details       []string         `json:"details,omitempty"`
+}
+
+func (e *AgentPayloadError) Error() string {
+	if len(e.Details) > 0 {
+		return fmt.Sprintf("%s: %s (%s)", e.Code, e.Message, strings.Join(e.Details, "; "))
+	}
+	return fmt.Sprintf("%s: %s", e.Code, e.Message)
+}
+
+// agentEnvelope is the versioned agent_response format:
+// {"schema_version": 1, "type": "report_card", "payload": {...}}
+type agentEnvelope struct {
+	SchemaVersion int              `json:"schema_version"`
+	Type          AgentPayloadType `json:"type"`
+	Payload       json.RawMessage  `json:"payload"`
+}
+
+// AgentPayload is a decoded and validated agent response. Exactly one of the payload fields is set,
+// matching Type.
+type AgentPayload struct {
+	SchemaVersion int
+	Type          AgentPayloadType
+
+	QuestionRequest  *QuestionRequestPayload
+	Assessment       *AssessmentPayload
+	AssignmentResult *AssignmentResultPayload
+	ReportCard       *ReportCardPayload
+	Text             *TextPayload
+}
+
+// Question types that can be requested in QuestionRequest.QuestionTypes
+const (
+	QuestionTypeMCQ        = "mcq"
+	QuestionTypeMSQ        = "msq"
+	QuestionTypeNAT        = "nat"
+	QuestionTypeSubjective = "subjective"
+)
+
+// QuestionRequest represents a question request from the agent
+type QuestionRequest struct {
+	Type              string         `json:"type"`
+	Subject           string         `json:"subject"`
+	NumberOfQuestions int            `json:"number_of_questions"`
+	Difficulty        string         `json:"difficulty"`
+	BankIDs           []string       `json:"bank_ids,omitempty"`       // Only questions from these banks
+	Tags              []string       `json:"tags,omitempty"`           // Only questions from banks with any of these tags
+	QuestionTypes     map[string]int `json:"question_types,omitempty"` // Number of questions of each type, e.g. {"mcq": 3, "nat": 2}
+	TotalPoints       int            `json:"total_points,omitempty"`   // Points the selected questions should add up to
+}
+
+// QuestionCount is the number of questions requested, given directly or as the sum of the type mix
+func (r QuestionRequest) QuestionCount() int {
+	if r.NumberOfQuestions > 0 || len(r.QuestionTypes) == 0 {
+		return r.NumberOfQuestions
+	}
+	count := 0
+	for _, n := range r.QuestionTypes {
+		count += n
+	}
+	return count
+}
+
+// QuestionRequestPayload asks for an assignment built from questions in the database
+type QuestionRequestPayload struct {
+	Title              string            `json:"title,omitempty"`
+	Body               string            `json:"body,omitempty"`
+	QuestionsRequested []QuestionRequest `json:"questions_requested"`
+	ClassroomID        string            `json:"classroom_id,omitempty"`         // Avoid questions this classroom has been assigned recently
+	AvoidRecentDays    int               `json:"avoid_recent_days,omitempty"`    // How far back to look for used questions, 30 days by default
+	FillWithVariations bool              `json:"fill_with_variations,omitempty"` // Fill MCQ/MSQ shortfalls with AI-generated variations
+}
+
+// AssessmentPayload is a subject assessment to be saved as a subject report
+type AssessmentPayload struct {
+	StudentID   *AgentNumber `json:"student_id"`
+	StudentName string       `json:"student_name"`
+	Subject     string       `json:"subject"`
+	Score       *AgentNumber `json:"score"`
+
+	// Optional fields
+	GradeLetter    string `json:"grade_letter,omitempty"`
+	ClassName      string `json:"class_name,omitempty"`
+	InstructorName string `json:"instructor_name,omitempty"`
+	Term           string `json:"term,omitempty"`
+	Remarks        string `json:"remarks,omitempty"`
+
+	// Assessment component breakdown
+	MidtermScore          *AgentNumber `json:"midterm_score,omitempty"`
+	FinalExamScore        *AgentNumber `json:"final_exam_score,omitempty"`
+	QuizScore             *AgentNumber `json:"quiz_score,omitempty"`
+	AssignmentScore       *AgentNumber `json:"assignment_score,omitempty"`
+	PracticalScore        *AgentNumber `json:"practical_score,omitempty"`
+	OralPresentationScore *AgentNumber `json:"oral_presentation_score,omitempty"`
+
+	// Skill evaluation (0-10 scale or %)
+	ConceptualUnderstanding *AgentNumber `json:"conceptual_understanding,omitempty"`
+	ProblemSolving          *AgentNumber `json:"problem_solving,omitempty"`
+	KnowledgeApplication    *AgentNumber `json:"knowledge_application,omitempty"`
+	AnalyticalThinking      *AgentNumber `json:"analytical_thinking,omitempty"`
+	Creativity              *AgentNumber `json:"creativity,omitempty"`
+	PracticalSkills         *AgentNumber `json:"practical_skills,omitempty"`
+
+	// Behavioral metrics
+	Participation *AgentNumber `json:"participation,omitempty"`
+	Discipline    *AgentNumber `json:"discipline,omitempty"`
+	Punctuality   *AgentNumber `json:"punctuality,omitempty"`
+	Teamwork      *AgentNumber `json:"teamwork,omitempty"`
+	EffortLevel   *AgentNumber `json:"effort_level,omitempty"`
+	Improvement   *AgentNumber `json:"improvement,omitempty"`
+
+	// Advanced insights
+	LearningObjectivesMastered string `json:"learning_objectives_mastered,omitempty"`
+	AreasForImprovement        string `json:"areas_for_improvement,omitempty"`
+	RecommendedResources       string `json:"recommended_resources,omitempty"`
+	TargetGoals                string `json:"target_goals,omitempty"`
+}
+
+// AssignmentResultPayload is the graded result of an assignment submission from the assessor agent
+type AssignmentResultPayload struct {
+	AssignmentID       string                   `json:"assignment_id"`
+	StudentID          string                   `json:"student_id"`
+	TotalPointsAwarded *AgentNumber             `json:"total_points_awarded"`
+	TotalMaxPoints     *AgentNumber             `json:"total_max_points"`
+	PercentageScore    *AgentNumber             `json:"percentage_score"`
+	MCQResults         []model.MCQResult        `json:"mcq_results"`
+	MSQResults         []model.MSQResult        `json:"msq_results"`
+	NATResults         []model.NATResult        `json:"nat_results"`
+	SubjectiveResults  []model.SubjectiveResult `json:"subjective_results"`
+}
+
+// ReportCardPayload is a generated report card
+type ReportCardPayload = model.AgentReportCardData
+
+// TextPayload is a conversational reply without side effects
+type TextPayload struct {
+	Text string `json:"text"`
+}
+
+// AgentNumber is a number the agent may send either as a JSON number or as a numeric string
+type AgentNumber float64
+
+func (n *AgentNumber) UnmarshalJSON(data []byte) error {
+	var value float64
+	if err := json.Unmarshal(data, &value); err == nil {
+		*n = AgentNumber(value)
+		return nil
+	}
+
+	var text string
+	if err := json.Unmarshal(data, &text); err != nil {
+		return fmt.Errorf("expected a number, got %s", data)
+	}
+	value, err := strconv.ParseFloat(strings.TrimSpace(text), 64)
+	if err != nil {
+		return fmt.Errorf("expected a number, got %q", text)
+	}
+	*n = AgentNumber(value)
+	return nil
+}
+
+// IsWhole reports whether the number has no fractional part
+func (n AgentNumber) IsWhole() bool {
+	return float64(n) == math.Trunc(float64(n))
+}
+
+// IntPtr returns the number truncated to an int, or nil when it was not sent
+func (n *AgentNumber) IntPtr() *int {
+	if n == nil {
+		return nil
+	}
+	value := int(*n)
+	return &value
+}
+
+// FloatPtr returns the number, or nil when it was not sent
+func (n *AgentNumber) FloatPtr() *float64 {
+	if n == nil {
+		return nil
+	}
+	value := float64(*n)
+	return &value
+}
+
+// ParseAgentPayload decodes and validates the legacy agent_response string of a LumenAgent reply.
+// Versioned envelopes are decoded strictly. Unversioned responses are mapped onto the same
+// payloads: plain text becomes a text payload and JSON objects must carry exactly one of the
+// known payload keys. Anything else is rejected with an *AgentPayloadError.
+func ParseAgentPayload(raw string) (*AgentPayload, error) {
+	trimmed := strings.TrimSpace(raw)
+	if trimmed == "" {
+		return nil, &AgentPayloadError{Code: AgentPayloadErrMalformed, Message: "agent response is empty"}
+	}
+
+	if !strings.HasPrefix(trimmed, "{") {
+		// The general chat agent replies with plain text
+		payload := &AgentPayload{Type: AgentPayloadText, Text: &TextPayload{Text: raw}}
+		return payload, nil
+	}
+
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal([]byte(trimmed), &fields); err != nil {
+		return nil, &AgentPayloadError{Code: AgentPayloadErrMalformed, Message: "agent response is not valid JSON", Details: []string{err.Error()}}
+	}
+
+	if _, versioned := fields["schema_version"]; versioned {
+		return parseAgentEnvelope(trimmed)
+	}
+	return parseUnversionedAgentPayload(trimmed, fields)
+}
+
+// parseAgentEnvelope decodes a versioned envelope, rejecting unknown fields
+func parseAgentEnvelope(raw string) (*AgentPayload, error) {
+	var envelope agentEnvelope
+	if err := decodeStrict([]byte(raw), &envelope); err != nil {
+		return nil, &AgentPayloadError{Code: AgentPayloadErrMalformed, Message: "invalid agent response envelope", Details: []string{err.Error()}}
+	}
+
+	if envelope.SchemaVersion < 1 || envelope.SchemaVersion > AgentPayloadSchemaVersion {
+		return nil, &AgentPayloadError{
+			Code:          AgentPayloadErrUnsupportedVersion,
+			Message:       fmt.Sprintf("schema version %d is not supported, expected 1 to %d", envelope.SchemaVersion, AgentPayloadSchemaVersion),
+			SchemaVersion: envelope.SchemaVersion,
+			PayloadType:   envelope.Type,
+		}
+	}
+
+	if len(envelope.Payload) == 0 || string(envelope.Payload) == "null" {
+		return nil, &AgentPayloadError{
+			Code:          AgentPayloadErrMalformed,
+			Message:       "agent response envelope has no payload",
+			SchemaVersion: envelope.SchemaVersion,
+			PayloadType:   envelope.Type,
+		}
+	}
+
+	return decodeAgentPayload(envelope.SchemaVersion, envelope.Type, envelope.Payload, true)
+}
+
+// parseUnversionedAgentPayload maps the keys of an unversioned JSON response onto a payload type
+func parseUnversionedAgentPayload(raw string, fields map[string]json.RawMessage) (*AgentPayload, error) {
+	type candidate struct {
+		payloadType AgentPayloadType
+		data        json.RawMessage
+	}
+
+	var candidates []candidate
+	var keys []string
+	add := func(payloadType AgentPayloadType, key string, data json.RawMessage) {
+		if len(data) == 0 || string(data) == "null" {
+			return
+		}
+		candidates = append(candidates, candidate{payloadType, data})
+		keys = append(keys, key)
+	}
+
+	for _, key := range []string{"assignment_result", "assessment_result"} {
+		add(AgentPayloadAssignmentResult, key, fields[key])
+	}
+	if questions, ok := fields["questions_requested"]; ok && string(questions) != "[]" {
+		// Title and body sit next to the requests at the top level
+		add(AgentPayloadQuestionRequest, "questions_requested", json.RawMessage(raw))
+	}
+	add(AgentPayloadAssessment, "assessment_data", fields["assessment_data"])
+	// The microservice has sent report cards under both names
+	for _, key := range []string{"report_card_data", "report_card"} {
+		add(AgentPayloadReportCard, key, fields[key])
+	}
+
+	switch len(candidates) {
+	case 0:
+		return nil, &AgentPayloadError{Code: AgentPayloadErrUnknownType, Message: "agent response does not contain a known payload"}
+	case 1:
+		return decodeAgentPayload(0, candidates[0].payloadType, candidates[0].data, false)
+	default:
+		return nil, &AgentPayloadError{
+			Code:    AgentPayloadErrAmbiguous,
+			Message: "agent response contains more than one payload",
+			Details: keys,
+		}
+	}
+}
+
+// decodeAgentPayload decodes data as the payload for payloadType and validates it
+func decodeAgentPayload(version int, payloadType AgentPayloadType, data json.RawMessage, strict bool) (*AgentPayload, error) {
+	payload := &AgentPayload{SchemaVersion: version, Type: payloadType}
+
+	var target interface{ validate() []string }
+	switch payloadType {
+	case AgentPayloadQuestionRequest:
+		payload.QuestionRequest = &QuestionRequestPayload{}
+		target = payload.QuestionRequest
+	case AgentPayloadAssessment:
+		payload.Assessment = &AssessmentPayload{}
+		target = payload.Assessment
+	case AgentPayloadAssignmentResult:
+		payload.AssignmentResult = &AssignmentResultPayload{}
+		target = payload.AssignmentResult
+	case AgentPayloadReportCard:
+		payload.ReportCard = &ReportCardPayload{}
+		target = (*reportCardPayload)(payload.ReportCard)
+	case AgentPayloadText:
+		payload.Text = &TextPayload{}
+		target = payload.Text
+	default:
+		return nil, &AgentPayloadError{
+			Code:          AgentPayloadErrUnknownType,
+			Message:       fmt.Sprintf("unknown payload type %q", payloadType),
+			SchemaVersion: version,
+			PayloadType:   payloadType,
+		}
+	}
+
+	var err error
+	if strict {
+		err = decodeStrict(data, target)
+	} else {
+		err = json.Unmarshal(data, target)
+	}
+	if err != nil {
+		return nil, &AgentPayloadError{
+			Code:          AgentPayloadErrMalformed,
+			Message:       fmt.Sprintf("%s payload does not match the schema", payloadType),
+			SchemaVersion: version,
+			PayloadType:   payloadType,
+			Details:       []string{err.Error()},
+		}
+	}
+
+	if err := validatePayload(version, payloadType, target); err != nil {
+		return nil, err
+	}
+	return payload, nil
+}
+
+// validatePayload reports the validation problems of a decoded payload as an *AgentPayloadError
+func validatePayload(version int, payloadType AgentPayloadType, target interface{ validate() []string }) error {
+	if problems := target.validate(); len(problems) > 0 {
+		return &AgentPayloadError{
+			Code:          AgentPayloadErrInvalid,
+			Message:       fmt.Sprintf("%s payload failed validation", payloadType),
+			SchemaVersion: version,
+			PayloadType:   payloadType,
+			Details:       problems,
+		}
+	}
+	return nil
+}
+
+// decodeStrict unmarshals data into target, rejecting unknown fields and trailing data
+func decodeStrict(data []byte, target interface{}) error {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(target); err != nil {
+		return err
+	}
+	if decoder.More() {
+		return fmt.Errorf("unexpected data after payload")
+	}
+	return nil
+}
+
+func (p *QuestionRequestPayload) validate() []string {
+	var problems []string
+	if len(p.QuestionsRequested) == 0 {
+		problems = append(problems, "questions_requested must not be empty")
+	}
+	for i, request := range p.QuestionsRequested {
+		field := fmt.Sprintf("questions_requested[%d]", i)
+		if request.Type != "assignment_generator_general" {
+			problems = append(problems, fmt.Sprintf("%s.type %q is not supported", field, request.Type))
+		}
+		if _, ok := model.GetSubjectFromString(strings.ToLower(strings.TrimSpace(request.Subject))); !ok {
+			problems = append(problems, fmt.Sprintf("%s.subject %q is not a known subject", field, request.Subject))
+		}
+		mixTotal := 0
+		for questionType, n := range request.QuestionTypes {
+			switch questionType {
+			case QuestionTypeMCQ, QuestionTypeMSQ, QuestionTypeNAT, QuestionTypeSubjective:
+			default:
+				problems = append(problems, fmt.Sprintf("%s.question_types has unknown type %q", field, questionType))
+			}
+			if n <= 0 {
+				problems = append(problems, fmt.Sprintf("%s.question_types.%s must be positive", field, questionType))
+			}
+			mixTotal += n
+		}
+		if request.NumberOfQuestions < 0 || (request.NumberOfQuestions == 0 && len(request.QuestionTypes) == 0) {
+			problems = append(problems, fmt.Sprintf("%s.number_of_questions must be positive", field))
+		}
+		if request.NumberOfQuestions > 0 && len(request.QuestionTypes) > 0 && mixTotal != request.NumberOfQuestions {
+			problems = append(problems, fmt.Sprintf("%s.question_types add up to %d questions, not number_of_questions %d", field, mixTotal, request.NumberOfQuestions))
+		}
+		if request.TotalPoints < 0 {
+			problems = append(problems, fmt.Sprintf("%s.total_points must not be negative", field))
+		}
+		switch strings.ToLower(strings.TrimSpace(request.Difficulty)) {
+		case "", "easy", "medium", "hard":
+		default:
+			problems = append(problems, fmt.Sprintf("%s.difficulty %q must be easy, medium or hard", field, request.Difficulty))
+		}
+	}
+	if p.AvoidRecentDays < 0 {
+		problems = append(problems, "avoid_recent_days must not be negative")
+	}
+	return problems
+}
+
+func (p *AssessmentPayload) validate() []string {
+	var problems []string
+	if p.StudentID == nil {
+		problems = append(problems, "student_id is required")
+	} else if !p.StudentID.IsWhole() {
+		problems = append(problems, "student_id must be a whole number")
+	}
+	if strings.TrimSpace(p.StudentName) == "" {
+		problems = append(problems, "student_name is required")
+	}
+	if strings.TrimSpace(p.Subject) == "" {
+		problems = append(problems, "subject is required")
+	} else if _, ok := model.GetSubjectFromString(strings.ToLower(strings.TrimSpace(p.Subject))); !ok {
+		problems = append(problems, fmt.Sprintf("subject %q is not a known subject", p.Subject))
+	}
+	if p.Score == nil {
+		problems = append(problems, "score is required")
+	} else if *p.Score < 0 {
+		problems = append(problems, "score must not be negative")
+	}
+	return problems
+}
+
+func (p *AssignmentResultPayload) validate() []string {
+	var problems []string
+	if strings.TrimSpace(p.AssignmentID) == "" {
+		problems = append(problems, "assignment_id is required")
+	}
+	if strings.TrimSpace(p.StudentID) == "" {
+		problems = append(problems, "student_id is required")
+	}
+	if p.TotalPointsAwarded != nil && *p.TotalPointsAwarded < 0 {
+		problems = append(problems, "total_points_awarded must not be negative")
+	}
+	if p.TotalMaxPoints != nil && *p.TotalMaxPoints < 0 {
+		problems = append(problems, "total_max_points must not be negative")
+	}
+	if p.TotalPointsAwarded != nil && p.TotalMaxPoints != nil && *p.TotalMaxPoints > 0 && *p.TotalPointsAwarded > *p.TotalMaxPoints {
+		problems = append(problems, "total_points_awarded must not exceed total_max_points")
+	}
+	if p.PercentageScore != nil && (*p.PercentageScore < 0 || *p.PercentageScore > 100) {
+		problems = append(problems, "percentage_score must be between 0 and 100")
+	}
+	return problems
+}
+
+// reportCardPayload attaches validation to the report card model without adding it to the model package
+type reportCardPayload ReportCardPayload
+
+func (p *reportCardPayload) validate() []string {
+	var problems []string
+	if strings.TrimSpace(p.StudentID) == "" {
+		problems = append(problems, "student_id is required")
+	}
+	if strings.TrimSpace(p.StudentName) == "" {
+		problems = append(problems, "student_name is required")
+	}
+	return problems
+}
+
+func (p *TextPayload) validate() []string {
+	if strings.TrimSpace(p.Text) == "" {
+		return []string{"text must not be empty"}
+	}
+	return nil
+}
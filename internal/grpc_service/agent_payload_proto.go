@@ -0,0 +1,256 @@
+package service
+
+import (
+	"fmt"
+
+	"lumenslate/internal/model"
+	pb "lumenslate/internal/proto/ai_service"
+)
+
+// DecodeAgentResponse returns the validated payload of a LumenAgent reply. The typed payload is
+// read when the agent sets one; the agent_response string is only parsed, by ParseAgentPayload,
+// for agents that predate it.
+func DecodeAgentResponse(res *pb.AgentResponse) (*AgentPayload, error) {
+	if res.GetPayload() == nil {
+		return ParseAgentPayload(res.GetAgentResponse())
+	}
+
+	version := int(res.GetSchemaVersion())
+	payload := &AgentPayload{SchemaVersion: version}
+
+	var target interface{ validate() []string }
+	switch p := res.GetPayload().(type) {
+	case *pb.AgentResponse_QuestionRequest:
+		payload.Type = AgentPayloadQuestionRequest
+		payload.QuestionRequest = questionRequestFromProto(p.QuestionRequest)
+		target = payload.QuestionRequest
+	case *pb.AgentResponse_Assessment:
+		payload.Type = AgentPayloadAssessment
+		payload.Assessment = assessmentFromProto(p.Assessment)
+		target = payload.Assessment
+	case *pb.AgentResponse_AssignmentResult:
+		payload.Type = AgentPayloadAssignmentResult
+		payload.AssignmentResult = assignmentResultFromProto(p.AssignmentResult)
+		target = payload.AssignmentResult
+	case *pb.AgentResponse_ReportCard:
+		payload.Type = AgentPayloadReportCard
+		payload.ReportCard = reportCardFromProto(p.ReportCard)
+		target = (*reportCardPayload)(payload.ReportCard)
+	case *pb.AgentResponse_Text:
+		payload.Type = AgentPayloadText
+		payload.Text = &TextPayload{Text: p.Text.GetText()}
+		target = payload.Text
+	default:
+		return nil, &AgentPayloadError{
+			Code:          AgentPayloadErrUnknownType,
+			Message:       fmt.Sprintf("unknown payload %T", p),
+			SchemaVersion: version,
+		}
+	}
+
+	if version < 1 || version > AgentPayloadSchemaVersion {
+		return nil, &AgentPayloadError{
+			Code:          AgentPayloadErrUnsupportedVersion,
+			Message:       fmt.Sprintf("schema version %d is not supported, expected 1 to %d", version, AgentPayloadSchemaVersion),
+			SchemaVersion: version,
+			PayloadType:   payload.Type,
+		}
+	}
+
+	if err := validatePayload(version, payload.Type, target); err != nil {
+		return nil, err
+	}
+	return payload, nil
+}
+
+func questionRequestFromProto(p *pb.AgentQuestionRequestPayload) *QuestionRequestPayload {
+	payload := &QuestionRequestPayload{
+		Title:              p.GetTitle(),
+		Body:               p.GetBody(),
+		ClassroomID:        p.GetClassroomId(),
+		AvoidRecentDays:    int(p.GetAvoidRecentDays()),
+		FillWithVariations: p.GetFillWithVariations(),
+	}
+	for _, request := range p.GetQuestionsRequested() {
+		var questionTypes map[string]int
+		for _, count := range request.GetQuestionTypes() {
+			if questionTypes == nil {
+				questionTypes = make(map[string]int)
+			}
+			questionTypes[count.GetQuestionType()] += int(count.GetCount())
+		}
+		payload.QuestionsRequested = append(payload.QuestionsRequested, QuestionRequest{
+			Type:              request.GetType(),
+			Subject:           request.GetSubject(),
+			NumberOfQuestions: int(request.GetNumberOfQuestions()),
+			Difficulty:        request.GetDifficulty(),
+			BankIDs:           request.GetBankIds(),
+			Tags:              request.GetTags(),
+			QuestionTypes:     questionTypes,
+			TotalPoints:       int(request.GetTotalPoints()),
+		})
+	}
+	return payload
+}
+
+func assessmentFromProto(p *pb.AgentAssessmentPayload) *AssessmentPayload {
+	payload := &AssessmentPayload{
+		StudentName:                p.GetStudentName(),
+		Subject:                    p.GetSubject(),
+		Score:                      agentNumber(p.Score),
+		GradeLetter:                p.GetGradeLetter(),
+		ClassName:                  p.GetClassName(),
+		InstructorName:             p.GetInstructorName(),
+		Term:                       p.GetTerm(),
+		Remarks:                    p.GetRemarks(),
+		MidtermScore:               agentNumber(p.MidtermScore),
+		FinalExamScore:             agentNumber(p.FinalExamScore),
+		QuizScore:                  agentNumber(p.QuizScore),
+		AssignmentScore:            agentNumber(p.AssignmentScore),
+		PracticalScore:             agentNumber(p.PracticalScore),
+		OralPresentationScore:      agentNumber(p.OralPresentationScore),
+		ConceptualUnderstanding:    agentNumber(p.ConceptualUnderstanding),
+		ProblemSolving:             agentNumber(p.ProblemSolving),
+		KnowledgeApplication:       agentNumber(p.KnowledgeApplication),
+		AnalyticalThinking:         agentNumber(p.AnalyticalThinking),
+		Creativity:                 agentNumber(p.Creativity),
+		PracticalSkills:            agentNumber(p.PracticalSkills),
+		Participation:              agentNumber(p.Participation),
+		Discipline:                 agentNumber(p.Discipline),
+		Punctuality:                agentNumber(p.Punctuality),
+		Teamwork:                   agentNumber(p.Teamwork),
+		EffortLevel:                agentNumber(p.EffortLevel),
+		Improvement:                agentNumber(p.Improvement),
+		LearningObjectivesMastered: p.GetLearningObjectivesMastered(),
+		AreasForImprovement:        p.GetAreasForImprovement(),
+		RecommendedResources:       p.GetRecommendedResources(),
+		TargetGoals:                p.GetTargetGoals(),
+	}
+	if p.StudentId != nil {
+		studentID := AgentNumber(*p.StudentId)
+		payload.StudentID = &studentID
+	}
+	return payload
+}
+
+func assignmentResultFromProto(p *pb.AgentAssignmentResultPayload) *AssignmentResultPayload {
+	payload := &AssignmentResultPayload{
+		AssignmentID:       p.GetAssignmentId(),
+		StudentID:          p.GetStudentId(),
+		TotalPointsAwarded: agentNumber(p.TotalPointsAwarded),
+		TotalMaxPoints:     agentNumber(p.TotalMaxPoints),
+		PercentageScore:    agentNumber(p.PercentageScore),
+	}
+	for _, r := range p.GetMcqResults() {
+		payload.MCQResults = append(payload.MCQResults, model.MCQResult{
+			QuestionID:    r.GetQuestionId(),
+			StudentAnswer: int(r.GetStudentAnswer()),
+			CorrectAnswer: int(r.GetCorrectAnswer()),
+			PointsAwarded: int(r.GetPointsAwarded()),
+			MaxPoints:     int(r.GetMaxPoints()),
+			IsCorrect:     r.GetIsCorrect(),
+		})
+	}
+	for _, r := range p.GetMsqResults() {
+		payload.MSQResults = append(payload.MSQResults, model.MSQResult{
+			QuestionID:     r.GetQuestionId(),
+			StudentAnswers: ints(r.GetStudentAnswers()),
+			CorrectAnswers: ints(r.GetCorrectAnswers()),
+			PointsAwarded:  int(r.GetPointsAwarded()),
+			MaxPoints:      int(r.GetMaxPoints()),
+			IsCorrect:      r.GetIsCorrect(),
+		})
+	}
+	for _, r := range p.GetNatResults() {
+		payload.NATResults = append(payload.NATResults, model.NATResult{
+			QuestionID:    r.GetQuestionId(),
+			StudentAnswer: r.GetStudentAnswer(),
+			CorrectAnswer: r.GetCorrectAnswer(),
+			PointsAwarded: int(r.GetPointsAwarded()),
+			MaxPoints:     int(r.GetMaxPoints()),
+			IsCorrect:     r.GetIsCorrect(),
+		})
+	}
+	for _, r := range p.GetSubjectiveResults() {
+		payload.SubjectiveResults = append(payload.SubjectiveResults, model.SubjectiveResult{
+			QuestionID:         r.GetQuestionId(),
+			StudentAnswer:      r.GetStudentAnswer(),
+			IdealAnswer:        r.GetIdealAnswer(),
+			GradingCriteria:    r.GetGradingCriteria(),
+			PointsAwarded:      int(r.GetPointsAwarded()),
+			MaxPoints:          int(r.GetMaxPoints()),
+			AssessmentFeedback: r.GetAssessmentFeedback(),
+			CriteriaMet:        r.GetCriteriaMet(),
+			CriteriaMissed:     r.GetCriteriaMissed(),
+		})
+	}
+	return payload
+}
+
+func reportCardFromProto(p *pb.AgentReportCardPayload) *ReportCardPayload {
+	overall := p.GetOverallPerformance()
+	insights := p.GetStudentInsights()
+	payload := &ReportCardPayload{
+		StudentID:      p.GetStudentId(),
+		StudentName:    p.GetStudentName(),
+		ReportPeriod:   p.GetReportPeriod(),
+		GenerationDate: p.GetGenerationDate(),
+		OverallPerformance: model.AgentOverallPerformance{
+			TotalAssignmentsCompleted: int(overall.GetTotalAssignmentsCompleted()),
+			OverallPercentage:         overall.GetOverallPercentage(),
+			ImprovementTrend:          overall.GetImprovementTrend(),
+			StrongestQuestionType:     overall.GetStrongestQuestionType(),
+			WeakestQuestionType:       overall.GetWeakestQuestionType(),
+		},
+		AIRemarks:      p.GetAiRemarks(),
+		TeacherRemarks: p.GetTeacherRemarks(),
+		StudentInsights: model.AgentStudentInsights{
+			KeyStrengths:        insights.GetKeyStrengths(),
+			AreasForImprovement: insights.GetAreasForImprovement(),
+			RecommendedActions:  insights.GetRecommendedActions(),
+		},
+	}
+	for _, s := range p.GetSubjectPerformance() {
+		payload.SubjectPerformance = append(payload.SubjectPerformance, model.AgentSubjectPerformance{
+			SubjectName:        s.GetSubjectName(),
+			PercentageScore:    s.GetPercentageScore(),
+			AssignmentCount:    int(s.GetAssignmentCount()),
+			MCQAccuracy:        s.GetMcqAccuracy(),
+			MSQAccuracy:        s.GetMsqAccuracy(),
+			NATAccuracy:        s.GetNatAccuracy(),
+			SubjectiveAvgScore: s.GetSubjectiveAvgScore(),
+			Strengths:          s.GetStrengths(),
+			Weaknesses:         s.GetWeaknesses(),
+			ImprovementTrend:   s.GetImprovementTrend(),
+		})
+	}
+	for _, a := range p.GetAssignmentSummaries() {
+		payload.AssignmentSummaries = append(payload.AssignmentSummaries, model.AgentAssignmentSummary{
+			AssignmentID:    a.GetAssignmentId(),
+			AssignmentTitle: a.GetAssignmentTitle(),
+			PercentageScore: a.GetPercentageScore(),
+			Subject:         a.GetSubject(),
+		})
+	}
+	return payload
+}
+
+// agentNumber converts an optional proto number, keeping unset values nil
+func agentNumber(value *float64) *AgentNumber {
+	if value == nil {
+		return nil
+	}
+	n := AgentNumber(*value)
+	return &n
+}
+
+func ints(values []int32) []int {
+	if values == nil {
+		return nil
+	}
+	result := make([]int, len(values))
+	for i, v := range values {
+		result[i] = int(v)
+	}
+	return result
+}
@@ -0,0 +1,366 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	"lumenslate/internal/db"
+	"lumenslate/internal/model"
+	"lumenslate/internal/repository"
+	"lumenslate/internal/utils"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// ErrInvalidAgentProposalEdit is returned when a teacher's edit of a proposal cannot be applied
+var ErrInvalidAgentProposalEdit = errors.New("invalid agent proposal edit")
+
+// proposeAgentWrite stores the record an agent payload would write as a pending proposal instead of
+// writing it. The teacher approves, edits or rejects the proposal; only an approval writes the record.
+func proposeAgentWrite(payload *AgentPayload, teacherId string) (*agentPayloadResult, error) {
+	proposal := &model.AgentProposal{
+		TeacherID:   teacherId,
+		PayloadType: string(payload.Type),
+		Operation:   model.AgentProposalOperationCreate,
+		Status:      model.AgentProposalStatusPending,
+	}
+
+	var message string
+	switch payload.Type {
+	case AgentPayloadQuestionRequest:
+		plan, err := planQuestionGeneration(payload.QuestionRequest)
+		if err != nil {
+			return nil, err
+		}
+		proposal.AgentName = "assignment_generator_general"
+		proposal.Collection = db.AssignmentCollection
+		proposal.Summary = fmt.Sprintf("Create assignment %q with %d questions worth %d points", plan.assignment.Title, plan.questionCount(), plan.assignment.Points)
+		if generated := len(plan.mcqs) + len(plan.msqs); generated > 0 {
+			proposal.Summary += fmt.Sprintf(", %d of them AI-generated", generated)
+		}
+		proposal.Records.Assignment = plan.assignment
+		proposal.Records.MCQs = plan.mcqs
+		proposal.Records.MSQs = plan.msqs
+		proposal.Shortfalls = plan.shortfalls
+		message = "Assignment generated and awaiting your approval"
+		if len(plan.shortfalls) > 0 {
+			message = "Assignment generated with shortfalls and awaiting your approval"
+		}
+	case AgentPayloadAssessment:
+		report := planAssessment(payload.Assessment, teacherId)
+		proposal.AgentName = "assessor_agent"
+		proposal.Collection = db.SubjectReportCollection
+		proposal.Summary = fmt.Sprintf("Save %s report for %s with score %d", report.Subject, report.StudentName, report.Score)
+		proposal.Records.SubjectReport = report
+		message = "Subject assessment report prepared and awaiting your approval"
+	case AgentPayloadAssignmentResult:
+		result := planAssignmentResult(payload.AssignmentResult)
+		proposal.AgentName = "assessor_agent"
+		proposal.Collection = db.AssignmentResultCollection
+		proposal.Summary = fmt.Sprintf("Save result of assignment %s for student %s: %d/%d points", result.AssignmentID, result.StudentID, result.TotalPointsAwarded, result.TotalMaxPoints)
+		proposal.Records.AssignmentResult = result
+		message = "Assignment assessment completed and awaiting your approval"
+	case AgentPayloadReportCard:
+		reportCard := planReportCard(payload.ReportCard, teacherId)
+		proposal.AgentName = "report_card_generator"
+		proposal.Collection = db.ReportCardCollection
+		proposal.Summary = fmt.Sprintf("Save report card for %s", reportCard.ReportCard.StudentName)
+		if period := reportCard.ReportCard.ReportPeriod; period != "" {
+			proposal.Summary += fmt.Sprintf(" (%s)", period)
+		}
+		proposal.Records.ReportCard = reportCard
+		message = "Report card generated and awaiting your approval"
+	default:
+		return nil, fmt.Errorf("payload type %q does not write to the database", payload.Type)
+	}
+
+	preview, err := previewAgentProposal(proposal.Records)
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	proposal.Preview = preview
+	proposal.History = []model.AgentProposalEvent{{Action: model.AgentProposalActionProposed, ActorID: proposal.AgentName, At: now}}
+	proposal.CreatedAt = now
+	proposal.UpdatedAt = now
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	if err := repository.NewAgentProposalRepository().Create(ctx, proposal); err != nil {
+		return nil, err
+	}
+
+	data := map[string]interface{}{
+		"proposalId": proposal.ID.Hex(),
+		"status":     proposal.Status,
+		"summary":    proposal.Summary,
+		"operation":  proposal.Operation,
+		"collection": proposal.Collection,
+		"preview":    proposal.Preview,
+	}
+	if len(proposal.Shortfalls) > 0 {
+		data["shortfalls"] = proposal.Shortfalls
+	}
+	return &agentPayloadResult{proposal.AgentName, message, data}, nil
+}
+
+// ApproveAgentProposal writes the record of a pending proposal and records who approved it.
+// It returns the approved proposal and the written record as the agent would have returned it.
+// When the write fails the proposal stays pending with the error, so that it can be approved again.
+func ApproveAgentProposal(ctx context.Context, proposalID primitive.ObjectID, actorID, note string) (*model.AgentProposal, map[string]interface{}, error) {
+	repo := repository.NewAgentProposalRepository()
+	proposal, err := repo.Claim(ctx, proposalID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// The record is written, or not, from here on; record the outcome even if the caller goes away
+	ctx = context.WithoutCancel(ctx)
+
+	committedID, data, err := commitAgentProposal(proposal.Records)
+	if err != nil {
+		failure := model.AgentProposalEvent{Action: model.AgentProposalActionFailed, ActorID: actorID, Note: err.Error(), At: time.Now()}
+		if _, releaseErr := repo.Release(ctx, proposalID, failure); releaseErr != nil {
+			log.Printf("ERROR: Failed to release agent proposal %s: %v", proposalID.Hex(), releaseErr)
+		}
+		return nil, nil, fmt.Errorf("failed to write agent proposal %s: %w", proposalID.Hex(), err)
+	}
+
+	approval := model.AgentProposalEvent{Action: model.AgentProposalActionApproved, ActorID: actorID, Note: note, At: time.Now()}
+	approved, err := repo.MarkApproved(ctx, proposalID, committedID, approval)
+	if err != nil {
+		return nil, data, fmt.Errorf("agent proposal %s was written as %s but could not be marked approved: %w", proposalID.Hex(), committedID, err)
+	}
+	return approved, data, nil
+}
+
+// RejectAgentProposal closes a pending proposal without writing it
+func RejectAgentProposal(ctx context.Context, proposalID primitive.ObjectID, actorID, note string) (*model.AgentProposal, error) {
+	rejection := model.AgentProposalEvent{Action: model.AgentProposalActionRejected, ActorID: actorID, Note: note, At: time.Now()}
+	return repository.NewAgentProposalRepository().Reject(ctx, proposalID, rejection)
+}
+
+// EditAgentProposal applies a teacher's changes to the record of a pending proposal. edits is a JSON
+// object with the fields to change, named as in the record of the proposal. The changed fields are
+// recorded in the proposal history.
+func EditAgentProposal(ctx context.Context, proposal *model.AgentProposal, actorID string, edits json.RawMessage, note string) (*model.AgentProposal, error) {
+	records, err := applyAgentProposalEdits(proposal.Records, edits)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidAgentProposalEdit, err)
+	}
+
+	changes, err := previewAgentRecord(proposalRecord(proposal.Records), proposalRecord(records))
+	if err != nil {
+		return nil, err
+	}
+	if len(changes) == 0 {
+		return nil, fmt.Errorf("%w: the edit does not change the proposal", ErrInvalidAgentProposalEdit)
+	}
+	preview, err := previewAgentProposal(records)
+	if err != nil {
+		return nil, err
+	}
+
+	edit := model.AgentProposalEvent{Action: model.AgentProposalActionEdited, ActorID: actorID, Note: note, Changes: changes, At: time.Now()}
+	return repository.NewAgentProposalRepository().UpdatePending(ctx, proposal.ID, records, preview, edit)
+}
+
+// applyAgentProposalEdits returns a copy of records with edits applied to its record. Identifiers,
+// timestamps and the owning teacher cannot be edited.
+func applyAgentProposalEdits(records model.AgentProposalRecords, edits json.RawMessage) (model.AgentProposalRecords, error) {
+	switch {
+	case records.Assignment != nil:
+		original := records.Assignment
+		var edited model.Assignment
+		if err := editRecord(original, edits, &edited); err != nil {
+			return records, err
+		}
+		edited.ID, edited.CreatedAt, edited.UpdatedAt = original.ID, original.CreatedAt, original.UpdatedAt
+		if err := utils.Validate.Struct(edited); err != nil {
+			return records, err
+		}
+		// Generated questions stay with the proposal; those no longer used are not created on approval
+		updated := records
+		updated.Assignment = &edited
+		return updated, nil
+	case records.SubjectReport != nil:
+		original := records.SubjectReport
+		var edited model.SubjectReport
+		if err := editRecord(original, edits, &edited); err != nil {
+			return records, err
+		}
+		edited.ID, edited.UserID, edited.CreatedAt, edited.UpdatedAt = original.ID, original.UserID, original.CreatedAt, original.UpdatedAt
+		subject, ok := model.GetSubjectFromString(strings.ToLower(strings.TrimSpace(string(edited.Subject))))
+		if !ok {
+			return records, fmt.Errorf("unknown subject %q", edited.Subject)
+		}
+		edited.Subject = subject
+		if err := utils.Validate.Struct(edited); err != nil {
+			return records, err
+		}
+		return model.AgentProposalRecords{SubjectReport: &edited}, nil
+	case records.AssignmentResult != nil:
+		original := records.AssignmentResult
+		var edited model.AssignmentResult
+		if err := editRecord(original, edits, &edited); err != nil {
+			return records, err
+		}
+		edited.ID, edited.CreatedAt, edited.UpdatedAt = original.ID, original.CreatedAt, original.UpdatedAt
+		if edited.AssignmentID == "" || edited.StudentID == "" {
+			return records, fmt.Errorf("assignment_id and student_id are required")
+		}
+		return model.AgentProposalRecords{AssignmentResult: &edited}, nil
+	case records.ReportCard != nil:
+		original := records.ReportCard
+		var edited model.AgentReportCard
+		if err := editRecord(original, edits, &edited); err != nil {
+			return records, err
+		}
+		edited.ID, edited.UserID, edited.CreatedAt, edited.UpdatedAt = original.ID, original.UserID, original.CreatedAt, original.UpdatedAt
+		if problems := (*reportCardPayload)(&edited.ReportCard).validate(); len(problems) > 0 {
+			return records, fmt.Errorf("%s", strings.Join(problems, "; "))
+		}
+		return model.AgentProposalRecords{ReportCard: &edited}, nil
+	default:
+		return records, fmt.Errorf("proposal has no record")
+	}
+}
+
+// editRecord decodes a copy of original with edits applied into edited. The copy is made through JSON
+// so that decoding the edits cannot write through pointers and slices shared with original.
+func editRecord(original interface{}, edits json.RawMessage, edited interface{}) error {
+	data, err := json.Marshal(original)
+	if err != nil {
+		return err
+	}
+	if err := json.Unmarshal(data, edited); err != nil {
+		return err
+	}
+	return decodeStrict(edits, edited)
+}
+
+// commitAgentProposal writes the record of a proposal and returns its ID and response data
+func commitAgentProposal(records model.AgentProposalRecords) (string, map[string]interface{}, error) {
+	switch {
+	case records.Assignment != nil:
+		data, err := saveGeneratedAssignment(records)
+		return records.Assignment.ID, data, err
+	case records.SubjectReport != nil:
+		data, err := saveAssessment(records.SubjectReport)
+		return records.SubjectReport.ID, data, err
+	case records.AssignmentResult != nil:
+		data, err := saveAssignmentResult(records.AssignmentResult)
+		return records.AssignmentResult.ID.Hex(), data, err
+	case records.ReportCard != nil:
+		data, err := saveReportCard(records.ReportCard)
+		return records.ReportCard.ID.Hex(), data, err
+	default:
+		return "", nil, fmt.Errorf("proposal has no record")
+	}
+}
+
+// proposalRecord returns the record a proposal writes, or nil
+func proposalRecord(records model.AgentProposalRecords) interface{} {
+	switch {
+	case records.Assignment != nil:
+		return records.Assignment
+	case records.SubjectReport != nil:
+		return records.SubjectReport
+	case records.AssignmentResult != nil:
+		return records.AssignmentResult
+	case records.ReportCard != nil:
+		return records.ReportCard
+	default:
+		return nil
+	}
+}
+
+// previewAgentProposal previews the creation of the records of a proposal. Generated questions an
+// assignment uses are listed after its fields.
+func previewAgentProposal(records model.AgentProposalRecords) ([]model.AgentProposalChange, error) {
+	preview, err := previewAgentRecord(nil, proposalRecord(records))
+	if err != nil {
+		return nil, err
+	}
+	if records.Assignment == nil {
+		return preview, nil
+	}
+	for _, mcq := range records.MCQs {
+		if slices.Contains(records.Assignment.MCQIds, mcq.ID) {
+			preview = append(preview, model.AgentProposalChange{Field: "generatedMcq." + mcq.ID, After: mcq.Question})
+		}
+	}
+	for _, msq := range records.MSQs {
+		if slices.Contains(records.Assignment.MSQIds, msq.ID) {
+			preview = append(preview, model.AgentProposalChange{Field: "generatedMsq." + msq.ID, After: msq.Question})
+		}
+	}
+	return preview, nil
+}
+
+// previewAgentRecord lists the fields that differ between two versions of a record, by field name.
+// A nil before previews the creation of after.
+func previewAgentRecord(before, after interface{}) ([]model.AgentProposalChange, error) {
+	beforeFields, err := recordFields(before)
+	if err != nil {
+		return nil, err
+	}
+	afterFields, err := recordFields(after)
+	if err != nil {
+		return nil, err
+	}
+
+	names := make([]string, 0, len(afterFields))
+	for name := range afterFields {
+		names = append(names, name)
+	}
+	for name := range beforeFields {
+		if _, ok := afterFields[name]; !ok {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+
+	changes := []model.AgentProposalChange{}
+	for _, name := range names {
+		if beforeFields[name] != afterFields[name] {
+			changes = append(changes, model.AgentProposalChange{Field: name, Before: beforeFields[name], After: afterFields[name]})
+		}
+	}
+	return changes, nil
+}
+
+// recordFields renders the top-level fields of a record's JSON form as text, omitting empty ones
+func recordFields(record interface{}) (map[string]string, error) {
+	fields := map[string]string{}
+	if record == nil {
+		return fields, nil
+	}
+
+	data, err := json.Marshal(record)
+	if err != nil {
+		return nil, fmt.Errorf("failed to render agent proposal record: %w", err)
+	}
+	var values map[string]json.RawMessage
+	if err := json.Unmarshal(data, &values); err != nil {
+		return nil, fmt.Errorf("failed to render agent proposal record: %w", err)
+	}
+
+	for name, value := range values {
+		var text string
+		if err := json.Unmarshal(value, &text); err != nil {
+			text = string(value)
+		}
+		if text != "" && text != "null" {
+			fields[name] = text
+		}
+	}
+	return fields, nil
+}
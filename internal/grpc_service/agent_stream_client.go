@@ -0,0 +1,152 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+
+	pb "lumenslate/internal/proto/ai_service"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// Frame kinds carried in the message field of streamed agent responses
+const (
+	AgentStreamToken = "token"
+	AgentStreamEvent = "event"
+	AgentStreamFinal = "final"
+)
+
+// AgentStreamUpdate is an incremental update relayed to the client while an agent runs
+type AgentStreamUpdate struct {
+	Kind string          `json:"kind"`
+	Text string          `json:"text,omitempty"`
+	Data json.RawMessage `json:"data,omitempty"`
+}
+
+// agentFrame is a streamed agent response message
+type agentFrame interface {
+	GetMessage() string
+	GetAgentResponse() string
+}
+
+// agentStream receives the frames of a streaming agent RPC
+type agentStream[T agentFrame] interface {
+	Recv() (T, error)
+}
+
+// LumenAgentStream runs the agent over the streaming RPC, passing tokens and progress events to onUpdate.
+// The final response is processed and persisted exactly like a LumenAgent reply. When the AI service
+// does not implement streaming yet, the unary RPC is used and only the final response is produced.
+// Once onUpdate fails, e.g. because the client has gone, updates are dropped but the agent still runs
+// to completion so that its reply is not lost.
+func LumenAgentStream(ctx context.Context, file, fileType, teacherId, role, message, createdAt, updatedAt, sessionId string, onUpdate func(AgentStreamUpdate) error) (map[string]interface{}, error) {
+	req := &pb.AgentRequest{
+		File:      file,
+		FileType:  fileType,
+		TeacherId: teacherId,
+		Role:      role,
+		Message:   message,
+		CreatedAt: createdAt,
+		UpdatedAt: updatedAt,
+	}
+
+	res, err := streamAgent(withAgentSession(ctx, sessionId), pb.AIService_LumenAgentStream_FullMethodName, func(ctx context.Context, client pb.AIServiceClient) (agentStream[*pb.AgentResponse], error) {
+		return client.LumenAgentStream(ctx, req)
+	}, onUpdate)
+	if status.Code(err) == codes.Unimplemented {
+		log.Printf("[gRPC] LumenAgentStream not implemented by AI service, falling back to LumenAgent")
+		return LumenAgent(ctx, file, fileType, teacherId, role, message, createdAt, updatedAt, sessionId)
+	}
+	if err != nil {
+		if _, ok := status.FromError(err); ok {
+			log.Printf("ERROR: gRPC Agent stream failed: %v", err)
+			return createErrorResponse(teacherId, err.Error(), res), nil
+		}
+		return nil, err
+	}
+
+	return processAgentResponse(teacherId, res), nil
+}
+
+// RAGAgentStream runs the RAG agent over the streaming RPC, passing tokens and progress events to onUpdate,
+// and returns the final response. It falls back to the unary RPC like LumenAgentStream.
+func RAGAgentStream(ctx context.Context, corpusName string, message string, onUpdate func(AgentStreamUpdate) error) (*pb.RAGAgentResponse, error) {
+	req := &pb.RAGAgentRequest{
+		CorpusName: corpusName,
+		Message:    message,
+	}
+
+	res, err := streamAgent(ctx, pb.AIService_RAGAgentStream_FullMethodName, func(ctx context.Context, client pb.AIServiceClient) (agentStream[*pb.RAGAgentResponse], error) {
+		return client.RAGAgentStream(ctx, req)
+	}, onUpdate)
+	if status.Code(err) == codes.Unimplemented {
+		log.Printf("[gRPC] RAGAgentStream not implemented by AI service, falling back to RAGAgent")
+		return RAGAgentClient(ctx, corpusName, message)
+	}
+	if err != nil {
+		log.Printf("ERROR: RAG agent stream failed: %v", err)
+		return nil, fmt.Errorf("failed to stream RAG agent: %w", err)
+	}
+
+	return res, nil
+}
+
+// streamAgent opens the server stream of method and relays token and event frames to onUpdate until the
+// final frame, which it returns. After onUpdate fails, such as for a disconnected client, the remaining
+// updates are dropped while waiting for the final frame.
+func streamAgent[T agentFrame](parent context.Context, method string, open func(context.Context, pb.AIServiceClient) (agentStream[T], error), onUpdate func(AgentStreamUpdate) error) (T, error) {
+	var final T
+
+	client, ctx, cancel, err := newCall(parent, method)
+	if err != nil {
+		return final, err
+	}
+	defer cancel()
+
+	stream, err := open(ctx, client)
+	if err != nil {
+		return final, err
+	}
+
+	relaying := true
+	for {
+		frame, err := stream.Recv()
+		if err != nil {
+			if errors.Is(err, io.EOF) {
+				return final, status.Errorf(codes.Internal, "%s ended without a final response", method)
+			}
+			return final, err
+		}
+
+		var update AgentStreamUpdate
+		switch frame.GetMessage() {
+		case AgentStreamFinal:
+			return frame, nil
+		case AgentStreamToken:
+			update = AgentStreamUpdate{Kind: AgentStreamToken, Text: frame.GetAgentResponse()}
+		case AgentStreamEvent:
+			data := json.RawMessage(frame.GetAgentResponse())
+			if !json.Valid(data) {
+				log.Printf("[gRPC] Dropping malformed %s event: %q", method, frame.GetAgentResponse())
+				continue
+			}
+			update = AgentStreamUpdate{Kind: AgentStreamEvent, Data: data}
+		default:
+			log.Printf("[gRPC] Ignoring unknown %s frame kind %q", method, frame.GetMessage())
+			continue
+		}
+
+		if !relaying {
+			continue
+		}
+		if err := onUpdate(update); err != nil {
+			log.Printf("[gRPC] Stopped relaying %s updates, waiting for the final response: %v", method, err)
+			relaying = false
+		}
+	}
+}
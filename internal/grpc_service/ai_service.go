@@ -0,0 +1,114 @@
+package service
+
+import (
+	"context"
+
+	pb "lumenslate/internal/proto/ai_service"
+)
+
+// AIService is the set of AI microservice operations the HTTP handlers depend on.
+// Handlers take it as a dependency so that another implementation can be injected in place
+// of the gRPC client, e.g. one backed by the in-process fake in internal/grpc_service/aifake.
+type AIService interface {
+	GenerateContext(question string, keywords []string, language string) (string, error)
+	DetectVariables(question string) ([]*pb.DetectedVariable, error)
+	SegmentQuestion(question string) (string, error)
+	GenerateMCQVariations(question string, options []string, answerIndex int32) ([]*pb.MCQQuestion, error)
+	GenerateMSQVariations(question string, options []string, answerIndices []int32) ([]*pb.MSQQuestion, error)
+	FilterAndRandomize(question string, userPrompt string) ([]*pb.RandomizedVariable, error)
+	LumenAgent(file, fileType, teacherId, role, message, createdAt, updatedAt, sessionId string) (map[string]interface{}, error)
+	RAGAgentClient(corpusName string, message string) (*pb.RAGAgentResponse, error)
+	LumenAgentStream(ctx context.Context, file, fileType, teacherId, role, message, createdAt, updatedAt, sessionId string, onUpdate func(AgentStreamUpdate) error) (map[string]interface{}, error)
+	RAGAgentStream(ctx context.Context, corpusName string, message string, onUpdate func(AgentStreamUpdate) error) (*pb.RAGAgentResponse, error)
+}
+
+// grpcAIService implements AIService over the shared client manager
+type grpcAIService struct {
+	ctx         context.Context // Parent of unary calls, see WithContext
+	bypassCache bool            // Ignore cached responses, see BypassCache
+}
+
+// NewAIService returns the AIService backed by the shared gRPC client.
+// Calls go to whichever client manager is installed, see SetClientManager, and
+// GenerateContext, DetectVariables and SegmentQuestion use the cache installed with SetResponseCache.
+func NewAIService() AIService {
+	return grpcAIService{}
+}
+
+// BypassCache returns s with cached responses ignored; fresh responses still refresh the cache.
+// Implementations without a cache are returned unchanged.
+func BypassCache(s AIService) AIService {
+	if cached, ok := s.(grpcAIService); ok {
+		cached.bypassCache = true
+		return cached
+	}
+	return s
+}
+
+// WithContext returns s making its unary calls on behalf of ctx, so that they join the trace of ctx.
+// Only values carry over: calls keep their own deadlines and complete even if ctx is canceled, because
+// a cached response may be shared with other callers. Implementations without a context are returned unchanged.
+func WithContext(s AIService, ctx context.Context) AIService {
+	if traced, ok := s.(grpcAIService); ok {
+		traced.ctx = context.WithoutCancel(ctx)
+		return traced
+	}
+	return s
+}
+
+// parent returns the context unary calls derive from
+func (s grpcAIService) parent() context.Context {
+	if s.ctx == nil {
+		return context.Background()
+	}
+	return s.ctx
+}
+
+func (s grpcAIService) GenerateContext(question string, keywords []string, language string) (string, error) {
+	request := newContextCacheRequest(question, keywords, language)
+	return cachedCall(getResponseCache(), "GenerateContext", request, s.bypassCache, func() (string, error) {
+		return GenerateContext(s.parent(), question, keywords, language)
+	})
+}
+
+func (s grpcAIService) DetectVariables(question string) ([]*pb.DetectedVariable, error) {
+	request := variablesCacheRequest{Question: question}
+	return cachedCall(getResponseCache(), "DetectVariables", request, s.bypassCache, func() ([]*pb.DetectedVariable, error) {
+		return DetectVariables(s.parent(), question)
+	})
+}
+
+func (s grpcAIService) SegmentQuestion(question string) (string, error) {
+	request := newSegmentCacheRequest(question)
+	return cachedCall(getResponseCache(), "SegmentQuestion", request, s.bypassCache, func() (string, error) {
+		return SegmentQuestion(s.parent(), question)
+	})
+}
+
+func (s grpcAIService) GenerateMCQVariations(question string, options []string, answerIndex int32) ([]*pb.MCQQuestion, error) {
+	return GenerateMCQVariations(s.parent(), question, options, answerIndex)
+}
+
+func (s grpcAIService) GenerateMSQVariations(question string, options []string, answerIndices []int32) ([]*pb.MSQQuestion, error) {
+	return GenerateMSQVariations(s.parent(), question, options, answerIndices)
+}
+
+func (s grpcAIService) FilterAndRandomize(question string, userPrompt string) ([]*pb.RandomizedVariable, error) {
+	return FilterAndRandomize(s.parent(), question, userPrompt)
+}
+
+func (s grpcAIService) LumenAgent(file, fileType, teacherId, role, message, createdAt, updatedAt, sessionId string) (map[string]interface{}, error) {
+	return LumenAgent(s.parent(), file, fileType, teacherId, role, message, createdAt, updatedAt, sessionId)
+}
+
+func (s grpcAIService) RAGAgentClient(corpusName string, message string) (*pb.RAGAgentResponse, error) {
+	return RAGAgentClient(s.parent(), corpusName, message)
+}
+
+func (grpcAIService) LumenAgentStream(ctx context.Context, file, fileType, teacherId, role, message, createdAt, updatedAt, sessionId string, onUpdate func(AgentStreamUpdate) error) (map[string]interface{}, error) {
+	return LumenAgentStream(ctx, file, fileType, teacherId, role, message, createdAt, updatedAt, sessionId, onUpdate)
+}
+
+func (grpcAIService) RAGAgentStream(ctx context.Context, corpusName string, message string, onUpdate func(AgentStreamUpdate) error) (*pb.RAGAgentResponse, error) {
+	return RAGAgentStream(ctx, corpusName, message, onUpdate)
+}
@@ -0,0 +1,84 @@
+package aifake
+
+import (
+	grpcservice "lumenslate/internal/grpc_service"
+	pb "lumenslate/internal/proto/ai_service"
+
+	"google.golang.org/protobuf/proto"
+)
+
+// defaultResponses are the canned responses served until a test or recording replaces them.
+// They are shaped like real microservice output so that handlers take their normal paths.
+func defaultResponses() map[string]proto.Message {
+	return map[string]proto.Message{
+		"GenerateContext": &pb.GenerateContextResponse{
+			Content: "A train leaves the station at 9 AM travelling at a constant speed.",
+		},
+		"DetectVariables": &pb.VariableDetectorResponse{
+			Variables: []*pb.DetectedVariable{
+				{Name: "speed", Value: "60", NamePositions: []int32{0, 5}, ValuePositions: []int32{10, 12}},
+			},
+		},
+		"SegmentQuestion": &pb.QuestionSegmentationResponse{
+			SegmentedQuestion: "How far does the train travel in 2 hours?",
+		},
+		"GenerateMCQVariations": &pb.MCQVariation{
+			Variations: []*pb.MCQQuestion{
+				{Question: "What is 2 + 3?", Options: []string{"4", "5", "6", "7"}, AnswerIndex: 1},
+			},
+		},
+		"GenerateMSQVariations": &pb.MSQVariation{
+			Variations: []*pb.MSQQuestion{
+				{Question: "Which of these are prime?", Options: []string{"2", "3", "4", "6"}, AnswerIndices: []int32{0, 1}},
+			},
+		},
+		"FilterAndRandomize": &pb.FilterAndRandomizerResponse{
+			Variables: []*pb.RandomizedVariable{
+				{Name: "speed", Value: "75", Filters: &pb.VariableFilter{Range: []int32{50, 100}}},
+			},
+		},
+		"LumenAgent": &pb.AgentResponse{
+			Message:       "success",
+			AgentName:     "root_agent",
+			SchemaVersion: grpcservice.AgentPayloadSchemaVersion,
+			Payload: &pb.AgentResponse_Text{Text: &pb.AgentTextPayload{
+				Text: "Hello! How can I help with your classroom today?",
+			}},
+			SessionId:    "fake-session",
+			ResponseTime: "0.01",
+			Role:         "agent",
+		},
+		"RAGAgent": &pb.RAGAgentResponse{
+			Message:       "success",
+			AgentName:     "rag_agent",
+			AgentResponse: "According to the uploaded documents, the answer is 42.",
+			SessionId:     "fake-rag-session",
+			ResponseTime:  "0.01",
+			Role:          "agent",
+		},
+	}
+}
+
+// newResponse returns an empty response message of the type method produces, or nil for an unknown method
+func newResponse(method string) proto.Message {
+	switch method {
+	case "GenerateContext":
+		return &pb.GenerateContextResponse{}
+	case "DetectVariables":
+		return &pb.VariableDetectorResponse{}
+	case "SegmentQuestion":
+		return &pb.QuestionSegmentationResponse{}
+	case "GenerateMCQVariations":
+		return &pb.MCQVariation{}
+	case "GenerateMSQVariations":
+		return &pb.MSQVariation{}
+	case "FilterAndRandomize":
+		return &pb.FilterAndRandomizerResponse{}
+	case "LumenAgent":
+		return &pb.AgentResponse{}
+	case "RAGAgent":
+		return &pb.RAGAgentResponse{}
+	default:
+		return nil
+	}
+}
@@ -0,0 +1,273 @@
+// Package aifake provides a scriptable in-process implementation of the AI microservice.
+//
+// The fake serves pb.AIServiceServer over a bufconn listener, so callers exercise the real
+// gRPC client stack (deadlines, interceptors, circuit breaker) without a network or the
+// Python service. Each RPC returns a canned response that can be replaced per method, loaded
+// from a recording file, or computed by a handler; every request is recorded for inspection.
+package aifake
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	grpcservice "lumenslate/internal/grpc_service"
+	pb "lumenslate/internal/proto/ai_service"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+)
+
+const bufferSize = 1024 * 1024
+
+// Handler computes the response to a request for a single method.
+// The request and response are the generated message types of that method.
+type Handler func(ctx context.Context, req proto.Message) (proto.Message, error)
+
+// Call is a request received by the fake
+type Call struct {
+	Method  string
+	Request proto.Message
+	At      time.Time
+}
+
+// Server is the in-process AI service. The zero value is not usable; use NewServer.
+type Server struct {
+	pb.UnimplementedAIServiceServer
+
+	mu        sync.Mutex
+	responses map[string]proto.Message
+	errors    map[string]error
+	handlers  map[string]Handler
+	delays    map[string]time.Duration
+	calls     []Call
+
+	listener *bufconn.Listener
+	server   *grpc.Server
+}
+
+// NewServer returns a fake answering every RPC with the default canned responses
+func NewServer() *Server {
+	s := &Server{}
+	s.Reset()
+	return s
+}
+
+// Reset restores the default canned responses and clears errors, handlers and recorded calls
+func (s *Server) Reset() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.responses = defaultResponses()
+	s.errors = make(map[string]error)
+	s.handlers = make(map[string]Handler)
+	s.delays = make(map[string]time.Duration)
+	s.calls = nil
+}
+
+// SetResponse sets the canned response for method, given by name ("GenerateContext")
+// or full method name (pb.AIService_GenerateContext_FullMethodName)
+func (s *Server) SetResponse(method string, response proto.Message) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.responses[methodName(method)] = response
+}
+
+// SetError makes method fail with err, which should be a gRPC status error
+func (s *Server) SetError(method string, err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.errors[methodName(method)] = err
+}
+
+// SetHandler makes method answer with the result of handler, taking precedence over canned responses
+func (s *Server) SetHandler(method string, handler Handler) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.handlers[methodName(method)] = handler
+}
+
+// SetDelay makes method wait before answering, e.g. to trigger client deadlines
+func (s *Server) SetDelay(method string, delay time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.delays[methodName(method)] = delay
+}
+
+// LoadRecordings reads canned responses from a JSON file mapping method names to
+// responses in protobuf JSON form, e.g. {"SegmentQuestion": {"segmentedQuestion": "..."}}
+func (s *Server) LoadRecordings(path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("failed to read recordings %s: %w", path, err)
+	}
+
+	var recordings map[string]json.RawMessage
+	if err := json.Unmarshal(data, &recordings); err != nil {
+		return fmt.Errorf("failed to parse recordings %s: %w", path, err)
+	}
+
+	for method, raw := range recordings {
+		response := newResponse(methodName(method))
+		if response == nil {
+			return fmt.Errorf("unknown method %q in recordings %s", method, path)
+		}
+		if err := protojson.Unmarshal(raw, response); err != nil {
+			return fmt.Errorf("invalid %s response in recordings %s: %w", method, path, err)
+		}
+		s.SetResponse(method, response)
+	}
+
+	return nil
+}
+
+// Calls returns the requests received for method, or for every method when method is empty
+func (s *Server) Calls(method string) []Call {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	name := methodName(method)
+	calls := make([]Call, 0, len(s.calls))
+	for _, call := range s.calls {
+		if name == "" || call.Method == name {
+			calls = append(calls, call)
+		}
+	}
+	return calls
+}
+
+// Start serves the fake over an in-memory listener and returns a client manager connected to it.
+// The manager uses config for timeouts, retries and the breaker; its target and credentials are
+// replaced. Stop shuts the server down.
+func (s *Server) Start(config grpcservice.ClientConfig) (*grpcservice.ClientManager, error) {
+	s.listener = bufconn.Listen(bufferSize)
+	s.server = grpc.NewServer()
+
+	pb.RegisterAIServiceServer(s.server, s)
+	healthpb.RegisterHealthServer(s.server, health.NewServer())
+
+	go func() {
+		if err := s.server.Serve(s.listener); err != nil {
+			log.Printf("[AI fake] Server stopped: %v", err)
+		}
+	}()
+
+	config.Target = "passthrough:///bufconn"
+	config.Insecure = true
+	config.CAFile = ""
+	config.Dialer = func(ctx context.Context, _ string) (net.Conn, error) {
+		return s.listener.DialContext(ctx)
+	}
+
+	manager, err := grpcservice.NewClientManager(config)
+	if err != nil {
+		s.Stop()
+		return nil, err
+	}
+
+	log.Printf("[AI fake] In-process AI service started")
+	return manager, nil
+}
+
+// Stop shuts the server down
+func (s *Server) Stop() {
+	if s.server != nil {
+		s.server.Stop()
+	}
+}
+
+// respond records req and produces the response for method
+func (s *Server) respond(ctx context.Context, method string, req proto.Message) (proto.Message, error) {
+	s.mu.Lock()
+	s.calls = append(s.calls, Call{Method: method, Request: proto.Clone(req), At: time.Now()})
+	handler := s.handlers[method]
+	err := s.errors[method]
+	response := s.responses[method]
+	delay := s.delays[method]
+	s.mu.Unlock()
+
+	if delay > 0 {
+		select {
+		case <-time.After(delay):
+		case <-ctx.Done():
+			return nil, status.FromContextError(ctx.Err()).Err()
+		}
+	}
+
+	if handler != nil {
+		return handler(ctx, req)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if response == nil {
+		return nil, status.Errorf(codes.Unimplemented, "no response configured for %s", method)
+	}
+	return proto.Clone(response), nil
+}
+
+func (s *Server) GenerateContext(ctx context.Context, req *pb.GenerateContextRequest) (*pb.GenerateContextResponse, error) {
+	return respondAs[*pb.GenerateContextResponse](s, ctx, "GenerateContext", req)
+}
+
+func (s *Server) DetectVariables(ctx context.Context, req *pb.VariableDetectorRequest) (*pb.VariableDetectorResponse, error) {
+	return respondAs[*pb.VariableDetectorResponse](s, ctx, "DetectVariables", req)
+}
+
+func (s *Server) SegmentQuestion(ctx context.Context, req *pb.QuestionSegmentationRequest) (*pb.QuestionSegmentationResponse, error) {
+	return respondAs[*pb.QuestionSegmentationResponse](s, ctx, "SegmentQuestion", req)
+}
+
+func (s *Server) GenerateMCQVariations(ctx context.Context, req *pb.MCQRequest) (*pb.MCQVariation, error) {
+	return respondAs[*pb.MCQVariation](s, ctx, "GenerateMCQVariations", req)
+}
+
+func (s *Server) GenerateMSQVariations(ctx context.Context, req *pb.MSQRequest) (*pb.MSQVariation, error) {
+	return respondAs[*pb.MSQVariation](s, ctx, "GenerateMSQVariations", req)
+}
+
+func (s *Server) FilterAndRandomize(ctx context.Context, req *pb.FilterAndRandomizerRequest) (*pb.FilterAndRandomizerResponse, error) {
+	return respondAs[*pb.FilterAndRandomizerResponse](s, ctx, "FilterAndRandomize", req)
+}
+
+func (s *Server) LumenAgent(ctx context.Context, req *pb.AgentRequest) (*pb.AgentResponse, error) {
+	return respondAs[*pb.AgentResponse](s, ctx, "LumenAgent", req)
+}
+
+func (s *Server) RAGAgent(ctx context.Context, req *pb.RAGAgentRequest) (*pb.RAGAgentResponse, error) {
+	return respondAs[*pb.RAGAgentResponse](s, ctx, "RAGAgent", req)
+}
+
+// respondAs calls respond and checks that the handler or canned response has the method's response type
+func respondAs[T proto.Message](s *Server, ctx context.Context, method string, req proto.Message) (T, error) {
+	var zero T
+	response, err := s.respond(ctx, method, req)
+	if err != nil {
+		return zero, err
+	}
+	typed, ok := response.(T)
+	if !ok {
+		return zero, status.Errorf(codes.Internal, "configured %s response has type %T, want %T", method, response, zero)
+	}
+	return typed, nil
+}
+
+// methodName strips the service prefix from a full method name
+func methodName(method string) string {
+	if i := strings.LastIndex(method, "/"); i >= 0 {
+		return method[i+1:]
+	}
+	return method
+}
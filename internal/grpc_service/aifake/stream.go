@@ -0,0 +1,69 @@
+package aifake
+
+import (
+	"strings"
+
+	grpcservice "lumenslate/internal/grpc_service"
+	pb "lumenslate/internal/proto/ai_service"
+
+	"google.golang.org/grpc"
+	"google.golang.org/protobuf/proto"
+)
+
+// Streaming calls are recorded and answered as their unary method: the configured LumenAgent or
+// RAGAgent response is sent as the final frame, preceded by a progress event and its text as tokens.
+
+func (s *Server) LumenAgentStream(req *pb.AgentRequest, stream grpc.ServerStreamingServer[pb.AgentResponse]) error {
+	response, err := respondAs[*pb.AgentResponse](s, stream.Context(), "LumenAgent", req)
+	if err != nil {
+		return err
+	}
+
+	// Stream the reply text of text payloads; structured payloads only arrive with the final frame
+	var text string
+	if payload, err := grpcservice.DecodeAgentResponse(response); err == nil && payload.Text != nil {
+		text = payload.Text.Text
+	}
+
+	return sendFrames(stream, text, func(kind, content string) *pb.AgentResponse {
+		return &pb.AgentResponse{Message: kind, AgentResponse: content}
+	}, func() *pb.AgentResponse {
+		final := proto.Clone(response).(*pb.AgentResponse)
+		final.Message = grpcservice.AgentStreamFinal
+		return final
+	})
+}
+
+func (s *Server) RAGAgentStream(req *pb.RAGAgentRequest, stream grpc.ServerStreamingServer[pb.RAGAgentResponse]) error {
+	response, err := respondAs[*pb.RAGAgentResponse](s, stream.Context(), "RAGAgent", req)
+	if err != nil {
+		return err
+	}
+
+	return sendFrames(stream, response.GetAgentResponse(), func(kind, content string) *pb.RAGAgentResponse {
+		return &pb.RAGAgentResponse{Message: kind, AgentResponse: content}
+	}, func() *pb.RAGAgentResponse {
+		final := proto.Clone(response).(*pb.RAGAgentResponse)
+		final.Message = grpcservice.AgentStreamFinal
+		return final
+	})
+}
+
+// sendFrames sends a progress event, text word by word as tokens, then the final frame
+func sendFrames[T any](stream grpc.ServerStreamingServer[T], text string, frame func(kind, content string) *T, final func() *T) error {
+	if err := stream.Send(frame(grpcservice.AgentStreamEvent, `{"stage":"generating"}`)); err != nil {
+		return err
+	}
+
+	words := strings.SplitAfter(text, " ")
+	for _, word := range words {
+		if word == "" {
+			continue
+		}
+		if err := stream.Send(frame(grpcservice.AgentStreamToken, word)); err != nil {
+			return err
+		}
+	}
+
+	return stream.Send(final())
+}
@@ -0,0 +1,531 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"math/rand"
+	"slices"
+	"strings"
+	"time"
+
+	"lumenslate/internal/model"
+	"lumenslate/internal/model/questions"
+	"lumenslate/internal/repository"
+	quest "lumenslate/internal/repository/questions"
+
+	"github.com/google/uuid"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// defaultAvoidRecentDays is how far back questions assigned to a classroom are avoided when the agent does not say
+const defaultAvoidRecentDays = 30
+
+// questionTypes lists the question types in the order they are selected and reported
+var questionTypes = []string{QuestionTypeMCQ, QuestionTypeMSQ, QuestionTypeNAT, QuestionTypeSubjective}
+
+// assignmentPlan is an assignment built from an agent question request, not yet saved
+type assignmentPlan struct {
+	assignment *model.Assignment
+	mcqs       []questions.MCQ // AI-generated questions the assignment uses, created together with it
+	msqs       []questions.MSQ
+	shortfalls []model.AgentQuestionShortfall
+}
+
+// candidate is a question that can be selected for an assignment
+type candidate struct {
+	id           string
+	questionType string
+	points       int
+}
+
+// planQuestionGeneration selects questions from the MCQ, MSQ, NAT and subjective collections for each
+// request and builds the assignment without saving it. Requests that cannot be fully met are reported
+// as shortfalls; when asked to, MCQ and MSQ gaps are filled with AI-generated variations.
+func planQuestionGeneration(payload *QuestionRequestPayload) (*assignmentPlan, error) {
+	assignmentTitle := strings.TrimSpace(payload.Title)
+	assignmentBody := strings.TrimSpace(payload.Body)
+
+	// Default values if not provided by agent
+	if assignmentTitle == "" {
+		assignmentTitle = "Generated Assignment"
+	}
+	if assignmentBody == "" {
+		assignmentBody = "Assignment generated from agent request"
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 60*time.Second)
+	defer cancel()
+
+	exclude, err := recentlyAssignedQuestionIDs(payload)
+	if err != nil {
+		return nil, err
+	}
+
+	assignment := model.NewAssignment()
+	assignment.ID = uuid.New().String()
+	assignment.Title = assignmentTitle
+	assignment.Body = assignmentBody
+	assignment.DueDate = time.Now().AddDate(0, 0, 7) // Default due date 7 days from now
+	plan := &assignmentPlan{assignment: assignment}
+
+	rng := rand.New(rand.NewSource(time.Now().UnixNano()))
+	for _, request := range payload.QuestionsRequested {
+		picked, shortfalls, err := selectQuestions(ctx, rng, request, exclude)
+		if err != nil {
+			return nil, err
+		}
+		for _, q := range picked {
+			plan.add(q.id, q.questionType, q.points)
+			exclude = append(exclude, q.id) // Never select a question twice for the same assignment
+		}
+
+		if payload.FillWithVariations {
+			for i := range shortfalls {
+				fillWithVariations(ctx, rng, request, &shortfalls[i], plan)
+			}
+		}
+		plan.shortfalls = append(plan.shortfalls, shortfalls...)
+	}
+
+	if plan.questionCount() == 0 {
+		reasons := make([]string, len(plan.shortfalls))
+		for i, shortfall := range plan.shortfalls {
+			reasons[i] = shortfall.Reason
+		}
+		return nil, fmt.Errorf("no questions could be selected for the assignment: %s", strings.Join(reasons, "; "))
+	}
+
+	return plan, nil
+}
+
+// add puts a question into the assignment
+func (p *assignmentPlan) add(id, questionType string, points int) {
+	switch questionType {
+	case QuestionTypeMCQ:
+		p.assignment.MCQIds = append(p.assignment.MCQIds, id)
+	case QuestionTypeMSQ:
+		p.assignment.MSQIds = append(p.assignment.MSQIds, id)
+	case QuestionTypeNAT:
+		p.assignment.NATIds = append(p.assignment.NATIds, id)
+	case QuestionTypeSubjective:
+		p.assignment.SubjectiveIds = append(p.assignment.SubjectiveIds, id)
+	}
+	p.assignment.Points += points
+}
+
+// questionCount is the number of questions in the assignment
+func (p *assignmentPlan) questionCount() int {
+	a := p.assignment
+	return len(a.MCQIds) + len(a.MSQIds) + len(a.NATIds) + len(a.SubjectiveIds)
+}
+
+// selectQuestions picks the questions for one request, honouring its type mix and point total
+func selectQuestions(ctx context.Context, rng *rand.Rand, request QuestionRequest, exclude []string) ([]candidate, []model.AgentQuestionShortfall, error) {
+	subject, _ := model.GetSubjectFromString(strings.ToLower(strings.TrimSpace(request.Subject)))
+	difficulty := strings.ToLower(strings.TrimSpace(request.Difficulty))
+	shortfall := func(questionType string, requested, selected int, reason string) model.AgentQuestionShortfall {
+		return model.AgentQuestionShortfall{
+			Subject:      string(subject),
+			Difficulty:   difficulty,
+			QuestionType: questionType,
+			Requested:    requested,
+			Selected:     selected,
+			Reason:       reason,
+		}
+	}
+
+	filter, err := candidateFilter(request, subject, difficulty, exclude)
+	if err != nil {
+		return nil, nil, err
+	}
+	if filter == nil {
+		reason := fmt.Sprintf("no question bank is tagged %s", strings.Join(request.Tags, ", "))
+		return nil, []model.AgentQuestionShortfall{shortfall("", request.QuestionCount(), 0, reason)}, nil
+	}
+
+	types := questionTypes
+	if len(request.QuestionTypes) > 0 {
+		types = nil
+		for _, questionType := range questionTypes {
+			if request.QuestionTypes[questionType] > 0 {
+				types = append(types, questionType)
+			}
+		}
+	}
+	pools, err := loadCandidates(ctx, *filter, types)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var picked, spare []candidate
+	var shortfalls []model.AgentQuestionShortfall
+	take := func(questionType string, pool []candidate, n int) {
+		rng.Shuffle(len(pool), func(i, j int) { pool[i], pool[j] = pool[j], pool[i] })
+		count := min(n, len(pool))
+		picked = append(picked, pool[:count]...)
+		spare = append(spare, pool[count:]...)
+		if count < n {
+			kind := "matching"
+			if questionType != "" {
+				kind = "matching " + strings.ToUpper(questionType)
+			}
+			shortfalls = append(shortfalls, shortfall(questionType, n, count,
+				fmt.Sprintf("only %d %s %s questions are available for %d requested", count, kind, describeRequest(subject, difficulty), n)))
+		}
+	}
+
+	if len(request.QuestionTypes) > 0 {
+		for _, questionType := range types {
+			take(questionType, pools[questionType], request.QuestionTypes[questionType])
+		}
+	} else {
+		var pool []candidate
+		for _, questionType := range types {
+			pool = append(pool, pools[questionType]...)
+		}
+		take("", pool, request.NumberOfQuestions)
+	}
+
+	if request.TotalPoints > 0 && len(picked) > 0 {
+		picked = balancePoints(picked, spare, request.TotalPoints, len(request.QuestionTypes) > 0)
+		if total := sumPoints(picked); total != request.TotalPoints {
+			pointsShortfall := shortfall("", request.QuestionCount(), len(picked),
+				fmt.Sprintf("no combination of %s questions adds up to %d points, closest is %d", describeRequest(subject, difficulty), request.TotalPoints, total))
+			pointsShortfall.RequestedPoints = request.TotalPoints
+			pointsShortfall.SelectedPoints = total
+			shortfalls = append(shortfalls, pointsShortfall)
+		}
+	}
+
+	return picked, shortfalls, nil
+}
+
+// candidateFilter builds the query for a request. It returns nil when the request is limited to tags
+// that no question bank carries, so that nothing can match.
+func candidateFilter(request QuestionRequest, subject model.Subject, difficulty string, exclude []string) (*quest.CandidateFilter, error) {
+	bankIDs := request.BankIDs
+	if len(request.Tags) > 0 {
+		tagged, err := repository.GetQuestionBankIDsByTags(request.Tags)
+		if err != nil {
+			return nil, fmt.Errorf("failed to look up question banks by tag: %w", err)
+		}
+		if len(bankIDs) > 0 {
+			tagged = intersect(bankIDs, tagged)
+		}
+		if len(tagged) == 0 {
+			return nil, nil
+		}
+		bankIDs = tagged
+	}
+
+	return &quest.CandidateFilter{
+		BankIDs:    bankIDs,
+		Subjects:   model.SubjectAliases(subject),
+		Difficulty: difficulty,
+		ExcludeIDs: exclude,
+	}, nil
+}
+
+// loadCandidates queries the collections of the given question types
+func loadCandidates(ctx context.Context, filter quest.CandidateFilter, types []string) (map[string][]candidate, error) {
+	pools := make(map[string][]candidate, len(types))
+	for _, questionType := range types {
+		var pool []candidate
+		switch questionType {
+		case QuestionTypeMCQ:
+			found, err := quest.FindMCQCandidates(ctx, filter)
+			if err != nil {
+				return nil, err
+			}
+			for _, q := range found {
+				pool = append(pool, candidate{q.ID, questionType, q.Points})
+			}
+		case QuestionTypeMSQ:
+			found, err := quest.FindMSQCandidates(ctx, filter)
+			if err != nil {
+				return nil, err
+			}
+			for _, q := range found {
+				pool = append(pool, candidate{q.ID, questionType, q.Points})
+			}
+		case QuestionTypeNAT:
+			found, err := quest.FindNATCandidates(ctx, filter)
+			if err != nil {
+				return nil, err
+			}
+			for _, q := range found {
+				pool = append(pool, candidate{q.ID, questionType, q.Points})
+			}
+		case QuestionTypeSubjective:
+			found, err := quest.FindSubjectiveCandidates(ctx, filter)
+			if err != nil {
+				return nil, err
+			}
+			for _, q := range found {
+				pool = append(pool, candidate{q.ID, questionType, q.Points})
+			}
+		}
+		pools[questionType] = pool
+	}
+	return pools, nil
+}
+
+// balancePoints swaps picked questions for spare ones while that brings the total closer to target.
+// With sameType, questions are only swapped for questions of the same type so that the mix is kept.
+func balancePoints(picked, spare []candidate, target int, sameType bool) []candidate {
+	total := sumPoints(picked)
+	for total != target {
+		bestPicked, bestSpare, bestGap := -1, -1, abs(target-total)
+		for i := range picked {
+			for j := range spare {
+				if sameType && spare[j].questionType != picked[i].questionType {
+					continue
+				}
+				if gap := abs(target - (total - picked[i].points + spare[j].points)); gap < bestGap {
+					bestPicked, bestSpare, bestGap = i, j, gap
+				}
+			}
+		}
+		if bestPicked < 0 {
+			break
+		}
+		total += spare[bestSpare].points - picked[bestPicked].points
+		picked[bestPicked], spare[bestSpare] = spare[bestSpare], picked[bestPicked]
+	}
+	return picked
+}
+
+// fillWithVariations closes an MCQ or MSQ shortfall with AI-generated variations of matching questions.
+// Gaps of any type are filled with MCQs. Questions already used are fine as sources, as only their
+// variations are assigned.
+func fillWithVariations(ctx context.Context, rng *rand.Rand, request QuestionRequest, shortfall *model.AgentQuestionShortfall, plan *assignmentPlan) {
+	need := shortfall.Requested - shortfall.Selected
+	if need <= 0 || shortfall.RequestedPoints > 0 {
+		return
+	}
+
+	subject := model.Subject(shortfall.Subject)
+	filter, err := candidateFilter(request, subject, shortfall.Difficulty, nil)
+	if err != nil || filter == nil {
+		return
+	}
+
+	var generated int
+	switch shortfall.QuestionType {
+	case QuestionTypeMCQ, "":
+		sources, err := quest.FindMCQCandidates(ctx, *filter)
+		if err != nil {
+			log.Printf("ERROR: Failed to load MCQs to vary: %v", err)
+			return
+		}
+		rng.Shuffle(len(sources), func(i, j int) { sources[i], sources[j] = sources[j], sources[i] })
+		for _, source := range sources {
+			if generated >= need {
+				break
+			}
+			// The AI call keeps its own deadline instead of the planning one
+			variations, err := GenerateMCQVariations(context.WithoutCancel(ctx), source.Question, source.Options, int32(source.AnswerIndex))
+			if err != nil {
+				log.Printf("ERROR: Failed to generate variations of MCQ %s: %v", source.ID, err)
+				break
+			}
+			for _, variation := range variations {
+				if generated >= need {
+					break
+				}
+				if len(variation.GetOptions()) < 2 || int(variation.GetAnswerIndex()) >= len(variation.GetOptions()) {
+					continue
+				}
+				mcq := questions.NewMCQ()
+				mcq.ID = uuid.New().String()
+				mcq.BankID = source.BankID
+				mcq.Question = variation.GetQuestion()
+				mcq.Options = variation.GetOptions()
+				mcq.AnswerIndex = int(variation.GetAnswerIndex())
+				mcq.Points = source.Points
+				mcq.Difficulty = source.Difficulty
+				mcq.Subject = source.Subject
+				mcq.ParentQuestionID = source.ID
+				mcq.Generator = questions.GeneratorMCQVariations
+				mcq.GenerationPrompt = questions.VariationPrompt(source.Question, source.Options)
+				plan.mcqs = append(plan.mcqs, *mcq)
+				plan.add(mcq.ID, QuestionTypeMCQ, mcq.Points)
+				generated++
+			}
+		}
+	case QuestionTypeMSQ:
+		sources, err := quest.FindMSQCandidates(ctx, *filter)
+		if err != nil {
+			log.Printf("ERROR: Failed to load MSQs to vary: %v", err)
+			return
+		}
+		rng.Shuffle(len(sources), func(i, j int) { sources[i], sources[j] = sources[j], sources[i] })
+		for _, source := range sources {
+			if generated >= need {
+				break
+			}
+			answerIndices := make([]int32, len(source.AnswerIndices))
+			for i, index := range source.AnswerIndices {
+				answerIndices[i] = int32(index)
+			}
+			// The AI call keeps its own deadline instead of the planning one
+			variations, err := GenerateMSQVariations(context.WithoutCancel(ctx), source.Question, source.Options, answerIndices)
+			if err != nil {
+				log.Printf("ERROR: Failed to generate variations of MSQ %s: %v", source.ID, err)
+				break
+			}
+			for _, variation := range variations {
+				if generated >= need {
+					break
+				}
+				msq := questions.NewMSQ()
+				for _, index := range variation.GetAnswerIndices() {
+					if int(index) < len(variation.GetOptions()) {
+						msq.AnswerIndices = append(msq.AnswerIndices, int(index))
+					}
+				}
+				if len(variation.GetOptions()) < 2 || len(msq.AnswerIndices) == 0 {
+					continue
+				}
+				msq.ID = uuid.New().String()
+				msq.BankID = source.BankID
+				msq.Question = variation.GetQuestion()
+				msq.Options = variation.GetOptions()
+				msq.Points = source.Points
+				msq.Difficulty = source.Difficulty
+				msq.Subject = source.Subject
+				msq.ParentQuestionID = source.ID
+				msq.Generator = questions.GeneratorMSQVariations
+				msq.GenerationPrompt = questions.VariationPrompt(source.Question, source.Options)
+				plan.msqs = append(plan.msqs, *msq)
+				plan.add(msq.ID, QuestionTypeMSQ, msq.Points)
+				generated++
+			}
+		}
+	default:
+		return
+	}
+
+	shortfall.Generated = generated
+	if generated > 0 {
+		shortfall.Reason += fmt.Sprintf(", %d filled with AI-generated variations", generated)
+	}
+}
+
+// recentlyAssignedQuestionIDs returns the questions of assignments given to the payload's classroom recently
+func recentlyAssignedQuestionIDs(payload *QuestionRequestPayload) ([]string, error) {
+	classroomID := strings.TrimSpace(payload.ClassroomID)
+	if classroomID == "" {
+		return nil, nil
+	}
+
+	classroom, err := repository.GetClassroomByID(classroomID)
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, fmt.Errorf("classroom %s not found", classroomID)
+		}
+		return nil, fmt.Errorf("failed to load classroom %s: %w", classroomID, err)
+	}
+
+	days := payload.AvoidRecentDays
+	if days == 0 {
+		days = defaultAvoidRecentDays
+	}
+	assignments, err := repository.GetRecentAssignmentsByIDs(classroom.AssignmentIDs, time.Now().AddDate(0, 0, -days))
+	if err != nil {
+		return nil, fmt.Errorf("failed to load recent assignments of classroom %s: %w", classroomID, err)
+	}
+
+	var used []string
+	for _, assignment := range assignments {
+		used = append(used, assignment.MCQIds...)
+		used = append(used, assignment.MSQIds...)
+		used = append(used, assignment.NATIds...)
+		used = append(used, assignment.SubjectiveIds...)
+	}
+	return used, nil
+}
+
+// saveGeneratedAssignment creates the AI-generated questions the assignment still uses and then the assignment.
+// The writes are upserts by ID, so an approval retried after a partial save completes it instead of
+// failing on the records already written.
+func saveGeneratedAssignment(records model.AgentProposalRecords) (map[string]interface{}, error) {
+	assignment := records.Assignment
+
+	var mcqs []questions.MCQ
+	for _, mcq := range records.MCQs {
+		if slices.Contains(assignment.MCQIds, mcq.ID) {
+			mcqs = append(mcqs, mcq)
+		}
+	}
+	if len(mcqs) > 0 {
+		if err := quest.UpsertBulkMCQs(mcqs); err != nil {
+			return nil, fmt.Errorf("failed to save generated MCQs: %v", err)
+		}
+	}
+
+	var msqs []questions.MSQ
+	for _, msq := range records.MSQs {
+		if slices.Contains(assignment.MSQIds, msq.ID) {
+			msqs = append(msqs, msq)
+		}
+	}
+	if len(msqs) > 0 {
+		if err := quest.UpsertBulkMSQs(msqs); err != nil {
+			return nil, fmt.Errorf("failed to save generated MSQs: %v", err)
+		}
+	}
+
+	// Save assignment to database
+	if err := repository.UpsertAssignment(*assignment); err != nil {
+		return nil, fmt.Errorf("failed to save assignment: %v", err)
+	}
+
+	// Prepare response
+	return map[string]interface{}{
+		"assignmentId":    assignment.ID,
+		"title":           assignment.Title,
+		"body":            assignment.Body,
+		"points":          assignment.Points,
+		"mcqCount":        len(assignment.MCQIds),
+		"msqCount":        len(assignment.MSQIds),
+		"natCount":        len(assignment.NATIds),
+		"subjectiveCount": len(assignment.SubjectiveIds),
+		"generatedCount":  len(mcqs) + len(msqs),
+	}, nil
+}
+
+// describeRequest names the subject and difficulty of a request for shortfall messages
+func describeRequest(subject model.Subject, difficulty string) string {
+	if difficulty == "" {
+		return string(subject)
+	}
+	return difficulty + " " + string(subject)
+}
+
+func sumPoints(picked []candidate) int {
+	total := 0
+	for _, q := range picked {
+		total += q.points
+	}
+	return total
+}
+
+func abs(n int) int {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
+
+func intersect(a, b []string) []string {
+	var both []string
+	for _, value := range a {
+		if slices.Contains(b, value) {
+			both = append(both, value)
+		}
+	}
+	return both
+}
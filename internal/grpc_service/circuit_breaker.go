@@ -0,0 +1,177 @@
+package service
+
+import (
+	"context"
+	"log"
+	"sync"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// breakerState is the state of the circuit breaker guarding the AI microservice
+type breakerState int
+
+const (
+	breakerClosed   breakerState = iota // Calls flow normally
+	breakerOpen                         // Calls fail fast until the open timeout elapses
+	breakerHalfOpen                     // A single probe call decides whether to close again
+)
+
+func (s breakerState) String() string {
+	switch s {
+	case breakerOpen:
+		return "open"
+	case breakerHalfOpen:
+		return "half_open"
+	default:
+		return "closed"
+	}
+}
+
+// circuitBreaker fails calls fast after repeated failures so that requests do not pile up
+// waiting for deadlines while the AI microservice is down
+type circuitBreaker struct {
+	mu               sync.Mutex
+	state            breakerState
+	failures         int
+	failureThreshold int
+	openTimeout      time.Duration
+	openedAt         time.Time
+	probeInFlight    bool
+	lastError        string
+	lastFailureAt    time.Time
+}
+
+type breakerSnapshot struct {
+	state         breakerState
+	failures      int
+	lastError     string
+	lastFailureAt time.Time
+}
+
+func newCircuitBreaker(failureThreshold int, openTimeout time.Duration) *circuitBreaker {
+	if failureThreshold <= 0 {
+		failureThreshold = 5
+	}
+	if openTimeout <= 0 {
+		openTimeout = 30 * time.Second
+	}
+	return &circuitBreaker{
+		failureThreshold: failureThreshold,
+		openTimeout:      openTimeout,
+	}
+}
+
+// allow reports whether a call may proceed, moving an expired open breaker to half-open
+func (b *circuitBreaker) allow() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	switch b.state {
+	case breakerOpen:
+		if time.Since(b.openedAt) < b.openTimeout {
+			return false
+		}
+		b.state = breakerHalfOpen
+		b.probeInFlight = true
+		log.Printf("[gRPC] Circuit breaker half-open, probing AI service")
+		return true
+	case breakerHalfOpen:
+		if b.probeInFlight {
+			return false
+		}
+		b.probeInFlight = true
+		return true
+	default:
+		return true
+	}
+}
+
+// record updates the breaker with the outcome of a call
+func (b *circuitBreaker) record(err error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.probeInFlight = false
+
+	if !isBreakerFailure(err) {
+		if b.state != breakerClosed {
+			log.Printf("[gRPC] Circuit breaker closed, AI service recovered")
+		}
+		b.state = breakerClosed
+		b.failures = 0
+		return
+	}
+
+	b.failures++
+	b.lastError = err.Error()
+	b.lastFailureAt = time.Now()
+
+	if b.state == breakerHalfOpen || b.failures >= b.failureThreshold {
+		if b.state != breakerOpen {
+			log.Printf("[gRPC] Circuit breaker open after %d consecutive failures: %v", b.failures, err)
+		}
+		b.state = breakerOpen
+		b.openedAt = time.Now()
+	}
+}
+
+func (b *circuitBreaker) snapshot() breakerSnapshot {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	state := b.state
+	if state == breakerOpen && time.Since(b.openedAt) >= b.openTimeout {
+		// The next call will probe the service
+		state = breakerHalfOpen
+	}
+	return breakerSnapshot{
+		state:         state,
+		failures:      b.failures,
+		lastError:     b.lastError,
+		lastFailureAt: b.lastFailureAt,
+	}
+}
+
+// unaryInterceptor rejects calls while the breaker is open and records the outcome of the others
+func (b *circuitBreaker) unaryInterceptor() grpc.UnaryClientInterceptor {
+	return func(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+		if !b.allow() {
+			return status.Errorf(codes.Unavailable, "AI service circuit breaker is open, failing fast: %s", method)
+		}
+
+		err := invoker(ctx, method, req, reply, cc, opts...)
+		b.record(err)
+		return err
+	}
+}
+
+// streamInterceptor rejects streams while the breaker is open and records whether they could be opened
+func (b *circuitBreaker) streamInterceptor() grpc.StreamClientInterceptor {
+	return func(ctx context.Context, desc *grpc.StreamDesc, cc *grpc.ClientConn, method string, streamer grpc.Streamer, opts ...grpc.CallOption) (grpc.ClientStream, error) {
+		if !b.allow() {
+			return nil, status.Errorf(codes.Unavailable, "AI service circuit breaker is open, failing fast: %s", method)
+		}
+
+		stream, err := streamer(ctx, desc, cc, method, opts...)
+		b.record(err)
+		return stream, err
+	}
+}
+
+// isBreakerFailure reports whether err means the AI service is unreachable or overloaded,
+// as opposed to rejecting a particular request
+func isBreakerFailure(err error) bool {
+	if err == nil {
+		return false
+	}
+	switch status.Code(err) {
+	case codes.Unavailable, codes.DeadlineExceeded, codes.ResourceExhausted:
+		return true
+	default:
+		return false
+	}
+}
@@ -4,22 +4,14 @@ import (
 	"context"
 	"log"
 	pb "lumenslate/internal/proto/ai_service"
-	"time"
 )
 
-func GenerateContext(question string, keywords []string, language string) (string, error) {
-	log.Printf("[GenerateContext] Dialing gRPC server...")
-	client, conn, err := DialGRPC()
+func GenerateContext(parent context.Context, question string, keywords []string, language string) (string, error) {
+	client, ctx, cancel, err := newCall(parent, pb.AIService_GenerateContext_FullMethodName)
 	if err != nil {
-		log.Printf("[GenerateContext] Failed to dial gRPC: %v", err)
+		log.Printf("[GenerateContext] Failed to get gRPC client: %v", err)
 		return "", err
 	}
-	defer func() {
-		log.Printf("[GenerateContext] Closing gRPC connection")
-		conn.Close()
-	}()
-
-	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
 	defer cancel()
 
 	log.Printf("[GenerateContext] Preparing request: question=%q, keywords=%v, language=%q", question, keywords, language)
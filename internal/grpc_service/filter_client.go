@@ -2,18 +2,15 @@ package service
 
 import (
 	"context"
-	"time"
+
 	pb "lumenslate/internal/proto/ai_service"
 )
 
-func FilterAndRandomize(question string, userPrompt string) ([]*pb.RandomizedVariable, error) {
-	client, conn, err := DialGRPC()
+func FilterAndRandomize(parent context.Context, question string, userPrompt string) ([]*pb.RandomizedVariable, error) {
+	client, ctx, cancel, err := newCall(parent, pb.AIService_FilterAndRandomize_FullMethodName)
 	if err != nil {
 		return nil, err
 	}
-	defer conn.Close()
-
-	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
 	defer cancel()
 
 	req := &pb.FilterAndRandomizerRequest{
@@ -1,44 +1,386 @@
 package service
 
 import (
-	// "crypto/tls"
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"fmt"
 	"log"
+	"net"
 	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
 
 	pb "lumenslate/internal/proto/ai_service"
 
+	"go.opentelemetry.io/contrib/instrumentation/google.golang.org/grpc/otelgrpc"
 	"google.golang.org/grpc"
-	// "google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
 	"google.golang.org/grpc/credentials/insecure"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/status"
 )
 
-func DialGRPC() (pb.AIServiceClient, *grpc.ClientConn, error) {
-	// Use environment variable or default to Cloud Run service
-	target := os.Getenv("GRPC_SERVICE_URL")
-	if target == "" {
-		target = "lumenslate-microservice-756147067348.asia-south1.run.app:443"
+const defaultGRPCTarget = "lumenslate-microservice-756147067348.asia-south1.run.app:443"
+
+// idempotentMethods are the RPCs without side effects on the AI service that are safe to retry.
+// The agent RPCs keep session state and are never retried.
+var idempotentMethods = []string{
+	"GenerateContext",
+	"DetectVariables",
+	"SegmentQuestion",
+	"GenerateMCQVariations",
+	"GenerateMSQVariations",
+	"FilterAndRandomize",
+}
+
+// ClientConfig configures the connection to the AI microservice
+type ClientConfig struct {
+	Target         string                   // GRPC_SERVICE_URL
+	Insecure       bool                     // GRPC_INSECURE, defaults to true only for localhost targets
+	CAFile         string                   // GRPC_CA_FILE, PEM bundle used instead of the system roots
+	ServerName     string                   // GRPC_SERVER_NAME, overrides the name verified in the server certificate
+	DefaultTimeout time.Duration            // GRPC_DEFAULT_TIMEOUT, deadline for RPCs without a specific timeout
+	MethodTimeouts map[string]time.Duration // GRPC_TIMEOUT_<METHOD>, e.g. GRPC_TIMEOUT_LUMENAGENT=90s
+
+	RetryMaxAttempts    int           // GRPC_RETRY_MAX_ATTEMPTS, total attempts for idempotent RPCs
+	RetryInitialBackoff time.Duration // GRPC_RETRY_INITIAL_BACKOFF
+	RetryMaxBackoff     time.Duration // GRPC_RETRY_MAX_BACKOFF
+
+	BreakerFailureThreshold int           // GRPC_BREAKER_FAILURES, consecutive failures that open the breaker
+	BreakerOpenTimeout      time.Duration // GRPC_BREAKER_OPEN_TIMEOUT, time before a probe call is let through
+
+	// Dialer replaces the network dialer, e.g. to reach an in-process server over a bufconn listener
+	Dialer func(ctx context.Context, addr string) (net.Conn, error)
+}
+
+// LoadClientConfig reads the client configuration from the environment
+func LoadClientConfig() ClientConfig {
+	target := getEnvWithDefault("GRPC_SERVICE_URL", defaultGRPCTarget)
+
+	config := ClientConfig{
+		Target:         target,
+		Insecure:       isLocalTarget(target),
+		CAFile:         os.Getenv("GRPC_CA_FILE"),
+		ServerName:     os.Getenv("GRPC_SERVER_NAME"),
+		DefaultTimeout: getDurationEnvWithDefault("GRPC_DEFAULT_TIMEOUT", 30*time.Second),
+		MethodTimeouts: map[string]time.Duration{
+			// Agent runs call tools and the database; give them longer by default
+			"LumenAgent": getDurationEnvWithDefault("GRPC_TIMEOUT_LUMENAGENT", 60*time.Second),
+			"RAGAgent":   getDurationEnvWithDefault("GRPC_TIMEOUT_RAGAGENT", 60*time.Second),
+			// Streams stay open for the whole generation while tokens keep the user informed
+			"LumenAgentStream": getDurationEnvWithDefault("GRPC_TIMEOUT_LUMENAGENTSTREAM", 5*time.Minute),
+			"RAGAgentStream":   getDurationEnvWithDefault("GRPC_TIMEOUT_RAGAGENTSTREAM", 5*time.Minute),
+		},
+		RetryMaxAttempts:        getIntEnvWithDefault("GRPC_RETRY_MAX_ATTEMPTS", 3),
+		RetryInitialBackoff:     getDurationEnvWithDefault("GRPC_RETRY_INITIAL_BACKOFF", 500*time.Millisecond),
+		RetryMaxBackoff:         getDurationEnvWithDefault("GRPC_RETRY_MAX_BACKOFF", 5*time.Second),
+		BreakerFailureThreshold: getIntEnvWithDefault("GRPC_BREAKER_FAILURES", 5),
+		BreakerOpenTimeout:      getDurationEnvWithDefault("GRPC_BREAKER_OPEN_TIMEOUT", 30*time.Second),
+	}
+
+	for _, method := range idempotentMethods {
+		if timeout := getDurationEnvWithDefault("GRPC_TIMEOUT_"+strings.ToUpper(method), 0); timeout > 0 {
+			config.MethodTimeouts[method] = timeout
+		}
+	}
+
+	if value := os.Getenv("GRPC_INSECURE"); value != "" {
+		config.Insecure, _ = strconv.ParseBool(value)
+	}
+
+	return config
+}
+
+// Timeout returns the deadline for a method, given by name or full method name
+func (c ClientConfig) Timeout(method string) time.Duration {
+	method = rpcName(method)
+	if timeout, ok := c.MethodTimeouts[method]; ok && timeout > 0 {
+		return timeout
+	}
+	return c.DefaultTimeout
+}
+
+// serviceConfig builds the gRPC service config enabling retries with backoff for idempotent RPCs
+func (c ClientConfig) serviceConfig() (string, error) {
+	type methodName struct {
+		Service string `json:"service"`
+		Method  string `json:"method"`
+	}
+	type retryPolicy struct {
+		MaxAttempts          int      `json:"maxAttempts"`
+		InitialBackoff       string   `json:"initialBackoff"`
+		MaxBackoff           string   `json:"maxBackoff"`
+		BackoffMultiplier    float64  `json:"backoffMultiplier"`
+		RetryableStatusCodes []string `json:"retryableStatusCodes"`
+	}
+	type methodConfig struct {
+		Name        []methodName `json:"name"`
+		RetryPolicy *retryPolicy `json:"retryPolicy,omitempty"`
+	}
+
+	if c.RetryMaxAttempts < 2 {
+		return `{}`, nil
+	}
+
+	names := make([]methodName, 0, len(idempotentMethods))
+	for _, method := range idempotentMethods {
+		names = append(names, methodName{Service: pb.AIService_ServiceDesc.ServiceName, Method: method})
+	}
+
+	config := map[string][]methodConfig{
+		"methodConfig": {{
+			Name: names,
+			RetryPolicy: &retryPolicy{
+				MaxAttempts:          c.RetryMaxAttempts,
+				InitialBackoff:       fmt.Sprintf("%.3fs", c.RetryInitialBackoff.Seconds()),
+				MaxBackoff:           fmt.Sprintf("%.3fs", c.RetryMaxBackoff.Seconds()),
+				BackoffMultiplier:    2,
+				RetryableStatusCodes: []string{"UNAVAILABLE", "RESOURCE_EXHAUSTED"},
+			},
+		}},
+	}
+
+	data, err := json.Marshal(config)
+	if err != nil {
+		return "", err
+	}
+	return string(data), nil
+}
+
+// transportCredentials returns TLS credentials using the system roots or the configured CA bundle
+func (c ClientConfig) transportCredentials() (credentials.TransportCredentials, error) {
+	if c.Insecure {
+		return insecure.NewCredentials(), nil
+	}
+
+	tlsConfig := &tls.Config{
+		MinVersion: tls.VersionTLS12,
+		ServerName: c.ServerName,
+	}
+
+	if c.CAFile != "" {
+		pem, err := os.ReadFile(c.CAFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read CA file %s: %w", c.CAFile, err)
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("no certificates found in CA file %s", c.CAFile)
+		}
+		tlsConfig.RootCAs = pool
+	}
+
+	return credentials.NewTLS(tlsConfig), nil
+}
+
+// ClientManager owns the single connection to the AI microservice shared by all callers
+type ClientManager struct {
+	config  ClientConfig
+	conn    *grpc.ClientConn
+	client  pb.AIServiceClient
+	breaker *circuitBreaker
+}
+
+var (
+	clientManager   *ClientManager
+	clientManagerMu sync.Mutex
+)
+
+// GetClientManager returns the shared client manager, creating it on first use
+func GetClientManager() (*ClientManager, error) {
+	clientManagerMu.Lock()
+	defer clientManagerMu.Unlock()
+
+	if clientManager == nil {
+		manager, err := NewClientManager(LoadClientConfig())
+		if err != nil {
+			return nil, err
+		}
+		clientManager = manager
+	}
+	return clientManager, nil
+}
+
+// SetClientManager replaces the shared client manager, closing the previous one.
+// It is used to point every caller at another AI service, such as the in-process fake.
+func SetClientManager(manager *ClientManager) {
+	clientManagerMu.Lock()
+	defer clientManagerMu.Unlock()
+
+	if clientManager != nil && clientManager != manager {
+		if err := clientManager.Close(); err != nil {
+			log.Printf("[gRPC] Failed to close previous client: %v", err)
+		}
+	}
+	clientManager = manager
+}
+
+// CloseClientManager closes the shared connection; a later GetClientManager creates a new one
+func CloseClientManager() error {
+	clientManagerMu.Lock()
+	defer clientManagerMu.Unlock()
+
+	if clientManager == nil {
+		return nil
+	}
+	err := clientManager.Close()
+	clientManager = nil
+	return err
+}
+
+// NewClientManager creates a client manager for the given configuration.
+// The connection is established lazily on the first RPC.
+func NewClientManager(config ClientConfig) (*ClientManager, error) {
+	creds, err := config.transportCredentials()
+	if err != nil {
+		return nil, err
+	}
+
+	serviceConfig, err := config.serviceConfig()
+	if err != nil {
+		return nil, fmt.Errorf("failed to build gRPC service config: %w", err)
+	}
+
+	breaker := newCircuitBreaker(config.BreakerFailureThreshold, config.BreakerOpenTimeout)
+
+	opts := []grpc.DialOption{
+		grpc.WithTransportCredentials(creds),
+		grpc.WithDefaultServiceConfig(serviceConfig),
+		grpc.WithStatsHandler(otelgrpc.NewClientHandler()),
+		grpc.WithChainUnaryInterceptor(metricsUnaryInterceptor(), breaker.unaryInterceptor()),
+		grpc.WithChainStreamInterceptor(metricsStreamInterceptor(), breaker.streamInterceptor()),
+	}
+	if config.Dialer != nil {
+		opts = append(opts, grpc.WithContextDialer(config.Dialer))
+	}
+
+	conn, err := grpc.NewClient(config.Target, opts...)
+	if err != nil {
+		log.Printf("[gRPC] Failed to create client for %s: %v", config.Target, err)
+		return nil, err
+	}
+
+	log.Printf("[gRPC] Client created for %s (tls=%t, default timeout=%s, retry attempts=%d)",
+		config.Target, !config.Insecure, config.DefaultTimeout, config.RetryMaxAttempts)
+
+	return &ClientManager{
+		config:  config,
+		conn:    conn,
+		client:  pb.NewAIServiceClient(conn),
+		breaker: breaker,
+	}, nil
+}
+
+// Client returns the shared AI service client
+func (m *ClientManager) Client() pb.AIServiceClient {
+	return m.client
+}
+
+// Config returns the configuration the manager was created with
+func (m *ClientManager) Config() ClientConfig {
+	return m.config
+}
+
+// CallContext derives a context carrying the configured deadline for method
+func (m *ClientManager) CallContext(parent context.Context, method string) (context.Context, context.CancelFunc) {
+	return context.WithTimeout(parent, m.config.Timeout(method))
+}
+
+// ClientHealth reports the state of the connection to the AI microservice
+type ClientHealth struct {
+	Target           string    `json:"target"`
+	TLS              bool      `json:"tls"`
+	ConnectionState  string    `json:"connectionState"`
+	CircuitState     string    `json:"circuitState"`
+	ConsecutiveFails int       `json:"consecutiveFailures"`
+	LastError        string    `json:"lastError,omitempty"`
+	LastFailureAt    time.Time `json:"lastFailureAt,omitempty"`
+	Healthy          bool      `json:"healthy"`
+}
+
+// Health reports the connection and circuit breaker state without making an RPC
+func (m *ClientManager) Health() ClientHealth {
+	snapshot := m.breaker.snapshot()
+	return ClientHealth{
+		Target:           m.config.Target,
+		TLS:              !m.config.Insecure,
+		ConnectionState:  m.conn.GetState().String(),
+		CircuitState:     snapshot.state.String(),
+		ConsecutiveFails: snapshot.failures,
+		LastError:        snapshot.lastError,
+		LastFailureAt:    snapshot.lastFailureAt,
+		Healthy:          snapshot.state != breakerOpen,
 	}
-	log.Printf("[gRPC] Target address: %s", target)
+}
 
-	var conn *grpc.ClientConn
-	var err error
+// CheckHealth asks the AI microservice for its serving status over the standard gRPC health
+// protocol. A server without the health service still answered, so it counts as serving.
+func (m *ClientManager) CheckHealth(ctx context.Context) error {
+	resp, err := healthpb.NewHealthClient(m.conn).Check(ctx, &healthpb.HealthCheckRequest{})
+	if status.Code(err) == codes.Unimplemented {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if resp.GetStatus() != healthpb.HealthCheckResponse_SERVING {
+		return fmt.Errorf("AI service is %s", resp.GetStatus())
+	}
+	return nil
+}
 
-	// For local development (localhost), use insecure connection
-	if target == "localhost:50051" {
-		log.Println("[gRPC] Using insecure credentials for localhost")
-		conn, err = grpc.NewClient(target, grpc.WithTransportCredentials(insecure.NewCredentials()))
-	} else {
-		log.Println("[gRPC] Using TLS credentials for remote connection")
-		// creds := credentials.NewTLS(&tls.Config{})
-		conn, err = grpc.NewClient(target, grpc.WithTransportCredentials(insecure.NewCredentials()))
+// Close closes the underlying connection
+func (m *ClientManager) Close() error {
+	return m.conn.Close()
+}
+
+// newCall returns the shared AI service client and a context derived from parent carrying the configured deadline for method
+func newCall(parent context.Context, method string) (pb.AIServiceClient, context.Context, context.CancelFunc, error) {
+	manager, err := GetClientManager()
+	if err != nil {
+		log.Printf("[gRPC] Failed to get client: %v", err)
+		return nil, nil, nil, err
 	}
+	ctx, cancel := manager.CallContext(parent, method)
+	return manager.Client(), ctx, cancel, nil
+}
 
+// isLocalTarget reports whether target points at this machine, where plaintext is allowed
+func isLocalTarget(target string) bool {
+	host, _, err := net.SplitHostPort(target)
 	if err != nil {
-		log.Printf("[gRPC] Failed to connect to %s: %v", target, err)
-		return nil, nil, err
+		host = target
+	}
+	return host == "localhost" || host == "127.0.0.1" || host == "::1"
+}
+
+// Helper functions for environment variable handling
+func getEnvWithDefault(key, defaultValue string) string {
+	if value := os.Getenv(key); value != "" {
+		return value
+	}
+	return defaultValue
+}
+
+func getIntEnvWithDefault(key string, defaultValue int) int {
+	if value := os.Getenv(key); value != "" {
+		if intValue, err := strconv.Atoi(value); err == nil {
+			return intValue
+		}
 	}
+	return defaultValue
+}
 
-	log.Printf("[gRPC] Successfully connected to %s", target)
-	client := pb.NewAIServiceClient(conn)
-	return client, conn, nil
+func getDurationEnvWithDefault(key string, defaultValue time.Duration) time.Duration {
+	if value := os.Getenv(key); value != "" {
+		if duration, err := time.ParseDuration(value); err == nil {
+			return duration
+		}
+	}
+	return defaultValue
 }
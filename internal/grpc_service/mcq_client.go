@@ -2,18 +2,15 @@ package service
 
 import (
 	"context"
-	"time"
+
 	pb "lumenslate/internal/proto/ai_service"
 )
 
-func GenerateMCQVariations(question string, options []string, answerIndex int32) ([]*pb.MCQQuestion, error) {
-	client, conn, err := DialGRPC()
+func GenerateMCQVariations(parent context.Context, question string, options []string, answerIndex int32) ([]*pb.MCQQuestion, error) {
+	client, ctx, cancel, err := newCall(parent, pb.AIService_GenerateMCQVariations_FullMethodName)
 	if err != nil {
 		return nil, err
 	}
-	defer conn.Close()
-
-	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
 	defer cancel()
 
 	req := &pb.MCQRequest{
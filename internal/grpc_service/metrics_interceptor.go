@@ -0,0 +1,70 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"io"
+	"strings"
+	"sync"
+	"time"
+
+	"lumenslate/internal/metrics"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/status"
+)
+
+// metricsUnaryInterceptor records the latency and status code of every unary call.
+// It runs outside the circuit breaker so that calls failed fast are counted too.
+func metricsUnaryInterceptor() grpc.UnaryClientInterceptor {
+	return func(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+		start := time.Now()
+		err := invoker(ctx, method, req, reply, cc, opts...)
+		metrics.ObserveGRPCCall(rpcName(method), status.Code(err).String(), time.Since(start))
+		return err
+	}
+}
+
+// metricsStreamInterceptor records the latency and status code of every stream, from opening it
+// until the server ends it
+func metricsStreamInterceptor() grpc.StreamClientInterceptor {
+	return func(ctx context.Context, desc *grpc.StreamDesc, cc *grpc.ClientConn, method string, streamer grpc.Streamer, opts ...grpc.CallOption) (grpc.ClientStream, error) {
+		start := time.Now()
+		stream, err := streamer(ctx, desc, cc, method, opts...)
+		if err != nil {
+			metrics.ObserveGRPCCall(rpcName(method), status.Code(err).String(), time.Since(start))
+			return nil, err
+		}
+		return &metricsClientStream{ClientStream: stream, method: rpcName(method), start: start}, nil
+	}
+}
+
+// metricsClientStream observes a stream once, when receiving from it first fails
+type metricsClientStream struct {
+	grpc.ClientStream
+	method string
+	start  time.Time
+	once   sync.Once
+}
+
+func (s *metricsClientStream) RecvMsg(m interface{}) error {
+	err := s.ClientStream.RecvMsg(m)
+	if err != nil {
+		s.once.Do(func() {
+			code := status.Code(err)
+			if errors.Is(err, io.EOF) {
+				code = status.Code(nil)
+			}
+			metrics.ObserveGRPCCall(s.method, code.String(), time.Since(s.start))
+		})
+	}
+	return err
+}
+
+// rpcName returns the RPC name of a full method name such as /ai_service.AIService/GenerateContext
+func rpcName(method string) string {
+	if i := strings.LastIndex(method, "/"); i >= 0 {
+		return method[i+1:]
+	}
+	return method
+}
@@ -2,18 +2,15 @@ package service
 
 import (
 	"context"
-	"time"
+
 	pb "lumenslate/internal/proto/ai_service"
 )
 
-func GenerateMSQVariations(question string, options []string, answerIndices []int32) ([]*pb.MSQQuestion, error) {
-	client, conn, err := DialGRPC()
+func GenerateMSQVariations(parent context.Context, question string, options []string, answerIndices []int32) ([]*pb.MSQQuestion, error) {
+	client, ctx, cancel, err := newCall(parent, pb.AIService_GenerateMSQVariations_FullMethodName)
 	if err != nil {
 		return nil, err
 	}
-	defer conn.Close()
-
-	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
 	defer cancel()
 
 	req := &pb.MSQRequest{
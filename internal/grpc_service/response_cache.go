@@ -0,0 +1,252 @@
+package service
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	"golang.org/x/sync/singleflight"
+)
+
+// responseCachePrefix namespaces the Redis keys of cached AI responses. Bump the version
+// when the cached encoding of a response changes.
+const responseCachePrefix = "lumenslate:ai-cache:v1"
+
+// responseCacheTimeout bounds each Redis round trip so a slow cache never delays an AI call by much
+const responseCacheTimeout = 500 * time.Millisecond
+
+// CacheConfig configures the cache of AI responses
+type CacheConfig struct {
+	Enabled   bool                     // AI_CACHE_ENABLED, defaults to true
+	RedisAddr string                   // REDIS_ADDR
+	TTLs      map[string]time.Duration // AI_CACHE_TTL_<METHOD>, e.g. AI_CACHE_TTL_GENERATECONTEXT=6h; 0 disables caching the method
+}
+
+// LoadCacheConfig reads the response cache configuration from the environment
+func LoadCacheConfig() CacheConfig {
+	config := CacheConfig{
+		Enabled:   true,
+		RedisAddr: getEnvWithDefault("REDIS_ADDR", "localhost:6379"),
+		TTLs: map[string]time.Duration{
+			// Segmentation and variable detection only depend on the text
+			"DetectVariables": getDurationEnvWithDefault("AI_CACHE_TTL_DETECTVARIABLES", 7*24*time.Hour),
+			"SegmentQuestion": getDurationEnvWithDefault("AI_CACHE_TTL_SEGMENTQUESTION", 7*24*time.Hour),
+			// Generated context is creative; expire it sooner so repeated requests eventually vary
+			"GenerateContext": getDurationEnvWithDefault("AI_CACHE_TTL_GENERATECONTEXT", 24*time.Hour),
+		},
+	}
+	if value := os.Getenv("AI_CACHE_ENABLED"); value != "" {
+		config.Enabled, _ = strconv.ParseBool(value)
+	}
+	return config
+}
+
+// CacheMetricsRecorder receives the outcome of every cacheable AI call, see service.MetricsCollector
+type CacheMetricsRecorder interface {
+	RecordCacheHit(method string)
+	RecordCacheMiss(method string)
+}
+
+// ResponseCache stores AI responses in Redis keyed by RPC name and normalized request,
+// and collapses concurrent identical calls into one
+type ResponseCache struct {
+	client  *redis.Client
+	ttls    map[string]time.Duration
+	metrics CacheMetricsRecorder
+	group   singleflight.Group
+}
+
+// NewResponseCache creates a response cache for the given configuration. It returns nil when
+// caching is disabled; a nil cache passes every call through. metrics may be nil.
+func NewResponseCache(config CacheConfig, metrics CacheMetricsRecorder) *ResponseCache {
+	if !config.Enabled {
+		return nil
+	}
+	return &ResponseCache{
+		client:  redis.NewClient(&redis.Options{Addr: config.RedisAddr}),
+		ttls:    config.TTLs,
+		metrics: metrics,
+	}
+}
+
+// Close closes the Redis connection of the cache
+func (c *ResponseCache) Close() error {
+	if c == nil {
+		return nil
+	}
+	return c.client.Close()
+}
+
+var (
+	responseCacheMu sync.RWMutex
+	responseCache   *ResponseCache
+)
+
+// SetResponseCache installs the cache used by the AIService returned from NewAIService; nil disables caching
+func SetResponseCache(cache *ResponseCache) {
+	responseCacheMu.Lock()
+	defer responseCacheMu.Unlock()
+	responseCache = cache
+}
+
+// CloseResponseCache closes and uninstalls the response cache
+func CloseResponseCache() error {
+	responseCacheMu.Lock()
+	defer responseCacheMu.Unlock()
+
+	err := responseCache.Close()
+	responseCache = nil
+	return err
+}
+
+// getResponseCache returns the installed response cache, or nil
+func getResponseCache() *ResponseCache {
+	responseCacheMu.RLock()
+	defer responseCacheMu.RUnlock()
+	return responseCache
+}
+
+// cachedCall returns the cached response to request when there is one, otherwise it makes the call
+// once for all concurrent identical requests and caches a successful response. With bypass the
+// cached response is ignored but the fresh one still replaces it.
+func cachedCall[T any](cache *ResponseCache, method string, request interface{}, bypass bool, call func() (T, error)) (T, error) {
+	if cache == nil || cache.ttls[method] <= 0 {
+		return call()
+	}
+
+	key, err := responseCacheKey(method, request)
+	if err != nil {
+		log.Printf("[gRPC] Not caching %s: %v", method, err)
+		return call()
+	}
+
+	if !bypass {
+		var cached T
+		found, err := cache.get(key, &cached)
+		if err != nil {
+			log.Printf("[gRPC] Response cache read failed for %s: %v", method, err)
+		}
+		if found {
+			cache.recordHit(method)
+			return cached, nil
+		}
+	}
+	cache.recordMiss(method)
+
+	result, err, _ := cache.group.Do(key, func() (interface{}, error) {
+		response, err := call()
+		if err != nil {
+			return nil, err
+		}
+		if err := cache.set(key, response, cache.ttls[method]); err != nil {
+			log.Printf("[gRPC] Response cache write failed for %s: %v", method, err)
+		}
+		return response, nil
+	})
+	if err != nil {
+		var zero T
+		return zero, err
+	}
+	return result.(T), nil
+}
+
+// get decodes the cached value of key into out and reports whether there was one
+func (c *ResponseCache) get(key string, out interface{}) (bool, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), responseCacheTimeout)
+	defer cancel()
+
+	raw, err := c.client.Get(ctx, key).Bytes()
+	if errors.Is(err, redis.Nil) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	if err := json.Unmarshal(raw, out); err != nil {
+		return false, fmt.Errorf("failed to decode cached response: %w", err)
+	}
+	return true, nil
+}
+
+// set stores value under key for ttl
+func (c *ResponseCache) set(key string, value interface{}, ttl time.Duration) error {
+	raw, err := json.Marshal(value)
+	if err != nil {
+		return fmt.Errorf("failed to encode response: %w", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), responseCacheTimeout)
+	defer cancel()
+	return c.client.Set(ctx, key, raw, ttl).Err()
+}
+
+func (c *ResponseCache) recordHit(method string) {
+	if c.metrics != nil {
+		c.metrics.RecordCacheHit(method)
+	}
+}
+
+func (c *ResponseCache) recordMiss(method string) {
+	if c.metrics != nil {
+		c.metrics.RecordCacheMiss(method)
+	}
+}
+
+// responseCacheKey addresses a response by RPC name and the SHA-256 of the normalized request
+func responseCacheKey(method string, request interface{}) (string, error) {
+	raw, err := json.Marshal(request)
+	if err != nil {
+		return "", fmt.Errorf("failed to encode request: %w", err)
+	}
+	sum := sha256.Sum256(raw)
+	return fmt.Sprintf("%s:%s:%s", responseCachePrefix, method, hex.EncodeToString(sum[:])), nil
+}
+
+// Normalized requests used as cache keys
+
+type contextCacheRequest struct {
+	Question string   `json:"question"`
+	Keywords []string `json:"keywords"`
+	Language string   `json:"language"`
+}
+
+// newContextCacheRequest ignores whitespace differences, keyword order and duplicates, and the case of the language
+func newContextCacheRequest(question string, keywords []string, language string) contextCacheRequest {
+	normalized := make([]string, 0, len(keywords))
+	for _, keyword := range keywords {
+		if keyword = strings.Join(strings.Fields(keyword), " "); keyword != "" {
+			normalized = append(normalized, keyword)
+		}
+	}
+	slices.Sort(normalized)
+	return contextCacheRequest{
+		Question: strings.Join(strings.Fields(question), " "),
+		Keywords: slices.Compact(normalized),
+		Language: strings.ToLower(strings.TrimSpace(language)),
+	}
+}
+
+type segmentCacheRequest struct {
+	Question string `json:"question"`
+}
+
+// newSegmentCacheRequest ignores whitespace differences
+func newSegmentCacheRequest(question string) segmentCacheRequest {
+	return segmentCacheRequest{Question: strings.Join(strings.Fields(question), " ")}
+}
+
+// variablesCacheRequest keeps the question exactly as given, since the detected positions index into it
+type variablesCacheRequest struct {
+	Question string `json:"question"`
+}
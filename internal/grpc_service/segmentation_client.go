@@ -2,18 +2,15 @@ package service
 
 import (
 	"context"
-	"time"
+
 	pb "lumenslate/internal/proto/ai_service"
 )
 
-func SegmentQuestion(question string) (string, error) {
-	client, conn, err := DialGRPC()
+func SegmentQuestion(parent context.Context, question string) (string, error) {
+	client, ctx, cancel, err := newCall(parent, pb.AIService_SegmentQuestion_FullMethodName)
 	if err != nil {
 		return "", err
 	}
-	defer conn.Close()
-
-	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
 	defer cancel()
 
 	req := &pb.QuestionSegmentationRequest{Question: question}
@@ -2,18 +2,15 @@ package service
 
 import (
 	"context"
-	"time"
+
 	pb "lumenslate/internal/proto/ai_service"
 )
 
-func DetectVariables(question string) ([]*pb.DetectedVariable, error) {
-	client, conn, err := DialGRPC()
+func DetectVariables(parent context.Context, question string) ([]*pb.DetectedVariable, error) {
+	client, ctx, cancel, err := newCall(parent, pb.AIService_DetectVariables_FullMethodName)
 	if err != nil {
 		return nil, err
 	}
-	defer conn.Close()
-
-	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
 	defer cancel()
 
 	req := &pb.VariableDetectorRequest{Question: question}
@@ -0,0 +1,80 @@
+package metrics
+
+import (
+	"log"
+
+	"github.com/hibiken/asynq"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+var (
+	queueSizeDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, "asynq", "queue_size"),
+		"Number of tasks in an asynq queue by state.",
+		[]string{"queue", "state"}, nil,
+	)
+	queueLatencyDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, "asynq", "queue_latency_seconds"),
+		"Time the oldest pending task of an asynq queue has been waiting.",
+		[]string{"queue"}, nil,
+	)
+	queuePausedDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, "asynq", "queue_paused"),
+		"Whether an asynq queue is paused (1) or not (0).",
+		[]string{"queue"}, nil,
+	)
+)
+
+// QueueCollector reads the state of every asynq queue from Redis when metrics are scraped,
+// so every replica reports the same shared queues
+type QueueCollector struct {
+	inspector *asynq.Inspector
+}
+
+// NewQueueCollector creates a collector reading queues through inspector; the caller keeps ownership of it
+func NewQueueCollector(inspector *asynq.Inspector) *QueueCollector {
+	return &QueueCollector{inspector: inspector}
+}
+
+// Describe implements prometheus.Collector
+func (qc *QueueCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- queueSizeDesc
+	ch <- queueLatencyDesc
+	ch <- queuePausedDesc
+}
+
+// Collect implements prometheus.Collector
+func (qc *QueueCollector) Collect(ch chan<- prometheus.Metric) {
+	queues, err := qc.inspector.Queues()
+	if err != nil {
+		log.Printf("ERROR: Failed to list asynq queues for metrics: %v", err)
+		return
+	}
+
+	for _, queue := range queues {
+		info, err := qc.inspector.GetQueueInfo(queue)
+		if err != nil {
+			log.Printf("ERROR: Failed to get asynq queue %s for metrics: %v", queue, err)
+			continue
+		}
+
+		for state, size := range map[string]int{
+			"pending":     info.Pending,
+			"active":      info.Active,
+			"scheduled":   info.Scheduled,
+			"retry":       info.Retry,
+			"archived":    info.Archived,
+			"completed":   info.Completed,
+			"aggregating": info.Aggregating,
+		} {
+			ch <- prometheus.MustNewConstMetric(queueSizeDesc, prometheus.GaugeValue, float64(size), queue, state)
+		}
+		ch <- prometheus.MustNewConstMetric(queueLatencyDesc, prometheus.GaugeValue, info.Latency.Seconds(), queue)
+
+		paused := 0.0
+		if info.Paused {
+			paused = 1
+		}
+		ch <- prometheus.MustNewConstMetric(queuePausedDesc, prometheus.GaugeValue, paused, queue)
+	}
+}
@@ -0,0 +1,144 @@
+package metrics
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+// namespace prefixes every metric exported by the API
+const namespace = "lumenslate"
+
+// registry holds the metrics served at /metrics. It is separate from the Prometheus default registry
+// so that only the collectors below are exported.
+var registry = prometheus.NewRegistry()
+
+var (
+	httpRequestDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Subsystem: "http",
+		Name:      "request_duration_seconds",
+		Help:      "Latency of HTTP requests by method, route template and status code.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"method", "route", "status"})
+
+	mongoOperationDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Subsystem: "mongodb",
+		Name:      "operation_duration_seconds",
+		Help:      "Latency of MongoDB commands by collection, command and outcome.",
+		Buckets:   prometheus.ExponentialBuckets(0.001, 2, 14), // 1ms to ~8s
+	}, []string{"collection", "command", "outcome"})
+
+	grpcClientDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Subsystem: "grpc_client",
+		Name:      "call_duration_seconds",
+		Help:      "Latency of calls to the AI microservice by RPC and status code.",
+		Buckets:   prometheus.ExponentialBuckets(0.05, 2, 12), // 50ms to ~100s, AI calls are slow
+	}, []string{"method", "code"})
+
+	documentIngestions = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "documents",
+		Name:      "ingestion_outcomes_total",
+		Help:      "Document ingestion runs by outcome (ready, deferred, failed) and the step deferred or failed at.",
+	}, []string{"outcome", "step"})
+
+	taskEnqueues = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "tasks",
+		Name:      "enqueued_total",
+		Help:      "Background task enqueue attempts by task type, queue and outcome (enqueued, duplicate, failed).",
+	}, []string{"type", "queue", "outcome"})
+
+	taskDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Subsystem: "tasks",
+		Name:      "duration_seconds",
+		Help:      "Processing time of background tasks by task type, queue and outcome (success, failure).",
+		Buckets:   prometheus.ExponentialBuckets(0.1, 2, 14), // 100ms to ~27min, the longest task timeout
+	}, []string{"type", "queue", "outcome"})
+)
+
+func init() {
+	registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		httpRequestDuration,
+		mongoOperationDuration,
+		grpcClientDuration,
+		documentIngestions,
+		taskEnqueues,
+		taskDuration,
+	)
+}
+
+// Register adds a collector, such as the asynq QueueCollector, to the exported metrics
+func Register(collector prometheus.Collector) error {
+	return registry.Register(collector)
+}
+
+// Handler serves the exported metrics in the Prometheus text format
+func Handler() http.Handler {
+	return promhttp.HandlerFor(registry, promhttp.HandlerOpts{})
+}
+
+// GinMiddleware records the latency of every request under its route template, so that
+// /api/v1/classrooms/:id is one series however many classrooms there are
+func GinMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		start := time.Now()
+		c.Next()
+
+		route := c.FullPath()
+		if route == "" {
+			route = "unmatched"
+		}
+		httpRequestDuration.WithLabelValues(c.Request.Method, route, strconv.Itoa(c.Writer.Status())).
+			Observe(time.Since(start).Seconds())
+	}
+}
+
+// ObserveGRPCCall records a call to the AI microservice; method is the RPC name and code its gRPC status code
+func ObserveGRPCCall(method, code string, duration time.Duration) {
+	grpcClientDuration.WithLabelValues(method, code).Observe(duration.Seconds())
+}
+
+// Document ingestion outcomes
+const (
+	IngestionReady    = "ready"
+	IngestionDeferred = "deferred"
+	IngestionFailed   = "failed"
+)
+
+// RecordDocumentIngestion counts a document ingestion run ending with outcome at step
+func RecordDocumentIngestion(outcome, step string) {
+	documentIngestions.WithLabelValues(outcome, step).Inc()
+}
+
+// Task enqueue outcomes
+const (
+	EnqueueEnqueued  = "enqueued"
+	EnqueueDuplicate = "duplicate" // A task with the same ID was already queued
+	EnqueueFailed    = "failed"
+)
+
+// RecordTaskEnqueue counts an attempt to enqueue a task of taskType to queue
+func RecordTaskEnqueue(taskType, queue, outcome string) {
+	taskEnqueues.WithLabelValues(taskType, queue, outcome).Inc()
+}
+
+// ObserveTask records one processing run of a task of taskType taken from queue
+func ObserveTask(taskType, queue string, success bool, duration time.Duration) {
+	outcome := "success"
+	if !success {
+		outcome = "failure"
+	}
+	taskDuration.WithLabelValues(taskType, queue, outcome).Observe(duration.Seconds())
+}
@@ -0,0 +1,49 @@
+package metrics
+
+import (
+	"context"
+	"sync"
+
+	"go.mongodb.org/mongo-driver/event"
+)
+
+// MongoCommandMonitor returns a command monitor recording the latency of every MongoDB command that
+// targets a collection. Handshake, authentication and session commands are not recorded.
+func MongoCommandMonitor() *event.CommandMonitor {
+	// Collection of each in-flight command; finished events only carry the request ID
+	var collections sync.Map
+
+	finish := func(requestID int64, command, outcome string, durationSeconds float64) {
+		collection, ok := collections.LoadAndDelete(requestID)
+		if !ok {
+			return
+		}
+		mongoOperationDuration.WithLabelValues(collection.(string), command, outcome).Observe(durationSeconds)
+	}
+
+	return &event.CommandMonitor{
+		Started: func(_ context.Context, e *event.CommandStartedEvent) {
+			if collection := commandCollection(e); collection != "" {
+				collections.Store(e.RequestID, collection)
+			}
+		},
+		Succeeded: func(_ context.Context, e *event.CommandSucceededEvent) {
+			finish(e.RequestID, e.CommandName, "success", e.Duration.Seconds())
+		},
+		Failed: func(_ context.Context, e *event.CommandFailedEvent) {
+			finish(e.RequestID, e.CommandName, "error", e.Duration.Seconds())
+		},
+	}
+}
+
+// commandCollection returns the collection a command targets. CRUD commands name it as the value
+// of the command itself; getMore and killCursors carry it in a "collection" field.
+func commandCollection(e *event.CommandStartedEvent) string {
+	if collection, ok := e.Command.Lookup(e.CommandName).StringValueOK(); ok {
+		return collection
+	}
+	if collection, ok := e.Command.Lookup("collection").StringValueOK(); ok {
+		return collection
+	}
+	return ""
+}
@@ -0,0 +1,32 @@
+package middleware
+
+import (
+	"crypto/subtle"
+	"net/http"
+	"os"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// AdminAuth requires the bearer token in ADMIN_API_TOKEN on every request. Without a configured
+// token the admin API is disabled and every request is refused.
+func AdminAuth() gin.HandlerFunc {
+	token := os.Getenv("ADMIN_API_TOKEN")
+
+	return func(c *gin.Context) {
+		if token == "" {
+			c.AbortWithStatusJSON(http.StatusServiceUnavailable, gin.H{"error": "Admin API is disabled, set ADMIN_API_TOKEN to enable it"})
+			return
+		}
+
+		provided, ok := strings.CutPrefix(c.GetHeader("Authorization"), "Bearer ")
+		if !ok || subtle.ConstantTimeCompare([]byte(provided), []byte(token)) != 1 {
+			c.Header("WWW-Authenticate", `Bearer realm="admin"`)
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Valid admin bearer token required"})
+			return
+		}
+
+		c.Next()
+	}
+}
@@ -0,0 +1,95 @@
+package model
+
+import (
+	"time"
+
+	"lumenslate/internal/model/questions"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Lifecycle of an agent proposal. Committing marks a proposal whose approval is being written,
+// so that a second approval cannot write it twice.
+const (
+	AgentProposalStatusPending    = "pending"
+	AgentProposalStatusCommitting = "committing"
+	AgentProposalStatusApproved   = "approved"
+	AgentProposalStatusRejected   = "rejected"
+)
+
+// Actions recorded in the history of a proposal
+const (
+	AgentProposalActionProposed = "proposed"
+	AgentProposalActionEdited   = "edited"
+	AgentProposalActionApproved = "approved"
+	AgentProposalActionRejected = "rejected"
+	AgentProposalActionFailed   = "failed"
+)
+
+// AgentProposalOperationCreate is the only write agents currently propose
+const AgentProposalOperationCreate = "create"
+
+// AgentProposal is a database write requested by the agent that waits for the teacher's approval
+type AgentProposal struct {
+	ID          primitive.ObjectID       `bson:"_id,omitempty" json:"id"`
+	TeacherID   string                   `bson:"teacherId" json:"teacherId"`
+	AgentName   string                   `bson:"agentName" json:"agentName"`
+	PayloadType string                   `bson:"payloadType" json:"payloadType"`
+	Summary     string                   `bson:"summary" json:"summary"`
+	Operation   string                   `bson:"operation" json:"operation"`
+	Collection  string                   `bson:"collection" json:"collection"`
+	Records     AgentProposalRecords     `bson:"records" json:"records"`
+	Preview     []AgentProposalChange    `bson:"preview" json:"preview"`
+	Shortfalls  []AgentQuestionShortfall `bson:"shortfalls,omitempty" json:"shortfalls,omitempty"` // Parts of a generated assignment that could not be met
+	Status      string                   `bson:"status" json:"status"`
+	CommittedID string                   `bson:"committedId,omitempty" json:"committedId,omitempty"` // ID of the record written on approval
+	LastError   string                   `bson:"lastError,omitempty" json:"lastError,omitempty"`
+	History     []AgentProposalEvent     `bson:"history" json:"history"`
+	DecidedBy   string                   `bson:"decidedBy,omitempty" json:"decidedBy,omitempty"`
+	DecidedAt   *time.Time               `bson:"decidedAt,omitempty" json:"decidedAt,omitempty"`
+	CreatedAt   time.Time                `bson:"createdAt" json:"createdAt"`
+	UpdatedAt   time.Time                `bson:"updatedAt" json:"updatedAt"`
+}
+
+// AgentProposalRecords holds the record a proposal writes. Exactly one of Assignment, SubjectReport,
+// AssignmentResult and ReportCard is set, matching the payload type. An assignment can come with AI-generated questions
+// that are created with it.
+type AgentProposalRecords struct {
+	Assignment       *Assignment       `bson:"assignment,omitempty" json:"assignment,omitempty"`
+	SubjectReport    *SubjectReport    `bson:"subjectReport,omitempty" json:"subjectReport,omitempty"`
+	AssignmentResult *AssignmentResult `bson:"assignmentResult,omitempty" json:"assignmentResult,omitempty"`
+	ReportCard       *AgentReportCard  `bson:"reportCard,omitempty" json:"reportCard,omitempty"`
+
+	MCQs []questions.MCQ `bson:"mcqs,omitempty" json:"mcqs,omitempty"`
+	MSQs []questions.MSQ `bson:"msqs,omitempty" json:"msqs,omitempty"`
+}
+
+// AgentQuestionShortfall reports a part of an assignment request that could not be met from the question bank
+type AgentQuestionShortfall struct {
+	Subject         string `bson:"subject" json:"subject"`
+	Difficulty      string `bson:"difficulty,omitempty" json:"difficulty,omitempty"`
+	QuestionType    string `bson:"questionType,omitempty" json:"questionType,omitempty"` // Empty when any type was accepted
+	Requested       int    `bson:"requested" json:"requested"`
+	Selected        int    `bson:"selected" json:"selected"`
+	Generated       int    `bson:"generated,omitempty" json:"generated,omitempty"` // Gaps filled with AI-generated variations
+	RequestedPoints int    `bson:"requestedPoints,omitempty" json:"requestedPoints,omitempty"`
+	SelectedPoints  int    `bson:"selectedPoints,omitempty" json:"selectedPoints,omitempty"`
+	Reason          string `bson:"reason" json:"reason"`
+}
+
+// AgentProposalChange is one line of a proposal preview: a field and its value before and after the write.
+// Values are rendered as text, with non-string values in their JSON form.
+type AgentProposalChange struct {
+	Field  string `bson:"field" json:"field"`
+	Before string `bson:"before,omitempty" json:"before,omitempty"`
+	After  string `bson:"after,omitempty" json:"after,omitempty"`
+}
+
+// AgentProposalEvent is an entry of the audit trail of a proposal
+type AgentProposalEvent struct {
+	Action  string                `bson:"action" json:"action"`
+	ActorID string                `bson:"actorId" json:"actorId"`
+	Note    string                `bson:"note,omitempty" json:"note,omitempty"`
+	Changes []AgentProposalChange `bson:"changes,omitempty" json:"changes,omitempty"` // Fields changed by an edit
+	At      time.Time             `bson:"at" json:"at"`
+}
@@ -0,0 +1,58 @@
+package model
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Authors of agent conversation messages
+const (
+	AgentMessageRoleTeacher = "teacher"
+	AgentMessageRoleAgent   = "agent"
+)
+
+// Feedback a teacher can leave on an agent message, matching the feedback field of the AI service
+const (
+	AgentFeedbackPositive = "positive"
+	AgentFeedbackNegative = "negative"
+)
+
+// AgentSession is a teacher's conversation with the Lumen agent
+type AgentSession struct {
+	ID               primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	TeacherID        string             `bson:"teacherId" json:"teacherId"`
+	Title            string             `bson:"title" json:"title"`
+	ServiceSessionID string             `bson:"serviceSessionId,omitempty" json:"-"` // Session ID the AI service uses to keep the conversation context
+	MessageCount     int64              `bson:"messageCount" json:"messageCount"`
+	LastMessageAt    time.Time          `bson:"lastMessageAt" json:"lastMessageAt"`
+	CreatedAt        time.Time          `bson:"createdAt" json:"createdAt"`
+	UpdatedAt        time.Time          `bson:"updatedAt" json:"updatedAt"`
+}
+
+// AgentMessage is a single teacher prompt or agent reply within a session
+type AgentMessage struct {
+	ID           primitive.ObjectID     `bson:"_id,omitempty" json:"id"`
+	SessionID    primitive.ObjectID     `bson:"sessionId" json:"sessionId"`
+	TeacherID    string                 `bson:"teacherId" json:"teacherId"`
+	Role         string                 `bson:"role" json:"role"`
+	Content      string                 `bson:"content" json:"content"`
+	AgentName    string                 `bson:"agentName,omitempty" json:"agentName,omitempty"`
+	PayloadType  string                 `bson:"payloadType,omitempty" json:"payloadType,omitempty"`
+	Data         map[string]interface{} `bson:"data,omitempty" json:"data,omitempty"` // Structured result of the agent reply, e.g. a generated assignment
+	ResponseTime string                 `bson:"responseTime,omitempty" json:"responseTime,omitempty"`
+	Feedback     string                 `bson:"feedback,omitempty" json:"feedback,omitempty"`
+	CreatedAt    time.Time              `bson:"createdAt" json:"createdAt"`
+}
+
+// NewAgentSession creates a new empty session for a teacher
+func NewAgentSession(teacherID, title string) *AgentSession {
+	now := time.Now()
+	return &AgentSession{
+		TeacherID:     teacherID,
+		Title:         title,
+		LastMessageAt: now,
+		CreatedAt:     now,
+		UpdatedAt:     now,
+	}
+}
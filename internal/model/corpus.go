@@ -0,0 +1,81 @@
+package model
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// CorpusStatus is the lifecycle state of a RAG corpus
+type CorpusStatus string
+
+const (
+	CorpusStatusActive       CorpusStatus = "active"        // Corpus accepts documents and queries
+	CorpusStatusDeleting     CorpusStatus = "deleting"      // Cascade delete is queued or running
+	CorpusStatusDeleteFailed CorpusStatus = "delete_failed" // Cascade delete stopped; see ErrorMsg
+)
+
+// CorpusChunking holds the chunking settings applied when documents are imported into a corpus.
+// Zero values leave the choice to the RAG engine defaults.
+type CorpusChunking struct {
+	ChunkSize    int64 `bson:"chunkSize,omitempty" json:"chunkSize,omitempty"`
+	ChunkOverlap int64 `bson:"chunkOverlap,omitempty" json:"chunkOverlap,omitempty"`
+}
+
+// IsZero reports whether no chunking settings were configured
+func (c CorpusChunking) IsZero() bool {
+	return c.ChunkSize == 0 && c.ChunkOverlap == 0
+}
+
+// Corpus mirrors a Vertex AI RAG corpus together with its ownership, settings and usage
+type Corpus struct {
+	ID            primitive.ObjectID `bson:"_id,omitempty" json:"id,omitempty"`
+	Name          string             `bson:"name" json:"name"`                                         // Corpus name used by the API and stored on documents
+	DisplayName   string             `bson:"displayName" json:"displayName"`                           // Human readable name shown to users
+	ResourceName  string             `bson:"resourceName,omitempty" json:"resourceName,omitempty"`     // Vertex AI resource name (projects/.../ragCorpora/...)
+	OwnerTeacher  string             `bson:"ownerTeacherId,omitempty" json:"ownerTeacherId,omitempty"` // Teacher who created the corpus
+	Description   string             `bson:"description,omitempty" json:"description,omitempty"`
+	ClassroomIDs  []string           `bson:"classroomIds" json:"classroomIds"` // Classrooms whose students may query the corpus (read-only)
+	Chunking      CorpusChunking     `bson:"chunking" json:"chunking"`
+	DocumentCount int64              `bson:"documentCount" json:"documentCount"`
+	TotalBytes    int64              `bson:"totalBytes" json:"totalBytes"`
+	Status        CorpusStatus       `bson:"status" json:"status"`
+	ErrorMsg      string             `bson:"errorMsg,omitempty" json:"errorMsg,omitempty"` // Error message if a cascade delete failed
+	CreatedAt     time.Time          `bson:"createdAt" json:"createdAt"`
+	UpdatedAt     time.Time          `bson:"updatedAt" json:"updatedAt"`
+}
+
+// NewCorpus creates a new active Corpus with default values
+func NewCorpus(name, ownerTeacher, description string, chunking CorpusChunking) *Corpus {
+	now := time.Now()
+	return &Corpus{
+		Name:         name,
+		DisplayName:  name,
+		OwnerTeacher: ownerTeacher,
+		Description:  description,
+		Chunking:     chunking,
+		ClassroomIDs: make([]string, 0),
+		Status:       CorpusStatusActive,
+		CreatedAt:    now,
+		UpdatedAt:    now,
+	}
+}
+
+// IsSharedWith reports whether the corpus is shared with any of the given classrooms
+func (c *Corpus) IsSharedWith(classroomIDs []string) bool {
+	for _, shared := range c.ClassroomIDs {
+		for _, classroomID := range classroomIDs {
+			if shared == classroomID {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// CorpusStats summarises the documents stored in a corpus
+type CorpusStats struct {
+	DocumentCount int64                    `json:"documentCount"`
+	TotalBytes    int64                    `json:"totalBytes"`
+	ByStatus      map[DocumentStatus]int64 `json:"byStatus"`
+}
@@ -0,0 +1,39 @@
+package model
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// DeadLetterCleanupAction is one cleanup step run for a dead-lettered task
+type DeadLetterCleanupAction struct {
+	Action string `bson:"action" json:"action"`                     // What was cleaned up, e.g. mark_failed, delete_temp_object, delete_rag_file
+	Target string `bson:"target,omitempty" json:"target,omitempty"` // Document, object or RAG file acted on
+	Error  string `bson:"error,omitempty" json:"error,omitempty"`   // Why the action failed; empty when it succeeded
+}
+
+// DeadLetter records a background task that failed permanently and was archived by asynq
+type DeadLetter struct {
+	ID        primitive.ObjectID        `bson:"_id,omitempty" json:"id,omitempty"`
+	TaskID    string                    `bson:"taskId" json:"taskId"`
+	TaskType  string                    `bson:"taskType" json:"taskType"`
+	Queue     string                    `bson:"queue" json:"queue"`
+	FileID    string                    `bson:"fileId,omitempty" json:"fileId,omitempty"` // Document the task ingested, if any
+	Payload   string                    `bson:"payload" json:"payload"`
+	Error     string                    `bson:"error" json:"error"` // Error of the final run
+	Retried   int                       `bson:"retried" json:"retried"`
+	MaxRetry  int                       `bson:"maxRetry" json:"maxRetry"`
+	Cleanup   []DeadLetterCleanupAction `bson:"cleanup" json:"cleanup"`
+	CreatedAt time.Time                 `bson:"createdAt" json:"createdAt"`
+}
+
+// CleanupFailed reports whether any cleanup action failed, leaving work for an operator
+func (d *DeadLetter) CleanupFailed() bool {
+	for _, action := range d.Cleanup {
+		if action.Error != "" {
+			return true
+		}
+	}
+	return false
+}
@@ -1,11 +1,122 @@
 package model
 
 import (
+	"path/filepath"
+	"strings"
 	"time"
 
 	"go.mongodb.org/mongo-driver/bson/primitive"
 )
 
+// DocumentStatus is a state of the document ingestion state machine
+type DocumentStatus string
+
+const (
+	DocumentStatusUploaded   DocumentStatus = "uploaded"   // File stored in GCS under its temporary name
+	DocumentStatusImporting  DocumentStatus = "importing"  // RAG import request is being submitted
+	DocumentStatusIndexing   DocumentStatus = "indexing"   // Waiting for the RAG import operation and resolving the RAG file ID
+	DocumentStatusFinalizing DocumentStatus = "finalizing" // Moving the GCS object to its final name
+	DocumentStatusReady      DocumentStatus = "ready"      // Document is fully ingested and searchable
+	DocumentStatusFailed     DocumentStatus = "failed"     // Processing stopped at FailedStep
+
+	// Legacy values written before the state machine was introduced
+	documentStatusLegacyPending   DocumentStatus = "pending"
+	documentStatusLegacyCompleted DocumentStatus = "completed"
+)
+
+// DocumentProcessingSteps lists the resumable processing steps in execution order
+var DocumentProcessingSteps = []DocumentStatus{
+	DocumentStatusImporting,
+	DocumentStatusIndexing,
+	DocumentStatusFinalizing,
+}
+
+// documentProgressOrder is the full path a successful ingestion walks through
+var documentProgressOrder = []DocumentStatus{
+	DocumentStatusUploaded,
+	DocumentStatusImporting,
+	DocumentStatusIndexing,
+	DocumentStatusFinalizing,
+	DocumentStatusReady,
+}
+
+// documentTransitions maps each state to the states it may move to
+var documentTransitions = map[DocumentStatus][]DocumentStatus{
+	DocumentStatusUploaded:   {DocumentStatusImporting, DocumentStatusFailed},
+	DocumentStatusImporting:  {DocumentStatusImporting, DocumentStatusIndexing, DocumentStatusFailed},
+	DocumentStatusIndexing:   {DocumentStatusIndexing, DocumentStatusFinalizing, DocumentStatusFailed},
+	DocumentStatusFinalizing: {DocumentStatusFinalizing, DocumentStatusReady, DocumentStatusFailed},
+	DocumentStatusFailed:     {DocumentStatusImporting, DocumentStatusIndexing, DocumentStatusFinalizing, DocumentStatusFailed},
+	DocumentStatusReady:      {},
+}
+
+// Normalize maps legacy status values onto the state machine
+func (s DocumentStatus) Normalize() DocumentStatus {
+	switch s {
+	case documentStatusLegacyPending, "":
+		return DocumentStatusUploaded
+	case documentStatusLegacyCompleted:
+		return DocumentStatusReady
+	}
+	return s
+}
+
+// IsValid reports whether s is a known state
+func (s DocumentStatus) IsValid() bool {
+	_, ok := documentTransitions[s]
+	return ok
+}
+
+// IsProcessingStep reports whether s is a step the ingestion task can resume from
+func (s DocumentStatus) IsProcessingStep() bool {
+	for _, step := range DocumentProcessingSteps {
+		if s == step {
+			return true
+		}
+	}
+	return false
+}
+
+// CanTransitionTo reports whether the state machine allows moving from s to next
+func (s DocumentStatus) CanTransitionTo(next DocumentStatus) bool {
+	for _, allowed := range documentTransitions[s.Normalize()] {
+		if allowed == next {
+			return true
+		}
+	}
+	return false
+}
+
+// DocumentStatusPredecessors returns every state (including legacy values) that may move to next
+func DocumentStatusPredecessors(next DocumentStatus) []DocumentStatus {
+	var predecessors []DocumentStatus
+	for from := range documentTransitions {
+		if from.CanTransitionTo(next) {
+			predecessors = append(predecessors, from)
+		}
+	}
+	if DocumentStatusUploaded.CanTransitionTo(next) {
+		predecessors = append(predecessors, documentStatusLegacyPending)
+	}
+	return predecessors
+}
+
+// DocumentStatusTransition records a single state change of a document
+type DocumentStatusTransition struct {
+	Status  DocumentStatus `bson:"status" json:"status"`
+	Attempt int            `bson:"attempt" json:"attempt"`
+	Message string         `bson:"message,omitempty" json:"message,omitempty"`
+	At      time.Time      `bson:"at" json:"at"`
+}
+
+// DocumentProgress summarises how far a document got through ingestion
+type DocumentProgress struct {
+	Step       int            `json:"step"`
+	TotalSteps int            `json:"totalSteps"`
+	Percent    int            `json:"percent"`
+	Current    DocumentStatus `json:"current"`
+}
+
 // Document represents a document stored in GCS and referenced in RAG corpus
 type Document struct {
 	ID          primitive.ObjectID `bson:"_id,omitempty" json:"id,omitempty"`
@@ -22,30 +133,90 @@ type Document struct {
 	UpdatedAt   time.Time          `bson:"updatedAt" json:"updatedAt"`
 
 	// Async processing fields
-	Status   string `bson:"status" json:"status"`                         // "pending", "completed", or "failed"
-	ErrorMsg string `bson:"errorMsg,omitempty" json:"errorMsg,omitempty"` // Error message if processing failed
+	Status          DocumentStatus             `bson:"status" json:"status"`                                       // Current state of the ingestion state machine
+	FailedStep      DocumentStatus             `bson:"failedStep,omitempty" json:"failedStep,omitempty"`           // Step that failed when Status is "failed"
+	ErrorMsg        string                     `bson:"errorMsg,omitempty" json:"errorMsg,omitempty"`               // Error message if processing failed
+	Attempts        int                        `bson:"attempts" json:"attempts"`                                   // Number of processing runs started
+	QueuedAttempt   int                        `bson:"queuedAttempt" json:"queuedAttempt"`                         // Attempt of the ingestion task that owns the document; runs of older tasks are dropped
+	TempObjectName  string                     `bson:"tempObjectName,omitempty" json:"tempObjectName,omitempty"`   // GCS object the file was uploaded to
+	ImportOperation string                     `bson:"importOperation,omitempty" json:"importOperation,omitempty"` // Vertex AI import operation name
+	StatusHistory   []DocumentStatusTransition `bson:"statusHistory,omitempty" json:"statusHistory,omitempty"`     // Every state change with timestamps
 }
 
 // NewDocument creates a new Document with default values
 func NewDocument(fileID, displayName, gcsBucket, gcsObject, contentType, corpusName, ragFileID, uploadedBy string, size int64) *Document {
 	now := time.Now()
 	return &Document{
-		FileID:      fileID,
-		DisplayName: displayName,
-		GCSBucket:   gcsBucket,
-		GCSObject:   gcsObject,
-		ContentType: contentType,
-		Size:        size,
-		CorpusName:  corpusName,
-		RAGFileID:   ragFileID,
-		UploadedBy:  uploadedBy,
-		CreatedAt:   now,
-		UpdatedAt:   now,
-		Status:      "pending", // Default status for async processing
-		ErrorMsg:    "",        // Empty error message initially
+		FileID:         fileID,
+		DisplayName:    displayName,
+		GCSBucket:      gcsBucket,
+		GCSObject:      gcsObject,
+		ContentType:    contentType,
+		Size:           size,
+		CorpusName:     corpusName,
+		RAGFileID:      ragFileID,
+		UploadedBy:     uploadedBy,
+		CreatedAt:      now,
+		UpdatedAt:      now,
+		Status:         DocumentStatusUploaded, // Initial state for async processing
+		ErrorMsg:       "",                     // Empty error message initially
+		TempObjectName: gcsObject,
+		StatusHistory: []DocumentStatusTransition{
+			{Status: DocumentStatusUploaded, At: now},
+		},
+	}
+}
+
+// Progress reports the position of the document in the ingestion pipeline.
+// Failed documents report the step they failed at.
+func (d *Document) Progress() DocumentProgress {
+	current := d.Status.Normalize()
+	position := current
+	if current == DocumentStatusFailed {
+		position = d.FailedStep
+	}
+
+	step := 0
+	for i, status := range documentProgressOrder {
+		if status == position {
+			step = i
+			break
+		}
+	}
+
+	totalSteps := len(documentProgressOrder) - 1
+	return DocumentProgress{
+		Step:       step,
+		TotalSteps: totalSteps,
+		Percent:    step * 100 / totalSteps,
+		Current:    current,
 	}
 }
 
+// ResumeStep returns the processing step a new run should start from
+func (d *Document) ResumeStep() DocumentStatus {
+	switch status := d.Status.Normalize(); {
+	case status == DocumentStatusFailed && d.FailedStep.IsProcessingStep():
+		return d.FailedStep
+	case status.IsProcessingStep():
+		// A previous run stopped without recording a failure (crash, timeout)
+		return status
+	default:
+		return DocumentStatusImporting
+	}
+}
+
+// MatchesRAGFile checks if the document is the one a RAG file named ragDisplayName was imported from
+func (d *Document) MatchesRAGFile(ragDisplayName string) bool {
+	// Try multiple matching strategies
+	return d.DisplayName == ragDisplayName ||
+		strings.Contains(ragDisplayName, d.DisplayName) ||
+		strings.Contains(d.DisplayName, ragDisplayName) ||
+		// Handle case where one has extension and other doesn't
+		strings.HasPrefix(ragDisplayName, strings.TrimSuffix(d.DisplayName, filepath.Ext(d.DisplayName))) ||
+		strings.HasPrefix(d.DisplayName, strings.TrimSuffix(ragDisplayName, filepath.Ext(ragDisplayName)))
+}
+
 // DocumentMetadata represents minimal document information for listings
 type DocumentMetadata struct {
 	FileID      string    `json:"fileId"`
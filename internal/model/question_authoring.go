@@ -0,0 +1,160 @@
+package model
+
+import (
+	"time"
+
+	"lumenslate/internal/model/questions"
+
+	"github.com/google/uuid"
+)
+
+// Question authoring states. A draft is queued until the pipeline picks it up and ready once
+// the question, its variables and the optional context have been created for review.
+const (
+	QuestionAuthoringStatusQueued     = "queued"
+	QuestionAuthoringStatusProcessing = "processing"
+	QuestionAuthoringStatusReady      = "ready"
+	QuestionAuthoringStatusFailed     = "failed"
+	QuestionAuthoringStatusAccepted   = "accepted"
+	QuestionAuthoringStatusRejected   = "rejected"
+)
+
+// Steps of the question authoring pipeline, in the order they run
+const (
+	QuestionAuthoringStepSegment         = "segment"
+	QuestionAuthoringStepDetectVariables = "detect_variables"
+	QuestionAuthoringStepSaveVariables   = "save_variables"
+	QuestionAuthoringStepCreateQuestion  = "create_question"
+	QuestionAuthoringStepGenerateContext = "generate_context"
+)
+
+// Question types the authoring pipeline can create
+const (
+	AuthoredQuestionMCQ        = "mcq"
+	AuthoredQuestionMSQ        = "msq"
+	AuthoredQuestionNAT        = "nat"
+	AuthoredQuestionSubjective = "subjective"
+)
+
+// QuestionAuthoring is a pasted question taken through segmentation, variable detection and context
+// generation. The pipeline stores the variables and an inactive question linked to them; the teacher
+// reviews the result and accepts it, which activates the question, or rejects it, which removes both.
+type QuestionAuthoring struct {
+	ID           string `json:"id" bson:"_id"`
+	TeacherID    string `json:"teacherId" bson:"teacherId"`
+	BankID       string `json:"bankId" bson:"bankId"`
+	QuestionType string `json:"questionType" bson:"questionType"`
+	RawQuestion  string `json:"rawQuestion" bson:"rawQuestion"`
+
+	// Answer and grading details copied onto the created question
+	Options         []string `json:"options,omitempty" bson:"options,omitempty"`
+	AnswerIndex     int      `json:"answerIndex" bson:"answerIndex"`
+	AnswerIndices   []int    `json:"answerIndices,omitempty" bson:"answerIndices,omitempty"`
+	Answer          float64  `json:"answer" bson:"answer"`
+	IdealAnswer     *string  `json:"idealAnswer,omitempty" bson:"idealAnswer,omitempty"`
+	GradingCriteria []string `json:"gradingCriteria,omitempty" bson:"gradingCriteria,omitempty"`
+	Points          int      `json:"points" bson:"points"`
+	Difficulty      string   `json:"difficulty" bson:"difficulty"`
+	Subject         string   `json:"subject" bson:"subject"`
+
+	GenerateContext bool     `json:"generateContext" bson:"generateContext"`
+	Keywords        []string `json:"keywords,omitempty" bson:"keywords,omitempty"`
+	Language        string   `json:"language,omitempty" bson:"language,omitempty"`
+
+	// Pipeline results
+	SegmentedQuestion      string     `json:"segmentedQuestion,omitempty" bson:"segmentedQuestion,omitempty"`
+	Variables              []Variable `json:"variables" bson:"variables"`
+	VariablesSaved         bool       `json:"variablesSaved" bson:"variablesSaved"`
+	QuestionID             string     `json:"questionId" bson:"questionId"`
+	QuestionCreated        bool       `json:"questionCreated" bson:"questionCreated"`
+	Context                string     `json:"context,omitempty" bson:"context,omitempty"`
+	ContextualizedQuestion string     `json:"contextualizedQuestion,omitempty" bson:"contextualizedQuestion,omitempty"`
+
+	Status      string     `json:"status" bson:"status"`
+	Step        string     `json:"step,omitempty" bson:"step,omitempty"` // Step the last run failed at
+	TaskID      string     `json:"taskId,omitempty" bson:"taskId,omitempty"`
+	Attempts    int        `json:"attempts" bson:"attempts"`
+	LastError   string     `json:"lastError,omitempty" bson:"lastError,omitempty"`
+	UsedContext bool       `json:"usedContext" bson:"usedContext"` // Accepted with the contextualized question text
+	ReviewedAt  *time.Time `json:"reviewedAt,omitempty" bson:"reviewedAt,omitempty"`
+	CreatedAt   time.Time  `json:"createdAt" bson:"createdAt"`
+	UpdatedAt   time.Time  `json:"updatedAt" bson:"updatedAt"`
+}
+
+// NewQuestionAuthoring creates a queued authoring draft. The question ID is chosen up front so a
+// retried pipeline run creates the same question instead of a second one.
+func NewQuestionAuthoring(teacherID, bankID, questionType, rawQuestion string) *QuestionAuthoring {
+	now := time.Now()
+	return &QuestionAuthoring{
+		ID:           uuid.New().String(),
+		TeacherID:    teacherID,
+		BankID:       bankID,
+		QuestionType: questionType,
+		RawQuestion:  rawQuestion,
+		Variables:    make([]Variable, 0),
+		QuestionID:   uuid.New().String(),
+		Status:       QuestionAuthoringStatusQueued,
+		CreatedAt:    now,
+		UpdatedAt:    now,
+	}
+}
+
+// QuestionText is the text the created question and the variable positions refer to
+func (a *QuestionAuthoring) QuestionText() string {
+	if a.SegmentedQuestion != "" {
+		return a.SegmentedQuestion
+	}
+	return a.RawQuestion
+}
+
+// VariableIDs lists the IDs of the detected variables
+func (a *QuestionAuthoring) VariableIDs() []string {
+	ids := make([]string, len(a.Variables))
+	for i, v := range a.Variables {
+		ids[i] = v.ID
+	}
+	return ids
+}
+
+// BuildQuestion builds the inactive question the pipeline stores, as a *questions.MCQ, *questions.MSQ,
+// *questions.NAT or *questions.Subjective. It returns nil for an unknown question type.
+func (a *QuestionAuthoring) BuildQuestion() interface{} {
+	switch a.QuestionType {
+	case AuthoredQuestionMCQ:
+		q := questions.NewMCQ()
+		q.ID, q.BankID, q.Question = a.QuestionID, a.BankID, a.QuestionText()
+		q.VariableIDs = a.VariableIDs()
+		q.Points, q.Difficulty, q.Subject = a.Points, a.Difficulty, a.Subject
+		q.Options, q.AnswerIndex = a.Options, a.AnswerIndex
+		q.IsActive = false
+		return q
+	case AuthoredQuestionMSQ:
+		q := questions.NewMSQ()
+		q.ID, q.BankID, q.Question = a.QuestionID, a.BankID, a.QuestionText()
+		q.VariableIDs = a.VariableIDs()
+		q.Points, q.Difficulty, q.Subject = a.Points, a.Difficulty, a.Subject
+		q.Options, q.AnswerIndices = a.Options, a.AnswerIndices
+		q.IsActive = false
+		return q
+	case AuthoredQuestionNAT:
+		q := questions.NewNAT()
+		q.ID, q.BankID, q.Question = a.QuestionID, a.BankID, a.QuestionText()
+		q.VariableIDs = a.VariableIDs()
+		q.Points, q.Difficulty, q.Subject = a.Points, a.Difficulty, a.Subject
+		q.Answer = a.Answer
+		q.IsActive = false
+		return q
+	case AuthoredQuestionSubjective:
+		q := questions.NewSubjective()
+		q.ID, q.BankID, q.Question = a.QuestionID, a.BankID, a.QuestionText()
+		q.VariableIDs = a.VariableIDs()
+		q.Points, q.Difficulty, q.Subject = a.Points, a.Difficulty, a.Subject
+		q.IdealAnswer = a.IdealAnswer
+		if a.GradingCriteria != nil {
+			q.GradingCriteria = a.GradingCriteria
+		}
+		q.IsActive = false
+		return q
+	}
+	return nil
+}
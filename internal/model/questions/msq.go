@@ -17,6 +17,11 @@ type MSQ struct {
 	CreatedAt     time.Time `json:"createdAt" bson:"createdAt"`
 	UpdatedAt     time.Time `json:"updatedAt" bson:"updatedAt"`
 	IsActive      bool      `json:"isActive" bson:"isActive"`
+
+	// Lineage of AI-generated variations
+	ParentQuestionID string `json:"parentQuestionId,omitempty" bson:"parentQuestionId,omitempty"` // Question this one was generated from
+	Generator        string `json:"generator,omitempty" bson:"generator,omitempty"`               // Generator that produced it, e.g. GenerateMCQVariations
+	GenerationPrompt string `json:"generationPrompt,omitempty" bson:"generationPrompt,omitempty"` // Input the generator was given
 }
 
 // NewMSQ creates a new MSQ with default values
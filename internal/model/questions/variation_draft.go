@@ -0,0 +1,90 @@
+package questions
+
+import (
+	"strings"
+	"time"
+)
+
+// Review states of a variation draft
+const (
+	VariationDraftStatusDraft     = "draft"
+	VariationDraftStatusAccepting = "accepting" // Claimed by an accept while its question is saved
+	VariationDraftStatusAccepted  = "accepted"
+	VariationDraftStatusRejected  = "rejected"
+)
+
+// Question types a variation draft can have
+const (
+	VariationTypeMCQ = "mcq"
+	VariationTypeMSQ = "msq"
+)
+
+// Generators recorded in the lineage of AI-generated questions
+const (
+	GeneratorMCQVariations = "GenerateMCQVariations"
+	GeneratorMSQVariations = "GenerateMSQVariations"
+)
+
+// VariationPrompt renders the question and options a variation generator is given, for the lineage record
+func VariationPrompt(question string, options []string) string {
+	return question + "\nOptions: " + strings.Join(options, " | ")
+}
+
+// VariationDraft is an AI-generated variation of a stored MCQ or MSQ awaiting the teacher's review.
+// Accepting it saves it as a question in the parent's bank with the same ID.
+type VariationDraft struct {
+	ID               string     `json:"id" bson:"_id"`
+	TeacherID        string     `json:"teacherId" bson:"teacherId"`
+	QuestionType     string     `json:"questionType" bson:"questionType"`
+	ParentQuestionID string     `json:"parentQuestionId" bson:"parentQuestionId"`
+	BankID           string     `json:"bankId" bson:"bankId"`
+	Generator        string     `json:"generator" bson:"generator"`
+	GenerationPrompt string     `json:"generationPrompt" bson:"generationPrompt"`
+	Question         string     `json:"question" bson:"question"`
+	Options          []string   `json:"options" bson:"options"`
+	AnswerIndex      int        `json:"answerIndex" bson:"answerIndex"`                         // MCQ only
+	AnswerIndices    []int      `json:"answerIndices,omitempty" bson:"answerIndices,omitempty"` // MSQ only
+	Points           int        `json:"points" bson:"points"`
+	Difficulty       string     `json:"difficulty" bson:"difficulty"`
+	Subject          string     `json:"subject" bson:"subject"`
+	Edited           bool       `json:"edited" bson:"edited"`
+	Status           string     `json:"status" bson:"status"`
+	ReviewedBy       string     `json:"reviewedBy,omitempty" bson:"reviewedBy,omitempty"`
+	ReviewedAt       *time.Time `json:"reviewedAt,omitempty" bson:"reviewedAt,omitempty"`
+	CreatedAt        time.Time  `json:"createdAt" bson:"createdAt"`
+	UpdatedAt        time.Time  `json:"updatedAt" bson:"updatedAt"`
+}
+
+// ToMCQ builds the MCQ an accepted MCQ draft is saved as
+func (d *VariationDraft) ToMCQ() *MCQ {
+	m := NewMCQ()
+	m.ID = d.ID
+	m.BankID = d.BankID
+	m.Question = d.Question
+	m.Options = d.Options
+	m.AnswerIndex = d.AnswerIndex
+	m.Points = d.Points
+	m.Difficulty = d.Difficulty
+	m.Subject = d.Subject
+	m.ParentQuestionID = d.ParentQuestionID
+	m.Generator = d.Generator
+	m.GenerationPrompt = d.GenerationPrompt
+	return m
+}
+
+// ToMSQ builds the MSQ an accepted MSQ draft is saved as
+func (d *VariationDraft) ToMSQ() *MSQ {
+	m := NewMSQ()
+	m.ID = d.ID
+	m.BankID = d.BankID
+	m.Question = d.Question
+	m.Options = d.Options
+	m.AnswerIndices = d.AnswerIndices
+	m.Points = d.Points
+	m.Difficulty = d.Difficulty
+	m.Subject = d.Subject
+	m.ParentQuestionID = d.ParentQuestionID
+	m.Generator = d.Generator
+	m.GenerationPrompt = d.GenerationPrompt
+	return m
+}
@@ -0,0 +1,74 @@
+package model
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// DriftKind classifies a mismatch between documents, object storage and RAG corpora
+type DriftKind string
+
+const (
+	DriftMissingObject   DriftKind = "missing_object"    // Document whose stored file is in neither its temp nor its final location
+	DriftOrphanedObject  DriftKind = "orphaned_object"   // Stored document file no document refers to
+	DriftOrphanedRAGFile DriftKind = "orphaned_rag_file" // RAG file no document refers to
+	DriftStaleStatus     DriftKind = "stale_status"      // Document stuck in a processing step with no progress
+)
+
+// DriftKinds lists every kind of drift the reconciliation detects
+var DriftKinds = []DriftKind{
+	DriftMissingObject,
+	DriftOrphanedObject,
+	DriftOrphanedRAGFile,
+	DriftStaleStatus,
+}
+
+// IsValid reports whether k is a known drift kind
+func (k DriftKind) IsValid() bool {
+	for _, kind := range DriftKinds {
+		if k == kind {
+			return true
+		}
+	}
+	return false
+}
+
+// Drift is one mismatch found by a reconciliation run
+type Drift struct {
+	Kind        DriftKind `bson:"kind" json:"kind"`
+	Corpus      string    `bson:"corpus,omitempty" json:"corpus,omitempty"`
+	FileID      string    `bson:"fileId,omitempty" json:"fileId,omitempty"`
+	Target      string    `bson:"target,omitempty" json:"target,omitempty"` // Object or RAG file concerned
+	Detail      string    `bson:"detail" json:"detail"`
+	Repair      string    `bson:"repair,omitempty" json:"repair,omitempty"` // Repair applied, empty when only reported
+	RepairError string    `bson:"repairError,omitempty" json:"repairError,omitempty"`
+}
+
+// ReconciliationReport is the drift report of one reconciliation run
+type ReconciliationReport struct {
+	ID         primitive.ObjectID `bson:"_id,omitempty" json:"id,omitempty"`
+	TaskID     string             `bson:"taskId,omitempty" json:"taskId,omitempty"`
+	Corpus     string             `bson:"corpus,omitempty" json:"corpus,omitempty"` // Only corpus reconciled; empty for every corpus
+	Repair     []DriftKind        `bson:"repair" json:"repair"`                     // Kinds of drift repaired automatically
+	Corpora    int                `bson:"corpora" json:"corpora"`
+	Documents  int                `bson:"documents" json:"documents"`
+	Counts     map[DriftKind]int  `bson:"counts" json:"counts"`
+	Repaired   int                `bson:"repaired" json:"repaired"`
+	Drifts     []Drift            `bson:"drifts" json:"drifts"`
+	Errors     []string           `bson:"errors,omitempty" json:"errors,omitempty"` // Checks that could not run
+	StartedAt  time.Time          `bson:"startedAt" json:"startedAt"`
+	FinishedAt time.Time          `bson:"finishedAt" json:"finishedAt"`
+}
+
+// AddDrift records a drift and counts it
+func (r *ReconciliationReport) AddDrift(drift Drift) {
+	if r.Counts == nil {
+		r.Counts = make(map[DriftKind]int)
+	}
+	r.Counts[drift.Kind]++
+	if drift.Repair != "" && drift.RepairError == "" {
+		r.Repaired++
+	}
+	r.Drifts = append(r.Drifts, drift)
+}
@@ -1,6 +1,7 @@
 package model
 
 import (
+	"sort"
 	"time"
 )
 
@@ -81,33 +82,46 @@ func NewSubjectReport() *SubjectReport {
 	}
 }
 
+// subjectMapping maps the spellings of subjects accepted from clients and agents to Subject
+var subjectMapping = map[string]Subject{
+	"math":           SubjectMath,
+	"mathematics":    SubjectMath,
+	"maths":          SubjectMath,
+	"science":        SubjectScience,
+	"biology":        SubjectScience,
+	"chemistry":      SubjectScience,
+	"physics":        SubjectScience,
+	"english":        SubjectEnglish,
+	"language arts":  SubjectEnglish,
+	"literature":     SubjectEnglish,
+	"reading":        SubjectEnglish,
+	"history":        SubjectHistory,
+	"social studies": SubjectHistory,
+	"world history":  SubjectHistory,
+	"geography":      SubjectGeography,
+	"geo":            SubjectGeography,
+}
+
 // GetSubjectFromString converts subject string to Subject enum, handling various formats
 func GetSubjectFromString(subjectString string) (Subject, bool) {
-	subjectMapping := map[string]Subject{
-		"math":           SubjectMath,
-		"mathematics":    SubjectMath,
-		"maths":          SubjectMath,
-		"science":        SubjectScience,
-		"biology":        SubjectScience,
-		"chemistry":      SubjectScience,
-		"physics":        SubjectScience,
-		"english":        SubjectEnglish,
-		"language arts":  SubjectEnglish,
-		"literature":     SubjectEnglish,
-		"reading":        SubjectEnglish,
-		"history":        SubjectHistory,
-		"social studies": SubjectHistory,
-		"world history":  SubjectHistory,
-		"geography":      SubjectGeography,
-		"geo":            SubjectGeography,
-	}
-
 	if subject, exists := subjectMapping[subjectString]; exists {
 		return subject, true
 	}
 	return "", false
 }
 
+// SubjectAliases returns every spelling that GetSubjectFromString maps to subject, sorted
+func SubjectAliases(subject Subject) []string {
+	aliases := []string{}
+	for alias, mapped := range subjectMapping {
+		if mapped == subject {
+			aliases = append(aliases, alias)
+		}
+	}
+	sort.Strings(aliases)
+	return aliases
+}
+
 // Questions model to match the SQLite structure
 type Questions struct {
 	ID         string     `json:"id,omitempty" bson:"_id" validate:"omitempty"`
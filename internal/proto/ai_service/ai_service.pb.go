@@ -1,6 +1,6 @@
 // Code generated by protoc-gen-go. DO NOT EDIT.
 // versions:
-// 	protoc-gen-go v1.36.7
+// 	protoc-gen-go v1.36.8
 // 	protoc        v5.27.1
 // source: internal/proto/ai_service.proto
 
@@ -913,6 +913,8 @@ func (x *VariableFilter) GetOptions() []string {
 }
 
 // --- /agent_service.proto ---
+// To continue a conversation, the gateway sends the session_id of an earlier AgentResponse
+// in the "x-session-id" request metadata.
 type AgentRequest struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
 	File          string                 `protobuf:"bytes,1,opt,name=file,proto3" json:"file,omitempty"`
@@ -1005,18 +1007,36 @@ func (x *AgentRequest) GetUpdatedAt() string {
 	return ""
 }
 
+// The agent's reply is carried in the typed payload oneof, tagged with the schema_version it
+// was written against. Agents that predate the typed payload send agent_response instead,
+// either as a versioned JSON envelope:
+//
+//	{"schema_version": 1, "type": "<payload type>", "payload": {...}}
+//
+// where type is one of question_request, assessment, assignment_result, report_card or text,
+// or in the unversioned format (plain text or top-level payload keys). The gateway only reads
+// agent_response when no payload is set.
 type AgentResponse struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
 	Message       string                 `protobuf:"bytes,1,opt,name=message,proto3" json:"message,omitempty"` // generic success or error message
 	TeacherId     string                 `protobuf:"bytes,2,opt,name=teacherId,proto3" json:"teacherId,omitempty"`
 	AgentName     string                 `protobuf:"bytes,3,opt,name=agent_name,json=agentName,proto3" json:"agent_name,omitempty"`
-	AgentResponse string                 `protobuf:"bytes,4,opt,name=agent_response,json=agentResponse,proto3" json:"agent_response,omitempty"` // structured JSON as string
+	AgentResponse string                 `protobuf:"bytes,4,opt,name=agent_response,json=agentResponse,proto3" json:"agent_response,omitempty"` // legacy: structured JSON as string
 	SessionId     string                 `protobuf:"bytes,5,opt,name=session_id,json=sessionId,proto3" json:"session_id,omitempty"`
 	CreatedAt     string                 `protobuf:"bytes,6,opt,name=createdAt,proto3" json:"createdAt,omitempty"`
 	UpdatedAt     string                 `protobuf:"bytes,7,opt,name=updatedAt,proto3" json:"updatedAt,omitempty"`
 	ResponseTime  string                 `protobuf:"bytes,8,opt,name=response_time,json=responseTime,proto3" json:"response_time,omitempty"`
-	Role          string                 `protobuf:"bytes,9,opt,name=role,proto3" json:"role,omitempty"`          // teacher, student
-	Feedback      string                 `protobuf:"bytes,10,opt,name=feedback,proto3" json:"feedback,omitempty"` // positive, negative, default=null
+	Role          string                 `protobuf:"bytes,9,opt,name=role,proto3" json:"role,omitempty"`                                          // teacher, student
+	Feedback      string                 `protobuf:"bytes,10,opt,name=feedback,proto3" json:"feedback,omitempty"`                                 // positive, negative, default=null
+	SchemaVersion int32                  `protobuf:"varint,11,opt,name=schema_version,json=schemaVersion,proto3" json:"schema_version,omitempty"` // version of the payload schema, required when payload is set
+	// Types that are valid to be assigned to Payload:
+	//
+	//	*AgentResponse_QuestionRequest
+	//	*AgentResponse_Assessment
+	//	*AgentResponse_AssignmentResult
+	//	*AgentResponse_ReportCard
+	//	*AgentResponse_Text
+	Payload       isAgentResponse_Payload `protobuf_oneof:"payload"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
@@ -1121,6 +1141,1572 @@ func (x *AgentResponse) GetFeedback() string {
 	return ""
 }
 
+func (x *AgentResponse) GetSchemaVersion() int32 {
+	if x != nil {
+		return x.SchemaVersion
+	}
+	return 0
+}
+
+func (x *AgentResponse) GetPayload() isAgentResponse_Payload {
+	if x != nil {
+		return x.Payload
+	}
+	return nil
+}
+
+func (x *AgentResponse) GetQuestionRequest() *AgentQuestionRequestPayload {
+	if x != nil {
+		if x, ok := x.Payload.(*AgentResponse_QuestionRequest); ok {
+			return x.QuestionRequest
+		}
+	}
+	return nil
+}
+
+func (x *AgentResponse) GetAssessment() *AgentAssessmentPayload {
+	if x != nil {
+		if x, ok := x.Payload.(*AgentResponse_Assessment); ok {
+			return x.Assessment
+		}
+	}
+	return nil
+}
+
+func (x *AgentResponse) GetAssignmentResult() *AgentAssignmentResultPayload {
+	if x != nil {
+		if x, ok := x.Payload.(*AgentResponse_AssignmentResult); ok {
+			return x.AssignmentResult
+		}
+	}
+	return nil
+}
+
+func (x *AgentResponse) GetReportCard() *AgentReportCardPayload {
+	if x != nil {
+		if x, ok := x.Payload.(*AgentResponse_ReportCard); ok {
+			return x.ReportCard
+		}
+	}
+	return nil
+}
+
+func (x *AgentResponse) GetText() *AgentTextPayload {
+	if x != nil {
+		if x, ok := x.Payload.(*AgentResponse_Text); ok {
+			return x.Text
+		}
+	}
+	return nil
+}
+
+type isAgentResponse_Payload interface {
+	isAgentResponse_Payload()
+}
+
+type AgentResponse_QuestionRequest struct {
+	QuestionRequest *AgentQuestionRequestPayload `protobuf:"bytes,12,opt,name=question_request,json=questionRequest,proto3,oneof"`
+}
+
+type AgentResponse_Assessment struct {
+	Assessment *AgentAssessmentPayload `protobuf:"bytes,13,opt,name=assessment,proto3,oneof"`
+}
+
+type AgentResponse_AssignmentResult struct {
+	AssignmentResult *AgentAssignmentResultPayload `protobuf:"bytes,14,opt,name=assignment_result,json=assignmentResult,proto3,oneof"`
+}
+
+type AgentResponse_ReportCard struct {
+	ReportCard *AgentReportCardPayload `protobuf:"bytes,15,opt,name=report_card,json=reportCard,proto3,oneof"`
+}
+
+type AgentResponse_Text struct {
+	Text *AgentTextPayload `protobuf:"bytes,16,opt,name=text,proto3,oneof"`
+}
+
+func (*AgentResponse_QuestionRequest) isAgentResponse_Payload() {}
+
+func (*AgentResponse_Assessment) isAgentResponse_Payload() {}
+
+func (*AgentResponse_AssignmentResult) isAgentResponse_Payload() {}
+
+func (*AgentResponse_ReportCard) isAgentResponse_Payload() {}
+
+func (*AgentResponse_Text) isAgentResponse_Payload() {}
+
+// Asks for an assignment built from questions in the database
+type AgentQuestionRequestPayload struct {
+	state              protoimpl.MessageState  `protogen:"open.v1"`
+	Title              string                  `protobuf:"bytes,1,opt,name=title,proto3" json:"title,omitempty"`
+	Body               string                  `protobuf:"bytes,2,opt,name=body,proto3" json:"body,omitempty"`
+	QuestionsRequested []*AgentQuestionRequest `protobuf:"bytes,3,rep,name=questions_requested,json=questionsRequested,proto3" json:"questions_requested,omitempty"`
+	ClassroomId        string                  `protobuf:"bytes,4,opt,name=classroom_id,json=classroomId,proto3" json:"classroom_id,omitempty"` // avoid questions this classroom has been assigned recently
+	AvoidRecentDays    int32                   `protobuf:"varint,5,opt,name=avoid_recent_days,json=avoidRecentDays,proto3" json:"avoid_recent_days,omitempty"`
+	FillWithVariations bool                    `protobuf:"varint,6,opt,name=fill_with_variations,json=fillWithVariations,proto3" json:"fill_with_variations,omitempty"` // fill MCQ/MSQ shortfalls with AI-generated variations
+	unknownFields      protoimpl.UnknownFields
+	sizeCache          protoimpl.SizeCache
+}
+
+func (x *AgentQuestionRequestPayload) Reset() {
+	*x = AgentQuestionRequestPayload{}
+	mi := &file_internal_proto_ai_service_proto_msgTypes[19]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *AgentQuestionRequestPayload) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*AgentQuestionRequestPayload) ProtoMessage() {}
+
+func (x *AgentQuestionRequestPayload) ProtoReflect() protoreflect.Message {
+	mi := &file_internal_proto_ai_service_proto_msgTypes[19]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use AgentQuestionRequestPayload.ProtoReflect.Descriptor instead.
+func (*AgentQuestionRequestPayload) Descriptor() ([]byte, []int) {
+	return file_internal_proto_ai_service_proto_rawDescGZIP(), []int{19}
+}
+
+func (x *AgentQuestionRequestPayload) GetTitle() string {
+	if x != nil {
+		return x.Title
+	}
+	return ""
+}
+
+func (x *AgentQuestionRequestPayload) GetBody() string {
+	if x != nil {
+		return x.Body
+	}
+	return ""
+}
+
+func (x *AgentQuestionRequestPayload) GetQuestionsRequested() []*AgentQuestionRequest {
+	if x != nil {
+		return x.QuestionsRequested
+	}
+	return nil
+}
+
+func (x *AgentQuestionRequestPayload) GetClassroomId() string {
+	if x != nil {
+		return x.ClassroomId
+	}
+	return ""
+}
+
+func (x *AgentQuestionRequestPayload) GetAvoidRecentDays() int32 {
+	if x != nil {
+		return x.AvoidRecentDays
+	}
+	return 0
+}
+
+func (x *AgentQuestionRequestPayload) GetFillWithVariations() bool {
+	if x != nil {
+		return x.FillWithVariations
+	}
+	return false
+}
+
+type AgentQuestionRequest struct {
+	state             protoimpl.MessageState    `protogen:"open.v1"`
+	Type              string                    `protobuf:"bytes,1,opt,name=type,proto3" json:"type,omitempty"`
+	Subject           string                    `protobuf:"bytes,2,opt,name=subject,proto3" json:"subject,omitempty"`
+	NumberOfQuestions int32                     `protobuf:"varint,3,opt,name=number_of_questions,json=numberOfQuestions,proto3" json:"number_of_questions,omitempty"`
+	Difficulty        string                    `protobuf:"bytes,4,opt,name=difficulty,proto3" json:"difficulty,omitempty"`
+	BankIds           []string                  `protobuf:"bytes,5,rep,name=bank_ids,json=bankIds,proto3" json:"bank_ids,omitempty"`
+	Tags              []string                  `protobuf:"bytes,6,rep,name=tags,proto3" json:"tags,omitempty"`
+	QuestionTypes     []*AgentQuestionTypeCount `protobuf:"bytes,7,rep,name=question_types,json=questionTypes,proto3" json:"question_types,omitempty"`
+	TotalPoints       int32                     `protobuf:"varint,8,opt,name=total_points,json=totalPoints,proto3" json:"total_points,omitempty"`
+	unknownFields     protoimpl.UnknownFields
+	sizeCache         protoimpl.SizeCache
+}
+
+func (x *AgentQuestionRequest) Reset() {
+	*x = AgentQuestionRequest{}
+	mi := &file_internal_proto_ai_service_proto_msgTypes[20]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *AgentQuestionRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*AgentQuestionRequest) ProtoMessage() {}
+
+func (x *AgentQuestionRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_internal_proto_ai_service_proto_msgTypes[20]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use AgentQuestionRequest.ProtoReflect.Descriptor instead.
+func (*AgentQuestionRequest) Descriptor() ([]byte, []int) {
+	return file_internal_proto_ai_service_proto_rawDescGZIP(), []int{20}
+}
+
+func (x *AgentQuestionRequest) GetType() string {
+	if x != nil {
+		return x.Type
+	}
+	return ""
+}
+
+func (x *AgentQuestionRequest) GetSubject() string {
+	if x != nil {
+		return x.Subject
+	}
+	return ""
+}
+
+func (x *AgentQuestionRequest) GetNumberOfQuestions() int32 {
+	if x != nil {
+		return x.NumberOfQuestions
+	}
+	return 0
+}
+
+func (x *AgentQuestionRequest) GetDifficulty() string {
+	if x != nil {
+		return x.Difficulty
+	}
+	return ""
+}
+
+func (x *AgentQuestionRequest) GetBankIds() []string {
+	if x != nil {
+		return x.BankIds
+	}
+	return nil
+}
+
+func (x *AgentQuestionRequest) GetTags() []string {
+	if x != nil {
+		return x.Tags
+	}
+	return nil
+}
+
+func (x *AgentQuestionRequest) GetQuestionTypes() []*AgentQuestionTypeCount {
+	if x != nil {
+		return x.QuestionTypes
+	}
+	return nil
+}
+
+func (x *AgentQuestionRequest) GetTotalPoints() int32 {
+	if x != nil {
+		return x.TotalPoints
+	}
+	return 0
+}
+
+type AgentQuestionTypeCount struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	QuestionType  string                 `protobuf:"bytes,1,opt,name=question_type,json=questionType,proto3" json:"question_type,omitempty"` // mcq, msq, nat, subjective
+	Count         int32                  `protobuf:"varint,2,opt,name=count,proto3" json:"count,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *AgentQuestionTypeCount) Reset() {
+	*x = AgentQuestionTypeCount{}
+	mi := &file_internal_proto_ai_service_proto_msgTypes[21]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *AgentQuestionTypeCount) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*AgentQuestionTypeCount) ProtoMessage() {}
+
+func (x *AgentQuestionTypeCount) ProtoReflect() protoreflect.Message {
+	mi := &file_internal_proto_ai_service_proto_msgTypes[21]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use AgentQuestionTypeCount.ProtoReflect.Descriptor instead.
+func (*AgentQuestionTypeCount) Descriptor() ([]byte, []int) {
+	return file_internal_proto_ai_service_proto_rawDescGZIP(), []int{21}
+}
+
+func (x *AgentQuestionTypeCount) GetQuestionType() string {
+	if x != nil {
+		return x.QuestionType
+	}
+	return ""
+}
+
+func (x *AgentQuestionTypeCount) GetCount() int32 {
+	if x != nil {
+		return x.Count
+	}
+	return 0
+}
+
+// A subject assessment to be saved as a subject report. Scores that were not assessed are unset.
+type AgentAssessmentPayload struct {
+	state                      protoimpl.MessageState `protogen:"open.v1"`
+	StudentId                  *int64                 `protobuf:"varint,1,opt,name=student_id,json=studentId,proto3,oneof" json:"student_id,omitempty"`
+	StudentName                string                 `protobuf:"bytes,2,opt,name=student_name,json=studentName,proto3" json:"student_name,omitempty"`
+	Subject                    string                 `protobuf:"bytes,3,opt,name=subject,proto3" json:"subject,omitempty"`
+	Score                      *float64               `protobuf:"fixed64,4,opt,name=score,proto3,oneof" json:"score,omitempty"`
+	GradeLetter                string                 `protobuf:"bytes,5,opt,name=grade_letter,json=gradeLetter,proto3" json:"grade_letter,omitempty"`
+	ClassName                  string                 `protobuf:"bytes,6,opt,name=class_name,json=className,proto3" json:"class_name,omitempty"`
+	InstructorName             string                 `protobuf:"bytes,7,opt,name=instructor_name,json=instructorName,proto3" json:"instructor_name,omitempty"`
+	Term                       string                 `protobuf:"bytes,8,opt,name=term,proto3" json:"term,omitempty"`
+	Remarks                    string                 `protobuf:"bytes,9,opt,name=remarks,proto3" json:"remarks,omitempty"`
+	MidtermScore               *float64               `protobuf:"fixed64,10,opt,name=midterm_score,json=midtermScore,proto3,oneof" json:"midterm_score,omitempty"`
+	FinalExamScore             *float64               `protobuf:"fixed64,11,opt,name=final_exam_score,json=finalExamScore,proto3,oneof" json:"final_exam_score,omitempty"`
+	QuizScore                  *float64               `protobuf:"fixed64,12,opt,name=quiz_score,json=quizScore,proto3,oneof" json:"quiz_score,omitempty"`
+	AssignmentScore            *float64               `protobuf:"fixed64,13,opt,name=assignment_score,json=assignmentScore,proto3,oneof" json:"assignment_score,omitempty"`
+	PracticalScore             *float64               `protobuf:"fixed64,14,opt,name=practical_score,json=practicalScore,proto3,oneof" json:"practical_score,omitempty"`
+	OralPresentationScore      *float64               `protobuf:"fixed64,15,opt,name=oral_presentation_score,json=oralPresentationScore,proto3,oneof" json:"oral_presentation_score,omitempty"`
+	ConceptualUnderstanding    *float64               `protobuf:"fixed64,16,opt,name=conceptual_understanding,json=conceptualUnderstanding,proto3,oneof" json:"conceptual_understanding,omitempty"`
+	ProblemSolving             *float64               `protobuf:"fixed64,17,opt,name=problem_solving,json=problemSolving,proto3,oneof" json:"problem_solving,omitempty"`
+	KnowledgeApplication       *float64               `protobuf:"fixed64,18,opt,name=knowledge_application,json=knowledgeApplication,proto3,oneof" json:"knowledge_application,omitempty"`
+	AnalyticalThinking         *float64               `protobuf:"fixed64,19,opt,name=analytical_thinking,json=analyticalThinking,proto3,oneof" json:"analytical_thinking,omitempty"`
+	Creativity                 *float64               `protobuf:"fixed64,20,opt,name=creativity,proto3,oneof" json:"creativity,omitempty"`
+	PracticalSkills            *float64               `protobuf:"fixed64,21,opt,name=practical_skills,json=practicalSkills,proto3,oneof" json:"practical_skills,omitempty"`
+	Participation              *float64               `protobuf:"fixed64,22,opt,name=participation,proto3,oneof" json:"participation,omitempty"`
+	Discipline                 *float64               `protobuf:"fixed64,23,opt,name=discipline,proto3,oneof" json:"discipline,omitempty"`
+	Punctuality                *float64               `protobuf:"fixed64,24,opt,name=punctuality,proto3,oneof" json:"punctuality,omitempty"`
+	Teamwork                   *float64               `protobuf:"fixed64,25,opt,name=teamwork,proto3,oneof" json:"teamwork,omitempty"`
+	EffortLevel                *float64               `protobuf:"fixed64,26,opt,name=effort_level,json=effortLevel,proto3,oneof" json:"effort_level,omitempty"`
+	Improvement                *float64               `protobuf:"fixed64,27,opt,name=improvement,proto3,oneof" json:"improvement,omitempty"`
+	LearningObjectivesMastered string                 `protobuf:"bytes,28,opt,name=learning_objectives_mastered,json=learningObjectivesMastered,proto3" json:"learning_objectives_mastered,omitempty"`
+	AreasForImprovement        string                 `protobuf:"bytes,29,opt,name=areas_for_improvement,json=areasForImprovement,proto3" json:"areas_for_improvement,omitempty"`
+	RecommendedResources       string                 `protobuf:"bytes,30,opt,name=recommended_resources,json=recommendedResources,proto3" json:"recommended_resources,omitempty"`
+	TargetGoals                string                 `protobuf:"bytes,31,opt,name=target_goals,json=targetGoals,proto3" json:"target_goals,omitempty"`
+	unknownFields              protoimpl.UnknownFields
+	sizeCache                  protoimpl.SizeCache
+}
+
+func (x *AgentAssessmentPayload) Reset() {
+	*x = AgentAssessmentPayload{}
+	mi := &file_internal_proto_ai_service_proto_msgTypes[22]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *AgentAssessmentPayload) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*AgentAssessmentPayload) ProtoMessage() {}
+
+func (x *AgentAssessmentPayload) ProtoReflect() protoreflect.Message {
+	mi := &file_internal_proto_ai_service_proto_msgTypes[22]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use AgentAssessmentPayload.ProtoReflect.Descriptor instead.
+func (*AgentAssessmentPayload) Descriptor() ([]byte, []int) {
+	return file_internal_proto_ai_service_proto_rawDescGZIP(), []int{22}
+}
+
+func (x *AgentAssessmentPayload) GetStudentId() int64 {
+	if x != nil && x.StudentId != nil {
+		return *x.StudentId
+	}
+	return 0
+}
+
+func (x *AgentAssessmentPayload) GetStudentName() string {
+	if x != nil {
+		return x.StudentName
+	}
+	return ""
+}
+
+func (x *AgentAssessmentPayload) GetSubject() string {
+	if x != nil {
+		return x.Subject
+	}
+	return ""
+}
+
+func (x *AgentAssessmentPayload) GetScore() float64 {
+	if x != nil && x.Score != nil {
+		return *x.Score
+	}
+	return 0
+}
+
+func (x *AgentAssessmentPayload) GetGradeLetter() string {
+	if x != nil {
+		return x.GradeLetter
+	}
+	return ""
+}
+
+func (x *AgentAssessmentPayload) GetClassName() string {
+	if x != nil {
+		return x.ClassName
+	}
+	return ""
+}
+
+func (x *AgentAssessmentPayload) GetInstructorName() string {
+	if x != nil {
+		return x.InstructorName
+	}
+	return ""
+}
+
+func (x *AgentAssessmentPayload) GetTerm() string {
+	if x != nil {
+		return x.Term
+	}
+	return ""
+}
+
+func (x *AgentAssessmentPayload) GetRemarks() string {
+	if x != nil {
+		return x.Remarks
+	}
+	return ""
+}
+
+func (x *AgentAssessmentPayload) GetMidtermScore() float64 {
+	if x != nil && x.MidtermScore != nil {
+		return *x.MidtermScore
+	}
+	return 0
+}
+
+func (x *AgentAssessmentPayload) GetFinalExamScore() float64 {
+	if x != nil && x.FinalExamScore != nil {
+		return *x.FinalExamScore
+	}
+	return 0
+}
+
+func (x *AgentAssessmentPayload) GetQuizScore() float64 {
+	if x != nil && x.QuizScore != nil {
+		return *x.QuizScore
+	}
+	return 0
+}
+
+func (x *AgentAssessmentPayload) GetAssignmentScore() float64 {
+	if x != nil && x.AssignmentScore != nil {
+		return *x.AssignmentScore
+	}
+	return 0
+}
+
+func (x *AgentAssessmentPayload) GetPracticalScore() float64 {
+	if x != nil && x.PracticalScore != nil {
+		return *x.PracticalScore
+	}
+	return 0
+}
+
+func (x *AgentAssessmentPayload) GetOralPresentationScore() float64 {
+	if x != nil && x.OralPresentationScore != nil {
+		return *x.OralPresentationScore
+	}
+	return 0
+}
+
+func (x *AgentAssessmentPayload) GetConceptualUnderstanding() float64 {
+	if x != nil && x.ConceptualUnderstanding != nil {
+		return *x.ConceptualUnderstanding
+	}
+	return 0
+}
+
+func (x *AgentAssessmentPayload) GetProblemSolving() float64 {
+	if x != nil && x.ProblemSolving != nil {
+		return *x.ProblemSolving
+	}
+	return 0
+}
+
+func (x *AgentAssessmentPayload) GetKnowledgeApplication() float64 {
+	if x != nil && x.KnowledgeApplication != nil {
+		return *x.KnowledgeApplication
+	}
+	return 0
+}
+
+func (x *AgentAssessmentPayload) GetAnalyticalThinking() float64 {
+	if x != nil && x.AnalyticalThinking != nil {
+		return *x.AnalyticalThinking
+	}
+	return 0
+}
+
+func (x *AgentAssessmentPayload) GetCreativity() float64 {
+	if x != nil && x.Creativity != nil {
+		return *x.Creativity
+	}
+	return 0
+}
+
+func (x *AgentAssessmentPayload) GetPracticalSkills() float64 {
+	if x != nil && x.PracticalSkills != nil {
+		return *x.PracticalSkills
+	}
+	return 0
+}
+
+func (x *AgentAssessmentPayload) GetParticipation() float64 {
+	if x != nil && x.Participation != nil {
+		return *x.Participation
+	}
+	return 0
+}
+
+func (x *AgentAssessmentPayload) GetDiscipline() float64 {
+	if x != nil && x.Discipline != nil {
+		return *x.Discipline
+	}
+	return 0
+}
+
+func (x *AgentAssessmentPayload) GetPunctuality() float64 {
+	if x != nil && x.Punctuality != nil {
+		return *x.Punctuality
+	}
+	return 0
+}
+
+func (x *AgentAssessmentPayload) GetTeamwork() float64 {
+	if x != nil && x.Teamwork != nil {
+		return *x.Teamwork
+	}
+	return 0
+}
+
+func (x *AgentAssessmentPayload) GetEffortLevel() float64 {
+	if x != nil && x.EffortLevel != nil {
+		return *x.EffortLevel
+	}
+	return 0
+}
+
+func (x *AgentAssessmentPayload) GetImprovement() float64 {
+	if x != nil && x.Improvement != nil {
+		return *x.Improvement
+	}
+	return 0
+}
+
+func (x *AgentAssessmentPayload) GetLearningObjectivesMastered() string {
+	if x != nil {
+		return x.LearningObjectivesMastered
+	}
+	return ""
+}
+
+func (x *AgentAssessmentPayload) GetAreasForImprovement() string {
+	if x != nil {
+		return x.AreasForImprovement
+	}
+	return ""
+}
+
+func (x *AgentAssessmentPayload) GetRecommendedResources() string {
+	if x != nil {
+		return x.RecommendedResources
+	}
+	return ""
+}
+
+func (x *AgentAssessmentPayload) GetTargetGoals() string {
+	if x != nil {
+		return x.TargetGoals
+	}
+	return ""
+}
+
+// The graded result of an assignment submission
+type AgentAssignmentResultPayload struct {
+	state              protoimpl.MessageState   `protogen:"open.v1"`
+	AssignmentId       string                   `protobuf:"bytes,1,opt,name=assignment_id,json=assignmentId,proto3" json:"assignment_id,omitempty"`
+	StudentId          string                   `protobuf:"bytes,2,opt,name=student_id,json=studentId,proto3" json:"student_id,omitempty"`
+	TotalPointsAwarded *float64                 `protobuf:"fixed64,3,opt,name=total_points_awarded,json=totalPointsAwarded,proto3,oneof" json:"total_points_awarded,omitempty"`
+	TotalMaxPoints     *float64                 `protobuf:"fixed64,4,opt,name=total_max_points,json=totalMaxPoints,proto3,oneof" json:"total_max_points,omitempty"`
+	PercentageScore    *float64                 `protobuf:"fixed64,5,opt,name=percentage_score,json=percentageScore,proto3,oneof" json:"percentage_score,omitempty"`
+	McqResults         []*AgentMCQResult        `protobuf:"bytes,6,rep,name=mcq_results,json=mcqResults,proto3" json:"mcq_results,omitempty"`
+	MsqResults         []*AgentMSQResult        `protobuf:"bytes,7,rep,name=msq_results,json=msqResults,proto3" json:"msq_results,omitempty"`
+	NatResults         []*AgentNATResult        `protobuf:"bytes,8,rep,name=nat_results,json=natResults,proto3" json:"nat_results,omitempty"`
+	SubjectiveResults  []*AgentSubjectiveResult `protobuf:"bytes,9,rep,name=subjective_results,json=subjectiveResults,proto3" json:"subjective_results,omitempty"`
+	unknownFields      protoimpl.UnknownFields
+	sizeCache          protoimpl.SizeCache
+}
+
+func (x *AgentAssignmentResultPayload) Reset() {
+	*x = AgentAssignmentResultPayload{}
+	mi := &file_internal_proto_ai_service_proto_msgTypes[23]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *AgentAssignmentResultPayload) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*AgentAssignmentResultPayload) ProtoMessage() {}
+
+func (x *AgentAssignmentResultPayload) ProtoReflect() protoreflect.Message {
+	mi := &file_internal_proto_ai_service_proto_msgTypes[23]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use AgentAssignmentResultPayload.ProtoReflect.Descriptor instead.
+func (*AgentAssignmentResultPayload) Descriptor() ([]byte, []int) {
+	return file_internal_proto_ai_service_proto_rawDescGZIP(), []int{23}
+}
+
+func (x *AgentAssignmentResultPayload) GetAssignmentId() string {
+	if x != nil {
+		return x.AssignmentId
+	}
+	return ""
+}
+
+func (x *AgentAssignmentResultPayload) GetStudentId() string {
+	if x != nil {
+		return x.StudentId
+	}
+	return ""
+}
+
+func (x *AgentAssignmentResultPayload) GetTotalPointsAwarded() float64 {
+	if x != nil && x.TotalPointsAwarded != nil {
+		return *x.TotalPointsAwarded
+	}
+	return 0
+}
+
+func (x *AgentAssignmentResultPayload) GetTotalMaxPoints() float64 {
+	if x != nil && x.TotalMaxPoints != nil {
+		return *x.TotalMaxPoints
+	}
+	return 0
+}
+
+func (x *AgentAssignmentResultPayload) GetPercentageScore() float64 {
+	if x != nil && x.PercentageScore != nil {
+		return *x.PercentageScore
+	}
+	return 0
+}
+
+func (x *AgentAssignmentResultPayload) GetMcqResults() []*AgentMCQResult {
+	if x != nil {
+		return x.McqResults
+	}
+	return nil
+}
+
+func (x *AgentAssignmentResultPayload) GetMsqResults() []*AgentMSQResult {
+	if x != nil {
+		return x.MsqResults
+	}
+	return nil
+}
+
+func (x *AgentAssignmentResultPayload) GetNatResults() []*AgentNATResult {
+	if x != nil {
+		return x.NatResults
+	}
+	return nil
+}
+
+func (x *AgentAssignmentResultPayload) GetSubjectiveResults() []*AgentSubjectiveResult {
+	if x != nil {
+		return x.SubjectiveResults
+	}
+	return nil
+}
+
+type AgentMCQResult struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	QuestionId    string                 `protobuf:"bytes,1,opt,name=question_id,json=questionId,proto3" json:"question_id,omitempty"`
+	StudentAnswer int32                  `protobuf:"varint,2,opt,name=student_answer,json=studentAnswer,proto3" json:"student_answer,omitempty"`
+	CorrectAnswer int32                  `protobuf:"varint,3,opt,name=correct_answer,json=correctAnswer,proto3" json:"correct_answer,omitempty"`
+	PointsAwarded int32                  `protobuf:"varint,4,opt,name=points_awarded,json=pointsAwarded,proto3" json:"points_awarded,omitempty"`
+	MaxPoints     int32                  `protobuf:"varint,5,opt,name=max_points,json=maxPoints,proto3" json:"max_points,omitempty"`
+	IsCorrect     bool                   `protobuf:"varint,6,opt,name=is_correct,json=isCorrect,proto3" json:"is_correct,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *AgentMCQResult) Reset() {
+	*x = AgentMCQResult{}
+	mi := &file_internal_proto_ai_service_proto_msgTypes[24]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *AgentMCQResult) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*AgentMCQResult) ProtoMessage() {}
+
+func (x *AgentMCQResult) ProtoReflect() protoreflect.Message {
+	mi := &file_internal_proto_ai_service_proto_msgTypes[24]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use AgentMCQResult.ProtoReflect.Descriptor instead.
+func (*AgentMCQResult) Descriptor() ([]byte, []int) {
+	return file_internal_proto_ai_service_proto_rawDescGZIP(), []int{24}
+}
+
+func (x *AgentMCQResult) GetQuestionId() string {
+	if x != nil {
+		return x.QuestionId
+	}
+	return ""
+}
+
+func (x *AgentMCQResult) GetStudentAnswer() int32 {
+	if x != nil {
+		return x.StudentAnswer
+	}
+	return 0
+}
+
+func (x *AgentMCQResult) GetCorrectAnswer() int32 {
+	if x != nil {
+		return x.CorrectAnswer
+	}
+	return 0
+}
+
+func (x *AgentMCQResult) GetPointsAwarded() int32 {
+	if x != nil {
+		return x.PointsAwarded
+	}
+	return 0
+}
+
+func (x *AgentMCQResult) GetMaxPoints() int32 {
+	if x != nil {
+		return x.MaxPoints
+	}
+	return 0
+}
+
+func (x *AgentMCQResult) GetIsCorrect() bool {
+	if x != nil {
+		return x.IsCorrect
+	}
+	return false
+}
+
+type AgentMSQResult struct {
+	state          protoimpl.MessageState `protogen:"open.v1"`
+	QuestionId     string                 `protobuf:"bytes,1,opt,name=question_id,json=questionId,proto3" json:"question_id,omitempty"`
+	StudentAnswers []int32                `protobuf:"varint,2,rep,packed,name=student_answers,json=studentAnswers,proto3" json:"student_answers,omitempty"`
+	CorrectAnswers []int32                `protobuf:"varint,3,rep,packed,name=correct_answers,json=correctAnswers,proto3" json:"correct_answers,omitempty"`
+	PointsAwarded  int32                  `protobuf:"varint,4,opt,name=points_awarded,json=pointsAwarded,proto3" json:"points_awarded,omitempty"`
+	MaxPoints      int32                  `protobuf:"varint,5,opt,name=max_points,json=maxPoints,proto3" json:"max_points,omitempty"`
+	IsCorrect      bool                   `protobuf:"varint,6,opt,name=is_correct,json=isCorrect,proto3" json:"is_correct,omitempty"`
+	unknownFields  protoimpl.UnknownFields
+	sizeCache      protoimpl.SizeCache
+}
+
+func (x *AgentMSQResult) Reset() {
+	*x = AgentMSQResult{}
+	mi := &file_internal_proto_ai_service_proto_msgTypes[25]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *AgentMSQResult) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*AgentMSQResult) ProtoMessage() {}
+
+func (x *AgentMSQResult) ProtoReflect() protoreflect.Message {
+	mi := &file_internal_proto_ai_service_proto_msgTypes[25]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use AgentMSQResult.ProtoReflect.Descriptor instead.
+func (*AgentMSQResult) Descriptor() ([]byte, []int) {
+	return file_internal_proto_ai_service_proto_rawDescGZIP(), []int{25}
+}
+
+func (x *AgentMSQResult) GetQuestionId() string {
+	if x != nil {
+		return x.QuestionId
+	}
+	return ""
+}
+
+func (x *AgentMSQResult) GetStudentAnswers() []int32 {
+	if x != nil {
+		return x.StudentAnswers
+	}
+	return nil
+}
+
+func (x *AgentMSQResult) GetCorrectAnswers() []int32 {
+	if x != nil {
+		return x.CorrectAnswers
+	}
+	return nil
+}
+
+func (x *AgentMSQResult) GetPointsAwarded() int32 {
+	if x != nil {
+		return x.PointsAwarded
+	}
+	return 0
+}
+
+func (x *AgentMSQResult) GetMaxPoints() int32 {
+	if x != nil {
+		return x.MaxPoints
+	}
+	return 0
+}
+
+func (x *AgentMSQResult) GetIsCorrect() bool {
+	if x != nil {
+		return x.IsCorrect
+	}
+	return false
+}
+
+type AgentNATResult struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	QuestionId    string                 `protobuf:"bytes,1,opt,name=question_id,json=questionId,proto3" json:"question_id,omitempty"`
+	StudentAnswer float64                `protobuf:"fixed64,2,opt,name=student_answer,json=studentAnswer,proto3" json:"student_answer,omitempty"`
+	CorrectAnswer float64                `protobuf:"fixed64,3,opt,name=correct_answer,json=correctAnswer,proto3" json:"correct_answer,omitempty"`
+	PointsAwarded int32                  `protobuf:"varint,4,opt,name=points_awarded,json=pointsAwarded,proto3" json:"points_awarded,omitempty"`
+	MaxPoints     int32                  `protobuf:"varint,5,opt,name=max_points,json=maxPoints,proto3" json:"max_points,omitempty"`
+	IsCorrect     bool                   `protobuf:"varint,6,opt,name=is_correct,json=isCorrect,proto3" json:"is_correct,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *AgentNATResult) Reset() {
+	*x = AgentNATResult{}
+	mi := &file_internal_proto_ai_service_proto_msgTypes[26]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *AgentNATResult) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*AgentNATResult) ProtoMessage() {}
+
+func (x *AgentNATResult) ProtoReflect() protoreflect.Message {
+	mi := &file_internal_proto_ai_service_proto_msgTypes[26]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use AgentNATResult.ProtoReflect.Descriptor instead.
+func (*AgentNATResult) Descriptor() ([]byte, []int) {
+	return file_internal_proto_ai_service_proto_rawDescGZIP(), []int{26}
+}
+
+func (x *AgentNATResult) GetQuestionId() string {
+	if x != nil {
+		return x.QuestionId
+	}
+	return ""
+}
+
+func (x *AgentNATResult) GetStudentAnswer() float64 {
+	if x != nil {
+		return x.StudentAnswer
+	}
+	return 0
+}
+
+func (x *AgentNATResult) GetCorrectAnswer() float64 {
+	if x != nil {
+		return x.CorrectAnswer
+	}
+	return 0
+}
+
+func (x *AgentNATResult) GetPointsAwarded() int32 {
+	if x != nil {
+		return x.PointsAwarded
+	}
+	return 0
+}
+
+func (x *AgentNATResult) GetMaxPoints() int32 {
+	if x != nil {
+		return x.MaxPoints
+	}
+	return 0
+}
+
+func (x *AgentNATResult) GetIsCorrect() bool {
+	if x != nil {
+		return x.IsCorrect
+	}
+	return false
+}
+
+type AgentSubjectiveResult struct {
+	state              protoimpl.MessageState `protogen:"open.v1"`
+	QuestionId         string                 `protobuf:"bytes,1,opt,name=question_id,json=questionId,proto3" json:"question_id,omitempty"`
+	StudentAnswer      string                 `protobuf:"bytes,2,opt,name=student_answer,json=studentAnswer,proto3" json:"student_answer,omitempty"`
+	IdealAnswer        string                 `protobuf:"bytes,3,opt,name=ideal_answer,json=idealAnswer,proto3" json:"ideal_answer,omitempty"`
+	GradingCriteria    []string               `protobuf:"bytes,4,rep,name=grading_criteria,json=gradingCriteria,proto3" json:"grading_criteria,omitempty"`
+	PointsAwarded      int32                  `protobuf:"varint,5,opt,name=points_awarded,json=pointsAwarded,proto3" json:"points_awarded,omitempty"`
+	MaxPoints          int32                  `protobuf:"varint,6,opt,name=max_points,json=maxPoints,proto3" json:"max_points,omitempty"`
+	AssessmentFeedback string                 `protobuf:"bytes,7,opt,name=assessment_feedback,json=assessmentFeedback,proto3" json:"assessment_feedback,omitempty"`
+	CriteriaMet        []string               `protobuf:"bytes,8,rep,name=criteria_met,json=criteriaMet,proto3" json:"criteria_met,omitempty"`
+	CriteriaMissed     []string               `protobuf:"bytes,9,rep,name=criteria_missed,json=criteriaMissed,proto3" json:"criteria_missed,omitempty"`
+	unknownFields      protoimpl.UnknownFields
+	sizeCache          protoimpl.SizeCache
+}
+
+func (x *AgentSubjectiveResult) Reset() {
+	*x = AgentSubjectiveResult{}
+	mi := &file_internal_proto_ai_service_proto_msgTypes[27]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *AgentSubjectiveResult) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*AgentSubjectiveResult) ProtoMessage() {}
+
+func (x *AgentSubjectiveResult) ProtoReflect() protoreflect.Message {
+	mi := &file_internal_proto_ai_service_proto_msgTypes[27]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use AgentSubjectiveResult.ProtoReflect.Descriptor instead.
+func (*AgentSubjectiveResult) Descriptor() ([]byte, []int) {
+	return file_internal_proto_ai_service_proto_rawDescGZIP(), []int{27}
+}
+
+func (x *AgentSubjectiveResult) GetQuestionId() string {
+	if x != nil {
+		return x.QuestionId
+	}
+	return ""
+}
+
+func (x *AgentSubjectiveResult) GetStudentAnswer() string {
+	if x != nil {
+		return x.StudentAnswer
+	}
+	return ""
+}
+
+func (x *AgentSubjectiveResult) GetIdealAnswer() string {
+	if x != nil {
+		return x.IdealAnswer
+	}
+	return ""
+}
+
+func (x *AgentSubjectiveResult) GetGradingCriteria() []string {
+	if x != nil {
+		return x.GradingCriteria
+	}
+	return nil
+}
+
+func (x *AgentSubjectiveResult) GetPointsAwarded() int32 {
+	if x != nil {
+		return x.PointsAwarded
+	}
+	return 0
+}
+
+func (x *AgentSubjectiveResult) GetMaxPoints() int32 {
+	if x != nil {
+		return x.MaxPoints
+	}
+	return 0
+}
+
+func (x *AgentSubjectiveResult) GetAssessmentFeedback() string {
+	if x != nil {
+		return x.AssessmentFeedback
+	}
+	return ""
+}
+
+func (x *AgentSubjectiveResult) GetCriteriaMet() []string {
+	if x != nil {
+		return x.CriteriaMet
+	}
+	return nil
+}
+
+func (x *AgentSubjectiveResult) GetCriteriaMissed() []string {
+	if x != nil {
+		return x.CriteriaMissed
+	}
+	return nil
+}
+
+// A generated report card
+type AgentReportCardPayload struct {
+	state               protoimpl.MessageState     `protogen:"open.v1"`
+	StudentId           string                     `protobuf:"bytes,1,opt,name=student_id,json=studentId,proto3" json:"student_id,omitempty"`
+	StudentName         string                     `protobuf:"bytes,2,opt,name=student_name,json=studentName,proto3" json:"student_name,omitempty"`
+	ReportPeriod        string                     `protobuf:"bytes,3,opt,name=report_period,json=reportPeriod,proto3" json:"report_period,omitempty"`
+	GenerationDate      string                     `protobuf:"bytes,4,opt,name=generation_date,json=generationDate,proto3" json:"generation_date,omitempty"`
+	OverallPerformance  *AgentOverallPerformance   `protobuf:"bytes,5,opt,name=overall_performance,json=overallPerformance,proto3" json:"overall_performance,omitempty"`
+	SubjectPerformance  []*AgentSubjectPerformance `protobuf:"bytes,6,rep,name=subject_performance,json=subjectPerformance,proto3" json:"subject_performance,omitempty"`
+	AssignmentSummaries []*AgentAssignmentSummary  `protobuf:"bytes,7,rep,name=assignment_summaries,json=assignmentSummaries,proto3" json:"assignment_summaries,omitempty"`
+	AiRemarks           string                     `protobuf:"bytes,8,opt,name=ai_remarks,json=aiRemarks,proto3" json:"ai_remarks,omitempty"`
+	TeacherRemarks      string                     `protobuf:"bytes,9,opt,name=teacher_remarks,json=teacherRemarks,proto3" json:"teacher_remarks,omitempty"`
+	StudentInsights     *AgentStudentInsights      `protobuf:"bytes,10,opt,name=student_insights,json=studentInsights,proto3" json:"student_insights,omitempty"`
+	unknownFields       protoimpl.UnknownFields
+	sizeCache           protoimpl.SizeCache
+}
+
+func (x *AgentReportCardPayload) Reset() {
+	*x = AgentReportCardPayload{}
+	mi := &file_internal_proto_ai_service_proto_msgTypes[28]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *AgentReportCardPayload) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*AgentReportCardPayload) ProtoMessage() {}
+
+func (x *AgentReportCardPayload) ProtoReflect() protoreflect.Message {
+	mi := &file_internal_proto_ai_service_proto_msgTypes[28]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use AgentReportCardPayload.ProtoReflect.Descriptor instead.
+func (*AgentReportCardPayload) Descriptor() ([]byte, []int) {
+	return file_internal_proto_ai_service_proto_rawDescGZIP(), []int{28}
+}
+
+func (x *AgentReportCardPayload) GetStudentId() string {
+	if x != nil {
+		return x.StudentId
+	}
+	return ""
+}
+
+func (x *AgentReportCardPayload) GetStudentName() string {
+	if x != nil {
+		return x.StudentName
+	}
+	return ""
+}
+
+func (x *AgentReportCardPayload) GetReportPeriod() string {
+	if x != nil {
+		return x.ReportPeriod
+	}
+	return ""
+}
+
+func (x *AgentReportCardPayload) GetGenerationDate() string {
+	if x != nil {
+		return x.GenerationDate
+	}
+	return ""
+}
+
+func (x *AgentReportCardPayload) GetOverallPerformance() *AgentOverallPerformance {
+	if x != nil {
+		return x.OverallPerformance
+	}
+	return nil
+}
+
+func (x *AgentReportCardPayload) GetSubjectPerformance() []*AgentSubjectPerformance {
+	if x != nil {
+		return x.SubjectPerformance
+	}
+	return nil
+}
+
+func (x *AgentReportCardPayload) GetAssignmentSummaries() []*AgentAssignmentSummary {
+	if x != nil {
+		return x.AssignmentSummaries
+	}
+	return nil
+}
+
+func (x *AgentReportCardPayload) GetAiRemarks() string {
+	if x != nil {
+		return x.AiRemarks
+	}
+	return ""
+}
+
+func (x *AgentReportCardPayload) GetTeacherRemarks() string {
+	if x != nil {
+		return x.TeacherRemarks
+	}
+	return ""
+}
+
+func (x *AgentReportCardPayload) GetStudentInsights() *AgentStudentInsights {
+	if x != nil {
+		return x.StudentInsights
+	}
+	return nil
+}
+
+type AgentOverallPerformance struct {
+	state                     protoimpl.MessageState `protogen:"open.v1"`
+	TotalAssignmentsCompleted int32                  `protobuf:"varint,1,opt,name=total_assignments_completed,json=totalAssignmentsCompleted,proto3" json:"total_assignments_completed,omitempty"`
+	OverallPercentage         float64                `protobuf:"fixed64,2,opt,name=overall_percentage,json=overallPercentage,proto3" json:"overall_percentage,omitempty"`
+	ImprovementTrend          string                 `protobuf:"bytes,3,opt,name=improvement_trend,json=improvementTrend,proto3" json:"improvement_trend,omitempty"`
+	StrongestQuestionType     string                 `protobuf:"bytes,4,opt,name=strongest_question_type,json=strongestQuestionType,proto3" json:"strongest_question_type,omitempty"`
+	WeakestQuestionType       string                 `protobuf:"bytes,5,opt,name=weakest_question_type,json=weakestQuestionType,proto3" json:"weakest_question_type,omitempty"`
+	unknownFields             protoimpl.UnknownFields
+	sizeCache                 protoimpl.SizeCache
+}
+
+func (x *AgentOverallPerformance) Reset() {
+	*x = AgentOverallPerformance{}
+	mi := &file_internal_proto_ai_service_proto_msgTypes[29]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *AgentOverallPerformance) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*AgentOverallPerformance) ProtoMessage() {}
+
+func (x *AgentOverallPerformance) ProtoReflect() protoreflect.Message {
+	mi := &file_internal_proto_ai_service_proto_msgTypes[29]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use AgentOverallPerformance.ProtoReflect.Descriptor instead.
+func (*AgentOverallPerformance) Descriptor() ([]byte, []int) {
+	return file_internal_proto_ai_service_proto_rawDescGZIP(), []int{29}
+}
+
+func (x *AgentOverallPerformance) GetTotalAssignmentsCompleted() int32 {
+	if x != nil {
+		return x.TotalAssignmentsCompleted
+	}
+	return 0
+}
+
+func (x *AgentOverallPerformance) GetOverallPercentage() float64 {
+	if x != nil {
+		return x.OverallPercentage
+	}
+	return 0
+}
+
+func (x *AgentOverallPerformance) GetImprovementTrend() string {
+	if x != nil {
+		return x.ImprovementTrend
+	}
+	return ""
+}
+
+func (x *AgentOverallPerformance) GetStrongestQuestionType() string {
+	if x != nil {
+		return x.StrongestQuestionType
+	}
+	return ""
+}
+
+func (x *AgentOverallPerformance) GetWeakestQuestionType() string {
+	if x != nil {
+		return x.WeakestQuestionType
+	}
+	return ""
+}
+
+type AgentSubjectPerformance struct {
+	state              protoimpl.MessageState `protogen:"open.v1"`
+	SubjectName        string                 `protobuf:"bytes,1,opt,name=subject_name,json=subjectName,proto3" json:"subject_name,omitempty"`
+	PercentageScore    float64                `protobuf:"fixed64,2,opt,name=percentage_score,json=percentageScore,proto3" json:"percentage_score,omitempty"`
+	AssignmentCount    int32                  `protobuf:"varint,3,opt,name=assignment_count,json=assignmentCount,proto3" json:"assignment_count,omitempty"`
+	McqAccuracy        float64                `protobuf:"fixed64,4,opt,name=mcq_accuracy,json=mcqAccuracy,proto3" json:"mcq_accuracy,omitempty"`
+	MsqAccuracy        float64                `protobuf:"fixed64,5,opt,name=msq_accuracy,json=msqAccuracy,proto3" json:"msq_accuracy,omitempty"`
+	NatAccuracy        float64                `protobuf:"fixed64,6,opt,name=nat_accuracy,json=natAccuracy,proto3" json:"nat_accuracy,omitempty"`
+	SubjectiveAvgScore float64                `protobuf:"fixed64,7,opt,name=subjective_avg_score,json=subjectiveAvgScore,proto3" json:"subjective_avg_score,omitempty"`
+	Strengths          []string               `protobuf:"bytes,8,rep,name=strengths,proto3" json:"strengths,omitempty"`
+	Weaknesses         []string               `protobuf:"bytes,9,rep,name=weaknesses,proto3" json:"weaknesses,omitempty"`
+	ImprovementTrend   string                 `protobuf:"bytes,10,opt,name=improvement_trend,json=improvementTrend,proto3" json:"improvement_trend,omitempty"`
+	unknownFields      protoimpl.UnknownFields
+	sizeCache          protoimpl.SizeCache
+}
+
+func (x *AgentSubjectPerformance) Reset() {
+	*x = AgentSubjectPerformance{}
+	mi := &file_internal_proto_ai_service_proto_msgTypes[30]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *AgentSubjectPerformance) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*AgentSubjectPerformance) ProtoMessage() {}
+
+func (x *AgentSubjectPerformance) ProtoReflect() protoreflect.Message {
+	mi := &file_internal_proto_ai_service_proto_msgTypes[30]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use AgentSubjectPerformance.ProtoReflect.Descriptor instead.
+func (*AgentSubjectPerformance) Descriptor() ([]byte, []int) {
+	return file_internal_proto_ai_service_proto_rawDescGZIP(), []int{30}
+}
+
+func (x *AgentSubjectPerformance) GetSubjectName() string {
+	if x != nil {
+		return x.SubjectName
+	}
+	return ""
+}
+
+func (x *AgentSubjectPerformance) GetPercentageScore() float64 {
+	if x != nil {
+		return x.PercentageScore
+	}
+	return 0
+}
+
+func (x *AgentSubjectPerformance) GetAssignmentCount() int32 {
+	if x != nil {
+		return x.AssignmentCount
+	}
+	return 0
+}
+
+func (x *AgentSubjectPerformance) GetMcqAccuracy() float64 {
+	if x != nil {
+		return x.McqAccuracy
+	}
+	return 0
+}
+
+func (x *AgentSubjectPerformance) GetMsqAccuracy() float64 {
+	if x != nil {
+		return x.MsqAccuracy
+	}
+	return 0
+}
+
+func (x *AgentSubjectPerformance) GetNatAccuracy() float64 {
+	if x != nil {
+		return x.NatAccuracy
+	}
+	return 0
+}
+
+func (x *AgentSubjectPerformance) GetSubjectiveAvgScore() float64 {
+	if x != nil {
+		return x.SubjectiveAvgScore
+	}
+	return 0
+}
+
+func (x *AgentSubjectPerformance) GetStrengths() []string {
+	if x != nil {
+		return x.Strengths
+	}
+	return nil
+}
+
+func (x *AgentSubjectPerformance) GetWeaknesses() []string {
+	if x != nil {
+		return x.Weaknesses
+	}
+	return nil
+}
+
+func (x *AgentSubjectPerformance) GetImprovementTrend() string {
+	if x != nil {
+		return x.ImprovementTrend
+	}
+	return ""
+}
+
+type AgentAssignmentSummary struct {
+	state           protoimpl.MessageState `protogen:"open.v1"`
+	AssignmentId    string                 `protobuf:"bytes,1,opt,name=assignment_id,json=assignmentId,proto3" json:"assignment_id,omitempty"`
+	AssignmentTitle string                 `protobuf:"bytes,2,opt,name=assignment_title,json=assignmentTitle,proto3" json:"assignment_title,omitempty"`
+	PercentageScore float64                `protobuf:"fixed64,3,opt,name=percentage_score,json=percentageScore,proto3" json:"percentage_score,omitempty"`
+	Subject         string                 `protobuf:"bytes,4,opt,name=subject,proto3" json:"subject,omitempty"`
+	unknownFields   protoimpl.UnknownFields
+	sizeCache       protoimpl.SizeCache
+}
+
+func (x *AgentAssignmentSummary) Reset() {
+	*x = AgentAssignmentSummary{}
+	mi := &file_internal_proto_ai_service_proto_msgTypes[31]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *AgentAssignmentSummary) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*AgentAssignmentSummary) ProtoMessage() {}
+
+func (x *AgentAssignmentSummary) ProtoReflect() protoreflect.Message {
+	mi := &file_internal_proto_ai_service_proto_msgTypes[31]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use AgentAssignmentSummary.ProtoReflect.Descriptor instead.
+func (*AgentAssignmentSummary) Descriptor() ([]byte, []int) {
+	return file_internal_proto_ai_service_proto_rawDescGZIP(), []int{31}
+}
+
+func (x *AgentAssignmentSummary) GetAssignmentId() string {
+	if x != nil {
+		return x.AssignmentId
+	}
+	return ""
+}
+
+func (x *AgentAssignmentSummary) GetAssignmentTitle() string {
+	if x != nil {
+		return x.AssignmentTitle
+	}
+	return ""
+}
+
+func (x *AgentAssignmentSummary) GetPercentageScore() float64 {
+	if x != nil {
+		return x.PercentageScore
+	}
+	return 0
+}
+
+func (x *AgentAssignmentSummary) GetSubject() string {
+	if x != nil {
+		return x.Subject
+	}
+	return ""
+}
+
+type AgentStudentInsights struct {
+	state               protoimpl.MessageState `protogen:"open.v1"`
+	KeyStrengths        []string               `protobuf:"bytes,1,rep,name=key_strengths,json=keyStrengths,proto3" json:"key_strengths,omitempty"`
+	AreasForImprovement []string               `protobuf:"bytes,2,rep,name=areas_for_improvement,json=areasForImprovement,proto3" json:"areas_for_improvement,omitempty"`
+	RecommendedActions  []string               `protobuf:"bytes,3,rep,name=recommended_actions,json=recommendedActions,proto3" json:"recommended_actions,omitempty"`
+	unknownFields       protoimpl.UnknownFields
+	sizeCache           protoimpl.SizeCache
+}
+
+func (x *AgentStudentInsights) Reset() {
+	*x = AgentStudentInsights{}
+	mi := &file_internal_proto_ai_service_proto_msgTypes[32]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *AgentStudentInsights) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*AgentStudentInsights) ProtoMessage() {}
+
+func (x *AgentStudentInsights) ProtoReflect() protoreflect.Message {
+	mi := &file_internal_proto_ai_service_proto_msgTypes[32]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use AgentStudentInsights.ProtoReflect.Descriptor instead.
+func (*AgentStudentInsights) Descriptor() ([]byte, []int) {
+	return file_internal_proto_ai_service_proto_rawDescGZIP(), []int{32}
+}
+
+func (x *AgentStudentInsights) GetKeyStrengths() []string {
+	if x != nil {
+		return x.KeyStrengths
+	}
+	return nil
+}
+
+func (x *AgentStudentInsights) GetAreasForImprovement() []string {
+	if x != nil {
+		return x.AreasForImprovement
+	}
+	return nil
+}
+
+func (x *AgentStudentInsights) GetRecommendedActions() []string {
+	if x != nil {
+		return x.RecommendedActions
+	}
+	return nil
+}
+
+// A conversational reply without side effects
+type AgentTextPayload struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Text          string                 `protobuf:"bytes,1,opt,name=text,proto3" json:"text,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *AgentTextPayload) Reset() {
+	*x = AgentTextPayload{}
+	mi := &file_internal_proto_ai_service_proto_msgTypes[33]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *AgentTextPayload) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*AgentTextPayload) ProtoMessage() {}
+
+func (x *AgentTextPayload) ProtoReflect() protoreflect.Message {
+	mi := &file_internal_proto_ai_service_proto_msgTypes[33]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use AgentTextPayload.ProtoReflect.Descriptor instead.
+func (*AgentTextPayload) Descriptor() ([]byte, []int) {
+	return file_internal_proto_ai_service_proto_rawDescGZIP(), []int{33}
+}
+
+func (x *AgentTextPayload) GetText() string {
+	if x != nil {
+		return x.Text
+	}
+	return ""
+}
+
 type RAGAgentRequest struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
 	CorpusName    string                 `protobuf:"bytes,1,opt,name=corpusName,proto3" json:"corpusName,omitempty"`
@@ -1134,7 +2720,7 @@ type RAGAgentRequest struct {
 
 func (x *RAGAgentRequest) Reset() {
 	*x = RAGAgentRequest{}
-	mi := &file_internal_proto_ai_service_proto_msgTypes[19]
+	mi := &file_internal_proto_ai_service_proto_msgTypes[34]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -1146,7 +2732,7 @@ func (x *RAGAgentRequest) String() string {
 func (*RAGAgentRequest) ProtoMessage() {}
 
 func (x *RAGAgentRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_internal_proto_ai_service_proto_msgTypes[19]
+	mi := &file_internal_proto_ai_service_proto_msgTypes[34]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -1159,7 +2745,7 @@ func (x *RAGAgentRequest) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use RAGAgentRequest.ProtoReflect.Descriptor instead.
 func (*RAGAgentRequest) Descriptor() ([]byte, []int) {
-	return file_internal_proto_ai_service_proto_rawDescGZIP(), []int{19}
+	return file_internal_proto_ai_service_proto_rawDescGZIP(), []int{34}
 }
 
 func (x *RAGAgentRequest) GetCorpusName() string {
@@ -1215,7 +2801,7 @@ type RAGAgentResponse struct {
 
 func (x *RAGAgentResponse) Reset() {
 	*x = RAGAgentResponse{}
-	mi := &file_internal_proto_ai_service_proto_msgTypes[20]
+	mi := &file_internal_proto_ai_service_proto_msgTypes[35]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -1227,7 +2813,7 @@ func (x *RAGAgentResponse) String() string {
 func (*RAGAgentResponse) ProtoMessage() {}
 
 func (x *RAGAgentResponse) ProtoReflect() protoreflect.Message {
-	mi := &file_internal_proto_ai_service_proto_msgTypes[20]
+	mi := &file_internal_proto_ai_service_proto_msgTypes[35]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -1240,7 +2826,7 @@ func (x *RAGAgentResponse) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use RAGAgentResponse.ProtoReflect.Descriptor instead.
 func (*RAGAgentResponse) Descriptor() ([]byte, []int) {
-	return file_internal_proto_ai_service_proto_rawDescGZIP(), []int{20}
+	return file_internal_proto_ai_service_proto_rawDescGZIP(), []int{35}
 }
 
 func (x *RAGAgentResponse) GetMessage() string {
@@ -1385,7 +2971,7 @@ const file_internal_proto_ai_service_proto_rawDesc = "" +
 	"\x04role\x18\x04 \x01(\tR\x04role\x12\x18\n" +
 	"\amessage\x18\x05 \x01(\tR\amessage\x12\x1c\n" +
 	"\tcreatedAt\x18\x06 \x01(\tR\tcreatedAt\x12\x1c\n" +
-	"\tupdatedAt\x18\a \x01(\tR\tupdatedAt\"\xbd\x02\n" +
+	"\tupdatedAt\x18\a \x01(\tR\tupdatedAt\"\xdf\x05\n" +
 	"\rAgentResponse\x12\x18\n" +
 	"\amessage\x18\x01 \x01(\tR\amessage\x12\x1c\n" +
 	"\tteacherId\x18\x02 \x01(\tR\tteacherId\x12\x1d\n" +
@@ -1399,7 +2985,203 @@ const file_internal_proto_ai_service_proto_rawDesc = "" +
 	"\rresponse_time\x18\b \x01(\tR\fresponseTime\x12\x12\n" +
 	"\x04role\x18\t \x01(\tR\x04role\x12\x1a\n" +
 	"\bfeedback\x18\n" +
-	" \x01(\tR\bfeedback\"\x9b\x01\n" +
+	" \x01(\tR\bfeedback\x12%\n" +
+	"\x0eschema_version\x18\v \x01(\x05R\rschemaVersion\x12T\n" +
+	"\x10question_request\x18\f \x01(\v2'.ai_service.AgentQuestionRequestPayloadH\x00R\x0fquestionRequest\x12D\n" +
+	"\n" +
+	"assessment\x18\r \x01(\v2\".ai_service.AgentAssessmentPayloadH\x00R\n" +
+	"assessment\x12W\n" +
+	"\x11assignment_result\x18\x0e \x01(\v2(.ai_service.AgentAssignmentResultPayloadH\x00R\x10assignmentResult\x12E\n" +
+	"\vreport_card\x18\x0f \x01(\v2\".ai_service.AgentReportCardPayloadH\x00R\n" +
+	"reportCard\x122\n" +
+	"\x04text\x18\x10 \x01(\v2\x1c.ai_service.AgentTextPayloadH\x00R\x04textB\t\n" +
+	"\apayload\"\x9b\x02\n" +
+	"\x1bAgentQuestionRequestPayload\x12\x14\n" +
+	"\x05title\x18\x01 \x01(\tR\x05title\x12\x12\n" +
+	"\x04body\x18\x02 \x01(\tR\x04body\x12Q\n" +
+	"\x13questions_requested\x18\x03 \x03(\v2 .ai_service.AgentQuestionRequestR\x12questionsRequested\x12!\n" +
+	"\fclassroom_id\x18\x04 \x01(\tR\vclassroomId\x12*\n" +
+	"\x11avoid_recent_days\x18\x05 \x01(\x05R\x0favoidRecentDays\x120\n" +
+	"\x14fill_with_variations\x18\x06 \x01(\bR\x12fillWithVariations\"\xb1\x02\n" +
+	"\x14AgentQuestionRequest\x12\x12\n" +
+	"\x04type\x18\x01 \x01(\tR\x04type\x12\x18\n" +
+	"\asubject\x18\x02 \x01(\tR\asubject\x12.\n" +
+	"\x13number_of_questions\x18\x03 \x01(\x05R\x11numberOfQuestions\x12\x1e\n" +
+	"\n" +
+	"difficulty\x18\x04 \x01(\tR\n" +
+	"difficulty\x12\x19\n" +
+	"\bbank_ids\x18\x05 \x03(\tR\abankIds\x12\x12\n" +
+	"\x04tags\x18\x06 \x03(\tR\x04tags\x12I\n" +
+	"\x0equestion_types\x18\a \x03(\v2\".ai_service.AgentQuestionTypeCountR\rquestionTypes\x12!\n" +
+	"\ftotal_points\x18\b \x01(\x05R\vtotalPoints\"S\n" +
+	"\x16AgentQuestionTypeCount\x12#\n" +
+	"\rquestion_type\x18\x01 \x01(\tR\fquestionType\x12\x14\n" +
+	"\x05count\x18\x02 \x01(\x05R\x05count\"\xa7\r\n" +
+	"\x16AgentAssessmentPayload\x12\"\n" +
+	"\n" +
+	"student_id\x18\x01 \x01(\x03H\x00R\tstudentId\x88\x01\x01\x12!\n" +
+	"\fstudent_name\x18\x02 \x01(\tR\vstudentName\x12\x18\n" +
+	"\asubject\x18\x03 \x01(\tR\asubject\x12\x19\n" +
+	"\x05score\x18\x04 \x01(\x01H\x01R\x05score\x88\x01\x01\x12!\n" +
+	"\fgrade_letter\x18\x05 \x01(\tR\vgradeLetter\x12\x1d\n" +
+	"\n" +
+	"class_name\x18\x06 \x01(\tR\tclassName\x12'\n" +
+	"\x0finstructor_name\x18\a \x01(\tR\x0einstructorName\x12\x12\n" +
+	"\x04term\x18\b \x01(\tR\x04term\x12\x18\n" +
+	"\aremarks\x18\t \x01(\tR\aremarks\x12(\n" +
+	"\rmidterm_score\x18\n" +
+	" \x01(\x01H\x02R\fmidtermScore\x88\x01\x01\x12-\n" +
+	"\x10final_exam_score\x18\v \x01(\x01H\x03R\x0efinalExamScore\x88\x01\x01\x12\"\n" +
+	"\n" +
+	"quiz_score\x18\f \x01(\x01H\x04R\tquizScore\x88\x01\x01\x12.\n" +
+	"\x10assignment_score\x18\r \x01(\x01H\x05R\x0fassignmentScore\x88\x01\x01\x12,\n" +
+	"\x0fpractical_score\x18\x0e \x01(\x01H\x06R\x0epracticalScore\x88\x01\x01\x12;\n" +
+	"\x17oral_presentation_score\x18\x0f \x01(\x01H\aR\x15oralPresentationScore\x88\x01\x01\x12>\n" +
+	"\x18conceptual_understanding\x18\x10 \x01(\x01H\bR\x17conceptualUnderstanding\x88\x01\x01\x12,\n" +
+	"\x0fproblem_solving\x18\x11 \x01(\x01H\tR\x0eproblemSolving\x88\x01\x01\x128\n" +
+	"\x15knowledge_application\x18\x12 \x01(\x01H\n" +
+	"R\x14knowledgeApplication\x88\x01\x01\x124\n" +
+	"\x13analytical_thinking\x18\x13 \x01(\x01H\vR\x12analyticalThinking\x88\x01\x01\x12#\n" +
+	"\n" +
+	"creativity\x18\x14 \x01(\x01H\fR\n" +
+	"creativity\x88\x01\x01\x12.\n" +
+	"\x10practical_skills\x18\x15 \x01(\x01H\rR\x0fpracticalSkills\x88\x01\x01\x12)\n" +
+	"\rparticipation\x18\x16 \x01(\x01H\x0eR\rparticipation\x88\x01\x01\x12#\n" +
+	"\n" +
+	"discipline\x18\x17 \x01(\x01H\x0fR\n" +
+	"discipline\x88\x01\x01\x12%\n" +
+	"\vpunctuality\x18\x18 \x01(\x01H\x10R\vpunctuality\x88\x01\x01\x12\x1f\n" +
+	"\bteamwork\x18\x19 \x01(\x01H\x11R\bteamwork\x88\x01\x01\x12&\n" +
+	"\feffort_level\x18\x1a \x01(\x01H\x12R\veffortLevel\x88\x01\x01\x12%\n" +
+	"\vimprovement\x18\x1b \x01(\x01H\x13R\vimprovement\x88\x01\x01\x12@\n" +
+	"\x1clearning_objectives_mastered\x18\x1c \x01(\tR\x1alearningObjectivesMastered\x122\n" +
+	"\x15areas_for_improvement\x18\x1d \x01(\tR\x13areasForImprovement\x123\n" +
+	"\x15recommended_resources\x18\x1e \x01(\tR\x14recommendedResources\x12!\n" +
+	"\ftarget_goals\x18\x1f \x01(\tR\vtargetGoalsB\r\n" +
+	"\v_student_idB\b\n" +
+	"\x06_scoreB\x10\n" +
+	"\x0e_midterm_scoreB\x13\n" +
+	"\x11_final_exam_scoreB\r\n" +
+	"\v_quiz_scoreB\x13\n" +
+	"\x11_assignment_scoreB\x12\n" +
+	"\x10_practical_scoreB\x1a\n" +
+	"\x18_oral_presentation_scoreB\x1b\n" +
+	"\x19_conceptual_understandingB\x12\n" +
+	"\x10_problem_solvingB\x18\n" +
+	"\x16_knowledge_applicationB\x16\n" +
+	"\x14_analytical_thinkingB\r\n" +
+	"\v_creativityB\x13\n" +
+	"\x11_practical_skillsB\x10\n" +
+	"\x0e_participationB\r\n" +
+	"\v_disciplineB\x0e\n" +
+	"\f_punctualityB\v\n" +
+	"\t_teamworkB\x0f\n" +
+	"\r_effort_levelB\x0e\n" +
+	"\f_improvement\"\xc4\x04\n" +
+	"\x1cAgentAssignmentResultPayload\x12#\n" +
+	"\rassignment_id\x18\x01 \x01(\tR\fassignmentId\x12\x1d\n" +
+	"\n" +
+	"student_id\x18\x02 \x01(\tR\tstudentId\x125\n" +
+	"\x14total_points_awarded\x18\x03 \x01(\x01H\x00R\x12totalPointsAwarded\x88\x01\x01\x12-\n" +
+	"\x10total_max_points\x18\x04 \x01(\x01H\x01R\x0etotalMaxPoints\x88\x01\x01\x12.\n" +
+	"\x10percentage_score\x18\x05 \x01(\x01H\x02R\x0fpercentageScore\x88\x01\x01\x12;\n" +
+	"\vmcq_results\x18\x06 \x03(\v2\x1a.ai_service.AgentMCQResultR\n" +
+	"mcqResults\x12;\n" +
+	"\vmsq_results\x18\a \x03(\v2\x1a.ai_service.AgentMSQResultR\n" +
+	"msqResults\x12;\n" +
+	"\vnat_results\x18\b \x03(\v2\x1a.ai_service.AgentNATResultR\n" +
+	"natResults\x12P\n" +
+	"\x12subjective_results\x18\t \x03(\v2!.ai_service.AgentSubjectiveResultR\x11subjectiveResultsB\x17\n" +
+	"\x15_total_points_awardedB\x13\n" +
+	"\x11_total_max_pointsB\x13\n" +
+	"\x11_percentage_score\"\xe4\x01\n" +
+	"\x0eAgentMCQResult\x12\x1f\n" +
+	"\vquestion_id\x18\x01 \x01(\tR\n" +
+	"questionId\x12%\n" +
+	"\x0estudent_answer\x18\x02 \x01(\x05R\rstudentAnswer\x12%\n" +
+	"\x0ecorrect_answer\x18\x03 \x01(\x05R\rcorrectAnswer\x12%\n" +
+	"\x0epoints_awarded\x18\x04 \x01(\x05R\rpointsAwarded\x12\x1d\n" +
+	"\n" +
+	"max_points\x18\x05 \x01(\x05R\tmaxPoints\x12\x1d\n" +
+	"\n" +
+	"is_correct\x18\x06 \x01(\bR\tisCorrect\"\xe8\x01\n" +
+	"\x0eAgentMSQResult\x12\x1f\n" +
+	"\vquestion_id\x18\x01 \x01(\tR\n" +
+	"questionId\x12'\n" +
+	"\x0fstudent_answers\x18\x02 \x03(\x05R\x0estudentAnswers\x12'\n" +
+	"\x0fcorrect_answers\x18\x03 \x03(\x05R\x0ecorrectAnswers\x12%\n" +
+	"\x0epoints_awarded\x18\x04 \x01(\x05R\rpointsAwarded\x12\x1d\n" +
+	"\n" +
+	"max_points\x18\x05 \x01(\x05R\tmaxPoints\x12\x1d\n" +
+	"\n" +
+	"is_correct\x18\x06 \x01(\bR\tisCorrect\"\xe4\x01\n" +
+	"\x0eAgentNATResult\x12\x1f\n" +
+	"\vquestion_id\x18\x01 \x01(\tR\n" +
+	"questionId\x12%\n" +
+	"\x0estudent_answer\x18\x02 \x01(\x01R\rstudentAnswer\x12%\n" +
+	"\x0ecorrect_answer\x18\x03 \x01(\x01R\rcorrectAnswer\x12%\n" +
+	"\x0epoints_awarded\x18\x04 \x01(\x05R\rpointsAwarded\x12\x1d\n" +
+	"\n" +
+	"max_points\x18\x05 \x01(\x05R\tmaxPoints\x12\x1d\n" +
+	"\n" +
+	"is_correct\x18\x06 \x01(\bR\tisCorrect\"\xf0\x02\n" +
+	"\x15AgentSubjectiveResult\x12\x1f\n" +
+	"\vquestion_id\x18\x01 \x01(\tR\n" +
+	"questionId\x12%\n" +
+	"\x0estudent_answer\x18\x02 \x01(\tR\rstudentAnswer\x12!\n" +
+	"\fideal_answer\x18\x03 \x01(\tR\videalAnswer\x12)\n" +
+	"\x10grading_criteria\x18\x04 \x03(\tR\x0fgradingCriteria\x12%\n" +
+	"\x0epoints_awarded\x18\x05 \x01(\x05R\rpointsAwarded\x12\x1d\n" +
+	"\n" +
+	"max_points\x18\x06 \x01(\x05R\tmaxPoints\x12/\n" +
+	"\x13assessment_feedback\x18\a \x01(\tR\x12assessmentFeedback\x12!\n" +
+	"\fcriteria_met\x18\b \x03(\tR\vcriteriaMet\x12'\n" +
+	"\x0fcriteria_missed\x18\t \x03(\tR\x0ecriteriaMissed\"\xc0\x04\n" +
+	"\x16AgentReportCardPayload\x12\x1d\n" +
+	"\n" +
+	"student_id\x18\x01 \x01(\tR\tstudentId\x12!\n" +
+	"\fstudent_name\x18\x02 \x01(\tR\vstudentName\x12#\n" +
+	"\rreport_period\x18\x03 \x01(\tR\freportPeriod\x12'\n" +
+	"\x0fgeneration_date\x18\x04 \x01(\tR\x0egenerationDate\x12T\n" +
+	"\x13overall_performance\x18\x05 \x01(\v2#.ai_service.AgentOverallPerformanceR\x12overallPerformance\x12T\n" +
+	"\x13subject_performance\x18\x06 \x03(\v2#.ai_service.AgentSubjectPerformanceR\x12subjectPerformance\x12U\n" +
+	"\x14assignment_summaries\x18\a \x03(\v2\".ai_service.AgentAssignmentSummaryR\x13assignmentSummaries\x12\x1d\n" +
+	"\n" +
+	"ai_remarks\x18\b \x01(\tR\taiRemarks\x12'\n" +
+	"\x0fteacher_remarks\x18\t \x01(\tR\x0eteacherRemarks\x12K\n" +
+	"\x10student_insights\x18\n" +
+	" \x01(\v2 .ai_service.AgentStudentInsightsR\x0fstudentInsights\"\xa1\x02\n" +
+	"\x17AgentOverallPerformance\x12>\n" +
+	"\x1btotal_assignments_completed\x18\x01 \x01(\x05R\x19totalAssignmentsCompleted\x12-\n" +
+	"\x12overall_percentage\x18\x02 \x01(\x01R\x11overallPercentage\x12+\n" +
+	"\x11improvement_trend\x18\x03 \x01(\tR\x10improvementTrend\x126\n" +
+	"\x17strongest_question_type\x18\x04 \x01(\tR\x15strongestQuestionType\x122\n" +
+	"\x15weakest_question_type\x18\x05 \x01(\tR\x13weakestQuestionType\"\x98\x03\n" +
+	"\x17AgentSubjectPerformance\x12!\n" +
+	"\fsubject_name\x18\x01 \x01(\tR\vsubjectName\x12)\n" +
+	"\x10percentage_score\x18\x02 \x01(\x01R\x0fpercentageScore\x12)\n" +
+	"\x10assignment_count\x18\x03 \x01(\x05R\x0fassignmentCount\x12!\n" +
+	"\fmcq_accuracy\x18\x04 \x01(\x01R\vmcqAccuracy\x12!\n" +
+	"\fmsq_accuracy\x18\x05 \x01(\x01R\vmsqAccuracy\x12!\n" +
+	"\fnat_accuracy\x18\x06 \x01(\x01R\vnatAccuracy\x120\n" +
+	"\x14subjective_avg_score\x18\a \x01(\x01R\x12subjectiveAvgScore\x12\x1c\n" +
+	"\tstrengths\x18\b \x03(\tR\tstrengths\x12\x1e\n" +
+	"\n" +
+	"weaknesses\x18\t \x03(\tR\n" +
+	"weaknesses\x12+\n" +
+	"\x11improvement_trend\x18\n" +
+	" \x01(\tR\x10improvementTrend\"\xad\x01\n" +
+	"\x16AgentAssignmentSummary\x12#\n" +
+	"\rassignment_id\x18\x01 \x01(\tR\fassignmentId\x12)\n" +
+	"\x10assignment_title\x18\x02 \x01(\tR\x0fassignmentTitle\x12)\n" +
+	"\x10percentage_score\x18\x03 \x01(\x01R\x0fpercentageScore\x12\x18\n" +
+	"\asubject\x18\x04 \x01(\tR\asubject\"\xa0\x01\n" +
+	"\x14AgentStudentInsights\x12#\n" +
+	"\rkey_strengths\x18\x01 \x03(\tR\fkeyStrengths\x122\n" +
+	"\x15areas_for_improvement\x18\x02 \x03(\tR\x13areasForImprovement\x12/\n" +
+	"\x13recommended_actions\x18\x03 \x03(\tR\x12recommendedActions\"&\n" +
+	"\x10AgentTextPayload\x12\x12\n" +
+	"\x04text\x18\x01 \x01(\tR\x04text\"\x9b\x01\n" +
 	"\x0fRAGAgentRequest\x12\x1e\n" +
 	"\n" +
 	"corpusName\x18\x01 \x01(\tR\n" +
@@ -1423,7 +3205,7 @@ const file_internal_proto_ai_service_proto_rawDesc = "" +
 	"\rresponse_time\x18\b \x01(\tR\fresponseTime\x12\x12\n" +
 	"\x04role\x18\t \x01(\tR\x04role\x12\x1a\n" +
 	"\bfeedback\x18\n" +
-	" \x01(\tR\bfeedback2\xb2\x05\n" +
+	" \x01(\tR\bfeedback2\xcc\x06\n" +
 	"\tAIService\x12Z\n" +
 	"\x0fGenerateContext\x12\".ai_service.GenerateContextRequest\x1a#.ai_service.GenerateContextResponse\x12\\\n" +
 	"\x0fDetectVariables\x12#.ai_service.VariableDetectorRequest\x1a$.ai_service.VariableDetectorResponse\x12d\n" +
@@ -1433,7 +3215,9 @@ const file_internal_proto_ai_service_proto_rawDesc = "" +
 	"\x12FilterAndRandomize\x12&.ai_service.FilterAndRandomizerRequest\x1a'.ai_service.FilterAndRandomizerResponse\x12A\n" +
 	"\n" +
 	"LumenAgent\x12\x18.ai_service.AgentRequest\x1a\x19.ai_service.AgentResponse\x12E\n" +
-	"\bRAGAgent\x12\x1b.ai_service.RAGAgentRequest\x1a\x1c.ai_service.RAGAgentResponseB&Z$internal/proto/ai_service;ai_serviceb\x06proto3"
+	"\bRAGAgent\x12\x1b.ai_service.RAGAgentRequest\x1a\x1c.ai_service.RAGAgentResponse\x12I\n" +
+	"\x10LumenAgentStream\x12\x18.ai_service.AgentRequest\x1a\x19.ai_service.AgentResponse0\x01\x12M\n" +
+	"\x0eRAGAgentStream\x12\x1b.ai_service.RAGAgentRequest\x1a\x1c.ai_service.RAGAgentResponse0\x01B&Z$internal/proto/ai_service;ai_serviceb\x06proto3"
 
 var (
 	file_internal_proto_ai_service_proto_rawDescOnce sync.Once
@@ -1447,7 +3231,7 @@ func file_internal_proto_ai_service_proto_rawDescGZIP() []byte {
 	return file_internal_proto_ai_service_proto_rawDescData
 }
 
-var file_internal_proto_ai_service_proto_msgTypes = make([]protoimpl.MessageInfo, 21)
+var file_internal_proto_ai_service_proto_msgTypes = make([]protoimpl.MessageInfo, 36)
 var file_internal_proto_ai_service_proto_goTypes = []any{
 	(*GenerateContextRequest)(nil),       // 0: ai_service.GenerateContextRequest
 	(*GenerateContextResponse)(nil),      // 1: ai_service.GenerateContextResponse
@@ -1468,8 +3252,23 @@ var file_internal_proto_ai_service_proto_goTypes = []any{
 	(*VariableFilter)(nil),               // 16: ai_service.VariableFilter
 	(*AgentRequest)(nil),                 // 17: ai_service.AgentRequest
 	(*AgentResponse)(nil),                // 18: ai_service.AgentResponse
-	(*RAGAgentRequest)(nil),              // 19: ai_service.RAGAgentRequest
-	(*RAGAgentResponse)(nil),             // 20: ai_service.RAGAgentResponse
+	(*AgentQuestionRequestPayload)(nil),  // 19: ai_service.AgentQuestionRequestPayload
+	(*AgentQuestionRequest)(nil),         // 20: ai_service.AgentQuestionRequest
+	(*AgentQuestionTypeCount)(nil),       // 21: ai_service.AgentQuestionTypeCount
+	(*AgentAssessmentPayload)(nil),       // 22: ai_service.AgentAssessmentPayload
+	(*AgentAssignmentResultPayload)(nil), // 23: ai_service.AgentAssignmentResultPayload
+	(*AgentMCQResult)(nil),               // 24: ai_service.AgentMCQResult
+	(*AgentMSQResult)(nil),               // 25: ai_service.AgentMSQResult
+	(*AgentNATResult)(nil),               // 26: ai_service.AgentNATResult
+	(*AgentSubjectiveResult)(nil),        // 27: ai_service.AgentSubjectiveResult
+	(*AgentReportCardPayload)(nil),       // 28: ai_service.AgentReportCardPayload
+	(*AgentOverallPerformance)(nil),      // 29: ai_service.AgentOverallPerformance
+	(*AgentSubjectPerformance)(nil),      // 30: ai_service.AgentSubjectPerformance
+	(*AgentAssignmentSummary)(nil),       // 31: ai_service.AgentAssignmentSummary
+	(*AgentStudentInsights)(nil),         // 32: ai_service.AgentStudentInsights
+	(*AgentTextPayload)(nil),             // 33: ai_service.AgentTextPayload
+	(*RAGAgentRequest)(nil),              // 34: ai_service.RAGAgentRequest
+	(*RAGAgentResponse)(nil),             // 35: ai_service.RAGAgentResponse
 }
 var file_internal_proto_ai_service_proto_depIdxs = []int32{
 	4,  // 0: ai_service.VariableDetectorResponse.variables:type_name -> ai_service.DetectedVariable
@@ -1477,27 +3276,46 @@ var file_internal_proto_ai_service_proto_depIdxs = []int32{
 	12, // 2: ai_service.MSQVariation.variations:type_name -> ai_service.MSQQuestion
 	15, // 3: ai_service.FilterAndRandomizerResponse.variables:type_name -> ai_service.RandomizedVariable
 	16, // 4: ai_service.RandomizedVariable.filters:type_name -> ai_service.VariableFilter
-	0,  // 5: ai_service.AIService.GenerateContext:input_type -> ai_service.GenerateContextRequest
-	2,  // 6: ai_service.AIService.DetectVariables:input_type -> ai_service.VariableDetectorRequest
-	5,  // 7: ai_service.AIService.SegmentQuestion:input_type -> ai_service.QuestionSegmentationRequest
-	7,  // 8: ai_service.AIService.GenerateMCQVariations:input_type -> ai_service.MCQRequest
-	10, // 9: ai_service.AIService.GenerateMSQVariations:input_type -> ai_service.MSQRequest
-	13, // 10: ai_service.AIService.FilterAndRandomize:input_type -> ai_service.FilterAndRandomizerRequest
-	17, // 11: ai_service.AIService.LumenAgent:input_type -> ai_service.AgentRequest
-	19, // 12: ai_service.AIService.RAGAgent:input_type -> ai_service.RAGAgentRequest
-	1,  // 13: ai_service.AIService.GenerateContext:output_type -> ai_service.GenerateContextResponse
-	3,  // 14: ai_service.AIService.DetectVariables:output_type -> ai_service.VariableDetectorResponse
-	6,  // 15: ai_service.AIService.SegmentQuestion:output_type -> ai_service.QuestionSegmentationResponse
-	8,  // 16: ai_service.AIService.GenerateMCQVariations:output_type -> ai_service.MCQVariation
-	11, // 17: ai_service.AIService.GenerateMSQVariations:output_type -> ai_service.MSQVariation
-	14, // 18: ai_service.AIService.FilterAndRandomize:output_type -> ai_service.FilterAndRandomizerResponse
-	18, // 19: ai_service.AIService.LumenAgent:output_type -> ai_service.AgentResponse
-	20, // 20: ai_service.AIService.RAGAgent:output_type -> ai_service.RAGAgentResponse
-	13, // [13:21] is the sub-list for method output_type
-	5,  // [5:13] is the sub-list for method input_type
-	5,  // [5:5] is the sub-list for extension type_name
-	5,  // [5:5] is the sub-list for extension extendee
-	0,  // [0:5] is the sub-list for field type_name
+	19, // 5: ai_service.AgentResponse.question_request:type_name -> ai_service.AgentQuestionRequestPayload
+	22, // 6: ai_service.AgentResponse.assessment:type_name -> ai_service.AgentAssessmentPayload
+	23, // 7: ai_service.AgentResponse.assignment_result:type_name -> ai_service.AgentAssignmentResultPayload
+	28, // 8: ai_service.AgentResponse.report_card:type_name -> ai_service.AgentReportCardPayload
+	33, // 9: ai_service.AgentResponse.text:type_name -> ai_service.AgentTextPayload
+	20, // 10: ai_service.AgentQuestionRequestPayload.questions_requested:type_name -> ai_service.AgentQuestionRequest
+	21, // 11: ai_service.AgentQuestionRequest.question_types:type_name -> ai_service.AgentQuestionTypeCount
+	24, // 12: ai_service.AgentAssignmentResultPayload.mcq_results:type_name -> ai_service.AgentMCQResult
+	25, // 13: ai_service.AgentAssignmentResultPayload.msq_results:type_name -> ai_service.AgentMSQResult
+	26, // 14: ai_service.AgentAssignmentResultPayload.nat_results:type_name -> ai_service.AgentNATResult
+	27, // 15: ai_service.AgentAssignmentResultPayload.subjective_results:type_name -> ai_service.AgentSubjectiveResult
+	29, // 16: ai_service.AgentReportCardPayload.overall_performance:type_name -> ai_service.AgentOverallPerformance
+	30, // 17: ai_service.AgentReportCardPayload.subject_performance:type_name -> ai_service.AgentSubjectPerformance
+	31, // 18: ai_service.AgentReportCardPayload.assignment_summaries:type_name -> ai_service.AgentAssignmentSummary
+	32, // 19: ai_service.AgentReportCardPayload.student_insights:type_name -> ai_service.AgentStudentInsights
+	0,  // 20: ai_service.AIService.GenerateContext:input_type -> ai_service.GenerateContextRequest
+	2,  // 21: ai_service.AIService.DetectVariables:input_type -> ai_service.VariableDetectorRequest
+	5,  // 22: ai_service.AIService.SegmentQuestion:input_type -> ai_service.QuestionSegmentationRequest
+	7,  // 23: ai_service.AIService.GenerateMCQVariations:input_type -> ai_service.MCQRequest
+	10, // 24: ai_service.AIService.GenerateMSQVariations:input_type -> ai_service.MSQRequest
+	13, // 25: ai_service.AIService.FilterAndRandomize:input_type -> ai_service.FilterAndRandomizerRequest
+	17, // 26: ai_service.AIService.LumenAgent:input_type -> ai_service.AgentRequest
+	34, // 27: ai_service.AIService.RAGAgent:input_type -> ai_service.RAGAgentRequest
+	17, // 28: ai_service.AIService.LumenAgentStream:input_type -> ai_service.AgentRequest
+	34, // 29: ai_service.AIService.RAGAgentStream:input_type -> ai_service.RAGAgentRequest
+	1,  // 30: ai_service.AIService.GenerateContext:output_type -> ai_service.GenerateContextResponse
+	3,  // 31: ai_service.AIService.DetectVariables:output_type -> ai_service.VariableDetectorResponse
+	6,  // 32: ai_service.AIService.SegmentQuestion:output_type -> ai_service.QuestionSegmentationResponse
+	8,  // 33: ai_service.AIService.GenerateMCQVariations:output_type -> ai_service.MCQVariation
+	11, // 34: ai_service.AIService.GenerateMSQVariations:output_type -> ai_service.MSQVariation
+	14, // 35: ai_service.AIService.FilterAndRandomize:output_type -> ai_service.FilterAndRandomizerResponse
+	18, // 36: ai_service.AIService.LumenAgent:output_type -> ai_service.AgentResponse
+	35, // 37: ai_service.AIService.RAGAgent:output_type -> ai_service.RAGAgentResponse
+	18, // 38: ai_service.AIService.LumenAgentStream:output_type -> ai_service.AgentResponse
+	35, // 39: ai_service.AIService.RAGAgentStream:output_type -> ai_service.RAGAgentResponse
+	30, // [30:40] is the sub-list for method output_type
+	20, // [20:30] is the sub-list for method input_type
+	20, // [20:20] is the sub-list for extension type_name
+	20, // [20:20] is the sub-list for extension extendee
+	0,  // [0:20] is the sub-list for field type_name
 }
 
 func init() { file_internal_proto_ai_service_proto_init() }
@@ -1505,13 +3323,22 @@ func file_internal_proto_ai_service_proto_init() {
 	if File_internal_proto_ai_service_proto != nil {
 		return
 	}
+	file_internal_proto_ai_service_proto_msgTypes[18].OneofWrappers = []any{
+		(*AgentResponse_QuestionRequest)(nil),
+		(*AgentResponse_Assessment)(nil),
+		(*AgentResponse_AssignmentResult)(nil),
+		(*AgentResponse_ReportCard)(nil),
+		(*AgentResponse_Text)(nil),
+	}
+	file_internal_proto_ai_service_proto_msgTypes[22].OneofWrappers = []any{}
+	file_internal_proto_ai_service_proto_msgTypes[23].OneofWrappers = []any{}
 	type x struct{}
 	out := protoimpl.TypeBuilder{
 		File: protoimpl.DescBuilder{
 			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
 			RawDescriptor: unsafe.Slice(unsafe.StringData(file_internal_proto_ai_service_proto_rawDesc), len(file_internal_proto_ai_service_proto_rawDesc)),
 			NumEnums:      0,
-			NumMessages:   21,
+			NumMessages:   36,
 			NumExtensions: 0,
 			NumServices:   1,
 		},
@@ -27,6 +27,8 @@ const (
 	AIService_FilterAndRandomize_FullMethodName    = "/ai_service.AIService/FilterAndRandomize"
 	AIService_LumenAgent_FullMethodName            = "/ai_service.AIService/LumenAgent"
 	AIService_RAGAgent_FullMethodName              = "/ai_service.AIService/RAGAgent"
+	AIService_LumenAgentStream_FullMethodName      = "/ai_service.AIService/LumenAgentStream"
+	AIService_RAGAgentStream_FullMethodName        = "/ai_service.AIService/RAGAgentStream"
 )
 
 // AIServiceClient is the client API for AIService service.
@@ -41,6 +43,14 @@ type AIServiceClient interface {
 	FilterAndRandomize(ctx context.Context, in *FilterAndRandomizerRequest, opts ...grpc.CallOption) (*FilterAndRandomizerResponse, error)
 	LumenAgent(ctx context.Context, in *AgentRequest, opts ...grpc.CallOption) (*AgentResponse, error)
 	RAGAgent(ctx context.Context, in *RAGAgentRequest, opts ...grpc.CallOption) (*RAGAgentResponse, error)
+	// Streaming variants of the agents. Every frame reuses the unary response message and its
+	// `message` field names the frame kind:
+	//
+	//	"token" - agent_response holds the next piece of generated text
+	//	"event" - agent_response holds a JSON progress event, e.g. {"stage": "tool_call", "tool": "..."}
+	//	"final" - the complete response, identical to what the unary RPC returns; always last
+	LumenAgentStream(ctx context.Context, in *AgentRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[AgentResponse], error)
+	RAGAgentStream(ctx context.Context, in *RAGAgentRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[RAGAgentResponse], error)
 }
 
 type aIServiceClient struct {
@@ -131,6 +141,44 @@ func (c *aIServiceClient) RAGAgent(ctx context.Context, in *RAGAgentRequest, opt
 	return out, nil
 }
 
+func (c *aIServiceClient) LumenAgentStream(ctx context.Context, in *AgentRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[AgentResponse], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &AIService_ServiceDesc.Streams[0], AIService_LumenAgentStream_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[AgentRequest, AgentResponse]{ClientStream: stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type AIService_LumenAgentStreamClient = grpc.ServerStreamingClient[AgentResponse]
+
+func (c *aIServiceClient) RAGAgentStream(ctx context.Context, in *RAGAgentRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[RAGAgentResponse], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &AIService_ServiceDesc.Streams[1], AIService_RAGAgentStream_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[RAGAgentRequest, RAGAgentResponse]{ClientStream: stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type AIService_RAGAgentStreamClient = grpc.ServerStreamingClient[RAGAgentResponse]
+
 // AIServiceServer is the server API for AIService service.
 // All implementations must embed UnimplementedAIServiceServer
 // for forward compatibility.
@@ -143,6 +191,14 @@ type AIServiceServer interface {
 	FilterAndRandomize(context.Context, *FilterAndRandomizerRequest) (*FilterAndRandomizerResponse, error)
 	LumenAgent(context.Context, *AgentRequest) (*AgentResponse, error)
 	RAGAgent(context.Context, *RAGAgentRequest) (*RAGAgentResponse, error)
+	// Streaming variants of the agents. Every frame reuses the unary response message and its
+	// `message` field names the frame kind:
+	//
+	//	"token" - agent_response holds the next piece of generated text
+	//	"event" - agent_response holds a JSON progress event, e.g. {"stage": "tool_call", "tool": "..."}
+	//	"final" - the complete response, identical to what the unary RPC returns; always last
+	LumenAgentStream(*AgentRequest, grpc.ServerStreamingServer[AgentResponse]) error
+	RAGAgentStream(*RAGAgentRequest, grpc.ServerStreamingServer[RAGAgentResponse]) error
 	mustEmbedUnimplementedAIServiceServer()
 }
 
@@ -177,6 +233,12 @@ func (UnimplementedAIServiceServer) LumenAgent(context.Context, *AgentRequest) (
 func (UnimplementedAIServiceServer) RAGAgent(context.Context, *RAGAgentRequest) (*RAGAgentResponse, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method RAGAgent not implemented")
 }
+func (UnimplementedAIServiceServer) LumenAgentStream(*AgentRequest, grpc.ServerStreamingServer[AgentResponse]) error {
+	return status.Errorf(codes.Unimplemented, "method LumenAgentStream not implemented")
+}
+func (UnimplementedAIServiceServer) RAGAgentStream(*RAGAgentRequest, grpc.ServerStreamingServer[RAGAgentResponse]) error {
+	return status.Errorf(codes.Unimplemented, "method RAGAgentStream not implemented")
+}
 func (UnimplementedAIServiceServer) mustEmbedUnimplementedAIServiceServer() {}
 func (UnimplementedAIServiceServer) testEmbeddedByValue()                   {}
 
@@ -342,6 +404,28 @@ func _AIService_RAGAgent_Handler(srv interface{}, ctx context.Context, dec func(
 	return interceptor(ctx, in, info, handler)
 }
 
+func _AIService_LumenAgentStream_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(AgentRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(AIServiceServer).LumenAgentStream(m, &grpc.GenericServerStream[AgentRequest, AgentResponse]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type AIService_LumenAgentStreamServer = grpc.ServerStreamingServer[AgentResponse]
+
+func _AIService_RAGAgentStream_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(RAGAgentRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(AIServiceServer).RAGAgentStream(m, &grpc.GenericServerStream[RAGAgentRequest, RAGAgentResponse]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type AIService_RAGAgentStreamServer = grpc.ServerStreamingServer[RAGAgentResponse]
+
 // AIService_ServiceDesc is the grpc.ServiceDesc for AIService service.
 // It's only intended for direct use with grpc.RegisterService,
 // and not to be introspected or modified (even as a copy)
@@ -382,6 +466,17 @@ var AIService_ServiceDesc = grpc.ServiceDesc{
 			Handler:    _AIService_RAGAgent_Handler,
 		},
 	},
-	Streams:  []grpc.StreamDesc{},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "LumenAgentStream",
+			Handler:       _AIService_LumenAgentStream_Handler,
+			ServerStreams: true,
+		},
+		{
+			StreamName:    "RAGAgentStream",
+			Handler:       _AIService_RAGAgentStream_Handler,
+			ServerStreams: true,
+		},
+	},
 	Metadata: "internal/proto/ai_service.proto",
 }
@@ -0,0 +1,147 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"lumenslate/internal/db"
+	"lumenslate/internal/model"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// ErrAgentProposalNotPending is returned when a proposal has already been approved, rejected or is being committed
+var ErrAgentProposalNotPending = errors.New("agent proposal is no longer pending")
+
+type AgentProposalRepository struct {
+	collection *mongo.Collection
+}
+
+func NewAgentProposalRepository() *AgentProposalRepository {
+	return &AgentProposalRepository{
+		collection: db.GetCollection(db.AgentProposalCollection),
+	}
+}
+
+// Create stores a new proposal and sets its ID
+func (r *AgentProposalRepository) Create(ctx context.Context, proposal *model.AgentProposal) error {
+	result, err := r.collection.InsertOne(ctx, proposal)
+	if err != nil {
+		return fmt.Errorf("failed to create agent proposal: %w", err)
+	}
+	proposal.ID = result.InsertedID.(primitive.ObjectID)
+	return nil
+}
+
+// Get retrieves a proposal by ID
+func (r *AgentProposalRepository) Get(ctx context.Context, proposalID primitive.ObjectID) (*model.AgentProposal, error) {
+	var proposal model.AgentProposal
+	if err := r.collection.FindOne(ctx, bson.M{"_id": proposalID}).Decode(&proposal); err != nil {
+		return nil, fmt.Errorf("failed to retrieve agent proposal %s: %w", proposalID.Hex(), err)
+	}
+	return &proposal, nil
+}
+
+// List retrieves a teacher's proposals, newest first, optionally only those with the given status
+func (r *AgentProposalRepository) List(ctx context.Context, teacherID, status string, limit, offset int64) ([]model.AgentProposal, int64, error) {
+	filter := bson.M{"teacherId": teacherID}
+	if status != "" {
+		filter["status"] = status
+	}
+
+	total, err := r.collection.CountDocuments(ctx, filter)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	opts := options.Find().SetSort(bson.M{"createdAt": -1}).SetSkip(offset)
+	if limit > 0 {
+		opts.SetLimit(limit)
+	}
+	cursor, err := r.collection.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer cursor.Close(ctx)
+
+	proposals := []model.AgentProposal{}
+	if err := cursor.All(ctx, &proposals); err != nil {
+		return nil, 0, err
+	}
+	return proposals, total, nil
+}
+
+// UpdatePending replaces the record and preview of a pending proposal and records the edit
+func (r *AgentProposalRepository) UpdatePending(ctx context.Context, proposalID primitive.ObjectID, records model.AgentProposalRecords, preview []model.AgentProposalChange, event model.AgentProposalEvent) (*model.AgentProposal, error) {
+	return r.transition(ctx, proposalID, model.AgentProposalStatusPending, bson.M{
+		"$set":  bson.M{"records": records, "preview": preview, "updatedAt": event.At},
+		"$push": bson.M{"history": event},
+	})
+}
+
+// Claim moves a pending proposal to committing, so that only one approval writes it
+func (r *AgentProposalRepository) Claim(ctx context.Context, proposalID primitive.ObjectID) (*model.AgentProposal, error) {
+	return r.transition(ctx, proposalID, model.AgentProposalStatusPending, bson.M{
+		"$set": bson.M{"status": model.AgentProposalStatusCommitting, "updatedAt": time.Now()},
+	})
+}
+
+// MarkApproved completes a claimed proposal once its record has been written
+func (r *AgentProposalRepository) MarkApproved(ctx context.Context, proposalID primitive.ObjectID, committedID string, event model.AgentProposalEvent) (*model.AgentProposal, error) {
+	return r.transition(ctx, proposalID, model.AgentProposalStatusCommitting, bson.M{
+		"$set": bson.M{
+			"status":      model.AgentProposalStatusApproved,
+			"committedId": committedID,
+			"decidedBy":   event.ActorID,
+			"decidedAt":   event.At,
+			"updatedAt":   event.At,
+		},
+		"$unset": bson.M{"lastError": ""},
+		"$push":  bson.M{"history": event},
+	})
+}
+
+// Release returns a claimed proposal to pending after its write failed, so that it can be approved again
+func (r *AgentProposalRepository) Release(ctx context.Context, proposalID primitive.ObjectID, event model.AgentProposalEvent) (*model.AgentProposal, error) {
+	return r.transition(ctx, proposalID, model.AgentProposalStatusCommitting, bson.M{
+		"$set":  bson.M{"status": model.AgentProposalStatusPending, "lastError": event.Note, "updatedAt": event.At},
+		"$push": bson.M{"history": event},
+	})
+}
+
+// Reject closes a pending proposal without writing it
+func (r *AgentProposalRepository) Reject(ctx context.Context, proposalID primitive.ObjectID, event model.AgentProposalEvent) (*model.AgentProposal, error) {
+	return r.transition(ctx, proposalID, model.AgentProposalStatusPending, bson.M{
+		"$set": bson.M{
+			"status":    model.AgentProposalStatusRejected,
+			"decidedBy": event.ActorID,
+			"decidedAt": event.At,
+			"updatedAt": event.At,
+		},
+		"$push": bson.M{"history": event},
+	})
+}
+
+// transition applies update to a proposal in status from and returns the updated record.
+// It returns ErrAgentProposalNotPending when the proposal exists but is in another status.
+func (r *AgentProposalRepository) transition(ctx context.Context, proposalID primitive.ObjectID, from string, update bson.M) (*model.AgentProposal, error) {
+	opts := options.FindOneAndUpdate().SetReturnDocument(options.After)
+
+	var proposal model.AgentProposal
+	err := r.collection.FindOneAndUpdate(ctx, bson.M{"_id": proposalID, "status": from}, update, opts).Decode(&proposal)
+	if err == nil {
+		return &proposal, nil
+	}
+	if !errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, fmt.Errorf("failed to update agent proposal %s: %w", proposalID.Hex(), err)
+	}
+
+	if _, getErr := r.Get(ctx, proposalID); getErr != nil {
+		return nil, getErr
+	}
+	return nil, ErrAgentProposalNotPending
+}
@@ -0,0 +1,161 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"lumenslate/internal/db"
+	"lumenslate/internal/model"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type AgentSessionRepository struct {
+	sessions *mongo.Collection
+	messages *mongo.Collection
+}
+
+func NewAgentSessionRepository() *AgentSessionRepository {
+	return &AgentSessionRepository{
+		sessions: db.GetCollection(db.AgentSessionCollection),
+		messages: db.GetCollection(db.AgentMessageCollection),
+	}
+}
+
+// CreateSession stores a new session and sets its ID
+func (r *AgentSessionRepository) CreateSession(ctx context.Context, session *model.AgentSession) error {
+	result, err := r.sessions.InsertOne(ctx, session)
+	if err != nil {
+		return fmt.Errorf("failed to create agent session: %w", err)
+	}
+	session.ID = result.InsertedID.(primitive.ObjectID)
+	return nil
+}
+
+// GetSession retrieves a session by ID
+func (r *AgentSessionRepository) GetSession(ctx context.Context, sessionID primitive.ObjectID) (*model.AgentSession, error) {
+	var session model.AgentSession
+	if err := r.sessions.FindOne(ctx, bson.M{"_id": sessionID}).Decode(&session); err != nil {
+		return nil, fmt.Errorf("failed to retrieve agent session %s: %w", sessionID.Hex(), err)
+	}
+	return &session, nil
+}
+
+// ListSessions retrieves a teacher's sessions, most recently active first
+func (r *AgentSessionRepository) ListSessions(ctx context.Context, teacherID string, limit, offset int64) ([]model.AgentSession, int64, error) {
+	filter := bson.M{"teacherId": teacherID}
+
+	total, err := r.sessions.CountDocuments(ctx, filter)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	opts := options.Find().SetSort(bson.M{"lastMessageAt": -1}).SetSkip(offset)
+	if limit > 0 {
+		opts.SetLimit(limit)
+	}
+	cursor, err := r.sessions.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer cursor.Close(ctx)
+
+	sessions := []model.AgentSession{}
+	if err := cursor.All(ctx, &sessions); err != nil {
+		return nil, 0, err
+	}
+	return sessions, total, nil
+}
+
+// RenameSession changes the title of a session and returns the updated record
+func (r *AgentSessionRepository) RenameSession(ctx context.Context, sessionID primitive.ObjectID, title string) (*model.AgentSession, error) {
+	opts := options.FindOneAndUpdate().SetReturnDocument(options.After)
+	update := bson.M{"$set": bson.M{"title": title, "updatedAt": time.Now()}}
+
+	var session model.AgentSession
+	if err := r.sessions.FindOneAndUpdate(ctx, bson.M{"_id": sessionID}, update, opts).Decode(&session); err != nil {
+		return nil, fmt.Errorf("failed to rename agent session %s: %w", sessionID.Hex(), err)
+	}
+	return &session, nil
+}
+
+// DeleteSession deletes a session together with its messages
+func (r *AgentSessionRepository) DeleteSession(ctx context.Context, sessionID primitive.ObjectID) error {
+	if _, err := r.messages.DeleteMany(ctx, bson.M{"sessionId": sessionID}); err != nil {
+		return fmt.Errorf("failed to delete messages of agent session %s: %w", sessionID.Hex(), err)
+	}
+	if _, err := r.sessions.DeleteOne(ctx, bson.M{"_id": sessionID}); err != nil {
+		return fmt.Errorf("failed to delete agent session %s: %w", sessionID.Hex(), err)
+	}
+	return nil
+}
+
+// AppendMessages stores messages in a session, sets their IDs and updates the session counters.
+// A non-empty serviceSessionID replaces the AI service session the conversation continues in.
+func (r *AgentSessionRepository) AppendMessages(ctx context.Context, sessionID primitive.ObjectID, serviceSessionID string, messages ...*model.AgentMessage) error {
+	if len(messages) == 0 {
+		return nil
+	}
+
+	documents := make([]interface{}, len(messages))
+	for i, message := range messages {
+		message.SessionID = sessionID
+		documents[i] = message
+	}
+	result, err := r.messages.InsertMany(ctx, documents)
+	if err != nil {
+		return fmt.Errorf("failed to store agent messages: %w", err)
+	}
+	for i, id := range result.InsertedIDs {
+		messages[i].ID = id.(primitive.ObjectID)
+	}
+
+	now := time.Now()
+	set := bson.M{"lastMessageAt": now, "updatedAt": now}
+	if serviceSessionID != "" {
+		set["serviceSessionId"] = serviceSessionID
+	}
+	_, err = r.sessions.UpdateOne(ctx, bson.M{"_id": sessionID}, bson.M{
+		"$set": set,
+		"$inc": bson.M{"messageCount": len(messages)},
+	})
+	if err != nil {
+		return fmt.Errorf("failed to update agent session %s: %w", sessionID.Hex(), err)
+	}
+	return nil
+}
+
+// ListMessages retrieves the transcript of a session in order
+func (r *AgentSessionRepository) ListMessages(ctx context.Context, sessionID primitive.ObjectID) ([]model.AgentMessage, error) {
+	opts := options.Find().SetSort(bson.D{{Key: "createdAt", Value: 1}, {Key: "_id", Value: 1}})
+	cursor, err := r.messages.Find(ctx, bson.M{"sessionId": sessionID}, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	messages := []model.AgentMessage{}
+	if err := cursor.All(ctx, &messages); err != nil {
+		return nil, err
+	}
+	return messages, nil
+}
+
+// SetMessageFeedback records feedback on an agent message of a session; an empty feedback clears it
+func (r *AgentSessionRepository) SetMessageFeedback(ctx context.Context, sessionID, messageID primitive.ObjectID, feedback string) (*model.AgentMessage, error) {
+	filter := bson.M{"_id": messageID, "sessionId": sessionID, "role": model.AgentMessageRoleAgent}
+	update := bson.M{"$set": bson.M{"feedback": feedback}}
+	if feedback == "" {
+		update = bson.M{"$unset": bson.M{"feedback": ""}}
+	}
+	opts := options.FindOneAndUpdate().SetReturnDocument(options.After)
+
+	var message model.AgentMessage
+	if err := r.messages.FindOneAndUpdate(ctx, filter, update, opts).Decode(&message); err != nil {
+		return nil, fmt.Errorf("failed to record feedback on agent message %s: %w", messageID.Hex(), err)
+	}
+	return &message, nil
+}
@@ -19,6 +19,15 @@ func SaveAssignment(a model.Assignment) error {
 	return err
 }
 
+// UpsertAssignment saves a by ID, replacing it if it is already saved
+func UpsertAssignment(a model.Assignment) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	_, err := db.GetCollection(db.AssignmentCollection).ReplaceOne(ctx, bson.M{"_id": a.ID}, a, options.Replace().SetUpsert(true))
+	return err
+}
+
 func GetAssignmentByID(id string) (*model.Assignment, error) {
 	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 	defer cancel()
@@ -193,3 +202,26 @@ func PatchAssignment(id string, updates map[string]interface{}) (*model.Assignme
 
 	return &updated, nil
 }
+
+// GetRecentAssignmentsByIDs returns the assignments among ids created at or after since
+func GetRecentAssignmentsByIDs(ids []string, since time.Time) ([]model.Assignment, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	assignments := []model.Assignment{}
+	if len(ids) == 0 {
+		return assignments, nil
+	}
+
+	filter := bson.M{"_id": bson.M{"$in": ids}, "createdAt": bson.M{"$gte": since}}
+	cursor, err := db.GetCollection(db.AssignmentCollection).Find(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	if err = cursor.All(ctx, &assignments); err != nil {
+		return nil, err
+	}
+	return assignments, nil
+}
@@ -0,0 +1,200 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"lumenslate/internal/db"
+	"lumenslate/internal/model"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// ErrCorpusDeleting is returned when a corpus is already being deleted
+var ErrCorpusDeleting = errors.New("corpus is being deleted")
+
+type CorpusRepository struct {
+	collection *mongo.Collection
+}
+
+func NewCorpusRepository() *CorpusRepository {
+	return &CorpusRepository{
+		collection: db.GetCollection(db.CorpusCollection),
+	}
+}
+
+// EnsureCorpus returns the stored record for corpus.Name, creating it from corpus when missing.
+// Existing records are left untouched so that corpora created before this collection existed
+// are adopted on first use.
+func (r *CorpusRepository) EnsureCorpus(ctx context.Context, corpus *model.Corpus) (*model.Corpus, error) {
+	now := time.Now()
+	if corpus.Status == "" {
+		corpus.Status = model.CorpusStatusActive
+	}
+	if corpus.DisplayName == "" {
+		corpus.DisplayName = corpus.Name
+	}
+
+	update := bson.M{
+		"$setOnInsert": bson.M{
+			"name":           corpus.Name,
+			"displayName":    corpus.DisplayName,
+			"resourceName":   corpus.ResourceName,
+			"ownerTeacherId": corpus.OwnerTeacher,
+			"description":    corpus.Description,
+			"classroomIds":   corpus.ClassroomIDs,
+			"chunking":       corpus.Chunking,
+			"documentCount":  corpus.DocumentCount,
+			"totalBytes":     corpus.TotalBytes,
+			"status":         corpus.Status,
+			"createdAt":      now,
+			"updatedAt":      now,
+		},
+	}
+	opts := options.FindOneAndUpdate().SetUpsert(true).SetReturnDocument(options.After)
+
+	var stored model.Corpus
+	if err := r.collection.FindOneAndUpdate(ctx, bson.M{"name": corpus.Name}, update, opts).Decode(&stored); err != nil {
+		return nil, fmt.Errorf("failed to ensure corpus %s: %w", corpus.Name, err)
+	}
+	return &stored, nil
+}
+
+// GetCorpusByName retrieves a corpus by its name
+func (r *CorpusRepository) GetCorpusByName(ctx context.Context, name string) (*model.Corpus, error) {
+	var corpus model.Corpus
+	if err := r.collection.FindOne(ctx, bson.M{"name": name}).Decode(&corpus); err != nil {
+		return nil, fmt.Errorf("failed to retrieve corpus %s: %w", name, err)
+	}
+	return &corpus, nil
+}
+
+// ListCorpora retrieves every corpus record, newest first
+func (r *CorpusRepository) ListCorpora(ctx context.Context) ([]model.Corpus, error) {
+	opts := options.Find().SetSort(bson.M{"createdAt": -1})
+	cursor, err := r.collection.Find(ctx, bson.M{}, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	corpora := []model.Corpus{}
+	if err := cursor.All(ctx, &corpora); err != nil {
+		return nil, err
+	}
+	return corpora, nil
+}
+
+// UpdateFields updates the editable fields of a corpus and returns the updated record
+func (r *CorpusRepository) UpdateFields(ctx context.Context, name string, updates bson.M) (*model.Corpus, error) {
+	// Identity, lifecycle and usage are managed by dedicated methods
+	immutableFields := []string{"_id", "name", "ownerTeacherId", "classroomIds", "status", "documentCount", "totalBytes", "createdAt"}
+	for _, field := range immutableFields {
+		if _, exists := updates[field]; exists {
+			return nil, fmt.Errorf("cannot update immutable field: %s", field)
+		}
+	}
+	updates["updatedAt"] = time.Now()
+
+	opts := options.FindOneAndUpdate().SetReturnDocument(options.After)
+	var corpus model.Corpus
+	if err := r.collection.FindOneAndUpdate(ctx, bson.M{"name": name}, bson.M{"$set": updates}, opts).Decode(&corpus); err != nil {
+		return nil, fmt.Errorf("failed to update corpus %s: %w", name, err)
+	}
+	return &corpus, nil
+}
+
+// AssignOwner makes a teacher the owner of a corpus. It is how corpora created before ownership
+// was tracked become writable again.
+func (r *CorpusRepository) AssignOwner(ctx context.Context, name, teacherID string) (*model.Corpus, error) {
+	opts := options.FindOneAndUpdate().SetReturnDocument(options.After)
+	update := bson.M{"$set": bson.M{"ownerTeacherId": teacherID, "updatedAt": time.Now()}}
+
+	var corpus model.Corpus
+	if err := r.collection.FindOneAndUpdate(ctx, bson.M{"name": name}, update, opts).Decode(&corpus); err != nil {
+		return nil, fmt.Errorf("failed to assign owner of corpus %s: %w", name, err)
+	}
+	return &corpus, nil
+}
+
+// ShareWithClassroom grants the students of a classroom read access to a corpus
+func (r *CorpusRepository) ShareWithClassroom(ctx context.Context, name, classroomID string) (*model.Corpus, error) {
+	return r.updateClassrooms(ctx, name, bson.M{"$addToSet": bson.M{"classroomIds": classroomID}})
+}
+
+// UnshareWithClassroom revokes the access granted to a classroom
+func (r *CorpusRepository) UnshareWithClassroom(ctx context.Context, name, classroomID string) (*model.Corpus, error) {
+	return r.updateClassrooms(ctx, name, bson.M{"$pull": bson.M{"classroomIds": classroomID}})
+}
+
+func (r *CorpusRepository) updateClassrooms(ctx context.Context, name string, update bson.M) (*model.Corpus, error) {
+	update["$set"] = bson.M{"updatedAt": time.Now()}
+	opts := options.FindOneAndUpdate().SetReturnDocument(options.After)
+
+	var corpus model.Corpus
+	if err := r.collection.FindOneAndUpdate(ctx, bson.M{"name": name}, update, opts).Decode(&corpus); err != nil {
+		return nil, fmt.Errorf("failed to update classrooms of corpus %s: %w", name, err)
+	}
+	return &corpus, nil
+}
+
+// SetUsage stores the document count and total size computed from the documents collection
+func (r *CorpusRepository) SetUsage(ctx context.Context, name string, stats model.CorpusStats) error {
+	_, err := r.collection.UpdateOne(ctx, bson.M{"name": name}, bson.M{
+		"$set": bson.M{
+			"documentCount": stats.DocumentCount,
+			"totalBytes":    stats.TotalBytes,
+			"updatedAt":     time.Now(),
+		},
+	})
+	return err
+}
+
+// MarkDeleting moves a corpus into the deleting state. A failed delete may be retried;
+// a delete that is already queued or running returns ErrCorpusDeleting.
+func (r *CorpusRepository) MarkDeleting(ctx context.Context, name string) (*model.Corpus, error) {
+	filter := bson.M{
+		"name":   name,
+		"status": bson.M{"$in": []model.CorpusStatus{model.CorpusStatusActive, model.CorpusStatusDeleteFailed}},
+	}
+	update := bson.M{
+		"$set":   bson.M{"status": model.CorpusStatusDeleting, "updatedAt": time.Now()},
+		"$unset": bson.M{"errorMsg": ""},
+	}
+	opts := options.FindOneAndUpdate().SetReturnDocument(options.After)
+
+	var corpus model.Corpus
+	err := r.collection.FindOneAndUpdate(ctx, filter, update, opts).Decode(&corpus)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		// Distinguish a missing corpus from one that is already being deleted
+		if _, getErr := r.GetCorpusByName(ctx, name); getErr != nil {
+			return nil, getErr
+		}
+		return nil, ErrCorpusDeleting
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to mark corpus %s as deleting: %w", name, err)
+	}
+	return &corpus, nil
+}
+
+// MarkDeleteFailed records that the cascade delete of a corpus stopped with an error
+func (r *CorpusRepository) MarkDeleteFailed(ctx context.Context, name, errorMsg string) error {
+	_, err := r.collection.UpdateOne(ctx, bson.M{"name": name}, bson.M{
+		"$set": bson.M{
+			"status":    model.CorpusStatusDeleteFailed,
+			"errorMsg":  errorMsg,
+			"updatedAt": time.Now(),
+		},
+	})
+	return err
+}
+
+// DeleteCorpus deletes a corpus record by name
+func (r *CorpusRepository) DeleteCorpus(ctx context.Context, name string) error {
+	_, err := r.collection.DeleteOne(ctx, bson.M{"name": name})
+	return err
+}
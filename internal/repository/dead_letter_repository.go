@@ -0,0 +1,81 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"lumenslate/internal/db"
+	"lumenslate/internal/model"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type DeadLetterRepository struct {
+	collection *mongo.Collection
+}
+
+func NewDeadLetterRepository() *DeadLetterRepository {
+	return &DeadLetterRepository{
+		collection: db.GetCollection(db.DeadLetterCollection),
+	}
+}
+
+// Create stores a dead-letter entry
+func (r *DeadLetterRepository) Create(ctx context.Context, deadLetter *model.DeadLetter) error {
+	result, err := r.collection.InsertOne(ctx, deadLetter)
+	if err != nil {
+		return fmt.Errorf("failed to create dead letter for task %s: %w", deadLetter.TaskID, err)
+	}
+	if id, ok := result.InsertedID.(primitive.ObjectID); ok {
+		deadLetter.ID = id
+	}
+	return nil
+}
+
+// Get retrieves a dead-letter entry by ID
+func (r *DeadLetterRepository) Get(ctx context.Context, id string) (*model.DeadLetter, error) {
+	objectID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, mongo.ErrNoDocuments
+	}
+
+	var deadLetter model.DeadLetter
+	if err := r.collection.FindOne(ctx, bson.M{"_id": objectID}).Decode(&deadLetter); err != nil {
+		return nil, fmt.Errorf("failed to retrieve dead letter %s: %w", id, err)
+	}
+	return &deadLetter, nil
+}
+
+// List retrieves dead-letter entries, newest first, optionally only those of a task type or document
+func (r *DeadLetterRepository) List(ctx context.Context, taskType, fileID string, limit, offset int64) ([]model.DeadLetter, int64, error) {
+	filter := bson.M{}
+	if taskType != "" {
+		filter["taskType"] = taskType
+	}
+	if fileID != "" {
+		filter["fileId"] = fileID
+	}
+
+	total, err := r.collection.CountDocuments(ctx, filter)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	opts := options.Find().SetSort(bson.M{"createdAt": -1}).SetSkip(offset)
+	if limit > 0 {
+		opts.SetLimit(limit)
+	}
+	cursor, err := r.collection.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer cursor.Close(ctx)
+
+	deadLetters := []model.DeadLetter{}
+	if err := cursor.All(ctx, &deadLetters); err != nil {
+		return nil, 0, err
+	}
+	return deadLetters, total, nil
+}
@@ -2,6 +2,7 @@ package repository
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"lumenslate/internal/db"
 	"lumenslate/internal/model"
@@ -10,6 +11,7 @@ import (
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
 	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
 )
 
 // DocumentError represents a document repository error with context
@@ -31,6 +33,12 @@ func (e *DocumentError) Unwrap() error {
 	return e.Err
 }
 
+// ErrInvalidStatusTransition is returned when the document state machine rejects a status change
+var ErrInvalidStatusTransition = errors.New("invalid document status transition")
+
+// ErrStaleAttempt is returned when an ingestion task was superseded by one queued later for the document
+var ErrStaleAttempt = errors.New("ingestion task superseded by a newer one")
+
 type DocumentRepository struct {
 	collection *mongo.Collection
 }
@@ -50,7 +58,7 @@ func (r *DocumentRepository) CreateDocument(ctx context.Context, doc *model.Docu
 
 	// Set default status for async processing
 	if doc.Status == "" {
-		doc.Status = "pending"
+		doc.Status = model.DocumentStatusUploaded
 	}
 
 	// Insert document with proper error handling
@@ -125,14 +133,21 @@ func (r *DocumentRepository) GetDocumentsByCorpus(ctx context.Context, corpusNam
 	return documents, nil
 }
 
-// UpdateStatus atomically updates document status, error message, and timestamp
-func (r *DocumentRepository) UpdateStatus(ctx context.Context, fileID string, status string, errorMsg string) error {
-	filter := bson.M{"fileId": fileID}
-
+// UpdateStatus atomically moves a document to the given state, recording the transition in its history.
+// The update only applies when the current state is allowed to move to status.
+func (r *DocumentRepository) UpdateStatus(ctx context.Context, fileID string, status model.DocumentStatus, errorMsg string) error {
+	now := time.Now()
 	update := bson.M{
 		"$set": bson.M{
 			"status":    status,
-			"updatedAt": time.Now(),
+			"updatedAt": now,
+		},
+		"$push": bson.M{
+			"statusHistory": model.DocumentStatusTransition{
+				Status:  status,
+				Message: errorMsg,
+				At:      now,
+			},
 		},
 	}
 
@@ -140,26 +155,150 @@ func (r *DocumentRepository) UpdateStatus(ctx context.Context, fileID string, st
 	if errorMsg != "" {
 		update["$set"].(bson.M)["errorMsg"] = errorMsg
 	} else {
-		update["$unset"] = bson.M{"errorMsg": ""}
+		update["$unset"] = bson.M{"errorMsg": "", "failedStep": ""}
+	}
+
+	return r.applyTransition(ctx, "UpdateStatus", fileID, status, update)
+}
+
+// MarkFailed moves a document to the failed state, remembering the step that failed so it can be resumed
+func (r *DocumentRepository) MarkFailed(ctx context.Context, fileID string, step model.DocumentStatus, errorMsg string) error {
+	now := time.Now()
+	update := bson.M{
+		"$set": bson.M{
+			"status":     model.DocumentStatusFailed,
+			"failedStep": step,
+			"errorMsg":   errorMsg,
+			"updatedAt":  now,
+		},
+		"$push": bson.M{
+			"statusHistory": model.DocumentStatusTransition{
+				Status:  model.DocumentStatusFailed,
+				Message: fmt.Sprintf("%s: %s", step, errorMsg),
+				At:      now,
+			},
+		},
+	}
+
+	return r.applyTransition(ctx, "MarkFailed", fileID, model.DocumentStatusFailed, update)
+}
+
+// StartAttempt increments the processing attempt counter and returns the updated document.
+// queuedAttempt is the attempt the task was queued for; when the document has since been queued
+// again, e.g. by a reprocess, nothing is updated and ErrStaleAttempt is returned.
+func (r *DocumentRepository) StartAttempt(ctx context.Context, fileID string, queuedAttempt int) (*model.Document, error) {
+	filter := bson.M{
+		"fileId": fileID,
+		"$or": bson.A{
+			bson.M{"queuedAttempt": queuedAttempt},
+			bson.M{"queuedAttempt": bson.M{"$exists": false}}, // Documents created before tasks were tracked
+		},
+	}
+	update := bson.M{
+		"$inc": bson.M{"attempts": 1},
+		"$set": bson.M{"updatedAt": time.Now()},
+	}
+	opts := options.FindOneAndUpdate().SetReturnDocument(options.After)
+
+	var doc model.Document
+	err := r.collection.FindOneAndUpdate(ctx, filter, update, opts).Decode(&doc)
+	if err == mongo.ErrNoDocuments {
+		if _, getErr := r.GetDocumentByFileID(ctx, fileID); getErr == nil {
+			return nil, &DocumentError{
+				Op:      "StartAttempt",
+				FileID:  fileID,
+				Message: fmt.Sprintf("attempt %d was queued again", queuedAttempt),
+				Err:     ErrStaleAttempt,
+			}
+		}
+	}
+	if err != nil {
+		message := "failed to start processing attempt"
+		if err == mongo.ErrNoDocuments {
+			message = "document not found"
+		}
+		return nil, &DocumentError{
+			Op:      "StartAttempt",
+			FileID:  fileID,
+			Message: message,
+			Err:     err,
+		}
+	}
+
+	return &doc, nil
+}
+
+// QueueAttempt hands a failed document to a new ingestion task queued for attempt, the current
+// attempt count, so that runs of tasks queued before it are dropped by StartAttempt. It fails with
+// ErrInvalidStatusTransition when the document is no longer failed or another run has started.
+func (r *DocumentRepository) QueueAttempt(ctx context.Context, fileID string, attempt int) error {
+	filter := bson.M{
+		"fileId":   fileID,
+		"status":   model.DocumentStatusFailed,
+		"attempts": attempt,
+	}
+	update := bson.M{"$set": bson.M{"queuedAttempt": attempt, "updatedAt": time.Now()}}
+
+	result, err := r.collection.UpdateOne(ctx, filter, update)
+	if err != nil {
+		return &DocumentError{Op: "QueueAttempt", FileID: fileID, Message: "failed to queue attempt", Err: err}
+	}
+	if result.MatchedCount == 0 {
+		return &DocumentError{
+			Op:      "QueueAttempt",
+			FileID:  fileID,
+			Message: "document is no longer failed or another run has started",
+			Err:     ErrInvalidStatusTransition,
+		}
+	}
+	return nil
+}
+
+// applyTransition runs a status update guarded by the allowed predecessor states of next
+func (r *DocumentRepository) applyTransition(ctx context.Context, op, fileID string, next model.DocumentStatus, update bson.M) error {
+	if !next.IsValid() {
+		return &DocumentError{
+			Op:      op,
+			FileID:  fileID,
+			Message: fmt.Sprintf("unknown document status: %s", next),
+			Err:     ErrInvalidStatusTransition,
+		}
+	}
+
+	// Stamp the transition with the attempt it belongs to
+	current, err := r.GetDocumentByFileID(ctx, fileID)
+	if err != nil {
+		return err
+	}
+	if push, ok := update["$push"].(bson.M); ok {
+		if transition, ok := push["statusHistory"].(model.DocumentStatusTransition); ok {
+			transition.Attempt = current.Attempts
+			push["statusHistory"] = transition
+		}
+	}
+
+	filter := bson.M{
+		"fileId": fileID,
+		"status": bson.M{"$in": model.DocumentStatusPredecessors(next)},
 	}
 
 	result, err := r.collection.UpdateOne(ctx, filter, update)
 	if err != nil {
 		return &DocumentError{
-			Op:      "UpdateStatus",
+			Op:      op,
 			FileID:  fileID,
 			Message: "failed to update document status",
 			Err:     err,
 		}
 	}
 
-	// Check if document was found and updated
+	// The document exists (checked above), so no match means the guard rejected the transition
 	if result.MatchedCount == 0 {
 		return &DocumentError{
-			Op:      "UpdateStatus",
+			Op:      op,
 			FileID:  fileID,
-			Message: "document not found",
-			Err:     mongo.ErrNoDocuments,
+			Message: fmt.Sprintf("cannot move document from %s to %s", current.Status, next),
+			Err:     ErrInvalidStatusTransition,
 		}
 	}
 
@@ -170,9 +309,11 @@ func (r *DocumentRepository) UpdateStatus(ctx context.Context, fileID string, st
 func (r *DocumentRepository) UpdateFields(ctx context.Context, fileID string, updates bson.M) error {
 	// Define immutable fields that cannot be updated
 	immutableFields := map[string]bool{
-		"fileId":    true,
-		"_id":       true,
-		"createdAt": true,
+		"fileId":        true,
+		"_id":           true,
+		"createdAt":     true,
+		"status":        true, // Status changes go through UpdateStatus/MarkFailed
+		"statusHistory": true,
 	}
 
 	// Validate that no immutable fields are being updated
@@ -266,3 +407,58 @@ func (r *DocumentRepository) ListAllDocuments(ctx context.Context, skip, limit i
 
 	return documents, nil
 }
+
+// CorpusNames returns the distinct corpora documents belong to
+func (r *DocumentRepository) CorpusNames(ctx context.Context) ([]string, error) {
+	values, err := r.collection.Distinct(ctx, "corpusName", bson.M{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list document corpora: %w", err)
+	}
+
+	names := make([]string, 0, len(values))
+	for _, value := range values {
+		if name, ok := value.(string); ok && name != "" {
+			names = append(names, name)
+		}
+	}
+	return names, nil
+}
+
+// GetCorpusStats counts the documents of a corpus and their total size, broken down by status
+func (r *DocumentRepository) GetCorpusStats(ctx context.Context, corpusName string) (model.CorpusStats, error) {
+	stats := model.CorpusStats{ByStatus: map[model.DocumentStatus]int64{}}
+
+	pipeline := []bson.M{
+		{"$match": bson.M{"corpusName": corpusName}},
+		{
+			"$group": bson.M{
+				"_id":   "$status",
+				"count": bson.M{"$sum": 1},
+				"bytes": bson.M{"$sum": "$size"},
+			},
+		},
+	}
+
+	cursor, err := r.collection.Aggregate(ctx, pipeline)
+	if err != nil {
+		return stats, err
+	}
+	defer cursor.Close(ctx)
+
+	var groups []struct {
+		Status model.DocumentStatus `bson:"_id"`
+		Count  int64                `bson:"count"`
+		Bytes  int64                `bson:"bytes"`
+	}
+	if err := cursor.All(ctx, &groups); err != nil {
+		return stats, err
+	}
+
+	for _, group := range groups {
+		stats.DocumentCount += group.Count
+		stats.TotalBytes += group.Bytes
+		stats.ByStatus[group.Status.Normalize()] += group.Count
+	}
+
+	return stats, nil
+}
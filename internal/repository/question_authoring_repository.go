@@ -0,0 +1,166 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"lumenslate/internal/db"
+	"lumenslate/internal/model"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// ErrQuestionAuthoringState is returned when an authoring draft is not in a status that allows the change
+var ErrQuestionAuthoringState = errors.New("question authoring draft is not in a status that allows this change")
+
+type QuestionAuthoringRepository struct {
+	collection *mongo.Collection
+}
+
+func NewQuestionAuthoringRepository() *QuestionAuthoringRepository {
+	return &QuestionAuthoringRepository{
+		collection: db.GetCollection(db.QuestionAuthoringCollection),
+	}
+}
+
+// Create stores a new authoring draft
+func (r *QuestionAuthoringRepository) Create(ctx context.Context, authoring *model.QuestionAuthoring) error {
+	if _, err := r.collection.InsertOne(ctx, authoring); err != nil {
+		return fmt.Errorf("failed to create question authoring draft: %w", err)
+	}
+	return nil
+}
+
+// Get retrieves an authoring draft by ID
+func (r *QuestionAuthoringRepository) Get(ctx context.Context, id string) (*model.QuestionAuthoring, error) {
+	var authoring model.QuestionAuthoring
+	if err := r.collection.FindOne(ctx, bson.M{"_id": id}).Decode(&authoring); err != nil {
+		return nil, fmt.Errorf("failed to retrieve question authoring draft %s: %w", id, err)
+	}
+	return &authoring, nil
+}
+
+// List retrieves a teacher's authoring drafts, newest first, optionally only those with the given status
+func (r *QuestionAuthoringRepository) List(ctx context.Context, teacherID, status string, limit, offset int64) ([]model.QuestionAuthoring, int64, error) {
+	filter := bson.M{"teacherId": teacherID}
+	if status != "" {
+		filter["status"] = status
+	}
+
+	total, err := r.collection.CountDocuments(ctx, filter)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	opts := options.Find().SetSort(bson.M{"createdAt": -1}).SetSkip(offset)
+	if limit > 0 {
+		opts.SetLimit(limit)
+	}
+	cursor, err := r.collection.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer cursor.Close(ctx)
+
+	drafts := []model.QuestionAuthoring{}
+	if err := cursor.All(ctx, &drafts); err != nil {
+		return nil, 0, err
+	}
+	return drafts, total, nil
+}
+
+// SetTaskID records the background task processing a draft
+func (r *QuestionAuthoringRepository) SetTaskID(ctx context.Context, id, taskID string) error {
+	_, err := r.collection.UpdateOne(ctx, bson.M{"_id": id}, bson.M{
+		"$set": bson.M{"taskId": taskID, "updatedAt": time.Now()},
+	})
+	if err != nil {
+		return fmt.Errorf("failed to set task of question authoring draft %s: %w", id, err)
+	}
+	return nil
+}
+
+// StartRun moves a queued or failed draft to processing and counts the attempt. A draft that is
+// already processing is picked up again, since that only happens when a previous run was interrupted.
+func (r *QuestionAuthoringRepository) StartRun(ctx context.Context, id string) (*model.QuestionAuthoring, error) {
+	return r.transition(ctx, id, []string{
+		model.QuestionAuthoringStatusQueued,
+		model.QuestionAuthoringStatusProcessing,
+		model.QuestionAuthoringStatusFailed,
+	}, bson.M{
+		"$set":   bson.M{"status": model.QuestionAuthoringStatusProcessing, "updatedAt": time.Now()},
+		"$inc":   bson.M{"attempts": 1},
+		"$unset": bson.M{"lastError": ""},
+	})
+}
+
+// SaveProgress stores the results of a pipeline step of a processing draft
+func (r *QuestionAuthoringRepository) SaveProgress(ctx context.Context, id string, updates bson.M) (*model.QuestionAuthoring, error) {
+	updates["updatedAt"] = time.Now()
+	return r.transition(ctx, id, []string{model.QuestionAuthoringStatusProcessing}, bson.M{"$set": updates})
+}
+
+// MarkFailed records the step a processing draft failed at
+func (r *QuestionAuthoringRepository) MarkFailed(ctx context.Context, id, step string, cause error) (*model.QuestionAuthoring, error) {
+	return r.SaveProgress(ctx, id, bson.M{
+		"status":    model.QuestionAuthoringStatusFailed,
+		"step":      step,
+		"lastError": cause.Error(),
+	})
+}
+
+// Accept closes a ready draft as accepted, recording whether the contextualized question was used
+func (r *QuestionAuthoringRepository) Accept(ctx context.Context, id string, usedContext bool) (*model.QuestionAuthoring, error) {
+	now := time.Now()
+	return r.transition(ctx, id, []string{model.QuestionAuthoringStatusReady}, bson.M{
+		"$set": bson.M{
+			"status":      model.QuestionAuthoringStatusAccepted,
+			"usedContext": usedContext,
+			"reviewedAt":  now,
+			"updatedAt":   now,
+		},
+	})
+}
+
+// Reject closes a ready or failed draft as rejected, so that the pipeline never resumes it
+func (r *QuestionAuthoringRepository) Reject(ctx context.Context, id string) (*model.QuestionAuthoring, error) {
+	now := time.Now()
+	return r.transition(ctx, id, []string{model.QuestionAuthoringStatusReady, model.QuestionAuthoringStatusFailed}, bson.M{
+		"$set": bson.M{
+			"status":     model.QuestionAuthoringStatusRejected,
+			"reviewedAt": now,
+			"updatedAt":  now,
+		},
+	})
+}
+
+// Delete removes a draft, used when its pipeline could not be queued
+func (r *QuestionAuthoringRepository) Delete(ctx context.Context, id string) error {
+	if _, err := r.collection.DeleteOne(ctx, bson.M{"_id": id}); err != nil {
+		return fmt.Errorf("failed to delete question authoring draft %s: %w", id, err)
+	}
+	return nil
+}
+
+// transition applies update to a draft in one of the statuses from and returns the updated record.
+// It returns ErrQuestionAuthoringState when the draft exists but is in another status.
+func (r *QuestionAuthoringRepository) transition(ctx context.Context, id string, from []string, update bson.M) (*model.QuestionAuthoring, error) {
+	opts := options.FindOneAndUpdate().SetReturnDocument(options.After)
+
+	var authoring model.QuestionAuthoring
+	err := r.collection.FindOneAndUpdate(ctx, bson.M{"_id": id, "status": bson.M{"$in": from}}, update, opts).Decode(&authoring)
+	if err == nil {
+		return &authoring, nil
+	}
+	if !errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, fmt.Errorf("failed to update question authoring draft %s: %w", id, err)
+	}
+
+	if _, getErr := r.Get(ctx, id); getErr != nil {
+		return nil, getErr
+	}
+	return nil, ErrQuestionAuthoringState
+}
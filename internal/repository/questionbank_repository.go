@@ -169,3 +169,27 @@ func PatchQuestionBank(id string, updates map[string]interface{}) (*model.Questi
 
 	return &updated, nil
 }
+
+// GetQuestionBankIDsByTags returns the IDs of active question banks carrying any of tags
+func GetQuestionBankIDsByTags(tags []string) ([]string, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	filter := bson.M{"tags": bson.M{"$in": tags}, "isActive": bson.M{"$ne": false}}
+	cursor, err := db.GetCollection(db.QuestionBankCollection).Find(ctx, filter, options.Find().SetProjection(bson.M{"_id": 1}))
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var banks []model.QuestionBank
+	if err = cursor.All(ctx, &banks); err != nil {
+		return nil, err
+	}
+
+	ids := make([]string, len(banks))
+	for i, bank := range banks {
+		ids[i] = bank.ID
+	}
+	return ids, nil
+}
@@ -0,0 +1,82 @@
+package questions
+
+import (
+	"context"
+	"fmt"
+	"lumenslate/internal/db"
+	"lumenslate/internal/model/questions"
+	"regexp"
+	"strings"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// CandidateFilter selects the questions an assignment can be built from
+type CandidateFilter struct {
+	BankIDs    []string // Only questions in these banks; every bank when empty
+	Subjects   []string // Accepted spellings of the subject, matched case-insensitively
+	Difficulty string   // Only this difficulty when set, matched case-insensitively
+	ExcludeIDs []string // Questions that must not be selected, e.g. already used
+}
+
+// query builds the MongoDB filter. Inactive questions are never candidates.
+func (f CandidateFilter) query() bson.M {
+	filter := bson.M{"isActive": bson.M{"$ne": false}}
+	if len(f.BankIDs) > 0 {
+		filter["bankId"] = bson.M{"$in": f.BankIDs}
+	}
+	if len(f.Subjects) > 0 {
+		filter["subject"] = exactAnyRegex(f.Subjects)
+	}
+	if f.Difficulty != "" {
+		filter["difficulty"] = exactAnyRegex([]string{f.Difficulty})
+	}
+	if len(f.ExcludeIDs) > 0 {
+		filter["_id"] = bson.M{"$nin": f.ExcludeIDs}
+	}
+	return filter
+}
+
+// exactAnyRegex matches any of values exactly, ignoring case and surrounding spaces
+func exactAnyRegex(values []string) primitive.Regex {
+	quoted := make([]string, len(values))
+	for i, value := range values {
+		quoted[i] = regexp.QuoteMeta(strings.TrimSpace(value))
+	}
+	return primitive.Regex{Pattern: fmt.Sprintf(`^\s*(%s)\s*$`, strings.Join(quoted, "|")), Options: "i"}
+}
+
+// FindMCQCandidates retrieves the MCQs matching filter
+func FindMCQCandidates(ctx context.Context, filter CandidateFilter) ([]questions.MCQ, error) {
+	return findCandidates[questions.MCQ](ctx, db.MCQCollection, filter)
+}
+
+// FindMSQCandidates retrieves the MSQs matching filter
+func FindMSQCandidates(ctx context.Context, filter CandidateFilter) ([]questions.MSQ, error) {
+	return findCandidates[questions.MSQ](ctx, db.MSQCollection, filter)
+}
+
+// FindNATCandidates retrieves the NAT questions matching filter
+func FindNATCandidates(ctx context.Context, filter CandidateFilter) ([]questions.NAT, error) {
+	return findCandidates[questions.NAT](ctx, db.NATCollection, filter)
+}
+
+// FindSubjectiveCandidates retrieves the subjective questions matching filter
+func FindSubjectiveCandidates(ctx context.Context, filter CandidateFilter) ([]questions.Subjective, error) {
+	return findCandidates[questions.Subjective](ctx, db.SubjectiveCollection, filter)
+}
+
+func findCandidates[T any](ctx context.Context, collection string, filter CandidateFilter) ([]T, error) {
+	cursor, err := db.GetCollection(collection).Find(ctx, filter.query())
+	if err != nil {
+		return nil, fmt.Errorf("failed to query %s: %w", collection, err)
+	}
+	defer cursor.Close(ctx)
+
+	results := make([]T, 0)
+	if err := cursor.All(ctx, &results); err != nil {
+		return nil, fmt.Errorf("failed to decode %s: %w", collection, err)
+	}
+	return results, nil
+}
@@ -8,6 +8,7 @@ import (
 	"time"
 
 	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
 	"go.mongodb.org/mongo-driver/mongo/options"
 )
 
@@ -62,6 +63,12 @@ func GetAllMCQs(filters map[string]string) ([]questions.MCQ, error) {
 	if bankID, ok := filters["bankId"]; ok && bankID != "" {
 		filter["bankId"] = bankID
 	}
+	if parentID, ok := filters["parentQuestionId"]; ok && parentID != "" {
+		filter["parentQuestionId"] = parentID
+	}
+	if aiGenerated, err := strconv.ParseBool(filters["aiGenerated"]); err == nil {
+		filter["generator"] = bson.M{"$exists": aiGenerated}
+	}
 
 	cursor, err := db.GetCollection(db.MCQCollection).Find(ctx, filter, findOptions)
 	if err != nil {
@@ -118,3 +125,17 @@ func SaveBulkMCQs(mcqs []questions.MCQ) error {
 	_, err := db.GetCollection(db.MCQCollection).InsertMany(ctx, documents)
 	return err
 }
+
+// UpsertBulkMCQs saves mcqs by ID, replacing any already saved, so that a retried save completes
+func UpsertBulkMCQs(mcqs []questions.MCQ) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	writes := make([]mongo.WriteModel, len(mcqs))
+	for i, m := range mcqs {
+		writes[i] = mongo.NewReplaceOneModel().SetFilter(bson.M{"_id": m.ID}).SetReplacement(m).SetUpsert(true)
+	}
+
+	_, err := db.GetCollection(db.MCQCollection).BulkWrite(ctx, writes)
+	return err
+}
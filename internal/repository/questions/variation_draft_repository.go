@@ -0,0 +1,122 @@
+package questions
+
+import (
+	"context"
+	"lumenslate/internal/db"
+	"lumenslate/internal/model/questions"
+	"strconv"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+func SaveVariationDrafts(drafts []questions.VariationDraft) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	documents := make([]interface{}, len(drafts))
+	for i, d := range drafts {
+		documents[i] = d
+	}
+
+	_, err := db.GetCollection(db.VariationDraftCollection).InsertMany(ctx, documents)
+	return err
+}
+
+func GetVariationDraftByID(id string) (*questions.VariationDraft, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	var d questions.VariationDraft
+	err := db.GetCollection(db.VariationDraftCollection).FindOne(ctx, bson.M{"_id": id}).Decode(&d)
+	if err != nil {
+		return nil, err
+	}
+	return &d, nil
+}
+
+// GetVariationDrafts lists a teacher's drafts, newest first, filtered by parentQuestionId and status
+func GetVariationDrafts(teacherID string, filters map[string]string) ([]questions.VariationDraft, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	findOptions := options.Find().SetSort(bson.D{{Key: "createdAt", Value: -1}})
+
+	// Handle pagination
+	limit := int64(20)
+	offset := int64(0)
+	if l, err := strconv.Atoi(filters["limit"]); err == nil {
+		limit = int64(l)
+	}
+	if o, err := strconv.Atoi(filters["offset"]); err == nil {
+		offset = int64(o)
+	}
+	findOptions.SetLimit(limit)
+	findOptions.SetSkip(offset)
+
+	// Build filter
+	filter := bson.M{"teacherId": teacherID}
+	if parentID, ok := filters["parentQuestionId"]; ok && parentID != "" {
+		filter["parentQuestionId"] = parentID
+	}
+	if status, ok := filters["status"]; ok && status != "" {
+		filter["status"] = status
+	}
+
+	cursor, err := db.GetCollection(db.VariationDraftCollection).Find(ctx, filter, findOptions)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	results := make([]questions.VariationDraft, 0)
+	if err = cursor.All(ctx, &results); err != nil {
+		return nil, err
+	}
+	return results, nil
+}
+
+// UpdateVariationDraft applies updates to a draft that is still awaiting review and returns it.
+// It returns mongo.ErrNoDocuments when the draft does not exist or has already been reviewed.
+func UpdateVariationDraft(id string, updates bson.M) (*questions.VariationDraft, error) {
+	return transitionVariationDraft(id, questions.VariationDraftStatusDraft, updates)
+}
+
+// ClaimVariationDraft moves a draft awaiting review to accepting, so that only one accept saves its question.
+// It returns mongo.ErrNoDocuments when the draft does not exist or has already been reviewed or claimed.
+func ClaimVariationDraft(id string) (*questions.VariationDraft, error) {
+	return transitionVariationDraft(id, questions.VariationDraftStatusDraft, bson.M{"status": questions.VariationDraftStatusAccepting})
+}
+
+// MarkVariationDraftAccepted completes a claimed draft once its question has been saved
+func MarkVariationDraftAccepted(id, reviewedBy string, reviewedAt time.Time) (*questions.VariationDraft, error) {
+	return transitionVariationDraft(id, questions.VariationDraftStatusAccepting, bson.M{
+		"status":     questions.VariationDraftStatusAccepted,
+		"reviewedBy": reviewedBy,
+		"reviewedAt": reviewedAt,
+	})
+}
+
+// ReleaseVariationDraft returns a claimed draft to review after saving its question failed
+func ReleaseVariationDraft(id string) (*questions.VariationDraft, error) {
+	return transitionVariationDraft(id, questions.VariationDraftStatusAccepting, bson.M{"status": questions.VariationDraftStatusDraft})
+}
+
+// transitionVariationDraft applies updates to a draft in status from and returns it.
+// It returns mongo.ErrNoDocuments when there is no such draft in that status.
+func transitionVariationDraft(id, from string, updates bson.M) (*questions.VariationDraft, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	updates["updatedAt"] = time.Now()
+	filter := bson.M{"_id": id, "status": from}
+	opts := options.FindOneAndUpdate().SetReturnDocument(options.After)
+
+	var updated questions.VariationDraft
+	err := db.GetCollection(db.VariationDraftCollection).FindOneAndUpdate(ctx, filter, bson.M{"$set": updates}, opts).Decode(&updated)
+	if err != nil {
+		return nil, err
+	}
+	return &updated, nil
+}
@@ -0,0 +1,76 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"lumenslate/internal/db"
+	"lumenslate/internal/model"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type ReconciliationRepository struct {
+	collection *mongo.Collection
+}
+
+func NewReconciliationRepository() *ReconciliationRepository {
+	return &ReconciliationRepository{
+		collection: db.GetCollection(db.ReconciliationCollection),
+	}
+}
+
+// Create stores a reconciliation report
+func (r *ReconciliationRepository) Create(ctx context.Context, report *model.ReconciliationReport) error {
+	result, err := r.collection.InsertOne(ctx, report)
+	if err != nil {
+		return fmt.Errorf("failed to create reconciliation report: %w", err)
+	}
+	if id, ok := result.InsertedID.(primitive.ObjectID); ok {
+		report.ID = id
+	}
+	return nil
+}
+
+// Get retrieves a reconciliation report by ID
+func (r *ReconciliationRepository) Get(ctx context.Context, id string) (*model.ReconciliationReport, error) {
+	objectID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, mongo.ErrNoDocuments
+	}
+
+	var report model.ReconciliationReport
+	if err := r.collection.FindOne(ctx, bson.M{"_id": objectID}).Decode(&report); err != nil {
+		return nil, fmt.Errorf("failed to retrieve reconciliation report %s: %w", id, err)
+	}
+	return &report, nil
+}
+
+// List retrieves reconciliation reports without their drifts, newest first
+func (r *ReconciliationRepository) List(ctx context.Context, limit, offset int64) ([]model.ReconciliationReport, int64, error) {
+	total, err := r.collection.CountDocuments(ctx, bson.M{})
+	if err != nil {
+		return nil, 0, err
+	}
+
+	opts := options.Find().
+		SetSort(bson.M{"startedAt": -1}).
+		SetSkip(offset).
+		SetProjection(bson.M{"drifts": 0})
+	if limit > 0 {
+		opts.SetLimit(limit)
+	}
+	cursor, err := r.collection.Find(ctx, bson.M{}, opts)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer cursor.Close(ctx)
+
+	reports := []model.ReconciliationReport{}
+	if err := cursor.All(ctx, &reports); err != nil {
+		return nil, 0, err
+	}
+	return reports, total, nil
+}
@@ -0,0 +1,35 @@
+package routes
+
+import (
+	"lumenslate/internal/controller"
+	"lumenslate/internal/middleware"
+	"lumenslate/internal/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+// RegisterAdminRoutes registers the operator endpoints, all requiring the admin bearer token
+func RegisterAdminRoutes(router *gin.RouterGroup, taskAdmin *service.TaskAdmin) {
+	taskAdminController := controller.NewTaskAdminController(taskAdmin)
+	corpusAdminController := controller.NewCorpusAdminController()
+
+	admin := router.Group("/admin", middleware.AdminAuth())
+	{
+		admin.GET("/queues", taskAdminController.ListQueuesHandler)
+		admin.POST("/queues/:queue/pause", taskAdminController.PauseQueueHandler)
+		admin.POST("/queues/:queue/unpause", taskAdminController.UnpauseQueueHandler)
+		admin.GET("/queues/:queue/tasks", taskAdminController.ListTasksHandler)
+		admin.GET("/queues/:queue/tasks/:taskId", taskAdminController.GetTaskHandler)
+		admin.DELETE("/queues/:queue/tasks/:taskId", taskAdminController.DeleteTaskHandler)
+		admin.POST("/queues/:queue/tasks/:taskId/retry", taskAdminController.RetryTaskHandler)
+		admin.POST("/queues/:queue/tasks/:taskId/archive", taskAdminController.ArchiveTaskHandler)
+		admin.POST("/queues/:queue/tasks/:taskId/cancel", taskAdminController.CancelTaskHandler)
+		admin.GET("/documents/:fileId/tasks", taskAdminController.DocumentTasksHandler)
+		admin.GET("/dead-letters", taskAdminController.ListDeadLettersHandler)
+		admin.GET("/dead-letters/:id", taskAdminController.GetDeadLetterHandler)
+		admin.PUT("/corpora/:corpusName/owner", corpusAdminController.AssignCorpusOwnerHandler)
+		admin.POST("/reconciliation/runs", taskAdminController.StartReconciliationHandler)
+		admin.GET("/reconciliation/reports", taskAdminController.ListReconciliationReportsHandler)
+		admin.GET("/reconciliation/reports/:id", taskAdminController.GetReconciliationReportHandler)
+	}
+}
@@ -2,12 +2,13 @@ package routes
 
 import (
 	"lumenslate/internal/controller/ai"
+	"lumenslate/internal/middleware"
 
 	"github.com/gin-gonic/gin"
 )
 
 func RegisterAIRoutes(r *gin.RouterGroup) {
-	aiGroup := r.Group("/ai")
+	aiGroup := r.Group("/ai", middleware.CallerAuth())
 	{
 		// Question-related AI services (from question_controller.go)
 		aiGroup.POST("/generate-context", ai.GenerateContextHandler)
@@ -17,16 +18,51 @@ func RegisterAIRoutes(r *gin.RouterGroup) {
 		aiGroup.POST("/generate-msq", ai.GenerateMSQVariationsHandler)
 		aiGroup.POST("/filter-and-randomize", ai.FilterAndRandomizeHandler)
 
+		// Variation drafts reviewed before they join a question bank (from variation_controller.go)
+		aiGroup.POST("/variations", ai.GenerateVariationDraftsHandler)
+		aiGroup.GET("/variations", ai.ListVariationDraftsHandler)
+		aiGroup.PATCH("/variations/:draftId", ai.EditVariationDraftHandler)
+		aiGroup.POST("/variations/:draftId/accept", ai.AcceptVariationDraftHandler)
+		aiGroup.POST("/variations/:draftId/reject", ai.RejectVariationDraftHandler)
+
+		// Question authoring pipeline run as a background job (from question_authoring_controller.go)
+		aiGroup.POST("/author-question", ai.AuthorQuestionHandler)
+		aiGroup.GET("/author-question", ai.ListQuestionAuthoringHandler)
+		aiGroup.GET("/author-question/:authoringId", ai.GetQuestionAuthoringHandler)
+		aiGroup.POST("/author-question/:authoringId/accept", ai.AcceptQuestionAuthoringHandler)
+		aiGroup.POST("/author-question/:authoringId/reject", ai.RejectQuestionAuthoringHandler)
+
 		// Agent services (from agent_controller.go)
 		aiGroup.POST("/agent", ai.AgentHandler)
+		aiGroup.POST("/agent/stream", ai.AgentStreamHandler)
+
+		// Agent conversation history (from agent_session_controller.go)
+		aiGroup.GET("/agent/sessions", ai.ListAgentSessionsHandler)
+		aiGroup.GET("/agent/sessions/:sessionId", ai.GetAgentSessionHandler)
+		aiGroup.PATCH("/agent/sessions/:sessionId", ai.RenameAgentSessionHandler)
+		aiGroup.DELETE("/agent/sessions/:sessionId", ai.DeleteAgentSessionHandler)
+		aiGroup.POST("/agent/sessions/:sessionId/messages/:messageId/feedback", ai.AgentMessageFeedbackHandler)
+
+		// Agent write proposals awaiting teacher approval (from agent_proposal_controller.go)
+		aiGroup.GET("/agent/proposals", ai.ListAgentProposalsHandler)
+		aiGroup.GET("/agent/proposals/:proposalId", ai.GetAgentProposalHandler)
+		aiGroup.PATCH("/agent/proposals/:proposalId", ai.EditAgentProposalHandler)
+		aiGroup.POST("/agent/proposals/:proposalId/approve", ai.ApproveAgentProposalHandler)
+		aiGroup.POST("/agent/proposals/:proposalId/reject", ai.RejectAgentProposalHandler)
 
 		// RAG agent services (from rag_controller.go)
 		aiGroup.POST("/rag-agent", ai.RAGAgentHandler)
+		aiGroup.POST("/rag-agent/stream", ai.RAGAgentStreamHandler)
 
 		// Corpus management (from corpus_controller.go and rag_controller.go)
 		aiGroup.POST("/rag-agent/create-corpus", ai.CreateCorpusHandler)
 		aiGroup.POST("/rag-agent/list-corpus-content", ai.ListCorpusContentHandler)
 		aiGroup.POST("/rag-agent/list-all-corpora", ai.ListAllCorporaHandler)
+		aiGroup.GET("/corpora/:corpusName/stats", ai.GetCorpusStatsHandler)
+		aiGroup.PATCH("/corpora/:corpusName", ai.UpdateCorpusHandler)
+		aiGroup.DELETE("/corpora/:corpusName", ai.DeleteCorpusHandler)
+		aiGroup.POST("/corpora/:corpusName/classrooms", ai.ShareCorpusHandler)
+		aiGroup.DELETE("/corpora/:corpusName/classrooms/:classroomId", ai.UnshareCorpusHandler)
 
 		// Document management (from document_controller.go)
 		aiGroup.POST("/rag-agent/add-corpus-document", ai.AddCorpusDocumentHandler)
@@ -35,6 +71,7 @@ func RegisterAIRoutes(r *gin.RouterGroup) {
 		aiGroup.GET("/rag-agent/:corpusName/documents", ai.ListCorpusDocumentsHandler)
 		aiGroup.GET("/documents/view/:id", ai.ViewDocumentHandler)
 		aiGroup.DELETE("/documents/:id", ai.DeleteCorpusDocumentByIDHandler)
+		aiGroup.POST("/documents/:id/reprocess", ai.ReprocessDocumentHandler)
 
 		// Operation management (from operation_controller.go)
 		aiGroup.POST("/operations/status", ai.CheckOperationStatusHandler)
@@ -0,0 +1,16 @@
+package routes
+
+import (
+	"lumenslate/internal/controller"
+	"lumenslate/internal/middleware"
+	"lumenslate/internal/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+// RegisterEventRoutes registers the Server-Sent Events stream for background job updates
+func RegisterEventRoutes(router *gin.RouterGroup, broker *service.EventBroker) {
+	eventController := controller.NewEventController(broker)
+
+	router.GET("/events", middleware.CallerAuth(), eventController.StreamEventsHandler)
+}
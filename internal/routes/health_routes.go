@@ -9,17 +9,19 @@ import (
 )
 
 // RegisterHealthRoutes registers health check and monitoring routes
-func RegisterHealthRoutes(router *gin.Engine, metricsCollector *service.MetricsCollector, startTime time.Time) {
-	healthController := controller.NewHealthController(metricsCollector, startTime)
+func RegisterHealthRoutes(router *gin.Engine, metricsCollector *service.MetricsCollector, readiness *service.ReadinessRegistry, startTime time.Time) {
+	healthController := controller.NewHealthController(metricsCollector, readiness, startTime)
 
 	// Health check routes
 	health := router.Group("/health")
 	{
-		health.GET("", healthController.BasicHealthHandler)                                      // Basic health check
+		health.GET("", healthController.BasicHealthHandler)                                      // Overall status from the dependency checks
 		health.GET("/live", healthController.LivenessHandler)                                    // Kubernetes liveness probe
 		health.GET("/ready", healthController.ReadinessHandler)                                  // Kubernetes readiness probe
 		health.GET("/background-processing", healthController.BackgroundProcessingHealthHandler) // Detailed background processing health
 		health.GET("/metrics", healthController.MetricsHandler)                                  // System metrics
 		health.GET("/metrics/task/:taskType", healthController.TaskMetricsHandler)               // Task-specific metrics
+		health.GET("/metrics/tasks", healthController.AllTaskMetricsHandler)                     // Metrics of every task type
+		health.GET("/metrics/cache", healthController.CacheMetricsHandler)                       // AI response cache metrics
 	}
 }
@@ -0,0 +1,234 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"time"
+
+	"lumenslate/internal/utils"
+
+	"github.com/google/uuid"
+	"github.com/redis/go-redis/v9"
+)
+
+// notifyTimeout bounds the delivery of one notification by one notifier
+const notifyTimeout = 15 * time.Second
+
+// Redis keys of the alert state shared by the evaluators of every worker
+const (
+	alertStateKey = "lumenslate:alerts:active" // Hash of the firing alerts' notifications by key
+	alertLockKey  = "lumenslate:alerts:lock"   // Held by the evaluator running the current round
+)
+
+// AlertEvaluator periodically evaluates the alert rules against the shared task history and queues
+// and notifies when an alert starts firing, changes level or resolves. An alert that keeps firing at
+// the same level is only notified once. Every worker runs an evaluator; the firing alerts are kept in
+// Redis and a lock held for the evaluation interval lets only one of them evaluate each round, so an
+// alert is notified once whichever worker sees it.
+type AlertEvaluator struct {
+	collector *MetricsCollector
+	notifiers []AlertNotifier
+	client    *redis.Client
+	owner     string // Value of the round lock held by this evaluator
+	logger    *utils.Logger
+
+	stop chan struct{}
+	done chan struct{}
+}
+
+// NewAlertEvaluator creates an evaluator of the collector's alert rules delivering to notifiers.
+// The alert state is kept in the Redis of the collector's task history.
+func NewAlertEvaluator(collector *MetricsCollector, notifiers []AlertNotifier) *AlertEvaluator {
+	return &AlertEvaluator{
+		collector: collector,
+		notifiers: notifiers,
+		client:    collector.TaskHistory().client,
+		owner:     uuid.NewString(),
+		logger:    utils.NewLogger("alert_evaluator"),
+	}
+}
+
+// Start evaluates the rules every evaluation interval until Stop is called
+func (e *AlertEvaluator) Start() {
+	e.stop = make(chan struct{})
+	e.done = make(chan struct{})
+
+	go func() {
+		defer close(e.done)
+		ticker := time.NewTicker(e.collector.AlertRules().EvaluationInterval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-e.stop:
+				return
+			case <-ticker.C:
+				ctx := context.Background()
+				if _, err := e.Evaluate(ctx); err != nil {
+					e.logger.ErrorWithOperation(ctx, "alert_evaluate", "Failed to evaluate alert rules", err)
+				}
+			}
+		}
+	}()
+}
+
+// Stop stops the evaluation loop started by Start and waits for the current round to finish
+func (e *AlertEvaluator) Stop() {
+	if e.stop == nil {
+		return
+	}
+	close(e.stop)
+	<-e.done
+}
+
+// Evaluate runs one round of evaluation, delivers the resulting notifications and returns them.
+// It does nothing when another evaluator has already run the round of the current interval.
+func (e *AlertEvaluator) Evaluate(ctx context.Context) ([]AlertNotification, error) {
+	rules := e.collector.AlertRules()
+	acquired, err := e.client.SetNX(ctx, alertLockKey, e.owner, rules.EvaluationInterval).Result()
+	if err != nil {
+		return nil, fmt.Errorf("failed to acquire the alert evaluation lock: %w", err)
+	}
+	if !acquired {
+		return nil, nil
+	}
+
+	alerts, err := e.collector.EvaluateAlerts(ctx, rules)
+	if err != nil {
+		return nil, fmt.Errorf("failed to evaluate alert rules: %w", err)
+	}
+	active, err := e.activeAlerts(ctx)
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+
+	var notifications []AlertNotification
+	updates := make(map[string]AlertNotification)
+	firing := make(map[string]bool, len(alerts))
+	for _, alert := range alerts {
+		key := alertKey(alert)
+		firing[key] = true
+
+		previous, exists := active[key]
+		if !exists {
+			notification := AlertNotification{State: AlertFiring, Key: key, Alert: alert, StartsAt: now}
+			updates[key] = notification
+			notifications = append(notifications, notification)
+			continue
+		}
+
+		// Keep the latest values without notifying again unless the level changed
+		changed := previous.Alert.Level != alert.Level
+		previous.Alert = alert
+		updates[key] = previous
+		if changed {
+			notifications = append(notifications, previous)
+		}
+	}
+	var resolved []string
+	for key, notification := range active {
+		if firing[key] {
+			continue
+		}
+		resolved = append(resolved, key)
+		notification.State = AlertResolved
+		notification.EndsAt = now
+		notifications = append(notifications, notification)
+	}
+
+	if err := e.saveAlerts(ctx, updates, resolved); err != nil {
+		return nil, err
+	}
+
+	for _, notification := range notifications {
+		e.notify(ctx, notification)
+	}
+	return notifications, nil
+}
+
+// ActiveAlerts returns the alerts currently firing, oldest first
+func (e *AlertEvaluator) ActiveAlerts(ctx context.Context) ([]AlertNotification, error) {
+	active, err := e.activeAlerts(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	alerts := make([]AlertNotification, 0, len(active))
+	for _, notification := range active {
+		alerts = append(alerts, notification)
+	}
+	sort.Slice(alerts, func(i, j int) bool {
+		if alerts[i].StartsAt.Equal(alerts[j].StartsAt) {
+			return alerts[i].Key < alerts[j].Key
+		}
+		return alerts[i].StartsAt.Before(alerts[j].StartsAt)
+	})
+	return alerts, nil
+}
+
+// activeAlerts reads the firing alerts by key from Redis
+func (e *AlertEvaluator) activeAlerts(ctx context.Context) (map[string]AlertNotification, error) {
+	values, err := e.client.HGetAll(ctx, alertStateKey).Result()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read active alerts: %w", err)
+	}
+
+	active := make(map[string]AlertNotification, len(values))
+	for key, value := range values {
+		var notification AlertNotification
+		if err := json.Unmarshal([]byte(value), &notification); err != nil {
+			return nil, fmt.Errorf("failed to decode active alert %s: %w", key, err)
+		}
+		active[key] = notification
+	}
+	return active, nil
+}
+
+// saveAlerts stores the firing alerts in updates and removes the resolved ones
+func (e *AlertEvaluator) saveAlerts(ctx context.Context, updates map[string]AlertNotification, resolved []string) error {
+	pipe := e.client.TxPipeline()
+	for key, notification := range updates {
+		value, err := json.Marshal(notification)
+		if err != nil {
+			return fmt.Errorf("failed to encode alert %s: %w", key, err)
+		}
+		pipe.HSet(ctx, alertStateKey, key, value)
+	}
+	if len(resolved) > 0 {
+		pipe.HDel(ctx, alertStateKey, resolved...)
+	}
+	if _, err := pipe.Exec(ctx); err != nil {
+		return fmt.Errorf("failed to save active alerts: %w", err)
+	}
+	return nil
+}
+
+// notify logs the notification and delivers it to every notifier, logging failures
+func (e *AlertEvaluator) notify(ctx context.Context, notification AlertNotification) {
+	metadata := map[string]string{
+		"state": notification.State,
+		"level": notification.Alert.Level,
+		"key":   notification.Key,
+	}
+	e.logger.InfoWithMetrics(ctx, "alert_"+notification.State, notification.Summary(), 0, metadata)
+
+	for _, notifier := range e.notifiers {
+		notifyCtx, cancel := context.WithTimeout(ctx, notifyTimeout)
+		err := notifier.Notify(notifyCtx, notification)
+		cancel()
+		if err != nil {
+			e.logger.ErrorWithOperation(ctx, "alert_notify", fmt.Sprintf("Failed to deliver alert %s via %s", notification.Key, notifier.Name()), err)
+		}
+	}
+}
+
+// alertKey identifies an alert across evaluations: its type, and its task type for per-task alerts
+func alertKey(alert Alert) string {
+	if taskType := alert.Metadata["task_type"]; taskType != "" {
+		return alert.Type + ":" + taskType
+	}
+	return alert.Type
+}
@@ -0,0 +1,255 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/smtp"
+	"os"
+	"strings"
+	"time"
+)
+
+// Alert notification states
+const (
+	AlertFiring   = "firing"
+	AlertResolved = "resolved"
+)
+
+// AlertNotification announces that an alert started firing, changed level or resolved
+type AlertNotification struct {
+	State    string    `json:"state"`
+	Key      string    `json:"key"`
+	Alert    Alert     `json:"alert"`
+	StartsAt time.Time `json:"starts_at"`
+	EndsAt   time.Time `json:"ends_at"` // Zero while firing
+}
+
+// Summary returns a one-line description of the notification
+func (n AlertNotification) Summary() string {
+	if n.State == AlertResolved {
+		return fmt.Sprintf("[RESOLVED] %s: %s", n.Alert.Type, n.Alert.Message)
+	}
+	return fmt.Sprintf("[FIRING %s] %s: %s", strings.ToUpper(n.Alert.Level), n.Alert.Type, n.Alert.Message)
+}
+
+// AlertNotifier delivers alert notifications to an outside channel
+type AlertNotifier interface {
+	Name() string
+	Notify(ctx context.Context, notification AlertNotification) error
+}
+
+// WebhookNotifier posts every notification as JSON to a URL
+type WebhookNotifier struct {
+	url    string
+	client *http.Client
+}
+
+// NewWebhookNotifier creates a notifier posting to url
+func NewWebhookNotifier(url string, client *http.Client) *WebhookNotifier {
+	if client == nil {
+		client = &http.Client{Timeout: 10 * time.Second}
+	}
+	return &WebhookNotifier{url: url, client: client}
+}
+
+// Name identifies the notifier in logs
+func (n *WebhookNotifier) Name() string {
+	return "webhook"
+}
+
+// Notify posts the notification
+func (n *WebhookNotifier) Notify(ctx context.Context, notification AlertNotification) error {
+	return postJSON(ctx, n.client, n.url, notification)
+}
+
+// SlackNotifier posts notifications to a Slack-compatible incoming webhook
+type SlackNotifier struct {
+	url    string
+	client *http.Client
+}
+
+// NewSlackNotifier creates a notifier posting to the incoming webhook at url
+func NewSlackNotifier(url string, client *http.Client) *SlackNotifier {
+	if client == nil {
+		client = &http.Client{Timeout: 10 * time.Second}
+	}
+	return &SlackNotifier{url: url, client: client}
+}
+
+// Name identifies the notifier in logs
+func (n *SlackNotifier) Name() string {
+	return "slack"
+}
+
+// Notify posts the notification as a message
+func (n *SlackNotifier) Notify(ctx context.Context, notification AlertNotification) error {
+	icon := ":rotating_light:"
+	if notification.State == AlertResolved {
+		icon = ":white_check_mark:"
+	}
+	return postJSON(ctx, n.client, n.url, map[string]string{
+		"text": fmt.Sprintf("%s %s", icon, notification.Summary()),
+	})
+}
+
+// EmailNotifier sends notifications by email over SMTP
+type EmailNotifier struct {
+	addr string
+	auth smtp.Auth
+	from string
+	to   []string
+}
+
+// NewEmailNotifier creates a notifier sending mail through the SMTP server at addr (host:port).
+// Without a username the server is used unauthenticated.
+func NewEmailNotifier(addr, username, password, from string, to []string) *EmailNotifier {
+	var auth smtp.Auth
+	if username != "" {
+		host, _, _ := net.SplitHostPort(addr)
+		auth = smtp.PlainAuth("", username, password, host)
+	}
+	return &EmailNotifier{addr: addr, auth: auth, from: from, to: to}
+}
+
+// Name identifies the notifier in logs
+func (n *EmailNotifier) Name() string {
+	return "email"
+}
+
+// Notify sends the notification to every recipient
+func (n *EmailNotifier) Notify(ctx context.Context, notification AlertNotification) error {
+	var body strings.Builder
+	fmt.Fprintf(&body, "From: %s\r\n", n.from)
+	fmt.Fprintf(&body, "To: %s\r\n", strings.Join(n.to, ", "))
+	fmt.Fprintf(&body, "Subject: [LumenSlate] %s\r\n", notification.Summary())
+	body.WriteString("Content-Type: text/plain; charset=UTF-8\r\n\r\n")
+	fmt.Fprintf(&body, "%s\r\n\r\n", notification.Alert.Message)
+	fmt.Fprintf(&body, "State: %s\r\nLevel: %s\r\nType: %s\r\nStarted: %s\r\n",
+		notification.State, notification.Alert.Level, notification.Alert.Type, notification.StartsAt.Format(time.RFC3339))
+	if notification.State == AlertResolved {
+		fmt.Fprintf(&body, "Resolved: %s\r\n", notification.EndsAt.Format(time.RFC3339))
+	}
+	for key, value := range notification.Alert.Metadata {
+		fmt.Fprintf(&body, "%s: %s\r\n", key, value)
+	}
+
+	if err := n.send(ctx, []byte(body.String())); err != nil {
+		return fmt.Errorf("failed to send alert email: %w", err)
+	}
+	return nil
+}
+
+// send delivers msg like smtp.SendMail, but dials under ctx and stops at its deadline, so that a
+// hung server cannot stall the alert evaluator
+func (n *EmailNotifier) send(ctx context.Context, msg []byte) error {
+	var dialer net.Dialer
+	conn, err := dialer.DialContext(ctx, "tcp", n.addr)
+	if err != nil {
+		return err
+	}
+	if deadline, ok := ctx.Deadline(); ok {
+		if err := conn.SetDeadline(deadline); err != nil {
+			conn.Close()
+			return err
+		}
+	}
+
+	host, _, _ := net.SplitHostPort(n.addr)
+	client, err := smtp.NewClient(conn, host)
+	if err != nil {
+		conn.Close()
+		return err
+	}
+	defer client.Close()
+
+	if ok, _ := client.Extension("STARTTLS"); ok {
+		if err := client.StartTLS(&tls.Config{ServerName: host}); err != nil {
+			return err
+		}
+	}
+	if n.auth != nil {
+		if err := client.Auth(n.auth); err != nil {
+			return err
+		}
+	}
+	if err := client.Mail(n.from); err != nil {
+		return err
+	}
+	for _, recipient := range n.to {
+		if err := client.Rcpt(recipient); err != nil {
+			return err
+		}
+	}
+	writer, err := client.Data()
+	if err != nil {
+		return err
+	}
+	if _, err := writer.Write(msg); err != nil {
+		return err
+	}
+	if err := writer.Close(); err != nil {
+		return err
+	}
+	return client.Quit()
+}
+
+// postJSON posts payload as JSON to url, failing on a non-2xx response
+func postJSON(ctx context.Context, client *http.Client, url string, payload interface{}) error {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return fmt.Errorf("failed to marshal alert notification: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to create alert notification request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to post alert notification: %w", err)
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, resp.Body)
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("alert notification rejected with status %d", resp.StatusCode)
+	}
+	return nil
+}
+
+// LoadAlertNotifiers creates the notifiers configured in the environment:
+// ALERT_WEBHOOK_URL, ALERT_SLACK_WEBHOOK_URL, and ALERT_SMTP_ADDR with ALERT_EMAIL_FROM,
+// ALERT_EMAIL_TO (comma-separated) and optionally ALERT_SMTP_USERNAME and ALERT_SMTP_PASSWORD
+func LoadAlertNotifiers() ([]AlertNotifier, error) {
+	var notifiers []AlertNotifier
+
+	if url := os.Getenv("ALERT_WEBHOOK_URL"); url != "" {
+		notifiers = append(notifiers, NewWebhookNotifier(url, nil))
+	}
+	if url := os.Getenv("ALERT_SLACK_WEBHOOK_URL"); url != "" {
+		notifiers = append(notifiers, NewSlackNotifier(url, nil))
+	}
+	if addr := os.Getenv("ALERT_SMTP_ADDR"); addr != "" {
+		from := os.Getenv("ALERT_EMAIL_FROM")
+		var to []string
+		for _, recipient := range strings.Split(os.Getenv("ALERT_EMAIL_TO"), ",") {
+			if recipient = strings.TrimSpace(recipient); recipient != "" {
+				to = append(to, recipient)
+			}
+		}
+		if from == "" || len(to) == 0 {
+			return nil, fmt.Errorf("ALERT_SMTP_ADDR requires ALERT_EMAIL_FROM and ALERT_EMAIL_TO")
+		}
+		notifiers = append(notifiers, NewEmailNotifier(addr, os.Getenv("ALERT_SMTP_USERNAME"), os.Getenv("ALERT_SMTP_PASSWORD"), from, to))
+	}
+
+	return notifiers, nil
+}
@@ -0,0 +1,152 @@
+package service
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"time"
+)
+
+// AlertRules holds the alert thresholds of the background processing system. Queue depth and
+// processing lag are checked against the default thresholds; success and error rates are checked per task
+// type, against the task type's own thresholds when it has any, over the outcomes every process
+// recorded in the task history during the last Window.
+type AlertRules struct {
+	EvaluationInterval time.Duration
+	Window             time.Duration
+	Default            AlertThresholds
+	TaskTypes          map[string]AlertThresholds
+}
+
+// DefaultAlertRules returns the thresholds used when no rules file is configured
+func DefaultAlertRules() AlertRules {
+	return AlertRules{
+		EvaluationInterval: time.Minute,
+		Window:             time.Hour,
+		Default: AlertThresholds{
+			MaxErrorRate:     0.1,             // 10% max error rate
+			MaxQueueDepth:    100,             // Max 100 items in queue
+			MaxProcessingLag: 5 * time.Minute, // Max 5 minutes processing lag
+			MinSuccessRate:   0.9,             // Min 90% success rate
+		},
+		TaskTypes: map[string]AlertThresholds{},
+	}
+}
+
+// ForTaskType returns the thresholds applying to taskType
+func (r AlertRules) ForTaskType(taskType string) AlertThresholds {
+	if thresholds, ok := r.TaskTypes[taskType]; ok {
+		return thresholds
+	}
+	return r.Default
+}
+
+// alertRulesFile is the JSON layout of ALERT_RULES_FILE. Thresholds left out of a task type
+// inherit the default ones, and thresholds left out of the default keep the built-in values.
+// A task type alerts when its success rate drops below min_success_rate or its error rate rises
+// above max_error_rate; a max_error_rate of 0 is not checked.
+//
+//	{
+//	  "evaluation_interval": "1m",
+//	  "window": "1h",
+//	  "default": {"max_queue_depth": 100, "max_processing_lag": "5m", "min_success_rate": 0.9},
+//	  "task_types": {"add_document_to_corpus": {"min_success_rate": 0.95, "max_error_rate": 0.02}}
+//	}
+type alertRulesFile struct {
+	EvaluationInterval string                         `json:"evaluation_interval"`
+	Window             string                         `json:"window"`
+	Default            alertThresholdsFile            `json:"default"`
+	TaskTypes          map[string]alertThresholdsFile `json:"task_types"`
+}
+
+type alertThresholdsFile struct {
+	MaxErrorRate     *float64 `json:"max_error_rate"`
+	MaxQueueDepth    *int64   `json:"max_queue_depth"`
+	MaxProcessingLag *string  `json:"max_processing_lag"`
+	MinSuccessRate   *float64 `json:"min_success_rate"`
+}
+
+// apply returns base with the thresholds set in the file replaced
+func (f alertThresholdsFile) apply(base AlertThresholds) (AlertThresholds, error) {
+	if f.MaxErrorRate != nil {
+		base.MaxErrorRate = *f.MaxErrorRate
+	}
+	if f.MaxQueueDepth != nil {
+		base.MaxQueueDepth = *f.MaxQueueDepth
+	}
+	if f.MaxProcessingLag != nil {
+		lag, err := time.ParseDuration(*f.MaxProcessingLag)
+		if err != nil {
+			return base, fmt.Errorf("invalid max_processing_lag %q: %w", *f.MaxProcessingLag, err)
+		}
+		base.MaxProcessingLag = lag
+	}
+	if f.MinSuccessRate != nil {
+		base.MinSuccessRate = *f.MinSuccessRate
+	}
+	return base, nil
+}
+
+// LoadAlertRules reads the alert rules from the JSON file named by ALERT_RULES_FILE, falling back
+// to DefaultAlertRules when it is not set. ALERT_EVALUATION_INTERVAL and ALERT_WINDOW override the
+// interval and window of the file.
+func LoadAlertRules() (AlertRules, error) {
+	rules := DefaultAlertRules()
+
+	if path := os.Getenv("ALERT_RULES_FILE"); path != "" {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return rules, fmt.Errorf("failed to read alert rules: %w", err)
+		}
+		var file alertRulesFile
+		if err := json.Unmarshal(data, &file); err != nil {
+			return rules, fmt.Errorf("failed to parse alert rules %s: %w", path, err)
+		}
+
+		if file.EvaluationInterval != "" {
+			interval, err := time.ParseDuration(file.EvaluationInterval)
+			if err != nil {
+				return rules, fmt.Errorf("invalid evaluation_interval %q: %w", file.EvaluationInterval, err)
+			}
+			rules.EvaluationInterval = interval
+		}
+		if file.Window != "" {
+			window, err := ParseWindow(file.Window)
+			if err != nil {
+				return rules, fmt.Errorf("invalid window %q: %w", file.Window, err)
+			}
+			rules.Window = window
+		}
+		if rules.Default, err = file.Default.apply(rules.Default); err != nil {
+			return rules, fmt.Errorf("default alert rules: %w", err)
+		}
+		for taskType, thresholds := range file.TaskTypes {
+			if rules.TaskTypes[taskType], err = thresholds.apply(rules.Default); err != nil {
+				return rules, fmt.Errorf("alert rules of %s: %w", taskType, err)
+			}
+		}
+	}
+
+	if value := os.Getenv("ALERT_EVALUATION_INTERVAL"); value != "" {
+		interval, err := time.ParseDuration(value)
+		if err != nil {
+			return rules, fmt.Errorf("invalid ALERT_EVALUATION_INTERVAL %q: %w", value, err)
+		}
+		rules.EvaluationInterval = interval
+	}
+	if value := os.Getenv("ALERT_WINDOW"); value != "" {
+		window, err := ParseWindow(value)
+		if err != nil {
+			return rules, fmt.Errorf("invalid ALERT_WINDOW %q: %w", value, err)
+		}
+		rules.Window = window
+	}
+	if rules.EvaluationInterval <= 0 {
+		return rules, fmt.Errorf("alert evaluation interval must be positive, got %s", rules.EvaluationInterval)
+	}
+	if rules.Window <= 0 {
+		return rules, fmt.Errorf("alert window must be positive, got %s", rules.Window)
+	}
+
+	return rules, nil
+}
@@ -0,0 +1,112 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/hibiken/asynq"
+	"github.com/robfig/cron/v3"
+)
+
+// AsynqScheduler enqueues periodic tasks on cron schedules. Every worker runs one, and each run is
+// enqueued with a task ID derived from its period, kept for the length of the period, so only the
+// first worker to fire in a period enqueues the run.
+type AsynqScheduler struct {
+	cron   *cron.Cron
+	client *asynq.Client
+}
+
+// NewAsynqScheduler creates a scheduler enqueuing to the Redis at redisAddr
+func NewAsynqScheduler(redisAddr string) *AsynqScheduler {
+	if redisAddr == "" {
+		redisAddr = getEnvWithDefault("REDIS_ADDR", "localhost:6379")
+	}
+
+	return &AsynqScheduler{
+		cron:   cron.New(cron.WithLocation(time.UTC)),
+		client: asynq.NewClient(asynq.RedisClientOpt{Addr: redisAddr}),
+	}
+}
+
+// alignedSchedule fires at the multiples of every since the Unix epoch, so that the "@every"
+// schedules of all workers fire together instead of relative to when each process started
+type alignedSchedule struct {
+	every time.Duration
+}
+
+func (s alignedSchedule) Next(t time.Time) time.Time {
+	return t.Truncate(s.every).Add(s.every)
+}
+
+// Register schedules the task newTask creates for each period on cronspec, a cron expression or
+// "@every <duration>". The period passed to newTask is the time the run was scheduled for.
+func (s *AsynqScheduler) Register(cronspec string, newTask func(period time.Time) (*asynq.Task, error)) error {
+	var schedule cron.Schedule
+	if value, ok := strings.CutPrefix(cronspec, "@every "); ok {
+		every, err := time.ParseDuration(value)
+		if err != nil || every < time.Second {
+			return fmt.Errorf("invalid schedule %q: @every needs a duration of at least a second", cronspec)
+		}
+		schedule = alignedSchedule{every: every}
+	} else {
+		parsed, err := cron.ParseStandard(cronspec)
+		if err != nil {
+			return fmt.Errorf("invalid schedule %q: %w", cronspec, err)
+		}
+		schedule = parsed
+	}
+
+	entryID := s.cron.Schedule(schedule, cron.FuncJob(func() {
+		s.enqueue(schedule, newTask)
+	}))
+	log.Printf("INFO: Scheduled periodic task on %q (entry=%d)", cronspec, entryID)
+	return nil
+}
+
+// enqueue enqueues the run of the period that just started
+func (s *AsynqScheduler) enqueue(schedule cron.Schedule, newTask func(period time.Time) (*asynq.Task, error)) {
+	// Schedules fire on whole seconds; the job may start a moment later
+	period := time.Now().UTC().Truncate(time.Second)
+	length := schedule.Next(period).Sub(period)
+
+	task, err := newTask(period)
+	if err != nil {
+		log.Printf("ERROR: Failed to create periodic task: %v", err)
+		return
+	}
+
+	// The completed run keeps its ID until the period ends, so a later worker cannot enqueue it again
+	taskID := fmt.Sprintf("%s:%d", task.Type(), period.Unix())
+	info, err := s.client.EnqueueContext(context.Background(), task, asynq.TaskID(taskID), asynq.Retention(length))
+	switch {
+	case errors.Is(err, asynq.ErrTaskIDConflict):
+		// Another worker's scheduler enqueued this period's run
+	case errors.Is(err, asynq.ErrDuplicateTask):
+		log.Printf("INFO: Skipped periodic task %s, the previous run is still queued", taskID)
+	case err != nil:
+		log.Printf("ERROR: Failed to enqueue periodic task %s: %v", taskID, err)
+	default:
+		log.Printf("INFO: Enqueued periodic task %s (id=%s, queue=%s)", info.Type, info.ID, info.Queue)
+	}
+}
+
+// Start begins enqueuing the registered tasks on their schedules
+func (s *AsynqScheduler) Start() error {
+	log.Printf("INFO: Starting Asynq scheduler for periodic tasks")
+	s.cron.Start()
+	return nil
+}
+
+// Stop stops enqueuing periodic tasks, waiting for an enqueue in progress
+func (s *AsynqScheduler) Stop() {
+	log.Printf("INFO: Stopping Asynq scheduler...")
+	<-s.cron.Stop().Done()
+	if err := s.client.Close(); err != nil {
+		log.Printf("ERROR: Failed to close Asynq scheduler client: %v", err)
+	}
+	log.Printf("INFO: Asynq scheduler stopped successfully")
+}
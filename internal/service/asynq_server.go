@@ -2,6 +2,7 @@ package service
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"log"
 	"lumenslate/internal/utils"
@@ -12,10 +13,32 @@ import (
 	"github.com/hibiken/asynq"
 )
 
+// Queues tasks are enqueued to
+const (
+	QueueCritical = "critical" // Interactive work a user is waiting on, such as grading or question authoring
+	QueueDefault  = "default"  // Regular background work such as document ingestion
+	QueueLow      = "low"      // Maintenance that can wait, such as corpus deletion
+)
+
+// QueuePriorities weights how often workers pick tasks from each queue
+var QueuePriorities = map[string]int{
+	QueueCritical: 6,
+	QueueDefault:  3,
+	QueueLow:      1,
+}
+
+// ArchivedTaskHandler is called when a task fails for the last time, right before asynq archives it.
+// ctx carries the task metadata (ID, queue, retry counts) but not the deadline of the failed run.
+type ArchivedTaskHandler func(ctx context.Context, task *asynq.Task, err error)
+
+// archivedTaskTimeout bounds the cleanup of an archived task, which holds a worker slot while it runs
+const archivedTaskTimeout = 2 * time.Minute
+
 // AsynqServer wraps the Asynq server and multiplexer for background task processing
 type AsynqServer struct {
-	server *asynq.Server
-	mux    *asynq.ServeMux
+	server     *asynq.Server
+	mux        *asynq.ServeMux
+	onArchived ArchivedTaskHandler
 }
 
 // NewAsynqServer creates a new AsynqServer with Redis configuration and configurable settings
@@ -33,13 +56,12 @@ func NewAsynqServer(redisAddr string, concurrency int) *AsynqServer {
 		Addr: redisAddr,
 	}
 
+	s := &AsynqServer{}
+
 	// Configure server options with retry policies
 	serverConfig := asynq.Config{
 		Concurrency: concurrency,
-		Queues: map[string]int{
-			"default": 6, // Higher priority for default queue
-			"low":     3, // Lower priority for less critical tasks
-		},
+		Queues:      QueuePriorities,
 		// Configure retry policy
 		RetryDelayFunc: func(n int, e error, t *asynq.Task) time.Duration {
 			// Exponential backoff: 1min, 2min, 4min, etc.
@@ -58,18 +80,42 @@ func NewAsynqServer(redisAddr string, concurrency int) *AsynqServer {
 				task.Type(), string(task.Payload()[:min(100, len(task.Payload()))]))
 
 			logger.ErrorWithOperation(ctx, "task_error", errorMessage, err)
+
+			if s.onArchived != nil && isFinalFailure(ctx, err) {
+				archivedCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), archivedTaskTimeout)
+				defer cancel()
+				s.onArchived(archivedCtx, task, err)
+			}
 		}),
 		// Use default logger settings
 	}
 
 	// Create server and multiplexer
-	server := asynq.NewServer(redisOpt, serverConfig)
-	mux := asynq.NewServeMux()
+	s.server = asynq.NewServer(redisOpt, serverConfig)
+	s.mux = asynq.NewServeMux()
+
+	return s
+}
 
-	return &AsynqServer{
-		server: server,
-		mux:    mux,
+// OnArchived sets the handler called for tasks whose retries are exhausted; set it before Start
+func (s *AsynqServer) OnArchived(handler ArchivedTaskHandler) {
+	s.onArchived = handler
+}
+
+// isFinalFailure reports whether asynq archives a task after this failed run instead of retrying it
+func isFinalFailure(ctx context.Context, err error) bool {
+	if errors.Is(err, asynq.RevokeTask) {
+		return false
+	}
+	if errors.Is(err, asynq.SkipRetry) {
+		return true
+	}
+	retried, ok := asynq.GetRetryCount(ctx)
+	if !ok {
+		return false
 	}
+	maxRetry, ok := asynq.GetMaxRetry(ctx)
+	return ok && retried >= maxRetry
 }
 
 // RegisterHandlers maps task types to their corresponding handler functions
@@ -0,0 +1,127 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"lumenslate/internal/utils"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// Event types published by background jobs
+const (
+	EventTypeDocumentStatus  = "document.status"
+	EventTypeOperationStatus = "operation.status"
+)
+
+// eventChannelPrefix namespaces the Redis pub/sub channels used for events
+const eventChannelPrefix = "lumenslate:events"
+
+// Event represents a state change pushed to clients over Server-Sent Events
+type Event struct {
+	Type      string                 `json:"type"`
+	FileID    string                 `json:"fileId,omitempty"`
+	UserID    string                 `json:"userId,omitempty"`
+	Status    string                 `json:"status,omitempty"`
+	Message   string                 `json:"message,omitempty"`
+	Data      map[string]interface{} `json:"data,omitempty"`
+	Timestamp time.Time              `json:"timestamp"`
+}
+
+// EventBroker publishes and subscribes to events over Redis pub/sub so that
+// events raised by a worker process reach SSE clients connected to any web replica
+type EventBroker struct {
+	client *redis.Client
+	logger *utils.Logger
+}
+
+// NewEventBroker creates a new event broker backed by the Redis instance at redisAddr
+func NewEventBroker(redisAddr string) *EventBroker {
+	if redisAddr == "" {
+		redisAddr = getEnvWithDefault("REDIS_ADDR", "localhost:6379")
+	}
+
+	return &EventBroker{
+		client: redis.NewClient(&redis.Options{Addr: redisAddr}),
+		logger: utils.NewLogger("event_broker"),
+	}
+}
+
+// FileChannel returns the channel carrying events for a single document
+func FileChannel(fileID string) string {
+	return fmt.Sprintf("%s:file:%s", eventChannelPrefix, fileID)
+}
+
+// UserChannel returns the channel carrying events for everything a user started
+func UserChannel(userID string) string {
+	return fmt.Sprintf("%s:user:%s", eventChannelPrefix, userID)
+}
+
+// Publish sends an event to the channels of its file and user
+func (b *EventBroker) Publish(ctx context.Context, event Event) error {
+	if event.Timestamp.IsZero() {
+		event.Timestamp = time.Now().UTC()
+	}
+
+	payload, err := json.Marshal(event)
+	if err != nil {
+		return fmt.Errorf("failed to marshal event: %w", err)
+	}
+
+	var channels []string
+	if event.FileID != "" {
+		channels = append(channels, FileChannel(event.FileID))
+	}
+	if event.UserID != "" {
+		channels = append(channels, UserChannel(event.UserID))
+	}
+	if len(channels) == 0 {
+		return fmt.Errorf("event %s has neither fileId nor userId", event.Type)
+	}
+
+	pipe := b.client.Pipeline()
+	for _, channel := range channels {
+		pipe.Publish(ctx, channel, payload)
+	}
+	if _, err := pipe.Exec(ctx); err != nil {
+		b.logger.ErrorWithOperation(ctx, "event_publish", fmt.Sprintf("Failed to publish %s event", event.Type), err)
+		return fmt.Errorf("failed to publish event: %w", err)
+	}
+
+	return nil
+}
+
+// Subscribe opens a subscription to the given channels. Callers must close the returned PubSub.
+func (b *EventBroker) Subscribe(ctx context.Context, channels ...string) (*redis.PubSub, error) {
+	pubsub := b.client.Subscribe(ctx, channels...)
+
+	// Wait for the subscription to be confirmed so no events are lost after we return
+	if _, err := pubsub.Receive(ctx); err != nil {
+		pubsub.Close()
+		return nil, fmt.Errorf("failed to subscribe to events: %w", err)
+	}
+
+	return pubsub, nil
+}
+
+// DecodeEvent parses a message received from an event channel
+func DecodeEvent(msg *redis.Message) (Event, error) {
+	var event Event
+	if err := json.Unmarshal([]byte(msg.Payload), &event); err != nil {
+		return Event{}, fmt.Errorf("failed to decode event: %w", err)
+	}
+	return event, nil
+}
+
+// Ping checks that Redis answers
+func (b *EventBroker) Ping(ctx context.Context) error {
+	return b.client.Ping(ctx).Err()
+}
+
+// Close closes the underlying Redis client
+func (b *EventBroker) Close() error {
+	return b.client.Close()
+}
@@ -12,6 +12,7 @@ import (
 	"time"
 
 	"cloud.google.com/go/storage"
+	"google.golang.org/api/iterator"
 	"google.golang.org/api/option"
 )
 
@@ -68,6 +69,34 @@ func (s *GCSService) Close() error {
 	return s.client.Close()
 }
 
+// Ping checks that the bucket is reachable by listing at most one object, which needs no more
+// than the object permissions the service already uses
+func (s *GCSService) Ping(ctx context.Context) error {
+	objects := s.client.Bucket(s.bucketName).Objects(ctx, nil)
+	objects.PageInfo().MaxSize = 1
+	_, err := objects.Next()
+	if err != nil && err != iterator.Done {
+		return fmt.Errorf("failed to list bucket '%s': %v", s.bucketName, err)
+	}
+	return nil
+}
+
+// ListObjects lists the objects whose names start with prefix
+func (s *GCSService) ListObjects(ctx context.Context, prefix string) ([]*storage.ObjectAttrs, error) {
+	var objects []*storage.ObjectAttrs
+	it := s.client.Bucket(s.bucketName).Objects(ctx, &storage.Query{Prefix: prefix})
+	for {
+		attrs, err := it.Next()
+		if err == iterator.Done {
+			return objects, nil
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to list objects under '%s' in bucket '%s': %v", prefix, s.bucketName, err)
+		}
+		objects = append(objects, attrs)
+	}
+}
+
 // UploadFile uploads a file to GCS and returns the object name
 func (s *GCSService) UploadFile(ctx context.Context, file multipart.File, filename, contentType string) (string, int64, error) {
 	// Generate unique object name with timestamp prefix
@@ -3,6 +3,7 @@ package service
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"sync"
 	"time"
@@ -10,6 +11,7 @@ import (
 	"lumenslate/internal/utils"
 
 	"github.com/hibiken/asynq"
+	"github.com/redis/go-redis/v9"
 )
 
 // MetricsCollector collects and tracks metrics for async document processing
@@ -18,9 +20,13 @@ type MetricsCollector struct {
 	taskSuccessCount map[string]int64
 	taskFailureCount map[string]int64
 	taskDurations    map[string][]time.Duration
+	cacheHits        map[string]int64 // AI response cache hits by RPC name
+	cacheMisses      map[string]int64
 	queueDepth       int64
 	processingLag    time.Duration
 	lastUpdated      time.Time
+	alertRules       AlertRules
+	history          *TaskHistory // Outcomes shared by every process, see TaskHistory
 	asynqInspector   *asynq.Inspector
 	logger           *utils.Logger
 }
@@ -38,6 +44,14 @@ type TaskMetrics struct {
 	LastUpdated     time.Time     `json:"last_updated"`
 }
 
+// CacheMetrics represents AI response cache outcomes for one RPC
+type CacheMetrics struct {
+	Method  string  `json:"method"`
+	Hits    int64   `json:"hits"`
+	Misses  int64   `json:"misses"`
+	HitRate float64 `json:"hit_rate"`
+}
+
 // SystemMetrics represents overall system metrics
 type SystemMetrics struct {
 	QueueDepth         int64         `json:"queue_depth"`
@@ -51,13 +65,14 @@ type SystemMetrics struct {
 
 // HealthStatus represents the health status of the background processing system
 type HealthStatus struct {
-	Status        string                 `json:"status"`
-	Healthy       bool                   `json:"healthy"`
-	Timestamp     time.Time              `json:"timestamp"`
-	SystemMetrics SystemMetrics          `json:"system_metrics"`
-	TaskMetrics   map[string]TaskMetrics `json:"task_metrics"`
-	Alerts        []Alert                `json:"alerts"`
-	Uptime        time.Duration          `json:"uptime"`
+	Status        string                  `json:"status"`
+	Healthy       bool                    `json:"healthy"`
+	Timestamp     time.Time               `json:"timestamp"`
+	SystemMetrics SystemMetrics           `json:"system_metrics"`
+	TaskMetrics   map[string]TaskMetrics  `json:"task_metrics"`
+	CacheMetrics  map[string]CacheMetrics `json:"cache_metrics"`
+	Alerts        []Alert                 `json:"alerts"`
+	Uptime        time.Duration           `json:"uptime"`
 }
 
 // Alert represents a system alert
@@ -71,7 +86,7 @@ type Alert struct {
 
 // AlertThresholds defines thresholds for generating alerts
 type AlertThresholds struct {
-	MaxErrorRate     float64       // Maximum acceptable error rate (0.0-1.0)
+	MaxErrorRate     float64       // Maximum acceptable error rate (0.0-1.0), not checked when 0
 	MaxQueueDepth    int64         // Maximum acceptable queue depth
 	MaxProcessingLag time.Duration // Maximum acceptable processing lag
 	MinSuccessRate   float64       // Minimum acceptable success rate
@@ -86,6 +101,10 @@ func NewMetricsCollector(redisAddr string) (*MetricsCollector, error) {
 		taskSuccessCount: make(map[string]int64),
 		taskFailureCount: make(map[string]int64),
 		taskDurations:    make(map[string][]time.Duration),
+		cacheHits:        make(map[string]int64),
+		cacheMisses:      make(map[string]int64),
+		alertRules:       DefaultAlertRules(),
+		history:          NewTaskHistory(redis.NewClient(&redis.Options{Addr: redisAddr}), 0),
 		asynqInspector:   inspector,
 		logger:           utils.NewLogger("metrics_collector"),
 		lastUpdated:      time.Now(),
@@ -95,8 +114,6 @@ func NewMetricsCollector(redisAddr string) (*MetricsCollector, error) {
 // RecordTaskSuccess records a successful task completion
 func (mc *MetricsCollector) RecordTaskSuccess(ctx context.Context, taskType string, duration time.Duration) {
 	mc.mu.Lock()
-	defer mc.mu.Unlock()
-
 	mc.taskSuccessCount[taskType]++
 	mc.taskDurations[taskType] = append(mc.taskDurations[taskType], duration)
 	mc.lastUpdated = time.Now()
@@ -106,14 +123,15 @@ func (mc *MetricsCollector) RecordTaskSuccess(ctx context.Context, taskType stri
 		mc.taskDurations[taskType] = mc.taskDurations[taskType][1:]
 	}
 
+	mc.mu.Unlock()
+
 	mc.logger.InfoWithOperation(ctx, "metrics_record", fmt.Sprintf("Recorded task success: %s", taskType))
+	mc.recordHistory(ctx, taskType, true, duration)
 }
 
 // RecordTaskFailure records a failed task
 func (mc *MetricsCollector) RecordTaskFailure(ctx context.Context, taskType string, duration time.Duration) {
 	mc.mu.Lock()
-	defer mc.mu.Unlock()
-
 	mc.taskFailureCount[taskType]++
 	mc.taskDurations[taskType] = append(mc.taskDurations[taskType], duration)
 	mc.lastUpdated = time.Now()
@@ -123,7 +141,59 @@ func (mc *MetricsCollector) RecordTaskFailure(ctx context.Context, taskType stri
 		mc.taskDurations[taskType] = mc.taskDurations[taskType][1:]
 	}
 
+	mc.mu.Unlock()
+
 	mc.logger.InfoWithOperation(ctx, "metrics_record", fmt.Sprintf("Recorded task failure: %s", taskType))
+	mc.recordHistory(ctx, taskType, false, duration)
+}
+
+// recordHistory adds a task outcome to the Redis history. Failing to record it does not fail the task.
+// It is called without holding mc.mu, so the Redis round trip does not hold up other tasks or readers.
+func (mc *MetricsCollector) recordHistory(ctx context.Context, taskType string, success bool, duration time.Duration) {
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), 2*time.Second)
+	defer cancel()
+
+	if err := mc.history.Record(ctx, taskType, success, duration, time.Now()); err != nil {
+		mc.logger.ErrorWithOperation(ctx, "metrics_history", "Failed to record task outcome history", err)
+	}
+}
+
+// TaskHistory returns the Redis history of task outcomes
+func (mc *MetricsCollector) TaskHistory() *TaskHistory {
+	return mc.history
+}
+
+// RecordCacheHit records an AI call answered from the response cache
+func (mc *MetricsCollector) RecordCacheHit(method string) {
+	mc.mu.Lock()
+	defer mc.mu.Unlock()
+	mc.cacheHits[method]++
+}
+
+// RecordCacheMiss records an AI call that went to the AI service
+func (mc *MetricsCollector) RecordCacheMiss(method string) {
+	mc.mu.Lock()
+	defer mc.mu.Unlock()
+	mc.cacheMisses[method]++
+}
+
+// GetCacheMetrics returns the AI response cache outcomes by RPC name
+func (mc *MetricsCollector) GetCacheMetrics() map[string]CacheMetrics {
+	mc.mu.RLock()
+	defer mc.mu.RUnlock()
+
+	metrics := make(map[string]CacheMetrics)
+	for _, counts := range []map[string]int64{mc.cacheHits, mc.cacheMisses} {
+		for method := range counts {
+			hits, misses := mc.cacheHits[method], mc.cacheMisses[method]
+			var hitRate float64
+			if hits+misses > 0 {
+				hitRate = float64(hits) / float64(hits+misses)
+			}
+			metrics[method] = CacheMetrics{Method: method, Hits: hits, Misses: misses, HitRate: hitRate}
+		}
+	}
+	return metrics
 }
 
 // UpdateQueueMetrics updates queue depth and processing lag metrics
@@ -132,7 +202,7 @@ func (mc *MetricsCollector) UpdateQueueMetrics(ctx context.Context) error {
 	defer mc.mu.Unlock()
 
 	// Get queue statistics from Asynq inspector
-	queueStats, err := mc.asynqInspector.GetQueueInfo("default")
+	queueStats, err := mc.queueTotals()
 	if err != nil {
 		mc.logger.ErrorWithOperation(ctx, "queue_metrics", "Failed to get queue statistics", err)
 		return fmt.Errorf("failed to get queue statistics: %w", err)
@@ -155,11 +225,33 @@ func (mc *MetricsCollector) UpdateQueueMetrics(ctx context.Context) error {
 	return nil
 }
 
+// queueTotals sums the pending and active tasks of every queue workers process.
+// Queues that have never held a task do not exist yet and count as empty.
+func (mc *MetricsCollector) queueTotals() (asynq.QueueInfo, error) {
+	var totals asynq.QueueInfo
+	for queue := range QueuePriorities {
+		info, err := mc.asynqInspector.GetQueueInfo(queue)
+		if errors.Is(err, asynq.ErrQueueNotFound) {
+			continue
+		}
+		if err != nil {
+			return asynq.QueueInfo{}, fmt.Errorf("queue %s: %w", queue, err)
+		}
+		totals.Pending += info.Pending
+		totals.Active += info.Active
+	}
+	return totals, nil
+}
+
 // GetTaskMetrics returns metrics for a specific task type
 func (mc *MetricsCollector) GetTaskMetrics(taskType string) TaskMetrics {
 	mc.mu.RLock()
 	defer mc.mu.RUnlock()
+	return mc.taskMetrics(taskType)
+}
 
+// taskMetrics returns the metrics of taskType; the caller holds mc.mu
+func (mc *MetricsCollector) taskMetrics(taskType string) TaskMetrics {
 	successCount := mc.taskSuccessCount[taskType]
 	failureCount := mc.taskFailureCount[taskType]
 	totalCount := successCount + failureCount
@@ -226,7 +318,7 @@ func (mc *MetricsCollector) GetSystemMetrics(ctx context.Context) (SystemMetrics
 	}
 
 	// Get active workers from Asynq inspector
-	queueStats, err := mc.asynqInspector.GetQueueInfo("default")
+	queueStats, err := mc.queueTotals()
 	activeWorkers := 0
 	if err == nil {
 		activeWorkers = queueStats.Active
@@ -243,28 +335,113 @@ func (mc *MetricsCollector) GetSystemMetrics(ctx context.Context) (SystemMetrics
 	}, nil
 }
 
-// CheckHealth performs health checks and generates alerts
+// CheckHealth performs health checks and generates alerts, applying thresholds to every task type
 func (mc *MetricsCollector) CheckHealth(ctx context.Context, thresholds AlertThresholds, startTime time.Time) HealthStatus {
+	return mc.CheckHealthWithRules(ctx, AlertRules{Default: thresholds}, startTime)
+}
+
+// CheckHealthWithRules performs health checks and generates alerts, applying the thresholds of each task type.
+// Task metrics come from the shared task history; when it cannot be read, the outcomes recorded by this
+// process are reported instead.
+func (mc *MetricsCollector) CheckHealthWithRules(ctx context.Context, rules AlertRules, startTime time.Time) HealthStatus {
+	systemMetrics, taskMetrics, err := mc.collectMetrics(ctx, rules)
+	if err != nil {
+		mc.logger.ErrorWithOperation(ctx, "health_check", "Failed to read the task history, reporting this process's task metrics", err)
+		taskMetrics = mc.localTaskMetrics()
+	}
+
+	return mc.healthStatus(systemMetrics, taskMetrics, rules, startTime)
+}
+
+// EvaluateAlerts returns the alerts raised by rules against the queues and the task outcomes every
+// process recorded in the shared task history during the rules' window
+func (mc *MetricsCollector) EvaluateAlerts(ctx context.Context, rules AlertRules) ([]Alert, error) {
+	systemMetrics, taskMetrics, err := mc.collectMetrics(ctx, rules)
+	if err != nil {
+		return nil, err
+	}
+	return mc.generateAlerts(systemMetrics, taskMetrics, rules), nil
+}
+
+// collectMetrics returns the system metrics and the metrics of every task type in the shared task
+// history over the rules' window, with the system success totals summed from the history
+func (mc *MetricsCollector) collectMetrics(ctx context.Context, rules AlertRules) (SystemMetrics, map[string]TaskMetrics, error) {
 	systemMetrics, err := mc.GetSystemMetrics(ctx)
 	if err != nil {
 		mc.logger.ErrorWithOperation(ctx, "health_check", "Failed to get system metrics", err)
 	}
 
-	// Collect task metrics
+	window := rules.Window
+	if window <= 0 {
+		window = DefaultAlertRules().Window
+	}
+	taskMetrics, err := mc.sharedTaskMetrics(ctx, window)
+	if err != nil {
+		return systemMetrics, nil, err
+	}
+
+	systemMetrics.TotalProcessed, systemMetrics.TotalFailed, systemMetrics.OverallSuccessRate = 0, 0, 0
+	for _, metrics := range taskMetrics {
+		systemMetrics.TotalProcessed += metrics.TotalCount
+		systemMetrics.TotalFailed += metrics.FailureCount
+	}
+	if systemMetrics.TotalProcessed > 0 {
+		systemMetrics.OverallSuccessRate = float64(systemMetrics.TotalProcessed-systemMetrics.TotalFailed) / float64(systemMetrics.TotalProcessed)
+	}
+	return systemMetrics, taskMetrics, nil
+}
+
+// sharedTaskMetrics summarizes the task history of every task type with outcomes during window
+func (mc *MetricsCollector) sharedTaskMetrics(ctx context.Context, window time.Duration) (map[string]TaskMetrics, error) {
+	taskTypes, err := mc.history.TaskTypes(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	taskMetrics := make(map[string]TaskMetrics, len(taskTypes))
+	for _, taskType := range taskTypes {
+		stats, err := mc.history.Stats(ctx, taskType, window)
+		if err != nil {
+			return nil, err
+		}
+		if stats.TotalCount == 0 {
+			continue
+		}
+		taskMetrics[taskType] = TaskMetrics{
+			TaskType:        taskType,
+			SuccessCount:    stats.SuccessCount,
+			FailureCount:    stats.FailureCount,
+			TotalCount:      stats.TotalCount,
+			SuccessRate:     stats.SuccessRate,
+			AverageDuration: msDuration(stats.AverageMS),
+			MinDuration:     msDuration(stats.MinMS),
+			MaxDuration:     msDuration(stats.MaxMS),
+			LastUpdated:     stats.To,
+		}
+	}
+	return taskMetrics, nil
+}
+
+// localTaskMetrics returns the metrics of the task outcomes recorded by this process
+func (mc *MetricsCollector) localTaskMetrics() map[string]TaskMetrics {
 	taskMetrics := make(map[string]TaskMetrics)
 	mc.mu.RLock()
 	for taskType := range mc.taskSuccessCount {
-		taskMetrics[taskType] = mc.GetTaskMetrics(taskType)
+		taskMetrics[taskType] = mc.taskMetrics(taskType)
 	}
 	for taskType := range mc.taskFailureCount {
 		if _, exists := taskMetrics[taskType]; !exists {
-			taskMetrics[taskType] = mc.GetTaskMetrics(taskType)
+			taskMetrics[taskType] = mc.taskMetrics(taskType)
 		}
 	}
 	mc.mu.RUnlock()
+	return taskMetrics
+}
 
+// healthStatus reports the metrics and the alerts they raise under rules
+func (mc *MetricsCollector) healthStatus(systemMetrics SystemMetrics, taskMetrics map[string]TaskMetrics, rules AlertRules, startTime time.Time) HealthStatus {
 	// Generate alerts based on thresholds
-	alerts := mc.generateAlerts(systemMetrics, taskMetrics, thresholds)
+	alerts := mc.generateAlerts(systemMetrics, taskMetrics, rules)
 
 	// Determine overall health status
 	healthy := len(alerts) == 0
@@ -285,15 +462,27 @@ func (mc *MetricsCollector) CheckHealth(ctx context.Context, thresholds AlertThr
 		Timestamp:     time.Now(),
 		SystemMetrics: systemMetrics,
 		TaskMetrics:   taskMetrics,
+		CacheMetrics:  mc.GetCacheMetrics(),
 		Alerts:        alerts,
 		Uptime:        time.Since(startTime),
 	}
 }
 
+// exceedsErrorRate reports whether errorRate is above the maximum of thresholds; a zero maximum is not checked
+func exceedsErrorRate(errorRate float64, thresholds AlertThresholds) bool {
+	return thresholds.MaxErrorRate > 0 && errorRate > thresholds.MaxErrorRate
+}
+
+// msDuration converts milliseconds to a duration
+func msDuration(ms float64) time.Duration {
+	return time.Duration(ms * float64(time.Millisecond))
+}
+
 // generateAlerts generates alerts based on metrics and thresholds
-func (mc *MetricsCollector) generateAlerts(systemMetrics SystemMetrics, taskMetrics map[string]TaskMetrics, thresholds AlertThresholds) []Alert {
+func (mc *MetricsCollector) generateAlerts(systemMetrics SystemMetrics, taskMetrics map[string]TaskMetrics, rules AlertRules) []Alert {
 	var alerts []Alert
 	now := time.Now()
+	thresholds := rules.Default
 
 	// Check overall error rate
 	if systemMetrics.OverallSuccessRate < thresholds.MinSuccessRate && systemMetrics.TotalProcessed > 0 {
@@ -307,6 +496,17 @@ func (mc *MetricsCollector) generateAlerts(systemMetrics SystemMetrics, taskMetr
 				"threshold":    fmt.Sprintf("%.2f", thresholds.MinSuccessRate),
 			},
 		})
+	} else if errorRate := 1 - systemMetrics.OverallSuccessRate; exceedsErrorRate(errorRate, thresholds) && systemMetrics.TotalProcessed > 0 {
+		alerts = append(alerts, Alert{
+			Level:     "error",
+			Type:      "high_error_rate",
+			Message:   fmt.Sprintf("Overall error rate (%.2f%%) exceeds threshold (%.2f%%)", errorRate*100, thresholds.MaxErrorRate*100),
+			Timestamp: now,
+			Metadata: map[string]string{
+				"current_error_rate": fmt.Sprintf("%.2f", errorRate),
+				"threshold":          fmt.Sprintf("%.2f", thresholds.MaxErrorRate),
+			},
+		})
 	}
 
 	// Check queue depth
@@ -347,6 +547,7 @@ func (mc *MetricsCollector) generateAlerts(systemMetrics SystemMetrics, taskMetr
 
 	// Check individual task error rates
 	for taskType, metrics := range taskMetrics {
+		thresholds := rules.ForTaskType(taskType)
 		if metrics.TotalCount > 0 && metrics.SuccessRate < thresholds.MinSuccessRate {
 			alerts = append(alerts, Alert{
 				Level:     "warning",
@@ -359,14 +560,48 @@ func (mc *MetricsCollector) generateAlerts(systemMetrics SystemMetrics, taskMetr
 					"threshold":    fmt.Sprintf("%.2f", thresholds.MinSuccessRate),
 				},
 			})
+		} else if errorRate := 1 - metrics.SuccessRate; metrics.TotalCount > 0 && exceedsErrorRate(errorRate, thresholds) {
+			alerts = append(alerts, Alert{
+				Level:     "warning",
+				Type:      "task_error_rate",
+				Message:   fmt.Sprintf("Task %s error rate (%.2f%%) exceeds threshold (%.2f%%)", taskType, errorRate*100, thresholds.MaxErrorRate*100),
+				Timestamp: now,
+				Metadata: map[string]string{
+					"task_type":          taskType,
+					"current_error_rate": fmt.Sprintf("%.2f", errorRate),
+					"threshold":          fmt.Sprintf("%.2f", thresholds.MaxErrorRate),
+				},
+			})
 		}
 	}
 
 	return alerts
 }
 
+// SetAlertRules replaces the alert rules used by the alert evaluator and the health endpoints
+func (mc *MetricsCollector) SetAlertRules(rules AlertRules) {
+	mc.mu.Lock()
+	defer mc.mu.Unlock()
+	mc.alertRules = rules
+}
+
+// AlertRules returns the configured alert rules
+func (mc *MetricsCollector) AlertRules() AlertRules {
+	mc.mu.RLock()
+	defer mc.mu.RUnlock()
+	return mc.alertRules
+}
+
+// Inspector returns the asynq inspector of the collector, shared with the Prometheus queue collector
+func (mc *MetricsCollector) Inspector() *asynq.Inspector {
+	return mc.asynqInspector
+}
+
 // Close closes the metrics collector and its resources
 func (mc *MetricsCollector) Close() error {
+	if err := mc.history.Close(); err != nil {
+		return err
+	}
 	return mc.asynqInspector.Close()
 }
 
@@ -0,0 +1,191 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	"lumenslate/internal/utils"
+
+	"golang.org/x/sync/singleflight"
+)
+
+// Dependency statuses
+const (
+	DependencyUp   = "up"
+	DependencyDown = "down"
+)
+
+// Readiness statuses
+const (
+	ReadinessReady    = "ready"
+	ReadinessDegraded = "degraded"
+	ReadinessNotReady = "not_ready"
+)
+
+// defaultDependencyTimeout bounds a check registered without a timeout
+const defaultDependencyTimeout = 2 * time.Second
+
+// Dependency is an external system the application needs to serve requests. The application is
+// not ready while a critical dependency is down; a non-critical one only degrades it.
+type Dependency struct {
+	Name     string
+	Critical bool
+	Timeout  time.Duration
+	Check    func(ctx context.Context) error
+}
+
+// DependencyStatus is the result of checking one dependency
+type DependencyStatus struct {
+	Name      string    `json:"name"`
+	Critical  bool      `json:"critical"`
+	Status    string    `json:"status"`
+	LatencyMS float64   `json:"latency_ms"`
+	Error     string    `json:"error,omitempty"`
+	CheckedAt time.Time `json:"checked_at"`
+}
+
+// ReadinessReport is the result of checking every registered dependency
+type ReadinessReport struct {
+	Status       string             `json:"status"`
+	Ready        bool               `json:"ready"`
+	CheckedAt    time.Time          `json:"checked_at"`
+	Dependencies []DependencyStatus `json:"dependencies"`
+}
+
+// ReadinessRegistry checks the registered dependencies concurrently, each under its own timeout,
+// and reuses the report for a short while so that frequent probes do not hammer the dependencies
+type ReadinessRegistry struct {
+	mu           sync.RWMutex
+	dependencies []Dependency
+	cacheTTL     time.Duration
+	report       *ReadinessReport
+	group        singleflight.Group
+	logger       *utils.Logger
+}
+
+// NewReadinessRegistry creates an empty registry caching reports for cacheTTL.
+// A zero cacheTTL reads READINESS_CACHE_TTL, defaulting to 5s.
+func NewReadinessRegistry(cacheTTL time.Duration) *ReadinessRegistry {
+	if cacheTTL == 0 {
+		cacheTTL = 5 * time.Second
+		if value := os.Getenv("READINESS_CACHE_TTL"); value != "" {
+			if parsed, err := time.ParseDuration(value); err == nil {
+				cacheTTL = parsed
+			}
+		}
+	}
+
+	return &ReadinessRegistry{
+		cacheTTL: cacheTTL,
+		logger:   utils.NewLogger("readiness"),
+	}
+}
+
+// Register adds a dependency to check
+func (r *ReadinessRegistry) Register(dependency Dependency) {
+	if dependency.Timeout <= 0 {
+		dependency.Timeout = defaultDependencyTimeout
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.dependencies = append(r.dependencies, dependency)
+	r.report = nil
+}
+
+// Check returns the readiness report, checking the dependencies unless a report younger than the
+// cache TTL exists. Concurrent callers share one round of checks.
+func (r *ReadinessRegistry) Check(ctx context.Context) ReadinessReport {
+	r.mu.RLock()
+	report := r.report
+	r.mu.RUnlock()
+	if report != nil && time.Since(report.CheckedAt) < r.cacheTTL {
+		return *report
+	}
+
+	// Checks outlive a caller that gives up, so the other callers still get a report
+	result, _, _ := r.group.Do("readiness", func() (interface{}, error) {
+		report := r.checkAll(context.WithoutCancel(ctx))
+		r.mu.Lock()
+		r.report = &report
+		r.mu.Unlock()
+		return report, nil
+	})
+	return result.(ReadinessReport)
+}
+
+// checkAll checks every dependency concurrently
+func (r *ReadinessRegistry) checkAll(ctx context.Context) ReadinessReport {
+	r.mu.RLock()
+	dependencies := append([]Dependency(nil), r.dependencies...)
+	r.mu.RUnlock()
+
+	statuses := make([]DependencyStatus, len(dependencies))
+	var wg sync.WaitGroup
+	for i, dependency := range dependencies {
+		wg.Add(1)
+		go func(i int, dependency Dependency) {
+			defer wg.Done()
+			statuses[i] = r.checkOne(ctx, dependency)
+		}(i, dependency)
+	}
+	wg.Wait()
+
+	report := ReadinessReport{
+		Status:       ReadinessReady,
+		Ready:        true,
+		CheckedAt:    time.Now().UTC(),
+		Dependencies: statuses,
+	}
+	for _, status := range statuses {
+		if status.Status == DependencyUp {
+			continue
+		}
+		if status.Critical {
+			report.Status = ReadinessNotReady
+			report.Ready = false
+		} else if report.Ready {
+			report.Status = ReadinessDegraded
+		}
+	}
+	return report
+}
+
+// checkOne checks a dependency under its timeout, turning a panic into a failed check
+func (r *ReadinessRegistry) checkOne(ctx context.Context, dependency Dependency) (status DependencyStatus) {
+	ctx, cancel := context.WithTimeout(ctx, dependency.Timeout)
+	defer cancel()
+
+	start := time.Now()
+	status = DependencyStatus{
+		Name:     dependency.Name,
+		Critical: dependency.Critical,
+		Status:   DependencyUp,
+	}
+
+	defer func() {
+		if recovered := recover(); recovered != nil {
+			status.Status = DependencyDown
+			status.Error = fmt.Sprintf("check panicked: %v", recovered)
+		}
+		status.LatencyMS = float64(time.Since(start).Microseconds()) / 1000
+		status.CheckedAt = time.Now().UTC()
+		if status.Status == DependencyDown {
+			message := fmt.Sprintf("Dependency %s is down: %s", status.Name, status.Error)
+			if status.Critical {
+				r.logger.Error(ctx, message, nil)
+			} else {
+				r.logger.Warn(ctx, message)
+			}
+		}
+	}()
+
+	if err := dependency.Check(ctx); err != nil {
+		status.Status = DependencyDown
+		status.Error = err.Error()
+	}
+	return status
+}
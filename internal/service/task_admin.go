@@ -0,0 +1,277 @@
+package service
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/hibiken/asynq"
+)
+
+// Task states that can be listed
+var TaskStates = []string{"pending", "active", "scheduled", "retry", "archived", "completed"}
+
+// documentScanLimit bounds the tasks read per queue and state when searching the tasks of a document
+const documentScanLimit = 1000
+
+// Errors returned by TaskAdmin besides asynq.ErrQueueNotFound and asynq.ErrTaskNotFound
+var (
+	ErrInvalidTaskState    = errors.New("invalid task state")
+	ErrTaskStateConflict   = errors.New("task is not in a state allowing the operation")
+	ErrTaskNotActive       = errors.New("only active tasks can be canceled")
+	ErrQueueAlreadyInState = errors.New("queue is already in the requested state")
+)
+
+// failedPreconditionCode is the code asynq reports for operations invalid in the task's state
+const failedPreconditionCode = "FAILED_PRECONDITION"
+
+// AdminQueue summarizes a queue for operators
+type AdminQueue struct {
+	Queue     string  `json:"queue"`
+	Paused    bool    `json:"paused"`
+	Size      int     `json:"size"`
+	Pending   int     `json:"pending"`
+	Active    int     `json:"active"`
+	Scheduled int     `json:"scheduled"`
+	Retry     int     `json:"retry"`
+	Archived  int     `json:"archived"`
+	Completed int     `json:"completed"`
+	LatencyMS float64 `json:"latency_ms"`
+	Processed int     `json:"processed_today"`
+	Failed    int     `json:"failed_today"`
+}
+
+// AdminTask describes a task for operators. FileID links document tasks to their model.Document.
+type AdminTask struct {
+	ID            string          `json:"id"`
+	Queue         string          `json:"queue"`
+	Type          string          `json:"type"`
+	State         string          `json:"state"`
+	FileID        string          `json:"file_id,omitempty"`
+	Payload       json.RawMessage `json:"payload"`
+	MaxRetry      int             `json:"max_retry"`
+	Retried       int             `json:"retried"`
+	LastError     string          `json:"last_error,omitempty"`
+	LastFailedAt  *time.Time      `json:"last_failed_at,omitempty"`
+	NextProcessAt *time.Time      `json:"next_process_at,omitempty"`
+	CompletedAt   *time.Time      `json:"completed_at,omitempty"`
+	Timeout       string          `json:"timeout,omitempty"`
+}
+
+// TaskAdmin inspects and manages the tasks of every asynq queue
+type TaskAdmin struct {
+	inspector *asynq.Inspector
+}
+
+// NewTaskAdmin creates a task admin on inspector
+func NewTaskAdmin(inspector *asynq.Inspector) *TaskAdmin {
+	return &TaskAdmin{inspector: inspector}
+}
+
+// Queues summarizes every queue
+func (a *TaskAdmin) Queues() ([]AdminQueue, error) {
+	names, err := a.inspector.Queues()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list queues: %w", err)
+	}
+
+	queues := make([]AdminQueue, 0, len(names))
+	for _, name := range names {
+		info, err := a.inspector.GetQueueInfo(name)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get queue %s: %w", name, err)
+		}
+		queues = append(queues, AdminQueue{
+			Queue:     info.Queue,
+			Paused:    info.Paused,
+			Size:      info.Size,
+			Pending:   info.Pending,
+			Active:    info.Active,
+			Scheduled: info.Scheduled,
+			Retry:     info.Retry,
+			Archived:  info.Archived,
+			Completed: info.Completed,
+			LatencyMS: float64(info.Latency.Milliseconds()),
+			Processed: info.Processed,
+			Failed:    info.Failed,
+		})
+	}
+	return queues, nil
+}
+
+// ListTasks lists a page (1-based) of the tasks of queue in state
+func (a *TaskAdmin) ListTasks(queue, state string, page, size int) ([]AdminTask, error) {
+	opts := []asynq.ListOption{asynq.Page(page), asynq.PageSize(size)}
+
+	var infos []*asynq.TaskInfo
+	var err error
+	switch state {
+	case "pending":
+		infos, err = a.inspector.ListPendingTasks(queue, opts...)
+	case "active":
+		infos, err = a.inspector.ListActiveTasks(queue, opts...)
+	case "scheduled":
+		infos, err = a.inspector.ListScheduledTasks(queue, opts...)
+	case "retry":
+		infos, err = a.inspector.ListRetryTasks(queue, opts...)
+	case "archived":
+		infos, err = a.inspector.ListArchivedTasks(queue, opts...)
+	case "completed":
+		infos, err = a.inspector.ListCompletedTasks(queue, opts...)
+	default:
+		return nil, fmt.Errorf("%w %q, expected one of %s", ErrInvalidTaskState, state, strings.Join(TaskStates, ", "))
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	tasks := make([]AdminTask, 0, len(infos))
+	for _, info := range infos {
+		tasks = append(tasks, newAdminTask(info))
+	}
+	return tasks, nil
+}
+
+// GetTask returns one task
+func (a *TaskAdmin) GetTask(queue, id string) (AdminTask, error) {
+	info, err := a.inspector.GetTaskInfo(queue, id)
+	if err != nil {
+		return AdminTask{}, err
+	}
+	return newAdminTask(info), nil
+}
+
+// TasksForFile returns the tasks of every queue and state whose payload refers to the document fileID.
+// At most documentScanLimit tasks are read per queue and state.
+func (a *TaskAdmin) TasksForFile(fileID string) ([]AdminTask, error) {
+	queues, err := a.inspector.Queues()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list queues: %w", err)
+	}
+
+	var tasks []AdminTask
+	for _, queue := range queues {
+		for _, state := range TaskStates {
+			page, err := a.ListTasks(queue, state, 1, documentScanLimit)
+			if err != nil {
+				return nil, fmt.Errorf("failed to list %s tasks of %s: %w", state, queue, err)
+			}
+			for _, task := range page {
+				if task.FileID == fileID {
+					tasks = append(tasks, task)
+				}
+			}
+		}
+	}
+	return tasks, nil
+}
+
+// RunTask moves a scheduled, retry or archived task to pending so it runs right away
+func (a *TaskAdmin) RunTask(queue, id string) error {
+	return taskAdminError(a.inspector.RunTask(queue, id))
+}
+
+// ArchiveTask archives a pending, scheduled or retry task
+func (a *TaskAdmin) ArchiveTask(queue, id string) error {
+	return taskAdminError(a.inspector.ArchiveTask(queue, id))
+}
+
+// DeleteTask deletes a task that is not active
+func (a *TaskAdmin) DeleteTask(queue, id string) error {
+	return taskAdminError(a.inspector.DeleteTask(queue, id))
+}
+
+// CancelTask signals the worker processing an active task to cancel it. Canceled tasks are
+// retried like failed ones until their retries run out.
+func (a *TaskAdmin) CancelTask(queue, id string) error {
+	info, err := a.inspector.GetTaskInfo(queue, id)
+	if err != nil {
+		return err
+	}
+	if info.State != asynq.TaskStateActive {
+		return ErrTaskNotActive
+	}
+	return a.inspector.CancelProcessing(id)
+}
+
+// PauseQueue stops workers from processing the tasks of queue
+func (a *TaskAdmin) PauseQueue(queue string) error {
+	return a.setQueuePaused(queue, true)
+}
+
+// UnpauseQueue resumes processing the tasks of queue
+func (a *TaskAdmin) UnpauseQueue(queue string) error {
+	return a.setQueuePaused(queue, false)
+}
+
+func (a *TaskAdmin) setQueuePaused(queue string, paused bool) error {
+	info, err := a.inspector.GetQueueInfo(queue)
+	if err != nil {
+		return err
+	}
+	if info.Paused == paused {
+		return ErrQueueAlreadyInState
+	}
+	if paused {
+		return a.inspector.PauseQueue(queue)
+	}
+	return a.inspector.UnpauseQueue(queue)
+}
+
+// taskAdminError reports operations asynq refused because of the task's state as ErrTaskStateConflict
+func taskAdminError(err error) error {
+	if err != nil && strings.Contains(err.Error(), failedPreconditionCode) {
+		return fmt.Errorf("%w: %v", ErrTaskStateConflict, err)
+	}
+	return err
+}
+
+// newAdminTask converts an asynq task into its admin view
+func newAdminTask(info *asynq.TaskInfo) AdminTask {
+	task := AdminTask{
+		ID:        info.ID,
+		Queue:     info.Queue,
+		Type:      info.Type,
+		State:     info.State.String(),
+		FileID:    PayloadFileID(info.Payload),
+		Payload:   info.Payload,
+		MaxRetry:  info.MaxRetry,
+		Retried:   info.Retried,
+		LastError: info.LastErr,
+	}
+	if !json.Valid(info.Payload) {
+		task.Payload, _ = json.Marshal(string(info.Payload))
+	}
+	if !info.LastFailedAt.IsZero() {
+		task.LastFailedAt = &info.LastFailedAt
+	}
+	if !info.NextProcessAt.IsZero() {
+		task.NextProcessAt = &info.NextProcessAt
+	}
+	if !info.CompletedAt.IsZero() {
+		task.CompletedAt = &info.CompletedAt
+	}
+	if info.Timeout > 0 {
+		task.Timeout = info.Timeout.String()
+	}
+	return task
+}
+
+// PayloadFileID returns the document a task payload refers to, either directly or through a nested document payload
+func PayloadFileID(payload []byte) string {
+	var fields struct {
+		FileID   string `json:"file_id"`
+		Document struct {
+			FileID string `json:"file_id"`
+		} `json:"document"`
+	}
+	if err := json.Unmarshal(payload, &fields); err != nil {
+		return ""
+	}
+	if fields.FileID != "" {
+		return fields.FileID
+	}
+	return fields.Document.FileID
+}
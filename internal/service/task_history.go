@@ -0,0 +1,366 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/redis/go-redis/v9"
+)
+
+// taskHistoryPrefix namespaces the Redis keys of the task metrics history
+const taskHistoryPrefix = "lumenslate:task_metrics"
+
+// taskHistoryBucket is the width of the time buckets of the outcome counters
+const taskHistoryBucket = time.Hour
+
+// taskHistorySampleWindow is how long individual outcomes are kept for exact statistics. Longer
+// windows are summarized from the hourly counters and their latency histograms.
+const taskHistorySampleWindow = time.Hour
+
+// taskLatencyBoundsMS are the upper bounds, in milliseconds, of the latency histogram buckets of
+// the hourly counters; slower outcomes fall in a last, unbounded bucket
+var taskLatencyBoundsMS = []float64{5, 10, 25, 50, 100, 250, 500, 1000, 2500, 5000, 10000, 30000, 60000, 120000, 300000, 600000}
+
+// Fields of the hourly counters besides the outcome counts
+const (
+	taskFieldSumMicros = "sum_us"
+	taskFieldLatency   = "le:" // Prefix of the histogram bucket counts, followed by the bucket index
+)
+
+// Task outcomes recorded in the history
+const (
+	taskOutcomeSuccess = "success"
+	taskOutcomeFailure = "failure"
+)
+
+// TaskHistory records task outcomes in Redis, so that every web and worker process reports the
+// same task statistics and they survive deploys. Each outcome increments an hourly counter and its
+// latency histogram, kept for the retention, and is added to a sorted set of the task type scored
+// by completion time, kept for taskHistorySampleWindow. Windows up to taskHistorySampleWindow are
+// summarized exactly from the sorted set; longer ones from the hourly buckets they overlap, with
+// latencies estimated from the histograms.
+type TaskHistory struct {
+	client    *redis.Client
+	retention time.Duration
+}
+
+// TaskStats summarizes the outcomes of one task type over a window
+type TaskStats struct {
+	TaskType     string            `json:"task_type"`
+	Window       string            `json:"window"`
+	From         time.Time         `json:"from"`
+	To           time.Time         `json:"to"`
+	SuccessCount int64             `json:"success_count"`
+	FailureCount int64             `json:"failure_count"`
+	TotalCount   int64             `json:"total_count"`
+	SuccessRate  float64           `json:"success_rate"`
+	AverageMS    float64           `json:"average_ms"`
+	MinMS        float64           `json:"min_ms"`
+	MaxMS        float64           `json:"max_ms"`
+	P50MS        float64           `json:"p50_ms"`
+	P95MS        float64           `json:"p95_ms"`
+	P99MS        float64           `json:"p99_ms"`
+	Approximate  bool              `json:"approximate"` // Latencies are histogram bucket bounds and counts cover whole hours
+	Buckets      []TaskStatsBucket `json:"buckets"`     // Hourly outcome counters overlapping the window
+}
+
+// TaskStatsBucket holds the outcome counters of one hour
+type TaskStatsBucket struct {
+	Start        time.Time `json:"start"`
+	SuccessCount int64     `json:"success_count"`
+	FailureCount int64     `json:"failure_count"`
+
+	sumMicros int64   // Total duration of the outcomes
+	latencies []int64 // Latency histogram counts by bucket of taskLatencyBoundsMS
+}
+
+// NewTaskHistory creates a history on client keeping outcomes for retention.
+// A zero retention reads TASK_METRICS_RETENTION, defaulting to 8 days.
+func NewTaskHistory(client *redis.Client, retention time.Duration) *TaskHistory {
+	if retention == 0 {
+		retention = 8 * 24 * time.Hour
+		if value := os.Getenv("TASK_METRICS_RETENTION"); value != "" {
+			if parsed, err := ParseWindow(value); err == nil {
+				retention = parsed
+			}
+		}
+	}
+	return &TaskHistory{client: client, retention: retention}
+}
+
+// Retention returns how long outcomes are kept
+func (h *TaskHistory) Retention() time.Duration {
+	return h.retention
+}
+
+// Record adds the outcome of one task execution
+func (h *TaskHistory) Record(ctx context.Context, taskType string, success bool, duration time.Duration, at time.Time) error {
+	outcome := taskOutcomeFailure
+	if success {
+		outcome = taskOutcomeSuccess
+	}
+
+	counterKey := h.counterKey(taskType, at.Truncate(taskHistoryBucket))
+	durationsKey := h.durationsKey(taskType)
+	ms := float64(duration.Microseconds()) / 1000
+	// The member carries the outcome and duration; the ID keeps identical executions apart
+	member := fmt.Sprintf("%s:%d:%s", outcome, duration.Microseconds(), uuid.NewString())
+
+	pipe := h.client.TxPipeline()
+	pipe.SAdd(ctx, h.typesKey(), taskType)
+	pipe.HIncrBy(ctx, counterKey, outcome, 1)
+	pipe.HIncrBy(ctx, counterKey, taskFieldSumMicros, duration.Microseconds())
+	pipe.HIncrBy(ctx, counterKey, taskFieldLatency+strconv.Itoa(latencyBucket(ms)), 1)
+	pipe.Expire(ctx, counterKey, h.retention+taskHistoryBucket)
+	pipe.ZAdd(ctx, durationsKey, redis.Z{Score: float64(at.UnixMilli()), Member: member})
+	pipe.ZRemRangeByScore(ctx, durationsKey, "-inf", fmt.Sprintf("(%d", at.Add(-taskHistorySampleWindow).UnixMilli()))
+	pipe.Expire(ctx, durationsKey, taskHistorySampleWindow)
+	if _, err := pipe.Exec(ctx); err != nil {
+		return fmt.Errorf("failed to record %s outcome: %w", taskType, err)
+	}
+	return nil
+}
+
+// TaskTypes returns the task types with recorded outcomes
+func (h *TaskHistory) TaskTypes(ctx context.Context) ([]string, error) {
+	taskTypes, err := h.client.SMembers(ctx, h.typesKey()).Result()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list task types: %w", err)
+	}
+	sort.Strings(taskTypes)
+	return taskTypes, nil
+}
+
+// Stats summarizes the outcomes of taskType over the window ending now
+func (h *TaskHistory) Stats(ctx context.Context, taskType string, window time.Duration) (TaskStats, error) {
+	if window <= 0 || window > h.retention {
+		return TaskStats{}, fmt.Errorf("window must be between 0 and the retention of %s, got %s", h.retention, window)
+	}
+
+	to := time.Now().UTC()
+	from := to.Add(-window)
+	stats := TaskStats{TaskType: taskType, Window: window.String(), From: from, To: to}
+
+	var err error
+	if window > taskHistorySampleWindow {
+		// Long windows are read from the hourly buckets they overlap
+		stats.From = from.Truncate(taskHistoryBucket)
+		stats.Approximate = true
+		if stats.Buckets, err = h.buckets(ctx, taskType, stats.From, to); err != nil {
+			return TaskStats{}, err
+		}
+		summarizeBuckets(&stats)
+		return stats, nil
+	}
+
+	if err := h.sampleStats(ctx, &stats); err != nil {
+		return TaskStats{}, err
+	}
+	if stats.Buckets, err = h.buckets(ctx, taskType, from, to); err != nil {
+		return TaskStats{}, err
+	}
+	return stats, nil
+}
+
+// sampleStats computes the statistics of stats' window from the individual outcomes
+func (h *TaskHistory) sampleStats(ctx context.Context, stats *TaskStats) error {
+	members, err := h.client.ZRangeByScore(ctx, h.durationsKey(stats.TaskType), &redis.ZRangeBy{
+		Min: strconv.FormatInt(stats.From.UnixMilli(), 10),
+		Max: strconv.FormatInt(stats.To.UnixMilli(), 10),
+	}).Result()
+	if err != nil {
+		return fmt.Errorf("failed to read %s durations: %w", stats.TaskType, err)
+	}
+
+	durations := make([]float64, 0, len(members))
+	var total float64
+	for _, member := range members {
+		parts := strings.SplitN(member, ":", 3)
+		if len(parts) != 3 {
+			continue
+		}
+		micros, err := strconv.ParseInt(parts[1], 10, 64)
+		if err != nil {
+			continue
+		}
+		if parts[0] == taskOutcomeSuccess {
+			stats.SuccessCount++
+		} else {
+			stats.FailureCount++
+		}
+		ms := float64(micros) / 1000
+		durations = append(durations, ms)
+		total += ms
+	}
+
+	stats.TotalCount = stats.SuccessCount + stats.FailureCount
+	if len(durations) > 0 {
+		sort.Float64s(durations)
+		stats.SuccessRate = float64(stats.SuccessCount) / float64(stats.TotalCount)
+		stats.AverageMS = total / float64(len(durations))
+		stats.MinMS = durations[0]
+		stats.MaxMS = durations[len(durations)-1]
+		stats.P50MS = percentile(durations, 0.50)
+		stats.P95MS = percentile(durations, 0.95)
+		stats.P99MS = percentile(durations, 0.99)
+	}
+	return nil
+}
+
+// summarizeBuckets computes the statistics of stats from its hourly buckets. Latencies are the upper
+// bounds of the histogram buckets holding them, and the minimum the lower bound of the first one.
+func summarizeBuckets(stats *TaskStats) {
+	histogram := make([]int64, len(taskLatencyBoundsMS)+1)
+	var sumMicros int64
+	for _, bucket := range stats.Buckets {
+		stats.SuccessCount += bucket.SuccessCount
+		stats.FailureCount += bucket.FailureCount
+		sumMicros += bucket.sumMicros
+		for i, count := range bucket.latencies {
+			histogram[i] += count
+		}
+	}
+
+	stats.TotalCount = stats.SuccessCount + stats.FailureCount
+	if stats.TotalCount == 0 {
+		return
+	}
+	stats.SuccessRate = float64(stats.SuccessCount) / float64(stats.TotalCount)
+	stats.AverageMS = float64(sumMicros) / 1000 / float64(stats.TotalCount)
+	stats.P50MS = histogramPercentile(histogram, 0.50)
+	stats.P95MS = histogramPercentile(histogram, 0.95)
+	stats.P99MS = histogramPercentile(histogram, 0.99)
+	for i, count := range histogram {
+		if count > 0 {
+			if i > 0 {
+				stats.MinMS = taskLatencyBoundsMS[i-1]
+			}
+			break
+		}
+	}
+	for i := len(histogram) - 1; i >= 0; i-- {
+		if histogram[i] > 0 {
+			stats.MaxMS = latencyBound(i)
+			break
+		}
+	}
+}
+
+// buckets reads the hourly counters overlapping [from, to]
+func (h *TaskHistory) buckets(ctx context.Context, taskType string, from, to time.Time) ([]TaskStatsBucket, error) {
+	var starts []time.Time
+	for start := from.Truncate(taskHistoryBucket); !start.After(to); start = start.Add(taskHistoryBucket) {
+		starts = append(starts, start)
+	}
+
+	pipe := h.client.Pipeline()
+	cmds := make([]*redis.MapStringStringCmd, len(starts))
+	for i, start := range starts {
+		cmds[i] = pipe.HGetAll(ctx, h.counterKey(taskType, start))
+	}
+	if _, err := pipe.Exec(ctx); err != nil {
+		return nil, fmt.Errorf("failed to read %s counters: %w", taskType, err)
+	}
+
+	buckets := make([]TaskStatsBucket, len(starts))
+	for i, start := range starts {
+		counts := cmds[i].Val()
+		success, _ := strconv.ParseInt(counts[taskOutcomeSuccess], 10, 64)
+		failure, _ := strconv.ParseInt(counts[taskOutcomeFailure], 10, 64)
+		buckets[i] = TaskStatsBucket{Start: start, SuccessCount: success, FailureCount: failure}
+		buckets[i].sumMicros, _ = strconv.ParseInt(counts[taskFieldSumMicros], 10, 64)
+		buckets[i].latencies = make([]int64, len(taskLatencyBoundsMS)+1)
+		for j := range buckets[i].latencies {
+			buckets[i].latencies[j], _ = strconv.ParseInt(counts[taskFieldLatency+strconv.Itoa(j)], 10, 64)
+		}
+	}
+	return buckets, nil
+}
+
+// Close closes the underlying Redis client
+func (h *TaskHistory) Close() error {
+	return h.client.Close()
+}
+
+func (h *TaskHistory) typesKey() string {
+	return taskHistoryPrefix + ":types"
+}
+
+func (h *TaskHistory) counterKey(taskType string, bucket time.Time) string {
+	return fmt.Sprintf("%s:counts:%s:%d", taskHistoryPrefix, taskType, bucket.Unix())
+}
+
+func (h *TaskHistory) durationsKey(taskType string) string {
+	return fmt.Sprintf("%s:durations:%s", taskHistoryPrefix, taskType)
+}
+
+// percentile returns the nearest-rank percentile p (0-1] of the sorted values
+func percentile(sorted []float64, p float64) float64 {
+	rank := int(math.Ceil(p*float64(len(sorted)))) - 1
+	if rank < 0 {
+		rank = 0
+	}
+	if rank >= len(sorted) {
+		rank = len(sorted) - 1
+	}
+	return sorted[rank]
+}
+
+// latencyBucket returns the index of the latency histogram bucket holding ms
+func latencyBucket(ms float64) int {
+	return sort.SearchFloat64s(taskLatencyBoundsMS, ms)
+}
+
+// latencyBound returns the upper bound of histogram bucket i; the unbounded bucket reports the largest bound
+func latencyBound(i int) float64 {
+	if i >= len(taskLatencyBoundsMS) {
+		return taskLatencyBoundsMS[len(taskLatencyBoundsMS)-1]
+	}
+	return taskLatencyBoundsMS[i]
+}
+
+// histogramPercentile returns the upper bound of the histogram bucket holding the nearest-rank percentile p (0-1]
+func histogramPercentile(histogram []int64, p float64) float64 {
+	var total int64
+	for _, count := range histogram {
+		total += count
+	}
+	rank := int64(math.Ceil(p * float64(total)))
+	if rank < 1 {
+		rank = 1
+	}
+
+	var seen int64
+	for i, count := range histogram {
+		seen += count
+		if seen >= rank {
+			return latencyBound(i)
+		}
+	}
+	return latencyBound(len(histogram) - 1)
+}
+
+// ParseWindow parses a window such as 1h, 30m, 7d or 1w; besides Go durations it accepts whole days and weeks
+func ParseWindow(value string) (time.Duration, error) {
+	for suffix, unit := range map[string]time.Duration{"d": 24 * time.Hour, "w": 7 * 24 * time.Hour} {
+		if count, ok := strings.CutSuffix(value, suffix); ok {
+			n, err := strconv.Atoi(count)
+			if err != nil || n <= 0 {
+				return 0, fmt.Errorf("invalid window %q", value)
+			}
+			return time.Duration(n) * unit, nil
+		}
+	}
+	window, err := time.ParseDuration(value)
+	if err != nil {
+		return 0, fmt.Errorf("invalid window %q", value)
+	}
+	return window, nil
+}
@@ -2,15 +2,22 @@ package service
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"net/http"
 	"os"
 	"regexp"
 	"strings"
+	"time"
 
 	"google.golang.org/api/aiplatform/v1"
+	"google.golang.org/api/googleapi"
 	"google.golang.org/api/option"
 )
 
+// ErrCorpusNotFound is returned when a corpus does not exist in Vertex AI
+var ErrCorpusNotFound = errors.New("corpus not found in Vertex AI")
+
 type VertexAIService struct {
 	projectID string
 	location  string
@@ -27,7 +34,7 @@ func (v *VertexAIService) ListRAGFilesInCorpus(ctx context.Context, corpusName s
 	corpusDisplayName := regexp.MustCompile(`[^a-zA-Z0-9_-]`).ReplaceAllString(corpusName, "_")
 	parent := fmt.Sprintf("projects/%s/locations/%s", v.projectID, v.location)
 	listCall := service.Projects.Locations.RagCorpora.List(parent)
-	existingCorpora, err := listCall.Do()
+	existingCorpora, err := listCall.Context(ctx).Do()
 	if err != nil {
 		return nil, fmt.Errorf("failed to list corpora: %v", err)
 	}
@@ -43,7 +50,7 @@ func (v *VertexAIService) ListRAGFilesInCorpus(ctx context.Context, corpusName s
 		return nil, fmt.Errorf("corpus '%s' not found", corpusName)
 	}
 
-	filesResponse, err := service.Projects.Locations.RagCorpora.RagFiles.List(corpusResourceName).Do()
+	filesResponse, err := service.Projects.Locations.RagCorpora.RagFiles.List(corpusResourceName).Context(ctx).Do()
 	if err != nil {
 		return nil, fmt.Errorf("failed to list files in corpus: %v", err)
 	}
@@ -74,6 +81,12 @@ func NewVertexAIService() *VertexAIService {
 
 // AddDocumentToCorpus adds a document from GCS to a RAG corpus
 func (v *VertexAIService) AddDocumentToCorpus(ctx context.Context, corpusName, fileLink string) (map[string]interface{}, error) {
+	return v.AddDocumentToCorpusWithChunking(ctx, corpusName, fileLink, 0, 0)
+}
+
+// AddDocumentToCorpusWithChunking adds a document from GCS to a RAG corpus using fixed length chunking.
+// A zero chunkSize keeps the RAG engine defaults.
+func (v *VertexAIService) AddDocumentToCorpusWithChunking(ctx context.Context, corpusName, fileLink string, chunkSize, chunkOverlap int64) (map[string]interface{}, error) {
 	// Use regional endpoint for RAG operations
 	endpoint := fmt.Sprintf("https://%s-aiplatform.googleapis.com/", v.location)
 
@@ -90,7 +103,7 @@ func (v *VertexAIService) AddDocumentToCorpus(ctx context.Context, corpusName, f
 	parent := fmt.Sprintf("projects/%s/locations/%s", v.projectID, v.location)
 	listCall := service.Projects.Locations.RagCorpora.List(parent)
 
-	existingCorpora, err := listCall.Do()
+	existingCorpora, err := listCall.Context(ctx).Do()
 	if err != nil {
 		return nil, fmt.Errorf("failed to list corpora: %v", err)
 	}
@@ -116,8 +129,19 @@ func (v *VertexAIService) AddDocumentToCorpus(ctx context.Context, corpusName, f
 		},
 	}
 
+	if chunkSize > 0 {
+		importRequest.ImportRagFilesConfig.RagFileTransformationConfig = &aiplatform.GoogleCloudAiplatformV1RagFileTransformationConfig{
+			RagFileChunkingConfig: &aiplatform.GoogleCloudAiplatformV1RagFileChunkingConfig{
+				FixedLengthChunking: &aiplatform.GoogleCloudAiplatformV1RagFileChunkingConfigFixedLengthChunking{
+					ChunkSize:    chunkSize,
+					ChunkOverlap: chunkOverlap,
+				},
+			},
+		}
+	}
+
 	// Import the file to the corpus
-	operation, err := service.Projects.Locations.RagCorpora.RagFiles.Import(corpusResourceName, importRequest).Do()
+	operation, err := service.Projects.Locations.RagCorpora.RagFiles.Import(corpusResourceName, importRequest).Context(ctx).Do()
 	if err != nil {
 		return nil, fmt.Errorf("failed to import file to corpus: %v", err)
 	}
@@ -131,6 +155,121 @@ func (v *VertexAIService) AddDocumentToCorpus(ctx context.Context, corpusName, f
 	}, nil
 }
 
+// CorpusDisplayName returns the display name a corpus is registered under in Vertex AI
+func CorpusDisplayName(corpusName string) string {
+	return regexp.MustCompile(`[^a-zA-Z0-9_-]`).ReplaceAllString(corpusName, "_")
+}
+
+// FindCorpusResourceName looks up the Vertex AI resource name of a corpus.
+// It returns an empty name without error when the corpus does not exist.
+func (v *VertexAIService) FindCorpusResourceName(ctx context.Context, corpusName string) (string, error) {
+	endpoint := fmt.Sprintf("https://%s-aiplatform.googleapis.com/", v.location)
+	service, err := aiplatform.NewService(ctx, option.WithEndpoint(endpoint))
+	if err != nil {
+		return "", fmt.Errorf("failed to create AI Platform service: %v", err)
+	}
+
+	displayName := CorpusDisplayName(corpusName)
+	parent := fmt.Sprintf("projects/%s/locations/%s", v.projectID, v.location)
+	existingCorpora, err := service.Projects.Locations.RagCorpora.List(parent).Context(ctx).Do()
+	if err != nil {
+		return "", fmt.Errorf("failed to list corpora: %v", err)
+	}
+
+	for _, corpus := range existingCorpora.RagCorpora {
+		if corpus.DisplayName == displayName {
+			return corpus.Name, nil
+		}
+	}
+	return "", nil
+}
+
+// DeleteCorpus deletes a RAG corpus together with all of its RAG files
+func (v *VertexAIService) DeleteCorpus(ctx context.Context, resourceName string) (string, error) {
+	endpoint := fmt.Sprintf("https://%s-aiplatform.googleapis.com/", v.location)
+	service, err := aiplatform.NewService(ctx, option.WithEndpoint(endpoint))
+	if err != nil {
+		return "", fmt.Errorf("failed to create AI Platform service: %v", err)
+	}
+
+	operation, err := service.Projects.Locations.RagCorpora.Delete(resourceName).Force(true).Context(ctx).Do()
+	if err != nil {
+		return "", fmt.Errorf("failed to delete corpus: %v", err)
+	}
+	return operation.Name, nil
+}
+
+// RAGFile describes a file imported into a RAG corpus
+type RAGFile struct {
+	ID          string    `json:"id"`
+	Name        string    `json:"name"` // Full resource name (projects/.../ragCorpora/.../ragFiles/{id})
+	DisplayName string    `json:"displayName"`
+	CreateTime  time.Time `json:"createTime"`
+}
+
+// ListRAGFiles lists every RAG file of a corpus, following pagination.
+// It returns ErrCorpusNotFound when the corpus does not exist in Vertex AI.
+func (v *VertexAIService) ListRAGFiles(ctx context.Context, corpusName string) ([]RAGFile, error) {
+	resourceName, err := v.FindCorpusResourceName(ctx, corpusName)
+	if err != nil {
+		return nil, err
+	}
+	if resourceName == "" {
+		return nil, ErrCorpusNotFound
+	}
+
+	endpoint := fmt.Sprintf("https://%s-aiplatform.googleapis.com/", v.location)
+	service, err := aiplatform.NewService(ctx, option.WithEndpoint(endpoint))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create AI Platform service: %v", err)
+	}
+
+	var files []RAGFile
+	err = service.Projects.Locations.RagCorpora.RagFiles.List(resourceName).Pages(ctx, func(page *aiplatform.GoogleCloudAiplatformV1ListRagFilesResponse) error {
+		for _, file := range page.RagFiles {
+			createTime, _ := time.Parse(time.RFC3339Nano, file.CreateTime)
+			files = append(files, RAGFile{
+				ID:          file.Name[strings.LastIndex(file.Name, "/")+1:],
+				Name:        file.Name,
+				DisplayName: file.DisplayName,
+				CreateTime:  createTime,
+			})
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list files in corpus: %v", err)
+	}
+	return files, nil
+}
+
+// DeleteRAGFile deletes one RAG file of a corpus. A file or corpus that no longer exists counts as deleted.
+func (v *VertexAIService) DeleteRAGFile(ctx context.Context, corpusName, ragFileID string) error {
+	resourceName, err := v.FindCorpusResourceName(ctx, corpusName)
+	if err != nil {
+		return err
+	}
+	if resourceName == "" {
+		return nil
+	}
+
+	endpoint := fmt.Sprintf("https://%s-aiplatform.googleapis.com/", v.location)
+	service, err := aiplatform.NewService(ctx, option.WithEndpoint(endpoint))
+	if err != nil {
+		return fmt.Errorf("failed to create AI Platform service: %v", err)
+	}
+
+	name := fmt.Sprintf("%s/ragFiles/%s", resourceName, ragFileID)
+	if _, err := service.Projects.Locations.RagCorpora.RagFiles.Delete(name).Context(ctx).Do(); err != nil {
+		var apiErr *googleapi.Error
+		if errors.As(err, &apiErr) && apiErr.Code == http.StatusNotFound {
+			return nil
+		}
+		return fmt.Errorf("failed to delete RAG file: %v", err)
+	}
+	return nil
+}
+
 // CheckOperationStatus checks the status of a Vertex AI operation
 func (v *VertexAIService) CheckOperationStatus(ctx context.Context, operationName string) (map[string]interface{}, error) {
 	// Use regional endpoint
@@ -143,7 +282,7 @@ func (v *VertexAIService) CheckOperationStatus(ctx context.Context, operationNam
 	}
 
 	// Get operation status
-	operation, err := service.Projects.Locations.Operations.Get(operationName).Do()
+	operation, err := service.Projects.Locations.Operations.Get(operationName).Context(ctx).Do()
 	if err != nil {
 		return nil, fmt.Errorf("failed to get operation status: %v", err)
 	}
@@ -190,7 +329,7 @@ func (v *VertexAIService) ExtractRAGFileIDFromDocument(ctx context.Context, corp
 	parent := fmt.Sprintf("projects/%s/locations/%s", v.projectID, v.location)
 	listCall := service.Projects.Locations.RagCorpora.List(parent)
 
-	existingCorpora, err := listCall.Do()
+	existingCorpora, err := listCall.Context(ctx).Do()
 	if err != nil {
 		return "", fmt.Errorf("failed to list corpora: %v", err)
 	}
@@ -208,7 +347,7 @@ func (v *VertexAIService) ExtractRAGFileIDFromDocument(ctx context.Context, corp
 	}
 
 	// List all files in the corpus
-	filesResponse, err := service.Projects.Locations.RagCorpora.RagFiles.List(corpusResourceName).Do()
+	filesResponse, err := service.Projects.Locations.RagCorpora.RagFiles.List(corpusResourceName).Context(ctx).Do()
 	if err != nil {
 		return "", fmt.Errorf("failed to list files in corpus: %v", err)
 	}
@@ -0,0 +1,67 @@
+package tracing
+
+import (
+	"context"
+	"sync"
+
+	"go.mongodb.org/mongo-driver/event"
+	"go.opentelemetry.io/otel/codes"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// MongoCommandMonitor returns a command monitor starting a client span for every MongoDB command,
+// as a child of the span in the context the command was issued with. Commands are not recorded,
+// since they contain student and teacher data.
+func MongoCommandMonitor() *event.CommandMonitor {
+	// Span of each in-flight command; finished events only carry the request ID
+	var spans sync.Map
+
+	finish := func(requestID int64, failure string) {
+		span, ok := spans.LoadAndDelete(requestID)
+		if !ok {
+			return
+		}
+		if failure != "" {
+			span.(trace.Span).SetStatus(codes.Error, failure)
+		}
+		span.(trace.Span).End()
+	}
+
+	return &event.CommandMonitor{
+		Started: func(ctx context.Context, e *event.CommandStartedEvent) {
+			collection := commandCollection(e)
+			name := e.CommandName
+			if collection != "" {
+				name += " " + collection
+			}
+			_, span := Tracer().Start(ctx, name,
+				trace.WithSpanKind(trace.SpanKindClient),
+				trace.WithAttributes(
+					semconv.DBSystemMongoDB,
+					semconv.DBNamespace(e.DatabaseName),
+					semconv.DBOperationName(e.CommandName),
+					semconv.DBCollectionName(collection),
+				),
+			)
+			spans.Store(e.RequestID, span)
+		},
+		Succeeded: func(_ context.Context, e *event.CommandSucceededEvent) {
+			finish(e.RequestID, "")
+		},
+		Failed: func(_ context.Context, e *event.CommandFailedEvent) {
+			finish(e.RequestID, e.Failure)
+		},
+	}
+}
+
+// commandCollection returns the collection a command targets, see metrics.MongoCommandMonitor
+func commandCollection(e *event.CommandStartedEvent) string {
+	if collection, ok := e.Command.Lookup(e.CommandName).StringValueOK(); ok {
+		return collection
+	}
+	if collection, ok := e.Command.Lookup("collection").StringValueOK(); ok {
+		return collection
+	}
+	return ""
+}
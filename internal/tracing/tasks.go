@@ -0,0 +1,38 @@
+package tracing
+
+import (
+	"context"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// TaskContext carries W3C trace context (traceparent, tracestate, baggage) inside a task payload.
+// asynq has no task headers, so payloads embed it to continue the enqueuing trace in the worker.
+type TaskContext map[string]string
+
+// InjectTaskContext returns the trace context of ctx to store in a task payload, or nil outside a trace
+func InjectTaskContext(ctx context.Context) TaskContext {
+	carrier := propagation.MapCarrier{}
+	otel.GetTextMapPropagator().Inject(ctx, carrier)
+	if len(carrier) == 0 {
+		return nil
+	}
+	return TaskContext(carrier)
+}
+
+// StartTask resumes the trace carried by a task payload and starts the consumer span of the task run.
+// The caller must end the returned span.
+func StartTask(ctx context.Context, taskType string, carried TaskContext, attrs ...attribute.KeyValue) (context.Context, trace.Span) {
+	if len(carried) > 0 {
+		ctx = otel.GetTextMapPropagator().Extract(ctx, propagation.MapCarrier(carried))
+	}
+	attrs = append(attrs,
+		attribute.String("messaging.system", "asynq"),
+		attribute.String("messaging.operation.type", "process"),
+		attribute.String("asynq.task_type", taskType),
+	)
+	return Tracer().Start(ctx, "process "+taskType, trace.WithSpanKind(trace.SpanKindConsumer), trace.WithAttributes(attrs...))
+}
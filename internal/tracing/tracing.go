@@ -0,0 +1,122 @@
+package tracing
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"go.opentelemetry.io/contrib/instrumentation/github.com/gin-gonic/gin/otelgin"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracegrpc"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// instrumentationName names the tracer of the spans started by this application
+const instrumentationName = "lumenslate"
+
+// Trace exporters selected with OTEL_TRACES_EXPORTER
+const (
+	ExporterNone   = "none"
+	ExporterOTLP   = "otlp"
+	ExporterStdout = "stdout"
+)
+
+// Config configures tracing. The OTLP exporter additionally reads the standard OTEL_EXPORTER_OTLP_*
+// variables (endpoint, insecure, headers) and the SDK reads OTEL_TRACES_SAMPLER and its argument.
+type Config struct {
+	Exporter    string // OTEL_TRACES_EXPORTER: otlp, stdout or none (default)
+	ServiceName string // OTEL_SERVICE_NAME, defaults to lumenslate-api
+}
+
+// LoadConfig reads the tracing configuration from the environment
+func LoadConfig() Config {
+	config := Config{
+		Exporter:    strings.ToLower(os.Getenv("OTEL_TRACES_EXPORTER")),
+		ServiceName: os.Getenv("OTEL_SERVICE_NAME"),
+	}
+	if config.Exporter == "" {
+		config.Exporter = ExporterNone
+	}
+	if config.ServiceName == "" {
+		config.ServiceName = "lumenslate-api"
+	}
+	return config
+}
+
+// Init installs the W3C trace-context propagator and, unless the exporter is none, a tracer provider
+// exporting spans in batches. The returned function flushes and stops the exporter.
+func Init(ctx context.Context, config Config) (func(context.Context) error, error) {
+	// Propagate trace context even when spans are not exported, so callers upstream and downstream
+	// still see one trace
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(propagation.TraceContext{}, propagation.Baggage{}))
+
+	var exporter sdktrace.SpanExporter
+	var err error
+	switch config.Exporter {
+	case ExporterNone:
+		return func(context.Context) error { return nil }, nil
+	case ExporterOTLP:
+		exporter, err = otlptracegrpc.New(ctx)
+	case ExporterStdout:
+		exporter, err = stdouttrace.New(stdouttrace.WithPrettyPrint())
+	default:
+		return nil, fmt.Errorf("unknown trace exporter %q, expected %s, %s or %s", config.Exporter, ExporterOTLP, ExporterStdout, ExporterNone)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to create %s trace exporter: %w", config.Exporter, err)
+	}
+
+	res, err := resource.Merge(resource.Default(), resource.NewWithAttributes(semconv.SchemaURL, semconv.ServiceName(config.ServiceName)))
+	if err != nil {
+		return nil, fmt.Errorf("failed to build trace resource: %w", err)
+	}
+
+	provider := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithResource(res),
+	)
+	otel.SetTracerProvider(provider)
+
+	return provider.Shutdown, nil
+}
+
+// Tracer returns the tracer for spans started by this application
+func Tracer() trace.Tracer {
+	return otel.Tracer(instrumentationName)
+}
+
+// Start starts an internal span, e.g. around a call to a Google Cloud API. The caller must end it.
+func Start(ctx context.Context, name string, attrs ...attribute.KeyValue) (context.Context, trace.Span) {
+	return Tracer().Start(ctx, name, trace.WithAttributes(attrs...))
+}
+
+// End records err on span, if any, and ends it
+func End(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
+
+// GinMiddleware starts a server span for every request, continuing the trace of the caller when
+// the request carries a traceparent header. Health checks and metric scrapes are not traced.
+func GinMiddleware(serviceName string) gin.HandlerFunc {
+	return otelgin.Middleware(serviceName, otelgin.WithFilter(func(r *http.Request) bool {
+		switch r.URL.Path {
+		case "/health", "/health/live", "/health/ready", "/metrics":
+			return false
+		}
+		return true
+	}))
+}
@@ -8,6 +8,7 @@ import (
 	"time"
 
 	"github.com/google/uuid"
+	"go.opentelemetry.io/otel/trace"
 )
 
 // LogLevel represents the severity level of a log entry
@@ -35,6 +36,7 @@ type LogEntry struct {
 	Message       string            `json:"message"`
 	CorrelationID string            `json:"correlation_id,omitempty"`
 	RequestID     string            `json:"request_id,omitempty"`
+	TraceID       string            `json:"trace_id,omitempty"`
 	Component     string            `json:"component,omitempty"`
 	Operation     string            `json:"operation,omitempty"`
 	FileID        string            `json:"file_id,omitempty"`
@@ -198,6 +200,9 @@ func (l *Logger) log(ctx context.Context, level LogLevel, message, operation, er
 	if ctx != nil {
 		entry.CorrelationID = GetCorrelationID(ctx)
 		entry.RequestID = GetRequestID(ctx)
+		if spanContext := trace.SpanContextFromContext(ctx); spanContext.HasTraceID() {
+			entry.TraceID = spanContext.TraceID().String()
+		}
 	}
 
 	// Add optional fields
@@ -1,22 +1,32 @@
 package main
 
 import (
+	"context"
+	"flag"
+	"fmt"
 	"io"
 	"log"
 	"net/http"
 	"os"
 	"os/signal"
+	"strconv"
+	"strings"
 	"syscall"
 	"time"
 
 	"github.com/gin-contrib/cors"
 	"github.com/gin-gonic/gin"
 	"github.com/joho/godotenv"
+	"go.mongodb.org/mongo-driver/mongo/readpref"
 
 	"lumenslate/internal/db"
+	grpcservice "lumenslate/internal/grpc_service"
+	"lumenslate/internal/grpc_service/aifake"
+	"lumenslate/internal/metrics"
 	"lumenslate/internal/routes"
 	"lumenslate/internal/routes/questions"
 	"lumenslate/internal/service"
+	"lumenslate/internal/tracing"
 	"lumenslate/tasks"
 
 	_ "lumenslate/internal/docs"
@@ -31,6 +41,16 @@ import (
 // @host            localhost:8080
 // @BasePath        /
 
+// @securityDefinitions.apikey  BearerAuth
+// @in                          header
+// @name                        Authorization
+// @description                 Admin endpoints require "Bearer <ADMIN_API_TOKEN>"
+
+// @securityDefinitions.apikey  CallerAuth
+// @in                          header
+// @name                        X-User-Signature
+// @description                 Requests naming a caller in X-User-ID must sign it: "<expiry unix>.<hex HMAC-SHA256 of "<X-User-ID>.<expiry unix>" keyed with CALLER_SIGNING_KEY>"
+
 func init() {
 	logADCIdentity()
 
@@ -51,46 +71,79 @@ func init() {
 func main() {
 	startTime := time.Now()
 
+	// Select which parts of the application this process runs
+	role := initializeRole()
+
+	// Initialize tracing before anything that starts spans
+	tracingConfig, shutdownTracing := initializeTracing()
+
 	gin.SetMode(os.Getenv("GIN_MODE")) // This will suppress the debug logs
 	gin.DisableConsoleColor()
 	router := gin.New()
 
 	router.Use(gin.LoggerWithConfig(gin.LoggerConfig{
-		SkipPaths: []string{"/health"}, // Skip logging health checks
+		SkipPaths: []string{"/health", "/health/live", "/health/ready", "/metrics"}, // Skip logging health checks and scrapes
 	}))
 	router.Use(gin.Recovery())
+	router.Use(tracing.GinMiddleware(tracingConfig.ServiceName))
+	router.Use(metrics.GinMiddleware())
 	router.Use(cors.Default())
 	router.RedirectTrailingSlash = false
 	router.RedirectFixedPath = false
-	router.Static("/media", "./media")
 
 	// Initialize metrics collector for monitoring
 	metricsCollector := initializeMetricsCollector()
 
-	// Create API v1 group
-	apiV1 := router.Group("/api/v1")
+	// Cache AI responses in Redis, counting hits and misses in the metrics collector
+	initializeResponseCache(metricsCollector)
 
-	// Register all API routes under /api/v1
-	registerRoutes(apiV1, metricsCollector, startTime)
+	// Initialize event broker for pushing background job progress to clients
+	eventBroker := initializeEventBroker()
 
-	// Health and docs endpoints remain at root
-	router.GET("/health", func(c *gin.Context) {
-		c.JSON(http.StatusOK, gin.H{"status": "ok"})
-	})
-	router.GET("/docs/*any", ginSwagger.WrapHandler(swaggerFiles.Handler))
+	// Serve AI calls from the in-process fake when running without the AI microservice
+	initializeAIServiceFake()
+
+	// Check the dependencies behind the health and readiness endpoints
+	readiness := initializeReadiness(eventBroker)
+
+	// Health and metrics endpoints are served in every role
+	routes.RegisterHealthRoutes(router, metricsCollector, readiness, startTime)
+	router.GET("/metrics", gin.WrapH(metrics.Handler()))
 
-	// Initialize and start Asynq server for background task processing
-	asynqServer := initializeAsynqServer()
-	if err := asynqServer.Start(); err != nil {
-		log.Fatalf("❌ Failed to start Asynq server: %v", err)
+	if role.ServesAPI() {
+		router.Static("/media", "./media")
+
+		// Create API v1 group
+		apiV1 := router.Group("/api/v1")
+
+		// Register all API routes under /api/v1
+		registerRoutes(apiV1, metricsCollector, eventBroker, startTime)
+
+		router.GET("/docs/*any", ginSwagger.WrapHandler(swaggerFiles.Handler))
 	}
 
-	port := os.Getenv("PORT")
-	if port == "" {
-		port = "8080"
+	// Alerts are evaluated by workers; their shared Redis state lets only one notify each change
+	var asynqServer *service.AsynqServer
+	var alertEvaluator *service.AlertEvaluator
+	var scheduler *service.AsynqScheduler
+	if role.ProcessesTasks() {
+		alertEvaluator = initializeAlertEvaluator(metricsCollector)
+
+		// Initialize and start Asynq server for background task processing
+		asynqServer = initializeAsynqServer()
+		if err := asynqServer.Start(); err != nil {
+			log.Fatalf("❌ Failed to start Asynq server: %v", err)
+		}
+
+		// Enqueue periodic tasks such as the storage reconciliation
+		scheduler = initializeScheduler()
+		if err := scheduler.Start(); err != nil {
+			log.Fatalf("❌ Failed to start Asynq scheduler: %v", err)
+		}
 	}
-	address := "0.0.0.0:" + port // ✅ REQUIRED for Cloud Run
-	log.Printf("[BOOT] Starting server on %s", address)
+
+	address := "0.0.0.0:" + role.Port() // ✅ REQUIRED for Cloud Run
+	log.Printf("[BOOT] Starting %s server on %s", role, address)
 
 	go func() {
 		if err := router.Run(address); err != nil {
@@ -98,11 +151,11 @@ func main() {
 		}
 	}()
 
-	gracefulShutdown(asynqServer, metricsCollector)
+	gracefulShutdown(asynqServer, scheduler, alertEvaluator, metricsCollector, eventBroker, shutdownTracing)
 }
 
 // Change router type from *gin.Engine to gin.IRoutes to allow both *gin.Engine and *gin.RouterGroup
-func registerRoutes(router *gin.RouterGroup, metricsCollector *service.MetricsCollector, startTime time.Time) {
+func registerRoutes(router *gin.RouterGroup, metricsCollector *service.MetricsCollector, eventBroker *service.EventBroker, startTime time.Time) {
 	routes.RegisterAssignmentRoutes(router)
 	routes.RegisterClassroomRoutes(router)
 	routes.RegisterCommentRoutes(router)
@@ -117,6 +170,8 @@ func registerRoutes(router *gin.RouterGroup, metricsCollector *service.MetricsCo
 	routes.SetupReportCardRoutes(router)
 	routes.SetupAgentReportCardRoutes(router)
 	routes.RegisterUserRoutes(router)
+	routes.RegisterEventRoutes(router, eventBroker)
+	routes.RegisterAdminRoutes(router, service.NewTaskAdmin(metricsCollector.Inspector()))
 	questions.RegisterMCQRoutes(router)
 	questions.RegisterMSQRoutes(router)
 	questions.RegisterNATRoutes(router)
@@ -153,15 +208,63 @@ func initializeAsynqServer() *service.AsynqServer {
 	// Create Asynq server with default concurrency
 	asynqServer := service.NewAsynqServer(redisAddr, 0) // 0 uses default from env or 10
 
-	// Register document processing task handler
-	if err := asynqServer.RegisterTaskHandler(tasks.TypeAddDocumentToCorpus, tasks.HandleAddDocumentToCorpusTask); err != nil {
-		log.Fatalf("❌ Failed to register document task handler: %v", err)
+	// Register the handlers of every task type
+	if err := tasks.RegisterHandlers(asynqServer); err != nil {
+		log.Fatalf("❌ Failed to register task handlers: %v", err)
 	}
 
 	log.Printf("[BOOT] Asynq server initialized with Redis at %s", redisAddr)
 	return asynqServer
 }
 
+// initializeScheduler creates the scheduler enqueuing periodic tasks
+func initializeScheduler() *service.AsynqScheduler {
+	redisAddr := os.Getenv("REDIS_ADDR")
+	if redisAddr == "" {
+		redisAddr = "localhost:6379"
+	}
+
+	scheduler := service.NewAsynqScheduler(redisAddr)
+	if err := tasks.RegisterPeriodicTasks(scheduler); err != nil {
+		log.Fatalf("❌ Failed to register periodic tasks: %v", err)
+	}
+
+	log.Printf("[BOOT] Asynq scheduler initialized with Redis at %s", redisAddr)
+	return scheduler
+}
+
+// initializeRole reads the role of the process from --role, or APP_ROLE when the flag is not given.
+// The legacy --async-only flag selects the worker role.
+func initializeRole() Role {
+	roleFlag := flag.String("role", os.Getenv("APP_ROLE"), "role of the process: web, worker or all")
+	asyncOnly := flag.Bool("async-only", false, "run as a worker; same as --role=worker")
+	flag.Parse()
+
+	value := *roleFlag
+	if *asyncOnly {
+		value = string(RoleWorker)
+	}
+	role, err := ParseRole(value)
+	if err != nil {
+		log.Fatalf("❌ %v", err)
+	}
+
+	log.Printf("[BOOT] Running as %s", role)
+	return role
+}
+
+// initializeTracing installs trace-context propagation and the span exporter selected by OTEL_TRACES_EXPORTER
+func initializeTracing() (tracing.Config, func(context.Context) error) {
+	config := tracing.LoadConfig()
+	shutdown, err := tracing.Init(context.Background(), config)
+	if err != nil {
+		log.Fatalf("❌ Failed to initialize tracing: %v", err)
+	}
+
+	log.Printf("[BOOT] Tracing initialized for %s with %s exporter", config.ServiceName, config.Exporter)
+	return config, shutdown
+}
+
 // initializeMetricsCollector creates and configures the metrics collector
 func initializeMetricsCollector() *service.MetricsCollector {
 	redisAddr := os.Getenv("REDIS_ADDR")
@@ -177,22 +280,166 @@ func initializeMetricsCollector() *service.MetricsCollector {
 	// Set the global metrics collector for task handlers
 	tasks.SetMetricsCollector(metricsCollector)
 
+	// Alert rules apply to the health endpoints in every role and to the alert evaluator of workers
+	rules, err := service.LoadAlertRules()
+	if err != nil {
+		log.Fatalf("❌ Failed to load alert rules: %v", err)
+	}
+	metricsCollector.SetAlertRules(rules)
+
+	// Export queue depth and latency of every asynq queue at /metrics
+	if err := metrics.Register(metrics.NewQueueCollector(metricsCollector.Inspector())); err != nil {
+		log.Fatalf("❌ Failed to register asynq queue metrics: %v", err)
+	}
+
 	log.Printf("[BOOT] Metrics collector initialized with Redis at %s", redisAddr)
 	return metricsCollector
 }
 
-func gracefulShutdown(asynqServer *service.AsynqServer, metricsCollector *service.MetricsCollector) {
+// initializeAlertEvaluator loads the notifiers configured in the environment and starts evaluating
+// the alert rules of the metrics collector
+func initializeAlertEvaluator(metricsCollector *service.MetricsCollector) *service.AlertEvaluator {
+	rules := metricsCollector.AlertRules()
+	notifiers, err := service.LoadAlertNotifiers()
+	if err != nil {
+		log.Fatalf("❌ Failed to configure alert notifiers: %v", err)
+	}
+
+	alertEvaluator := service.NewAlertEvaluator(metricsCollector, notifiers)
+	alertEvaluator.Start()
+
+	log.Printf("[BOOT] Alert evaluator started (interval=%s, window=%s, task type rules=%d, notifiers=%d)",
+		rules.EvaluationInterval, rules.Window, len(rules.TaskTypes), len(notifiers))
+	return alertEvaluator
+}
+
+// initializeResponseCache installs the Redis cache of AI responses unless AI_CACHE_ENABLED is false
+func initializeResponseCache(metricsCollector *service.MetricsCollector) {
+	config := grpcservice.LoadCacheConfig()
+	cache := grpcservice.NewResponseCache(config, metricsCollector)
+	if cache == nil {
+		log.Printf("[BOOT] AI response cache disabled")
+		return
+	}
+	grpcservice.SetResponseCache(cache)
+
+	log.Printf("[BOOT] AI response cache initialized with Redis at %s", config.RedisAddr)
+}
+
+// initializeEventBroker creates the Redis-backed event broker shared by handlers and task processors
+func initializeEventBroker() *service.EventBroker {
+	redisAddr := os.Getenv("REDIS_ADDR")
+	if redisAddr == "" {
+		redisAddr = "localhost:6379"
+	}
+
+	eventBroker := service.NewEventBroker(redisAddr)
+
+	// Set the global event broker for task handlers
+	tasks.SetEventBroker(eventBroker)
+
+	log.Printf("[BOOT] Event broker initialized with Redis at %s", redisAddr)
+	return eventBroker
+}
+
+// initializeReadiness registers the dependency checks of the readiness probe. MongoDB and Redis
+// are critical; without the AI microservice or object storage only some endpoints fail, so their
+// outages degrade the application instead of taking it out of rotation.
+func initializeReadiness(eventBroker *service.EventBroker) *service.ReadinessRegistry {
+	readiness := service.NewReadinessRegistry(0)
+
+	readiness.Register(service.Dependency{
+		Name:     "mongodb",
+		Critical: true,
+		Timeout:  2 * time.Second,
+		Check: func(ctx context.Context) error {
+			return db.Client.Ping(ctx, readpref.Primary())
+		},
+	})
+	readiness.Register(service.Dependency{
+		Name:     "redis",
+		Critical: true,
+		Timeout:  time.Second,
+		Check:    eventBroker.Ping,
+	})
+	readiness.Register(service.Dependency{
+		Name:    "ai_service",
+		Timeout: 3 * time.Second,
+		Check: func(ctx context.Context) error {
+			manager, err := grpcservice.GetClientManager()
+			if err != nil {
+				return err
+			}
+			return manager.CheckHealth(ctx)
+		},
+	})
+
+	if os.Getenv("GCS_BUCKET_NAME") != "" {
+		gcsService, err := service.NewGCSService()
+		if err != nil {
+			log.Fatalf("❌ Failed to create object storage client for readiness checks: %v", err)
+		}
+		readiness.Register(service.Dependency{
+			Name:    "object_storage",
+			Timeout: 3 * time.Second,
+			Check:   gcsService.Ping,
+		})
+	} else {
+		log.Printf("[BOOT] GCS_BUCKET_NAME is not set, object storage is not checked for readiness")
+	}
+
+	log.Printf("[BOOT] Readiness checks initialized")
+	return readiness
+}
+
+// initializeAIServiceFake points the shared AI client at the in-process fake when AI_SERVICE_FAKE is set.
+// AI_SERVICE_FAKE_RECORDINGS optionally names a JSON file of canned responses.
+func initializeAIServiceFake() {
+	if enabled, _ := strconv.ParseBool(os.Getenv("AI_SERVICE_FAKE")); !enabled {
+		return
+	}
+
+	fake := aifake.NewServer()
+	if recordings := os.Getenv("AI_SERVICE_FAKE_RECORDINGS"); recordings != "" {
+		if err := fake.LoadRecordings(recordings); err != nil {
+			log.Fatalf("❌ Failed to load AI service fake recordings: %v", err)
+		}
+	}
+
+	manager, err := fake.Start(grpcservice.LoadClientConfig())
+	if err != nil {
+		log.Fatalf("❌ Failed to start AI service fake: %v", err)
+	}
+	grpcservice.SetClientManager(manager)
+
+	log.Printf("[BOOT] AI service calls are served by the in-process fake")
+}
+
+func gracefulShutdown(asynqServer *service.AsynqServer, scheduler *service.AsynqScheduler, alertEvaluator *service.AlertEvaluator, metricsCollector *service.MetricsCollector, eventBroker *service.EventBroker, shutdownTracing func(context.Context) error) {
 	quit := make(chan os.Signal, 1)
 	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
 	<-quit
 
 	log.Println("🛑 Shutting down server...")
 
-	// Stop Asynq server first
+	// Stop enqueuing periodic tasks, then the Asynq server
+	if scheduler != nil {
+		scheduler.Stop()
+	}
 	if asynqServer != nil {
 		asynqServer.Stop()
 	}
 
+	// Stop evaluating alerts before closing the metrics they are evaluated against
+	if alertEvaluator != nil {
+		alertEvaluator.Stop()
+	}
+
+	// Close the client tasks are enqueued with, once no handler can enqueue follow-up tasks
+	if err := tasks.CloseClient(); err != nil {
+		log.Printf("❌ Error closing task client: %v", err)
+	}
+
 	// Close metrics collector
 	if metricsCollector != nil {
 		if err := metricsCollector.Close(); err != nil {
@@ -200,10 +447,80 @@ func gracefulShutdown(asynqServer *service.AsynqServer, metricsCollector *servic
 		}
 	}
 
+	// Close event broker
+	if eventBroker != nil {
+		if err := eventBroker.Close(); err != nil {
+			log.Printf("❌ Error closing event broker: %v", err)
+		}
+	}
+
+	// Close the shared AI service connection
+	if err := grpcservice.CloseClientManager(); err != nil {
+		log.Printf("❌ Error closing AI service connection: %v", err)
+	}
+	if err := grpcservice.CloseResponseCache(); err != nil {
+		log.Printf("❌ Error closing AI response cache: %v", err)
+	}
+
 	// Close MongoDB connection
 	if err := db.CloseMongoDB(); err != nil {
 		log.Printf("❌ Error closing MongoDB connection: %v", err)
 	}
 
+	// Flush the spans of the last requests and tasks
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := shutdownTracing(ctx); err != nil {
+		log.Printf("❌ Error shutting down tracing: %v", err)
+	}
+
 	log.Println("✅ Server exited cleanly")
 }
+
+// Role selects the parts of the application a process runs
+type Role string
+
+const (
+	// RoleWeb serves the API and only enqueues background tasks
+	RoleWeb Role = "web"
+	// RoleWorker only processes background tasks, serving health checks and metrics on WORKER_PORT
+	RoleWorker Role = "worker"
+	// RoleAll serves the API and processes background tasks in one process
+	RoleAll Role = "all"
+)
+
+// ParseRole parses a role name, defaulting to RoleAll when empty
+func ParseRole(value string) (Role, error) {
+	switch role := Role(strings.ToLower(strings.TrimSpace(value))); role {
+	case "":
+		return RoleAll, nil
+	case RoleWeb, RoleWorker, RoleAll:
+		return role, nil
+	default:
+		return "", fmt.Errorf("unknown role %q, expected %s, %s or %s", value, RoleWeb, RoleWorker, RoleAll)
+	}
+}
+
+// ServesAPI reports whether the role serves the API
+func (r Role) ServesAPI() bool {
+	return r != RoleWorker
+}
+
+// ProcessesTasks reports whether the role runs the asynq server
+func (r Role) ProcessesTasks() bool {
+	return r != RoleWeb
+}
+
+// Port returns the port to listen on: WORKER_PORT (default 8090) for workers, PORT (default 8080) otherwise
+func (r Role) Port() string {
+	if r == RoleWorker {
+		if port := os.Getenv("WORKER_PORT"); port != "" {
+			return port
+		}
+		return "8090"
+	}
+	if port := os.Getenv("PORT"); port != "" {
+		return port
+	}
+	return "8080"
+}
@@ -0,0 +1,186 @@
+package tasks
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"strconv"
+	"time"
+
+	"github.com/hibiken/asynq"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.opentelemetry.io/otel/attribute"
+
+	"lumenslate/internal/model"
+	"lumenslate/internal/repository"
+	"lumenslate/internal/service"
+	"lumenslate/internal/tracing"
+	"lumenslate/internal/utils"
+)
+
+const TypeDeleteCorpus = "delete_corpus"
+
+// DeleteCorpusPayload represents the payload for cascading corpus deletion
+type DeleteCorpusPayload struct {
+	CorpusName  string `json:"corpus_name"`
+	RequestedBy string `json:"requested_by,omitempty"`
+
+	// Request that queued the delete, so its logs and spans can be tied to the background run
+	CorrelationID string              `json:"correlation_id,omitempty"`
+	TraceContext  tracing.TaskContext `json:"trace_context,omitempty"`
+}
+
+// NewDeleteCorpusTask creates a new Asynq task that deletes a corpus with its documents, GCS objects and RAG files
+func NewDeleteCorpusTask(payload DeleteCorpusPayload) (*asynq.Task, error) {
+	return newTask(TypeDeleteCorpus, payload)
+}
+
+// DeleteCorpusTaskID returns the task ID that keeps a single delete per corpus queued at a time
+func DeleteCorpusTaskID(corpusName string) string {
+	return fmt.Sprintf("%s:%s", TypeDeleteCorpus, corpusName)
+}
+
+// HandleDeleteCorpusTask removes every document of a corpus (GCS objects and database records),
+// then the Vertex AI corpus with its RAG files, and finally the corpus record. Each stage is
+// idempotent so a retry picks up where the previous run stopped.
+func HandleDeleteCorpusTask(ctx context.Context, t *asynq.Task) (err error) {
+	startTime := time.Now()
+
+	var payload DeleteCorpusPayload
+	if err := json.Unmarshal(t.Payload(), &payload); err != nil {
+		log.Printf("ERROR: Failed to unmarshal task payload: %v", err)
+		return fmt.Errorf("failed to unmarshal task payload: %v: %w", err, asynq.SkipRetry)
+	}
+
+	// Continue the trace of the delete request and keep its correlation ID
+	ctx, span := tracing.StartTask(ctx, TypeDeleteCorpus, payload.TraceContext, attribute.String("corpus.name", payload.CorpusName))
+	defer func() { tracing.End(span, err) }()
+	ctx = utils.WithCorrelationID(ctx, payload.CorrelationID)
+	ctx = utils.LogTaskStart(ctx, TypeDeleteCorpus, payload.CorpusName, map[string]string{
+		"corpus_name":  payload.CorpusName,
+		"requested_by": payload.RequestedBy,
+	})
+	logger := utils.NewLogger("task_processor")
+
+	corpusRepo := repository.NewCorpusRepository()
+	corpus, err := corpusRepo.GetCorpusByName(ctx, payload.CorpusName)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		logger.InfoWithOperation(ctx, "corpus_delete", "Corpus record already deleted")
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to load corpus: %w", err)
+	}
+	if corpus.Status == model.CorpusStatusActive {
+		// The delete was never requested through the API or the corpus was restored
+		logger.InfoWithOperation(ctx, "corpus_delete", "Corpus is active, skipping delete")
+		return nil
+	}
+
+	fail := func(err error) error {
+		if updateErr := corpusRepo.MarkDeleteFailed(ctx, payload.CorpusName, err.Error()); updateErr != nil {
+			logger.ErrorWithOperation(ctx, "status_update", "Failed to mark corpus delete as failed", updateErr)
+		}
+		utils.LogTaskComplete(ctx, TypeDeleteCorpus, payload.CorpusName, startTime, false, map[string]string{
+			"error": err.Error(),
+		})
+		if metricsCollector := GetMetricsCollector(); metricsCollector != nil {
+			metricsCollector.RecordTaskFailure(ctx, TypeDeleteCorpus, time.Since(startTime))
+		}
+		return err
+	}
+
+	deletedDocuments, err := deleteCorpusDocuments(ctx, logger, payload.CorpusName)
+	if err != nil {
+		return fail(err)
+	}
+
+	// Deleting the Vertex AI corpus with force also removes all of its RAG files
+	vertexAI := service.NewVertexAIService()
+	resourceName := corpus.ResourceName
+	if resourceName == "" {
+		resourceName, err = vertexAI.FindCorpusResourceName(ctx, payload.CorpusName)
+		if err != nil {
+			return fail(fmt.Errorf("failed to look up RAG corpus: %w", err))
+		}
+	}
+	if resourceName != "" {
+		operationName, err := vertexAI.DeleteCorpus(ctx, resourceName)
+		if err != nil {
+			return fail(fmt.Errorf("failed to delete RAG corpus: %w", err))
+		}
+		logger.InfoWithMetrics(ctx, "rag_corpus_delete", "RAG corpus deletion initiated", 0, map[string]string{
+			"resource_name": resourceName,
+			"operation":     operationName,
+		})
+	} else {
+		logger.InfoWithOperation(ctx, "rag_corpus_delete", "RAG corpus not found, skipping RAG engine deletion")
+	}
+
+	if err := corpusRepo.DeleteCorpus(ctx, payload.CorpusName); err != nil {
+		return fail(fmt.Errorf("failed to delete corpus record: %w", err))
+	}
+
+	completionMetadata := map[string]string{
+		"corpus_name":       payload.CorpusName,
+		"deleted_documents": strconv.Itoa(deletedDocuments),
+	}
+	utils.LogTaskComplete(ctx, TypeDeleteCorpus, payload.CorpusName, startTime, true, completionMetadata)
+	if metricsCollector := GetMetricsCollector(); metricsCollector != nil {
+		metricsCollector.RecordTaskSuccess(ctx, TypeDeleteCorpus, time.Since(startTime))
+	}
+
+	return nil
+}
+
+// deleteCorpusDocuments deletes the GCS objects and database records of every document in a corpus
+func deleteCorpusDocuments(ctx context.Context, logger *utils.Logger, corpusName string) (int, error) {
+	docRepo := repository.NewDocumentRepository()
+	documents, err := docRepo.GetDocumentsByCorpus(ctx, corpusName)
+	if err != nil {
+		return 0, fmt.Errorf("failed to list corpus documents: %w", err)
+	}
+	if len(documents) == 0 {
+		return 0, nil
+	}
+
+	gcsService, err := service.NewGCSService()
+	if err != nil {
+		return 0, fmt.Errorf("failed to initialize GCS service: %w", err)
+	}
+	defer gcsService.Close()
+
+	deleted := 0
+	for _, document := range documents {
+		objects := []string{document.GCSObject}
+		if document.TempObjectName != "" && document.TempObjectName != document.GCSObject {
+			objects = append(objects, document.TempObjectName)
+		}
+
+		for _, objectName := range objects {
+			exists, err := gcsService.ObjectExists(ctx, objectName)
+			if err != nil {
+				return deleted, fmt.Errorf("failed to check GCS object %s: %w", objectName, err)
+			}
+			if !exists {
+				continue
+			}
+			if err := gcsService.DeleteObject(ctx, objectName); err != nil {
+				return deleted, fmt.Errorf("failed to delete GCS object %s: %w", objectName, err)
+			}
+		}
+
+		if err := docRepo.DeleteDocument(ctx, document.FileID); err != nil {
+			return deleted, fmt.Errorf("failed to delete document %s: %w", document.FileID, err)
+		}
+		deleted++
+	}
+
+	logger.InfoWithMetrics(ctx, "corpus_documents_delete", "Deleted corpus documents", 0, map[string]string{
+		"corpus_name": corpusName,
+		"deleted":     strconv.Itoa(deleted),
+	})
+	return deleted, nil
+}
@@ -0,0 +1,180 @@
+package tasks
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/hibiken/asynq"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+
+	"lumenslate/internal/model"
+	"lumenslate/internal/repository"
+	"lumenslate/internal/service"
+)
+
+// Cleanup actions recorded on dead letters
+const (
+	deadLetterMarkFailed       = "mark_failed"
+	deadLetterDeleteTempObject = "delete_temp_object"
+	deadLetterDeleteRAGFile    = "delete_rag_file"
+)
+
+// HandleArchivedTask runs the cleanup of the task type for a task whose retries are exhausted and
+// records a dead letter listing the cleanup actions, so operators can find what is left to fix.
+// Tasks that gave up on a failure the document can be reprocessed from are recorded without cleanup.
+func HandleArchivedTask(ctx context.Context, task *asynq.Task, taskErr error) {
+	deadLetter := &model.DeadLetter{
+		TaskType:  task.Type(),
+		FileID:    service.PayloadFileID(task.Payload()),
+		Payload:   string(task.Payload()),
+		Error:     taskErr.Error(),
+		Cleanup:   []model.DeadLetterCleanupAction{},
+		CreatedAt: time.Now(),
+	}
+	deadLetter.TaskID, _ = asynq.GetTaskID(ctx)
+	deadLetter.Queue, _ = asynq.GetQueueName(ctx)
+	deadLetter.Retried, _ = asynq.GetRetryCount(ctx)
+	deadLetter.MaxRetry, _ = asynq.GetMaxRetry(ctx)
+
+	var recoverable recoverableError
+	if errors.As(taskErr, &recoverable) {
+		log.Printf("INFO: Skipping cleanup of %s task %s, its document can be reprocessed", deadLetter.TaskType, deadLetter.TaskID)
+	} else if definition, ok := definitions[task.Type()]; ok && definition.OnArchived != nil {
+		if actions := definition.OnArchived(ctx, task.Payload(), taskErr); actions != nil {
+			deadLetter.Cleanup = actions
+		}
+	}
+
+	if err := repository.NewDeadLetterRepository().Create(ctx, deadLetter); err != nil {
+		log.Printf("ERROR: Failed to record dead letter for %s task %s: %v", deadLetter.TaskType, deadLetter.TaskID, err)
+		return
+	}
+	log.Printf("INFO: Recorded dead letter %s for %s task %s (cleanup failed: %t)",
+		deadLetter.ID.Hex(), deadLetter.TaskType, deadLetter.TaskID, deadLetter.CleanupFailed())
+}
+
+// cleanupArchivedIngestion is the archive cleanup of add_document_to_corpus tasks
+func cleanupArchivedIngestion(ctx context.Context, payload []byte, taskErr error) []model.DeadLetterCleanupAction {
+	var p DocumentTaskPayload
+	if err := json.Unmarshal(payload, &p); err != nil {
+		return []model.DeadLetterCleanupAction{{Action: deadLetterMarkFailed, Error: fmt.Sprintf("invalid payload: %v", err)}}
+	}
+	return cleanupFailedDocument(ctx, p, taskErr)
+}
+
+// cleanupArchivedRAGOperationCheck is the archive cleanup of check_rag_operation tasks
+func cleanupArchivedRAGOperationCheck(ctx context.Context, payload []byte, taskErr error) []model.DeadLetterCleanupAction {
+	var p RAGOperationCheckPayload
+	if err := json.Unmarshal(payload, &p); err != nil {
+		return []model.DeadLetterCleanupAction{{Action: deadLetterMarkFailed, Error: fmt.Sprintf("invalid payload: %v", err)}}
+	}
+	return cleanupFailedDocument(ctx, p.Document, taskErr)
+}
+
+// cleanupFailedDocument gives up on a document whose ingestion failed permanently: it is marked failed
+// with the final error, the RAG file of a partial import is deleted and the uploaded temp object removed.
+// Every step runs even when an earlier one fails, and each is recorded with its outcome.
+func cleanupFailedDocument(ctx context.Context, payload DocumentTaskPayload, taskErr error) []model.DeadLetterCleanupAction {
+	docRepo := repository.NewDocumentRepository()
+	document, err := docRepo.GetDocumentByFileID(ctx, payload.FileID)
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			// The document was deleted along with its objects; nothing is left to clean up
+			return nil
+		}
+		return []model.DeadLetterCleanupAction{{Action: deadLetterMarkFailed, Target: payload.FileID, Error: err.Error()}}
+	}
+	if document.Status.Normalize() == model.DocumentStatusReady {
+		return nil
+	}
+
+	var actions []model.DeadLetterCleanupAction
+
+	// Failed runs normally mark the document themselves; a run that timed out or could not reach
+	// MongoDB leaves it in its processing step
+	if document.Status.Normalize() != model.DocumentStatusFailed {
+		step := document.ResumeStep()
+		message := fmt.Sprintf("retries exhausted: %v", taskErr)
+		action := model.DeadLetterCleanupAction{Action: deadLetterMarkFailed, Target: payload.FileID}
+		if err := docRepo.MarkFailed(ctx, payload.FileID, step, message); err != nil {
+			action.Error = err.Error()
+		} else {
+			PublishDocumentStatus(ctx, document, model.DocumentStatusFailed, step, message)
+		}
+		actions = append(actions, action)
+	}
+
+	if action, ok := deleteDocumentRAGFile(ctx, docRepo, document); ok {
+		actions = append(actions, action)
+	}
+
+	tempObjectName := payload.TempObjectName
+	if tempObjectName == "" {
+		tempObjectName = document.TempObjectName
+	}
+	if tempObjectName != "" {
+		actions = append(actions, deleteTempObject(ctx, tempObjectName))
+	}
+
+	return actions
+}
+
+// deleteDocumentRAGFile deletes the RAG file a partial import created, if one is known.
+// ok is false when there was nothing to delete.
+func deleteDocumentRAGFile(ctx context.Context, docRepo *repository.DocumentRepository, document *model.Document) (model.DeadLetterCleanupAction, bool) {
+	vertexAI := service.NewVertexAIService()
+
+	ragFileID := document.RAGFileID
+	if ragFileID == "" && document.ImportOperation != "" {
+		// The import may have finished after the last run gave up waiting for it
+		operationStatus, err := vertexAI.CheckOperationStatus(ctx, document.ImportOperation)
+		if err != nil {
+			return model.DeadLetterCleanupAction{Action: deadLetterDeleteRAGFile, Target: document.ImportOperation, Error: err.Error()}, true
+		}
+		if done, _ := operationStatus["done"].(bool); done {
+			ragFileID = extractRAGFileIDFromOperationResponse(operationStatus)
+		}
+	}
+	if ragFileID == "" {
+		return model.DeadLetterCleanupAction{}, false
+	}
+
+	action := model.DeadLetterCleanupAction{Action: deadLetterDeleteRAGFile, Target: ragFileID}
+	if err := vertexAI.DeleteRAGFile(ctx, document.CorpusName, ragFileID); err != nil {
+		action.Error = err.Error()
+		return action, true
+	}
+	if err := docRepo.UpdateFields(ctx, document.FileID, bson.M{"ragFileId": "", "importOperation": ""}); err != nil {
+		action.Error = fmt.Sprintf("RAG file deleted but document not updated: %v", err)
+	}
+	return action, true
+}
+
+// deleteTempObject removes the uploaded file from its temporary GCS location
+func deleteTempObject(ctx context.Context, objectName string) model.DeadLetterCleanupAction {
+	action := model.DeadLetterCleanupAction{Action: deadLetterDeleteTempObject, Target: objectName}
+
+	gcsService, err := service.NewGCSService()
+	if err != nil {
+		action.Error = err.Error()
+		return action
+	}
+	defer gcsService.Close()
+
+	exists, err := gcsService.ObjectExists(ctx, objectName)
+	if err != nil {
+		action.Error = err.Error()
+		return action
+	}
+	if exists {
+		if err := gcsService.DeleteObject(ctx, objectName); err != nil {
+			action.Error = err.Error()
+		}
+	}
+	return action
+}
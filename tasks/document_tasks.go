@@ -3,17 +3,24 @@ package tasks
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"log"
 	"os"
+	"strconv"
 	"strings"
 	"time"
 
 	"github.com/hibiken/asynq"
 	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.opentelemetry.io/otel/attribute"
 
+	"lumenslate/internal/metrics"
+	"lumenslate/internal/model"
 	"lumenslate/internal/repository"
 	"lumenslate/internal/service"
+	"lumenslate/internal/tracing"
 	"lumenslate/internal/utils"
 )
 
@@ -34,45 +41,76 @@ func GetMetricsCollector() *service.MetricsCollector {
 
 // DocumentTaskPayload represents the payload for document processing tasks
 type DocumentTaskPayload struct {
-	FileID          string `json:"file_id"`
-	TempObjectName  string `json:"temp_object_name"`
-	FinalObjectName string `json:"final_object_name"`
-	CorpusName      string `json:"corpus_name"`
-	DisplayName     string `json:"display_name"`
+	FileID          string               `json:"file_id"`
+	TempObjectName  string               `json:"temp_object_name"`
+	FinalObjectName string               `json:"final_object_name"`
+	CorpusName      string               `json:"corpus_name"`
+	DisplayName     string               `json:"display_name"`
+	ResumeFrom      model.DocumentStatus `json:"resume_from,omitempty"` // Step to start from; derived from the document when empty
+	Attempt         int                  `json:"attempt"`               // Processing runs the document had started when queued; runs are dropped once it is queued again
+
+	// Request that queued the ingestion, so its logs and spans can be tied to the background run
+	CorrelationID string              `json:"correlation_id,omitempty"`
+	TraceContext  tracing.TaskContext `json:"trace_context,omitempty"`
 }
 
 // NewAddDocumentToCorpusTask creates a new Asynq task for adding a document to the RAG corpus
 func NewAddDocumentToCorpusTask(payload DocumentTaskPayload) (*asynq.Task, error) {
-	// Marshal the payload to JSON
-	payloadBytes, err := json.Marshal(payload)
-	if err != nil {
-		return nil, fmt.Errorf("failed to marshal document task payload: %w", err)
-	}
+	return newTask(TypeAddDocumentToCorpus, payload)
+}
 
-	// Create the task with appropriate options
-	task := asynq.NewTask(
-		TypeAddDocumentToCorpus,
-		payloadBytes,
-		asynq.MaxRetry(3),
-		asynq.Timeout(300), // 5 minutes timeout for RAG processing
-	)
+// DocumentTaskID returns the task ID that keeps a single ingestion per document and attempt queued,
+// so a retried upload or reprocess request does not ingest the same file twice
+func DocumentTaskID(fileID string, attempt int) string {
+	return fmt.Sprintf("%s:%s:%d", TypeAddDocumentToCorpus, fileID, attempt)
+}
+
+// stepError attributes a failure to a step other than the one that was running,
+// e.g. a failed RAG operation discovered while indexing has to be re-imported
+type stepError struct {
+	step model.DocumentStatus
+	err  error
+}
 
-	return task, nil
+func (e *stepError) Error() string {
+	return e.err.Error()
 }
 
-// HandleAddDocumentToCorpusTask processes the document ingestion task with comprehensive error handling
-func HandleAddDocumentToCorpusTask(ctx context.Context, t *asynq.Task) error {
+func (e *stepError) Unwrap() error {
+	return e.err
+}
+
+// errStepDeferred is returned by a step that handed the rest of the pipeline to a scheduled follow-up task
+var errStepDeferred = errors.New("step continues in a follow-up task")
+
+// documentIngestion carries the state shared by the processing steps of one task run
+type documentIngestion struct {
+	taskType   string
+	payload    DocumentTaskPayload
+	document   *model.Document
+	docRepo    *repository.DocumentRepository
+	gcsService *service.GCSService
+	vertexAI   *service.VertexAIService
+	logger     *utils.Logger
+	startTime  time.Time
+}
+
+// HandleAddDocumentToCorpusTask drives a document through the ingestion state machine
+// (importing -> indexing -> finalizing -> ready), resuming from the step a previous run failed at
+func HandleAddDocumentToCorpusTask(ctx context.Context, t *asynq.Task) (err error) {
 	startTime := time.Now()
 
 	// Parse the task payload
 	var payload DocumentTaskPayload
 	if err := json.Unmarshal(t.Payload(), &payload); err != nil {
 		log.Printf("ERROR: Failed to unmarshal task payload: %v", err)
-		return fmt.Errorf("failed to unmarshal task payload: %w", err)
+		return fmt.Errorf("failed to unmarshal task payload: %v: %w", err, asynq.SkipRetry)
 	}
 
-	// Initialize structured logging with correlation ID and task context
-	ctx = utils.WithCorrelationID(ctx, "")
+	// Continue the trace of the upload and keep its correlation ID for structured logging
+	ctx, span := tracing.StartTask(ctx, TypeAddDocumentToCorpus, payload.TraceContext, attribute.String("document.file_id", payload.FileID))
+	defer func() { tracing.End(span, err) }()
+	ctx = utils.WithCorrelationID(ctx, payload.CorrelationID)
 	ctx = utils.LogTaskStart(ctx, TypeAddDocumentToCorpus, payload.FileID, map[string]string{
 		"corpus_name":       payload.CorpusName,
 		"temp_object_name":  payload.TempObjectName,
@@ -91,361 +129,391 @@ func HandleAddDocumentToCorpusTask(ctx context.Context, t *asynq.Task) error {
 	}
 	logger.InfoWithMetrics(ctx, "task_start", "Starting document processing task", 0, taskMetadata)
 
-	// Initialize services with error handling
+	// Load the document and count this run as a new attempt
 	docRepo := repository.NewDocumentRepository()
-	gcsService, err := service.NewGCSService()
+	document, err := docRepo.StartAttempt(ctx, payload.FileID, payload.Attempt)
+	if errors.Is(err, repository.ErrStaleAttempt) {
+		// A retry of a task the document was reprocessed after; the newer task does the work
+		logger.InfoWithOperation(ctx, "task_stale", "Document was queued again since this task, dropping it")
+		return nil
+	}
 	if err != nil {
-		logger.ErrorWithOperation(ctx, "service_init", "Failed to initialize GCS service", err)
-		// Update document status to failed with detailed error
-		errorMsg := fmt.Sprintf("Service initialization failed: %v", err)
-		if updateErr := docRepo.UpdateStatus(ctx, payload.FileID, "failed", errorMsg); updateErr != nil {
-			logger.ErrorWithOperation(ctx, "status_update", "Failed to update document status after GCS service initialization error", updateErr)
-		}
+		logger.ErrorWithOperation(ctx, "attempt_start", "Failed to load document for processing", err)
 		utils.LogTaskComplete(ctx, TypeAddDocumentToCorpus, payload.FileID, startTime, false, map[string]string{
-			"error": "service_initialization_failed",
+			"error": "document_load_failed",
 		})
-
-		// Record metrics for failed task
 		if metricsCollector := GetMetricsCollector(); metricsCollector != nil {
 			metricsCollector.RecordTaskFailure(ctx, TypeAddDocumentToCorpus, time.Since(startTime))
 		}
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			// The document was deleted while the task was queued - retrying cannot help
+			return fmt.Errorf("failed to load document: %v: %w", err, asynq.SkipRetry)
+		}
+		return fmt.Errorf("failed to load document: %w", err)
+	}
 
-		return fmt.Errorf("failed to initialize GCS service: %w", err)
+	if document.Status.Normalize() == model.DocumentStatusReady {
+		logger.InfoWithOperation(ctx, "task_skip", "Document is already ready, nothing to process")
+		return nil
 	}
-	defer func() {
-		if closeErr := gcsService.Close(); closeErr != nil {
-			logger.ErrorWithOperation(ctx, "service_cleanup", "Failed to close GCS service", closeErr)
-		}
-	}()
 
-	logger.InfoWithOperation(ctx, "service_init", "Services initialized successfully")
+	resumeFrom := payload.ResumeFrom
+	if !resumeFrom.IsProcessingStep() {
+		resumeFrom = document.ResumeStep()
+	}
+	logger.InfoWithMetrics(ctx, "task_resume", "Resolved processing step to start from", 0, map[string]string{
+		"file_id":     payload.FileID,
+		"resume_from": string(resumeFrom),
+		"attempt":     strconv.Itoa(document.Attempts),
+	})
 
-	// Step 1: Add document to Vertex AI RAG corpus with comprehensive error handling
-	gcsURL := fmt.Sprintf("gs://%s/%s", os.Getenv("GCS_BUCKET_NAME"), payload.TempObjectName)
+	ingestion := &documentIngestion{
+		taskType:  TypeAddDocumentToCorpus,
+		payload:   payload,
+		document:  document,
+		docRepo:   docRepo,
+		vertexAI:  service.NewVertexAIService(),
+		logger:    logger,
+		startTime: startTime,
+	}
 
-	ragMetadata := map[string]string{
-		"file_id": payload.FileID,
-		"gcs_url": gcsURL,
-		"corpus":  payload.CorpusName,
+	closeServices, err := ingestion.initServices(ctx)
+	if err != nil {
+		return ingestion.fail(ctx, resumeFrom, err)
 	}
-	logger.InfoWithMetrics(ctx, "rag_ingestion_start", "Starting RAG corpus ingestion", 0, ragMetadata)
+	defer closeServices()
 
-	vertexAIService := service.NewVertexAIService()
-	ragStartTime := time.Now()
-	addResult, err := vertexAIService.AddDocumentToCorpus(ctx, payload.CorpusName, gcsURL)
-	ragDuration := time.Since(ragStartTime)
+	return ingestion.run(ctx, resumeFrom)
+}
 
+// initServices initializes the storage client used by the processing steps
+func (in *documentIngestion) initServices(ctx context.Context) (func(), error) {
+	gcsService, err := service.NewGCSService()
 	if err != nil {
-		logger.ErrorWithMetrics(ctx, "rag_ingestion", "RAG ingestion failed", err, ragDuration, ragMetadata)
-
-		// Comprehensive cleanup and error reporting
-		errorMsg := fmt.Sprintf("RAG ingestion failed: %v", err)
+		in.logger.ErrorWithOperation(ctx, "service_init", "Failed to initialize GCS service", err)
+		return nil, fmt.Errorf("service initialization failed: %w", err)
+	}
+	in.gcsService = gcsService
 
-		// Update document status to failed
-		if updateErr := docRepo.UpdateStatus(ctx, payload.FileID, "failed", errorMsg); updateErr != nil {
-			logger.ErrorWithOperation(ctx, "status_update", "Failed to update document status to failed after RAG error", updateErr)
-		} else {
-			logger.InfoWithOperation(ctx, "status_update", "Updated document status to failed")
+	in.logger.InfoWithOperation(ctx, "service_init", "Services initialized successfully")
+	return func() {
+		if closeErr := gcsService.Close(); closeErr != nil {
+			in.logger.ErrorWithOperation(ctx, "service_cleanup", "Failed to close GCS service", closeErr)
 		}
+	}, nil
+}
 
-		// Clean up: delete the temporary GCS file
-		logger.InfoWithOperation(ctx, "cleanup_start", "Starting cleanup of temporary GCS object after RAG failure")
-		if deleteErr := gcsService.DeleteObject(ctx, payload.TempObjectName); deleteErr != nil {
-			logger.ErrorWithOperation(ctx, "cleanup", "Failed to clean up temporary GCS object after RAG error", deleteErr)
-			// Log but don't fail - the main error is more important
-		} else {
-			logger.InfoWithOperation(ctx, "cleanup", "Successfully cleaned up temporary GCS object")
+// run executes the processing steps starting at from and moves the document to ready.
+// A step may defer the remainder of the pipeline to a scheduled follow-up task.
+func (in *documentIngestion) run(ctx context.Context, from model.DocumentStatus) error {
+	steps := []struct {
+		status model.DocumentStatus
+		run    func(context.Context) error
+	}{
+		{model.DocumentStatusImporting, in.runImport},
+		{model.DocumentStatusIndexing, in.runIndexing},
+		{model.DocumentStatusFinalizing, in.runFinalizing},
+	}
+
+	started := false
+	for _, step := range steps {
+		if step.status == from {
+			started = true
+		}
+		if !started {
+			continue
 		}
 
-		utils.LogTaskComplete(ctx, TypeAddDocumentToCorpus, payload.FileID, startTime, false, map[string]string{
-			"error":        "rag_ingestion_failed",
-			"rag_duration": ragDuration.String(),
+		if err := in.docRepo.UpdateStatus(ctx, in.payload.FileID, step.status, ""); err != nil {
+			in.logger.ErrorWithOperation(ctx, "status_update", fmt.Sprintf("Failed to move document to %s", step.status), err)
+			return in.fail(ctx, step.status, fmt.Errorf("failed to enter %s step: %w", step.status, err))
+		}
+		PublishDocumentStatus(ctx, in.document, step.status, "", fmt.Sprintf("Document entered %s step", step.status))
+
+		stepStartTime := time.Now()
+		err := step.run(ctx)
+		if errors.Is(err, errStepDeferred) {
+			in.logger.InfoWithMetrics(ctx, "step_deferred", fmt.Sprintf("Handed %s step to a follow-up task", step.status), time.Since(stepStartTime), map[string]string{
+				"file_id": in.payload.FileID,
+				"step":    string(step.status),
+			})
+			metrics.RecordDocumentIngestion(metrics.IngestionDeferred, string(step.status))
+			in.complete(ctx, map[string]string{
+				"file_id":     in.payload.FileID,
+				"deferred_at": string(step.status),
+			})
+			return nil
+		}
+		if err != nil {
+			return in.fail(ctx, step.status, err)
+		}
+		in.logger.InfoWithMetrics(ctx, "step_complete", fmt.Sprintf("Completed %s step", step.status), time.Since(stepStartTime), map[string]string{
+			"file_id": in.payload.FileID,
+			"step":    string(step.status),
 		})
+	}
 
-		// Record metrics for failed task
-		if metricsCollector := GetMetricsCollector(); metricsCollector != nil {
-			metricsCollector.RecordTaskFailure(ctx, TypeAddDocumentToCorpus, time.Since(startTime))
-		}
+	// Final transition to "ready" with error handling
+	in.logger.InfoWithOperation(ctx, "status_update_start", "Updating document status to ready")
+	statusUpdateStartTime := time.Now()
 
-		return fmt.Errorf("failed to add document to RAG corpus: %w", err)
+	if err := in.docRepo.UpdateStatus(ctx, in.payload.FileID, model.DocumentStatusReady, ""); err != nil {
+		statusUpdateDuration := time.Since(statusUpdateStartTime)
+		in.logger.ErrorWithMetrics(ctx, "status_update", "Failed to update document status to ready", err, statusUpdateDuration, map[string]string{
+			"file_id": in.payload.FileID,
+			"warning": "document_processing_completed_but_status_update_failed",
+		})
+		// This is concerning but not fatal - the document was processed successfully
+		// The RAG ingestion and file operations succeeded, so we don't want to fail the task
+		// However, we should log this as an error for monitoring
+	} else {
+		statusUpdateDuration := time.Since(statusUpdateStartTime)
+		in.logger.InfoWithMetrics(ctx, "status_update", "Successfully updated document status to ready", statusUpdateDuration, map[string]string{
+			"file_id": in.payload.FileID,
+		})
+		PublishDocumentStatus(ctx, in.document, model.DocumentStatusReady, "", "Document is ready")
 	}
 
-	ragMetadata["rag_duration"] = ragDuration.String()
-	ragMetadata["result"] = fmt.Sprintf("%v", addResult)
-	logger.InfoWithMetrics(ctx, "rag_ingestion", "RAG import initiated successfully", ragDuration, ragMetadata)
+	metrics.RecordDocumentIngestion(metrics.IngestionReady, "")
+	in.complete(ctx, map[string]string{
+		"file_id":      in.payload.FileID,
+		"corpus_name":  in.payload.CorpusName,
+		"resumed_from": string(from),
+	})
+	return nil
+}
 
-	// Step 1.1: Wait for and verify RAG operation completion
-	if operationName, ok := addResult["operation"].(string); ok && operationName != "" {
-		logger.InfoWithOperation(ctx, "rag_operation_wait", "Waiting for RAG operation to complete")
+// complete logs and records a successful task run
+func (in *documentIngestion) complete(ctx context.Context, completionMetadata map[string]string) {
+	totalDuration := time.Since(in.startTime)
+	completionMetadata["total_duration"] = totalDuration.String()
+	in.logger.InfoWithMetrics(ctx, "task_complete", "Successfully completed document processing task", totalDuration, completionMetadata)
 
-		// Wait for the operation to complete (with timeout)
-		operationStartTime := time.Now()
-		maxWaitTime := 4 * time.Minute // Maximum wait time for RAG processing
+	utils.LogTaskComplete(ctx, in.taskType, in.payload.FileID, in.startTime, true, completionMetadata)
 
-		for time.Since(operationStartTime) < maxWaitTime {
-			operationStatus, err := checkRAGOperationStatus(operationName)
-			if err != nil {
-				logger.ErrorWithOperation(ctx, "rag_operation_check", "Failed to check RAG operation status", err)
-				break
-			}
+	// Record metrics for successful task completion
+	if metricsCollector := GetMetricsCollector(); metricsCollector != nil {
+		metricsCollector.RecordTaskSuccess(ctx, in.taskType, totalDuration)
+	}
+}
 
-			if done, ok := operationStatus["done"].(bool); ok && done {
-				// Operation completed
-				if operationError, hasError := operationStatus["error"]; hasError {
-					// Operation failed
-					errorMsg := fmt.Sprintf("RAG operation failed: %v", operationError)
-					logger.ErrorWithOperation(ctx, "rag_operation_failed", errorMsg, nil)
+// fail records the failed step on the document and reports the task failure.
+// The temporary GCS object is kept so the document can be retried from that step; it is only
+// removed once the retries are exhausted (see HandleArchivedTask).
+func (in *documentIngestion) fail(ctx context.Context, step model.DocumentStatus, err error) error {
+	var se *stepError
+	if errors.As(err, &se) {
+		step = se.step
+	}
 
-					// Update document status to failed
-					if updateErr := docRepo.UpdateStatus(ctx, payload.FileID, "failed", errorMsg); updateErr != nil {
-						logger.ErrorWithOperation(ctx, "status_update", "Failed to update document status after RAG operation failure", updateErr)
-					}
+	if updateErr := in.docRepo.MarkFailed(ctx, in.payload.FileID, step, err.Error()); updateErr != nil {
+		in.logger.ErrorWithOperation(ctx, "status_update", "Failed to mark document as failed", updateErr)
+	} else {
+		in.logger.InfoWithOperation(ctx, "status_update", fmt.Sprintf("Marked document as failed at %s step", step))
+		PublishDocumentStatus(ctx, in.document, model.DocumentStatusFailed, step, err.Error())
+	}
 
-					// Clean up GCS file
-					if deleteErr := gcsService.DeleteObject(ctx, payload.TempObjectName); deleteErr != nil {
-						logger.ErrorWithOperation(ctx, "cleanup", "Failed to clean up GCS object after RAG operation failure", deleteErr)
-					}
+	utils.LogTaskComplete(ctx, in.taskType, in.payload.FileID, in.startTime, false, map[string]string{
+		"error":       err.Error(),
+		"failed_step": string(step),
+	})
 
-					utils.LogTaskComplete(ctx, TypeAddDocumentToCorpus, payload.FileID, startTime, false, map[string]string{
-						"error": "rag_operation_failed",
-					})
+	// Record metrics for failed task
+	if metricsCollector := GetMetricsCollector(); metricsCollector != nil {
+		metricsCollector.RecordTaskFailure(ctx, in.taskType, time.Since(in.startTime))
+	}
+	metrics.RecordDocumentIngestion(metrics.IngestionFailed, string(step))
 
-					if metricsCollector := GetMetricsCollector(); metricsCollector != nil {
-						metricsCollector.RecordTaskFailure(ctx, TypeAddDocumentToCorpus, time.Since(startTime))
-					}
+	return fmt.Errorf("%s step failed: %w", step, err)
+}
 
-					return fmt.Errorf("RAG operation failed: %v", operationError)
-				}
+// tempObjectName returns the GCS object the file was originally uploaded to
+func (in *documentIngestion) tempObjectName() string {
+	if in.payload.TempObjectName != "" {
+		return in.payload.TempObjectName
+	}
+	return in.document.TempObjectName
+}
 
-				// Operation succeeded - extract RAG file information
-				operationDuration := time.Since(operationStartTime)
+// runImport submits the GCS object to the Vertex AI RAG corpus and stores the import operation name
+func (in *documentIngestion) runImport(ctx context.Context) error {
+	gcsURL := fmt.Sprintf("gs://%s/%s", os.Getenv("GCS_BUCKET_NAME"), in.tempObjectName())
 
-				// Try to extract RAG file ID: First attempt is direct from temp_object_name
-				var ragFileID string
-				ragFileID = extractRagFileIdFromTempObjectName(payload.TempObjectName)
-				if ragFileID != "" {
-					logger.InfoWithOperation(ctx, "rag_file_id_direct", "Extracted RAG file ID directly from temp_object_name")
-				}
+	// Apply the corpus chunking settings; corpora without a record use the RAG engine defaults
+	var chunking model.CorpusChunking
+	if corpus, err := repository.NewCorpusRepository().GetCorpusByName(ctx, in.payload.CorpusName); err == nil {
+		chunking = corpus.Chunking
+	}
 
-				// Second attempt: Extract from operation response
-				if ragFileID == "" {
-					ragFileID = extractRAGFileIDFromOperationResponse(operationStatus)
-					if ragFileID != "" {
-						logger.InfoWithOperation(ctx, "rag_file_id_operation_response", "Extracted RAG file ID from operation response")
-					}
-				}
+	ragMetadata := map[string]string{
+		"file_id":       in.payload.FileID,
+		"gcs_url":       gcsURL,
+		"corpus":        in.payload.CorpusName,
+		"chunk_size":    strconv.FormatInt(chunking.ChunkSize, 10),
+		"chunk_overlap": strconv.FormatInt(chunking.ChunkOverlap, 10),
+	}
+	in.logger.InfoWithMetrics(ctx, "rag_ingestion_start", "Starting RAG corpus ingestion", 0, ragMetadata)
 
-				// Third attempt: Fallback to VertexAIService
-				if ragFileID == "" {
-					logger.InfoWithOperation(ctx, "rag_file_id_fallback", "Direct and operation response extraction failed, querying RAG engine directly")
-					ragFileID, err = vertexAIService.ExtractRAGFileIDFromDocument(ctx, payload.CorpusName, payload.DisplayName)
-					if err != nil {
-						logger.ErrorWithOperation(ctx, "rag_file_id_fallback", "Failed to extract RAG file ID from RAG engine", err)
-						// Continue to the failure handling below
-					} else {
-						logger.InfoWithOperation(ctx, "rag_file_id_fallback", "Successfully extracted RAG file ID from RAG engine")
-					}
-				}
+	ragStartTime := time.Now()
+	addResult, err := in.vertexAI.AddDocumentToCorpusWithChunking(ctx, in.payload.CorpusName, gcsURL, chunking.ChunkSize, chunking.ChunkOverlap)
+	ragDuration := time.Since(ragStartTime)
 
-				// RAG file ID extraction is now compulsory - task fails if we can't get it
-				if ragFileID == "" {
-					errorMsg := "Could not extract RAG file ID from operation response or temp_object_name - this is required for proper document tracking"
-					logger.ErrorWithOperation(ctx, "rag_file_id_extract", errorMsg, nil)
+	if err != nil {
+		in.logger.ErrorWithMetrics(ctx, "rag_ingestion", "RAG ingestion failed", err, ragDuration, ragMetadata)
+		return fmt.Errorf("failed to add document to RAG corpus: %w", err)
+	}
 
-					// Update document status to failed
-					if updateErr := docRepo.UpdateStatus(ctx, payload.FileID, "failed", errorMsg); updateErr != nil {
-						logger.ErrorWithOperation(ctx, "status_update", "Failed to update document status after RAG file ID extraction failure", updateErr)
-					}
+	ragMetadata["rag_duration"] = ragDuration.String()
+	ragMetadata["result"] = fmt.Sprintf("%v", addResult)
+	in.logger.InfoWithMetrics(ctx, "rag_ingestion", "RAG import initiated successfully", ragDuration, ragMetadata)
 
-					// Clean up GCS file since we can't properly track the RAG file
-					if deleteErr := gcsService.DeleteObject(ctx, payload.TempObjectName); deleteErr != nil {
-						logger.ErrorWithOperation(ctx, "cleanup", "Failed to clean up GCS object after RAG file ID extraction failure", deleteErr)
-					}
+	operationName, _ := addResult["operation"].(string)
+	if operationName == "" {
+		in.logger.ErrorWithOperation(ctx, "rag_operation_missing", "No operation name returned from RAG service", nil)
+		// Continue processing - this might be a different response format
+		return nil
+	}
 
-					utils.LogTaskComplete(ctx, TypeAddDocumentToCorpus, payload.FileID, startTime, false, map[string]string{
-						"error": "rag_file_id_extraction_failed",
-					})
+	// Persist the operation so indexing can be resumed without re-importing
+	if err := in.docRepo.UpdateFields(ctx, in.payload.FileID, bson.M{"importOperation": operationName}); err != nil {
+		return fmt.Errorf("failed to record RAG import operation: %w", err)
+	}
+	in.document.ImportOperation = operationName
 
-					if metricsCollector := GetMetricsCollector(); metricsCollector != nil {
-						metricsCollector.RecordTaskFailure(ctx, TypeAddDocumentToCorpus, time.Since(startTime))
-					}
+	return nil
+}
 
-					return fmt.Errorf("RAG file ID extraction failed: %s", errorMsg)
-				}
+// runIndexing hands the wait for the RAG import operation to a scheduled check task so no worker
+// sits idle polling, or resolves the RAG file ID right away when there is no operation to wait for
+func (in *documentIngestion) runIndexing(ctx context.Context) error {
+	operationName := in.document.ImportOperation
+	if operationName == "" {
+		return in.resolveRAGFileID(ctx, nil)
+	}
 
-				// Database update with RAG file ID is now compulsory - task fails if update fails
-				if err := docRepo.UpdateFields(ctx, payload.FileID, bson.M{
-					"ragFileId": ragFileID,
-					"updatedAt": time.Now(),
-				}); err != nil {
-					errorMsg := fmt.Sprintf("Failed to update RAG file ID in database: %v", err)
-					logger.ErrorWithOperation(ctx, "rag_file_id_update", errorMsg, err)
-
-					// Update document status to failed
-					if updateErr := docRepo.UpdateStatus(ctx, payload.FileID, "failed", errorMsg); updateErr != nil {
-						logger.ErrorWithOperation(ctx, "status_update", "Failed to update document status after RAG file ID database update failure", updateErr)
-					}
+	checkPayload := RAGOperationCheckPayload{
+		Document:      in.payload,
+		OperationName: operationName,
+		Attempt:       in.document.Attempts,
+		Deadline:      time.Now().Add(ragOperationTimeout()),
+	}
+	if err := scheduleRAGOperationCheck(ctx, checkPayload); err != nil {
+		in.logger.ErrorWithOperation(ctx, "rag_operation_schedule", "Failed to schedule RAG operation check", err)
+		return fmt.Errorf("failed to schedule RAG operation check: %w", err)
+	}
 
-					utils.LogTaskComplete(ctx, TypeAddDocumentToCorpus, payload.FileID, startTime, false, map[string]string{
-						"error": "rag_file_id_database_update_failed",
-					})
+	in.logger.InfoWithMetrics(ctx, "rag_operation_schedule", "Scheduled RAG operation check", 0, map[string]string{
+		"file_id":        in.payload.FileID,
+		"operation_name": operationName,
+		"deadline":       checkPayload.Deadline.Format(time.RFC3339),
+	})
+	return errStepDeferred
+}
 
-					if metricsCollector := GetMetricsCollector(); metricsCollector != nil {
-						metricsCollector.RecordTaskFailure(ctx, TypeAddDocumentToCorpus, time.Since(startTime))
-					}
+// resolveRAGFileID records the RAG file ID of the imported document.
+// operationStatus is the finished import operation, or nil when there was none to wait for.
+func (in *documentIngestion) resolveRAGFileID(ctx context.Context, operationStatus map[string]interface{}) error {
+	// Try to extract RAG file ID: First attempt is direct from temp_object_name
+	ragFileID := extractRagFileIdFromTempObjectName(in.tempObjectName())
+	if ragFileID != "" {
+		in.logger.InfoWithOperation(ctx, "rag_file_id_direct", "Extracted RAG file ID directly from temp_object_name")
+	}
 
-					return fmt.Errorf("RAG file ID database update failed: %w", err)
-				}
+	// Second attempt: Extract from operation response
+	if ragFileID == "" && operationStatus != nil {
+		ragFileID = extractRAGFileIDFromOperationResponse(operationStatus)
+		if ragFileID != "" {
+			in.logger.InfoWithOperation(ctx, "rag_file_id_operation_response", "Extracted RAG file ID from operation response")
+		}
+	}
 
-				logger.InfoWithOperation(ctx, "rag_file_id_update", "Successfully updated RAG file ID in database")
+	// Third attempt: Fallback to VertexAIService
+	if ragFileID == "" {
+		in.logger.InfoWithOperation(ctx, "rag_file_id_fallback", "Direct and operation response extraction failed, querying RAG engine directly")
+		var err error
+		ragFileID, err = in.vertexAI.ExtractRAGFileIDFromDocument(ctx, in.payload.CorpusName, in.payload.DisplayName)
+		if err != nil {
+			in.logger.ErrorWithOperation(ctx, "rag_file_id_fallback", "Failed to extract RAG file ID from RAG engine", err)
+		} else {
+			in.logger.InfoWithOperation(ctx, "rag_file_id_fallback", "Successfully extracted RAG file ID from RAG engine")
+		}
+	}
 
-				logger.InfoWithMetrics(ctx, "rag_operation_complete", "RAG operation completed successfully", operationDuration, map[string]string{
-					"operation_name": operationName,
-					"total_rag_time": time.Since(ragStartTime).String(),
-					"rag_file_id":    ragFileID,
-				})
-				break
-			}
+	// RAG file ID extraction is compulsory - the step fails if we can't get it
+	if ragFileID == "" {
+		errorMsg := "Could not extract RAG file ID from operation response or temp_object_name - this is required for proper document tracking"
+		in.logger.ErrorWithOperation(ctx, "rag_file_id_extract", errorMsg, nil)
+		return errors.New(errorMsg)
+	}
 
-			// Wait before checking again
-			time.Sleep(10 * time.Second)
-		}
+	if err := in.docRepo.UpdateFields(ctx, in.payload.FileID, bson.M{"ragFileId": ragFileID}); err != nil {
+		in.logger.ErrorWithOperation(ctx, "rag_file_id_update", "Failed to update RAG file ID in database", err)
+		return fmt.Errorf("failed to update RAG file ID in database: %w", err)
+	}
+	in.document.RAGFileID = ragFileID
 
-		// Check if we timed out
-		if time.Since(operationStartTime) >= maxWaitTime {
-			logger.ErrorWithOperation(ctx, "rag_operation_timeout", "RAG operation timed out", nil)
-			// Don't fail the task - let it continue and mark as completed
-			// The operation might still complete in the background
-		}
-	} else {
-		logger.ErrorWithOperation(ctx, "rag_operation_missing", "No operation name returned from RAG service", nil)
-		// Continue processing - this might be a different response format
+	in.logger.InfoWithMetrics(ctx, "rag_file_id_update", "Successfully updated RAG file ID in database", 0, map[string]string{
+		"file_id":     in.payload.FileID,
+		"rag_file_id": ragFileID,
+	})
+	return nil
+}
+
+// runFinalizing moves the GCS object from its temporary to its final name
+func (in *documentIngestion) runFinalizing(ctx context.Context) error {
+	if in.document.GCSObject == in.payload.FinalObjectName {
+		in.logger.InfoWithOperation(ctx, "gcs_rename", "GCS object already has its final name")
+		return nil
 	}
 
-	// Step 2: Rename GCS object from temporary to final name with graceful error handling
 	renameMetadata := map[string]string{
-		"file_id":     payload.FileID,
-		"from_object": payload.TempObjectName,
-		"to_object":   payload.FinalObjectName,
+		"file_id":     in.payload.FileID,
+		"from_object": in.tempObjectName(),
+		"to_object":   in.payload.FinalObjectName,
 	}
-	logger.InfoWithMetrics(ctx, "gcs_rename_start", "Starting GCS object rename", 0, renameMetadata)
+	in.logger.InfoWithMetrics(ctx, "gcs_rename_start", "Starting GCS object rename", 0, renameMetadata)
 
 	// Track the actual object name that will be used
-	actualObjectName := payload.TempObjectName // Default to temp name in case rename fails
+	actualObjectName := in.tempObjectName() // Default to temp name in case rename fails
 
 	renameStartTime := time.Now()
-	if err := gcsService.RenameObject(ctx, payload.TempObjectName, payload.FinalObjectName); err != nil {
+	if err := in.gcsService.RenameObject(ctx, in.tempObjectName(), in.payload.FinalObjectName); err != nil {
 		renameDuration := time.Since(renameStartTime)
 		renameMetadata["rename_duration"] = renameDuration.String()
-		logger.ErrorWithMetrics(ctx, "gcs_rename", "Failed to rename GCS object, but RAG ingestion succeeded. File will remain with temporary name", err, renameDuration, renameMetadata)
-		// Don't fail the task - RAG ingestion succeeded, just log the warning
-		// The file will remain with the temporary name but still be accessible
-		// actualObjectName remains as payload.TempObjectName
+		in.logger.ErrorWithMetrics(ctx, "gcs_rename", "Failed to rename GCS object, but RAG ingestion succeeded. File will remain with temporary name", err, renameDuration, renameMetadata)
+		// Don't fail the step - RAG ingestion succeeded, the file stays accessible under the temporary name
 	} else {
 		renameDuration := time.Since(renameStartTime)
 		renameMetadata["rename_duration"] = renameDuration.String()
-		logger.InfoWithMetrics(ctx, "gcs_rename", "Successfully renamed GCS object", renameDuration, renameMetadata)
-		// Update actual object name to final name since rename succeeded
-		actualObjectName = payload.FinalObjectName
+		in.logger.InfoWithMetrics(ctx, "gcs_rename", "Successfully renamed GCS object", renameDuration, renameMetadata)
+		actualObjectName = in.payload.FinalObjectName
 	}
 
-	// Step 2.1: Update database with actual GCS object path
-	logger.InfoWithOperation(ctx, "db_object_update", "Updating database with actual GCS object path")
+	// Update database with actual GCS object path
+	in.logger.InfoWithOperation(ctx, "db_object_update", "Updating database with actual GCS object path")
 	dbUpdateStartTime := time.Now()
 
-	if err := docRepo.UpdateFields(ctx, payload.FileID, bson.M{
+	if err := in.docRepo.UpdateFields(ctx, in.payload.FileID, bson.M{
 		"gcsObject": actualObjectName,
-		"updatedAt": time.Now(),
 	}); err != nil {
 		dbUpdateDuration := time.Since(dbUpdateStartTime)
-		logger.ErrorWithMetrics(ctx, "db_object_update", "Failed to update GCS object path in database", err, dbUpdateDuration, map[string]string{
-			"file_id":     payload.FileID,
+		in.logger.ErrorWithMetrics(ctx, "db_object_update", "Failed to update GCS object path in database", err, dbUpdateDuration, map[string]string{
+			"file_id":     in.payload.FileID,
 			"object_name": actualObjectName,
 		})
-		// Log error but don't fail the task - the file processing was successful
+		// Log error but don't fail the step - the file processing was successful
 	} else {
 		dbUpdateDuration := time.Since(dbUpdateStartTime)
-		logger.InfoWithMetrics(ctx, "db_object_update", "Successfully updated GCS object path in database", dbUpdateDuration, map[string]string{
-			"file_id":     payload.FileID,
+		in.logger.InfoWithMetrics(ctx, "db_object_update", "Successfully updated GCS object path in database", dbUpdateDuration, map[string]string{
+			"file_id":     in.payload.FileID,
 			"object_name": actualObjectName,
 		})
 	}
-
-	// Step 3: Update MongoDB status to "completed" with error handling
-	logger.InfoWithOperation(ctx, "status_update_start", "Updating document status to completed")
-	statusUpdateStartTime := time.Now()
-
-	if err := docRepo.UpdateStatus(ctx, payload.FileID, "completed", ""); err != nil {
-		statusUpdateDuration := time.Since(statusUpdateStartTime)
-		logger.ErrorWithMetrics(ctx, "status_update", "Failed to update document status to completed", err, statusUpdateDuration, map[string]string{
-			"file_id": payload.FileID,
-			"warning": "document_processing_completed_but_status_update_failed",
-		})
-		// This is concerning but not fatal - the document was processed successfully
-		// The RAG ingestion and file operations succeeded, so we don't want to fail the task
-		// However, we should log this as an error for monitoring
-	} else {
-		statusUpdateDuration := time.Since(statusUpdateStartTime)
-		logger.InfoWithMetrics(ctx, "status_update", "Successfully updated document status to completed", statusUpdateDuration, map[string]string{
-			"file_id": payload.FileID,
-		})
-	}
-
-	totalDuration := time.Since(startTime)
-	completionMetadata := map[string]string{
-		"file_id":        payload.FileID,
-		"corpus_name":    payload.CorpusName,
-		"total_duration": totalDuration.String(),
-	}
-	logger.InfoWithMetrics(ctx, "task_complete", "Successfully completed document processing task", totalDuration, completionMetadata)
-
-	utils.LogTaskComplete(ctx, TypeAddDocumentToCorpus, payload.FileID, startTime, true, completionMetadata)
-
-	// Record metrics for successful task completion
-	if metricsCollector := GetMetricsCollector(); metricsCollector != nil {
-		metricsCollector.RecordTaskSuccess(ctx, TypeAddDocumentToCorpus, totalDuration)
-	}
+	in.document.GCSObject = actualObjectName
 
 	return nil
 }
 
-// logTaskError logs task errors with context for debugging and monitoring
-func logTaskError(fileID, operation, message string, err error) {
-	log.Printf("TASK_ERROR: fileId=%s, operation=%s, message=%s, error=%v", fileID, operation, message, err)
-}
-
-// logTaskWarning logs task warnings with context
-func logTaskWarning(fileID, operation, message string) {
-	log.Printf("TASK_WARNING: fileId=%s, operation=%s, message=%s", fileID, operation, message)
-}
-
-// logTaskInfo logs task information with context
-func logTaskInfo(fileID, operation, message string) {
-	log.Printf("TASK_INFO: fileId=%s, operation=%s, message=%s", fileID, operation, message)
-}
-
-// handleCriticalError handles critical errors that require immediate attention
-func handleCriticalError(ctx context.Context, docRepo *repository.DocumentRepository, fileID, operation, errorMsg string, err error) {
-	logTaskError(fileID, operation, "Critical error occurred", err)
-
-	// Update document status with detailed error information
-	fullErrorMsg := fmt.Sprintf("%s: %v", errorMsg, err)
-	if updateErr := docRepo.UpdateStatus(ctx, fileID, "failed", fullErrorMsg); updateErr != nil {
-		logTaskError(fileID, "status_update", "Failed to update document status after critical error", updateErr)
-	}
-}
-
-// checkRAGOperationStatus checks the status of a Vertex AI RAG operation
-func checkRAGOperationStatus(operationName string) (map[string]interface{}, error) {
-	ctx := context.Background()
-
-	// Create Vertex AI service
-	vertexService := service.NewVertexAIService()
-
-	// Use the service's method to check operation status
-	return vertexService.CheckOperationStatus(ctx, operationName)
-}
-
 // extractRAGFileIDFromOperationResponse extracts RAG file ID from operation response
 func extractRAGFileIDFromOperationResponse(operationStatus map[string]interface{}) string {
 	if response, hasResponse := operationStatus["response"]; hasResponse {
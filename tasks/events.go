@@ -0,0 +1,78 @@
+package tasks
+
+import (
+	"context"
+
+	"lumenslate/internal/model"
+	"lumenslate/internal/service"
+)
+
+// Global event broker instance
+var globalEventBroker *service.EventBroker
+
+// SetEventBroker sets the global event broker used to push task progress to SSE clients
+func SetEventBroker(broker *service.EventBroker) {
+	globalEventBroker = broker
+}
+
+// GetEventBroker returns the global event broker instance
+func GetEventBroker() *service.EventBroker {
+	return globalEventBroker
+}
+
+// PublishDocumentStatus notifies subscribers of a document and its uploader that the document changed state.
+// Publishing is best effort; failures are logged by the broker and never fail the caller.
+func PublishDocumentStatus(ctx context.Context, document *model.Document, status model.DocumentStatus, failedStep model.DocumentStatus, message string) {
+	broker := GetEventBroker()
+	if broker == nil || document == nil {
+		return
+	}
+
+	snapshot := *document
+	snapshot.Status = status
+	snapshot.FailedStep = failedStep
+
+	data := map[string]interface{}{
+		"corpusName":  document.CorpusName,
+		"displayName": document.DisplayName,
+		"attempt":     document.Attempts,
+		"progress":    snapshot.Progress(),
+	}
+	if failedStep != "" {
+		data["failedStep"] = failedStep
+	}
+
+	_ = broker.Publish(ctx, service.Event{
+		Type:    service.EventTypeDocumentStatus,
+		FileID:  document.FileID,
+		UserID:  document.UploadedBy,
+		Status:  string(status),
+		Message: message,
+		Data:    data,
+	})
+}
+
+// PublishOperationStatus notifies subscribers of a document about the long-running operation it is waiting on
+func PublishOperationStatus(ctx context.Context, document *model.Document, operationName string, done bool, message string) {
+	broker := GetEventBroker()
+	if broker == nil || document == nil {
+		return
+	}
+
+	status := "running"
+	if done {
+		status = "done"
+	}
+
+	_ = broker.Publish(ctx, service.Event{
+		Type:    service.EventTypeOperationStatus,
+		FileID:  document.FileID,
+		UserID:  document.UploadedBy,
+		Status:  status,
+		Message: message,
+		Data: map[string]interface{}{
+			"operationName": operationName,
+			"done":          done,
+		},
+	})
+}
@@ -0,0 +1,297 @@
+package tasks
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/google/uuid"
+	"github.com/hibiken/asynq"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.opentelemetry.io/otel/attribute"
+
+	grpcservice "lumenslate/internal/grpc_service"
+	"lumenslate/internal/model"
+	"lumenslate/internal/model/questions"
+	pb "lumenslate/internal/proto/ai_service"
+	"lumenslate/internal/repository"
+	quest "lumenslate/internal/repository/questions"
+	"lumenslate/internal/tracing"
+	"lumenslate/internal/utils"
+)
+
+const TypeAuthorQuestion = "author_question"
+
+// Variable types given to detected variables, which the AI service does not classify
+const (
+	authoredVariableNumeric = "numeric"
+	authoredVariableText    = "text"
+)
+
+// contextSeparator joins the generated context and the question in the contextualized version
+const contextSeparator = "\n\n"
+
+// AuthorQuestionPayload represents the payload for the question authoring pipeline
+type AuthorQuestionPayload struct {
+	AuthoringID string `json:"authoring_id"`
+	TeacherID   string `json:"teacher_id"`
+
+	// Request that queued the run, so its logs and spans can be tied to the background run
+	CorrelationID string              `json:"correlation_id,omitempty"`
+	TraceContext  tracing.TaskContext `json:"trace_context,omitempty"`
+}
+
+// NewAuthorQuestionTask creates a new Asynq task that runs the authoring pipeline of a draft
+func NewAuthorQuestionTask(payload AuthorQuestionPayload) (*asynq.Task, error) {
+	return newTask(TypeAuthorQuestion, payload)
+}
+
+// AuthorQuestionTaskID returns the task ID that keeps a single pipeline run per draft queued at a time
+func AuthorQuestionTaskID(authoringID string) string {
+	return fmt.Sprintf("%s:%s", TypeAuthorQuestion, authoringID)
+}
+
+// HandleAuthorQuestionTask segments a pasted question, detects its variables, stores them, creates the
+// inactive question linked to them and optionally generates a contextualized version. Every step stores
+// its result on the draft before the next one runs, so a retry resumes after the last completed step.
+func HandleAuthorQuestionTask(ctx context.Context, t *asynq.Task) (err error) {
+	startTime := time.Now()
+
+	var payload AuthorQuestionPayload
+	if err := json.Unmarshal(t.Payload(), &payload); err != nil {
+		log.Printf("ERROR: Failed to unmarshal task payload: %v", err)
+		return fmt.Errorf("failed to unmarshal task payload: %v: %w", err, asynq.SkipRetry)
+	}
+
+	// Continue the trace of the request that created the draft and keep its correlation ID
+	ctx, span := tracing.StartTask(ctx, TypeAuthorQuestion, payload.TraceContext, attribute.String("authoring.id", payload.AuthoringID))
+	defer func() { tracing.End(span, err) }()
+	ctx = utils.WithCorrelationID(ctx, payload.CorrelationID)
+	ctx = utils.LogTaskStart(ctx, TypeAuthorQuestion, payload.AuthoringID, map[string]string{
+		"authoring_id": payload.AuthoringID,
+		"teacher_id":   payload.TeacherID,
+	})
+	logger := utils.NewLogger("task_processor")
+
+	repo := repository.NewQuestionAuthoringRepository()
+	authoring, err := repo.StartRun(ctx, payload.AuthoringID)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		logger.InfoWithOperation(ctx, "question_authoring", "Authoring draft no longer exists")
+		return nil
+	}
+	if errors.Is(err, repository.ErrQuestionAuthoringState) {
+		logger.InfoWithOperation(ctx, "question_authoring", "Authoring draft is already ready or reviewed, skipping")
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to start question authoring: %w", err)
+	}
+
+	authoring, step, err := runQuestionAuthoring(ctx, repo, grpcservice.WithContext(grpcservice.NewAIService(), ctx), authoring)
+	if err != nil {
+		if _, updateErr := repo.MarkFailed(ctx, payload.AuthoringID, step, err); updateErr != nil {
+			logger.ErrorWithOperation(ctx, "status_update", "Failed to mark question authoring as failed", updateErr)
+		}
+		utils.LogTaskComplete(ctx, TypeAuthorQuestion, payload.AuthoringID, startTime, false, map[string]string{
+			"step":  step,
+			"error": err.Error(),
+		})
+		if metricsCollector := GetMetricsCollector(); metricsCollector != nil {
+			metricsCollector.RecordTaskFailure(ctx, TypeAuthorQuestion, time.Since(startTime))
+		}
+		return err
+	}
+
+	utils.LogTaskComplete(ctx, TypeAuthorQuestion, payload.AuthoringID, startTime, true, map[string]string{
+		"question_id": authoring.QuestionID,
+		"variables":   strconv.Itoa(len(authoring.Variables)),
+	})
+	if metricsCollector := GetMetricsCollector(); metricsCollector != nil {
+		metricsCollector.RecordTaskSuccess(ctx, TypeAuthorQuestion, time.Since(startTime))
+	}
+
+	return nil
+}
+
+// runQuestionAuthoring runs the pipeline steps the draft has not completed yet and marks it ready.
+// On failure it returns the step that failed.
+func runQuestionAuthoring(ctx context.Context, repo *repository.QuestionAuthoringRepository, ai grpcservice.AIService, a *model.QuestionAuthoring) (*model.QuestionAuthoring, string, error) {
+	var err error
+
+	if a.SegmentedQuestion == "" {
+		segmented, err := ai.SegmentQuestion(a.RawQuestion)
+		if err != nil {
+			return a, model.QuestionAuthoringStepSegment, fmt.Errorf("failed to segment question: %w", err)
+		}
+		segmented = strings.TrimSpace(segmented)
+		if segmented == "" {
+			segmented = strings.TrimSpace(a.RawQuestion)
+		}
+		if a, err = repo.SaveProgress(ctx, a.ID, bson.M{"segmentedQuestion": segmented}); err != nil {
+			return a, model.QuestionAuthoringStepSegment, err
+		}
+	}
+
+	if !a.VariablesSaved {
+		variables := a.Variables
+		if len(variables) == 0 {
+			detected, err := ai.DetectVariables(a.QuestionText())
+			if err != nil {
+				return a, model.QuestionAuthoringStepDetectVariables, fmt.Errorf("failed to detect variables: %w", err)
+			}
+			variables = detectedVariables(a.QuestionText(), detected)
+			// Store the variables with their IDs first so a retry saves the same records
+			if a, err = repo.SaveProgress(ctx, a.ID, bson.M{"variables": variables}); err != nil {
+				return a, model.QuestionAuthoringStepDetectVariables, err
+			}
+		}
+
+		if err := saveAuthoredVariables(variables); err != nil {
+			return a, model.QuestionAuthoringStepSaveVariables, fmt.Errorf("failed to save variables: %w", err)
+		}
+		if a, err = repo.SaveProgress(ctx, a.ID, bson.M{"variablesSaved": true}); err != nil {
+			return a, model.QuestionAuthoringStepSaveVariables, err
+		}
+	}
+
+	if !a.QuestionCreated {
+		if err := saveAuthoredQuestion(a); err != nil {
+			return a, model.QuestionAuthoringStepCreateQuestion, fmt.Errorf("failed to create question: %w", err)
+		}
+		if a, err = repo.SaveProgress(ctx, a.ID, bson.M{"questionCreated": true}); err != nil {
+			return a, model.QuestionAuthoringStepCreateQuestion, err
+		}
+	}
+
+	if a.GenerateContext && a.Context == "" {
+		content, err := ai.GenerateContext(a.QuestionText(), a.Keywords, a.Language)
+		if err != nil {
+			return a, model.QuestionAuthoringStepGenerateContext, fmt.Errorf("failed to generate context: %w", err)
+		}
+		if content = strings.TrimSpace(content); content != "" {
+			if a, err = repo.SaveProgress(ctx, a.ID, bson.M{
+				"context":                content,
+				"contextualizedQuestion": content + contextSeparator + a.QuestionText(),
+			}); err != nil {
+				return a, model.QuestionAuthoringStepGenerateContext, err
+			}
+		}
+	}
+
+	a, err = repo.SaveProgress(ctx, a.ID, bson.M{"status": model.QuestionAuthoringStatusReady, "step": ""})
+	return a, "", err
+}
+
+// detectedVariables converts the variables the AI service detected into variable records whose
+// positions point into text. Positions are [start, end) rune offsets in pairs; reported positions
+// that do not cover the variable's name or value are replaced by the occurrences found in text.
+func detectedVariables(text string, detected []*pb.DetectedVariable) []model.Variable {
+	variables := make([]model.Variable, 0, len(detected))
+	for _, d := range detected {
+		name := strings.TrimSpace(d.GetName())
+		value := strings.TrimSpace(d.GetValue())
+		if name == "" || value == "" {
+			continue
+		}
+
+		v := model.NewVariable()
+		v.ID = uuid.New().String()
+		v.Name = name
+		v.Value = value
+		v.NamePositions = textPositions(text, name, d.GetNamePositions())
+		v.ValuePositions = textPositions(text, value, d.GetValuePositions())
+		v.VariableType = authoredVariableText
+		if _, err := strconv.ParseFloat(strings.Fields(value)[0], 64); err == nil {
+			v.VariableType = authoredVariableNumeric
+		}
+		variables = append(variables, *v)
+	}
+	return variables
+}
+
+// textPositions returns the reported positions when every pair covers token in text, otherwise
+// the positions of each whole-word occurrence of token in text
+func textPositions(text, token string, reported []int32) []int {
+	runes := []rune(text)
+	if len(reported) > 0 && len(reported)%2 == 0 {
+		positions := make([]int, len(reported))
+		valid := true
+		for i := 0; i < len(reported); i += 2 {
+			start, end := int(reported[i]), int(reported[i+1])
+			if start < 0 || end > len(runes) || start >= end || string(runes[start:end]) != token {
+				valid = false
+				break
+			}
+			positions[i], positions[i+1] = start, end
+		}
+		if valid {
+			return positions
+		}
+	}
+
+	tokenRunes := []rune(token)
+	positions := make([]int, 0)
+	for start := 0; start+len(tokenRunes) <= len(runes); start++ {
+		end := start + len(tokenRunes)
+		if string(runes[start:end]) != token {
+			continue
+		}
+		if (start > 0 && isWordRune(runes[start-1])) || (end < len(runes) && isWordRune(runes[end])) {
+			continue
+		}
+		positions = append(positions, start, end)
+		start = end - 1
+	}
+	return positions
+}
+
+func isWordRune(r rune) bool {
+	return unicode.IsLetter(r) || unicode.IsDigit(r) || r == '_'
+}
+
+// saveAuthoredVariables stores the variables of a draft. Variables stored by an earlier attempt are kept.
+func saveAuthoredVariables(variables []model.Variable) error {
+	if len(variables) == 0 {
+		return nil
+	}
+	err := repository.SaveBulkVariables(variables)
+	if err == nil || !mongo.IsDuplicateKeyError(err) {
+		return err
+	}
+
+	// A previous attempt stored some of them; an ordered insert stops at the first duplicate
+	for _, v := range variables {
+		if err := repository.SaveVariable(v); err != nil && !mongo.IsDuplicateKeyError(err) {
+			return err
+		}
+	}
+	return nil
+}
+
+// saveAuthoredQuestion stores the inactive question of a draft. A question stored by an earlier attempt is kept.
+func saveAuthoredQuestion(a *model.QuestionAuthoring) error {
+	var err error
+	switch q := a.BuildQuestion().(type) {
+	case *questions.MCQ:
+		err = quest.SaveMCQ(*q)
+	case *questions.MSQ:
+		err = quest.SaveMSQ(*q)
+	case *questions.NAT:
+		err = quest.SaveNAT(*q)
+	case *questions.Subjective:
+		err = quest.SaveSubjective(*q)
+	default:
+		return fmt.Errorf("unsupported question type %q: %w", a.QuestionType, asynq.SkipRetry)
+	}
+	if mongo.IsDuplicateKeyError(err) {
+		return nil
+	}
+	return err
+}
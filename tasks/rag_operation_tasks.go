@@ -0,0 +1,221 @@
+package tasks
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/hibiken/asynq"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.opentelemetry.io/otel/attribute"
+
+	"lumenslate/internal/model"
+	"lumenslate/internal/repository"
+	"lumenslate/internal/service"
+	"lumenslate/internal/tracing"
+	"lumenslate/internal/utils"
+)
+
+const TypeCheckRAGOperation = "check_rag_operation"
+
+const (
+	ragOperationInitialCheckDelay = 10 * time.Second
+	ragOperationMaxCheckDelay     = 2 * time.Minute
+	ragOperationDefaultTimeout    = 30 * time.Minute
+)
+
+// RAGOperationCheckPayload represents the payload for a scheduled RAG import operation check
+type RAGOperationCheckPayload struct {
+	Document      DocumentTaskPayload `json:"document"`
+	OperationName string              `json:"operation_name"`
+	Attempt       int                 `json:"attempt"`  // Document processing attempt that started the operation
+	Check         int                 `json:"check"`    // Number of checks made before this one
+	Deadline      time.Time           `json:"deadline"` // Give up waiting for the operation after this time
+}
+
+// NewCheckRAGOperationTask creates a new Asynq task that checks a RAG import operation once
+func NewCheckRAGOperationTask(payload RAGOperationCheckPayload) (*asynq.Task, error) {
+	return newTask(TypeCheckRAGOperation, payload)
+}
+
+// RAGOperationCheckTaskID returns the task ID of a check, derived from the document, attempt and
+// check number so a retried enqueue never forks a second chain of checks
+func RAGOperationCheckTaskID(fileID string, attempt, check int) string {
+	return fmt.Sprintf("%s:%s:%d:%d", TypeCheckRAGOperation, fileID, attempt, check)
+}
+
+// scheduleRAGOperationCheck enqueues the next check with exponential backoff
+func scheduleRAGOperationCheck(ctx context.Context, payload RAGOperationCheckPayload) error {
+	task, err := NewCheckRAGOperationTask(payload)
+	if err != nil {
+		return err
+	}
+
+	delay := ragOperationCheckDelay(payload.Check)
+	_, err = Enqueue(ctx, task, payload.Document.FileID, map[string]string{
+		"operation_name": payload.OperationName,
+		"check":          strconv.Itoa(payload.Check),
+		"process_in":     delay.String(),
+	}, asynq.ProcessIn(delay))
+	return err
+}
+
+// ragOperationCheckDelay returns the wait before the given check: 10s, 20s, 40s, ... capped at 2 minutes
+func ragOperationCheckDelay(check int) time.Duration {
+	if check > 10 {
+		return ragOperationMaxCheckDelay
+	}
+	delay := ragOperationInitialCheckDelay << uint(check)
+	if delay > ragOperationMaxCheckDelay {
+		delay = ragOperationMaxCheckDelay
+	}
+	return delay
+}
+
+// ragOperationTimeout returns how long to wait for a RAG import operation (RAG_OPERATION_TIMEOUT, e.g. "30m")
+func ragOperationTimeout() time.Duration {
+	if value := os.Getenv("RAG_OPERATION_TIMEOUT"); value != "" {
+		if timeout, err := time.ParseDuration(value); err == nil && timeout > 0 {
+			return timeout
+		}
+	}
+	return ragOperationDefaultTimeout
+}
+
+// HandleCheckRAGOperationTask checks a RAG import operation once. While the operation is running it
+// schedules the next check and returns; once done it finishes the indexing step and the remaining pipeline.
+func HandleCheckRAGOperationTask(ctx context.Context, t *asynq.Task) (err error) {
+	startTime := time.Now()
+
+	var payload RAGOperationCheckPayload
+	if err := json.Unmarshal(t.Payload(), &payload); err != nil {
+		log.Printf("ERROR: Failed to unmarshal task payload: %v", err)
+		return fmt.Errorf("failed to unmarshal task payload: %v: %w", err, asynq.SkipRetry)
+	}
+
+	fileID := payload.Document.FileID
+	ctx, span := tracing.StartTask(ctx, TypeCheckRAGOperation, payload.Document.TraceContext, attribute.String("document.file_id", fileID))
+	defer func() { tracing.End(span, err) }()
+	ctx = utils.WithCorrelationID(ctx, payload.Document.CorrelationID)
+	ctx = utils.LogTaskStart(ctx, TypeCheckRAGOperation, fileID, map[string]string{
+		"operation_name": payload.OperationName,
+		"check":          strconv.Itoa(payload.Check),
+	})
+	logger := utils.NewLogger("task_processor")
+
+	docRepo := repository.NewDocumentRepository()
+	document, err := docRepo.GetDocumentByFileID(ctx, fileID)
+	if err != nil {
+		logger.ErrorWithOperation(ctx, "document_load", "Failed to load document for RAG operation check", err)
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return fmt.Errorf("failed to load document: %v: %w", err, asynq.SkipRetry)
+		}
+		return fmt.Errorf("failed to load document: %w", err)
+	}
+
+	// Drop checks that belong to a run the document has moved on from (reprocessed, deleted operation, ...)
+	if document.Status.Normalize() != model.DocumentStatusIndexing ||
+		document.Attempts != payload.Attempt ||
+		document.ImportOperation != payload.OperationName {
+		logger.InfoWithMetrics(ctx, "rag_operation_stale", "Document moved on since this check was scheduled", 0, map[string]string{
+			"file_id":  fileID,
+			"status":   string(document.Status),
+			"attempts": strconv.Itoa(document.Attempts),
+		})
+		return nil
+	}
+
+	ingestion := &documentIngestion{
+		taskType:  TypeCheckRAGOperation,
+		payload:   payload.Document,
+		document:  document,
+		docRepo:   docRepo,
+		vertexAI:  service.NewVertexAIService(),
+		logger:    logger,
+		startTime: startTime,
+	}
+
+	operationStatus, err := ingestion.vertexAI.CheckOperationStatus(ctx, payload.OperationName)
+	if err != nil {
+		logger.ErrorWithOperation(ctx, "rag_operation_check", "Failed to check RAG operation status", err)
+		if time.Now().After(payload.Deadline) {
+			return skipRetry(ingestion.fail(ctx, model.DocumentStatusIndexing, fmt.Errorf("failed to check RAG operation status: %w", err)))
+		}
+		// Transient lookup failure - asynq retries this check
+		return fmt.Errorf("failed to check RAG operation status: %w", err)
+	}
+
+	done, _ := operationStatus["done"].(bool)
+	PublishOperationStatus(ctx, document, payload.OperationName, done, "Checked RAG import operation")
+
+	if !done {
+		if time.Now().After(payload.Deadline) {
+			logger.ErrorWithOperation(ctx, "rag_operation_timeout", "RAG operation timed out", nil)
+			return skipRetry(ingestion.fail(ctx, model.DocumentStatusIndexing,
+				fmt.Errorf("RAG operation %s did not complete before %s", payload.OperationName, payload.Deadline.Format(time.RFC3339))))
+		}
+
+		next := payload
+		next.Check++
+		if err := scheduleRAGOperationCheck(ctx, next); err != nil {
+			logger.ErrorWithOperation(ctx, "rag_operation_schedule", "Failed to schedule next RAG operation check", err)
+			return fmt.Errorf("failed to schedule next RAG operation check: %w", err)
+		}
+
+		logger.InfoWithMetrics(ctx, "rag_operation_pending", "RAG operation still running, scheduled next check", time.Since(startTime), map[string]string{
+			"file_id":    fileID,
+			"next_check": strconv.Itoa(next.Check),
+			"process_in": ragOperationCheckDelay(next.Check).String(),
+		})
+		return nil
+	}
+
+	if operationError, hasError := operationStatus["error"]; hasError {
+		errorMsg := fmt.Sprintf("RAG operation failed: %v", operationError)
+		logger.ErrorWithOperation(ctx, "rag_operation_failed", errorMsg, nil)
+		// The import itself failed, so reprocessing has to start over from importing
+		return skipRetry(ingestion.fail(ctx, model.DocumentStatusImporting, errors.New(errorMsg)))
+	}
+
+	logger.InfoWithMetrics(ctx, "rag_operation_complete", "RAG operation completed successfully", 0, map[string]string{
+		"file_id":        fileID,
+		"operation_name": payload.OperationName,
+		"checks":         strconv.Itoa(payload.Check + 1),
+	})
+
+	closeServices, err := ingestion.initServices(ctx)
+	if err != nil {
+		return ingestion.fail(ctx, model.DocumentStatusIndexing, err)
+	}
+	defer closeServices()
+
+	if err := ingestion.resolveRAGFileID(ctx, operationStatus); err != nil {
+		return skipRetry(ingestion.fail(ctx, model.DocumentStatusIndexing, err))
+	}
+
+	return ingestion.run(ctx, model.DocumentStatusFinalizing)
+}
+
+// skipRetry stops asynq from retrying a check whose failure has already been recorded on the document;
+// the document can be reprocessed from the failed step instead, so it is marked recoverable to keep the
+// archive cleanup from discarding its upload
+func skipRetry(err error) error {
+	if err == nil {
+		return nil
+	}
+	return recoverableError{fmt.Errorf("%v: %w", err, asynq.SkipRetry)}
+}
+
+// recoverableError marks a failure that leaves the document reprocessable
+type recoverableError struct {
+	err error
+}
+
+func (e recoverableError) Error() string { return e.err.Error() }
+
+func (e recoverableError) Unwrap() error { return e.err }
@@ -0,0 +1,502 @@
+package tasks
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"cloud.google.com/go/storage"
+	"github.com/hibiken/asynq"
+	"go.mongodb.org/mongo-driver/bson"
+
+	"lumenslate/internal/model"
+	"lumenslate/internal/repository"
+	"lumenslate/internal/service"
+)
+
+const TypeReconcileStorage = "reconcile_storage"
+
+// documentObjectPrefixes are the storage prefixes document files are uploaded to and moved to once processed
+var documentObjectPrefixes = []string{"temp/", "documents/"}
+
+// Repairs recorded on drifts
+const (
+	repairMarkedFailed = "marked_failed"
+	repairDeleted      = "deleted"
+	repairLinked       = "linked"
+)
+
+// ReconcilePayload represents the payload for a reconciliation run
+type ReconcilePayload struct {
+	CorpusName  string            `json:"corpus_name,omitempty"`  // Only reconcile this corpus; every corpus when empty
+	Repair      []model.DriftKind `json:"repair"`                 // Kinds of drift to repair; the RECONCILE_REPAIR policy when nil
+	RequestedBy string            `json:"requested_by,omitempty"` // Operator who started a manual run
+}
+
+// NewReconcileStorageTask creates a new Asynq task that reconciles documents with object storage and RAG corpora
+func NewReconcileStorageTask(payload ReconcilePayload) (*asynq.Task, error) {
+	return newTask(TypeReconcileStorage, payload)
+}
+
+// ReconcilePolicy decides which drifts are repaired and when a mismatch counts as drift
+type ReconcilePolicy struct {
+	Repair      map[model.DriftKind]bool
+	StaleAfter  time.Duration // A processing document without progress for this long is stale
+	GracePeriod time.Duration // Objects and RAG files younger than this may belong to an upload still in progress
+}
+
+// LoadReconcilePolicy reads the policy from RECONCILE_REPAIR (drift kinds to repair, comma separated,
+// or "all"; nothing by default), RECONCILE_STALE_AFTER (default 2h) and RECONCILE_GRACE_PERIOD (default 24h)
+func LoadReconcilePolicy() ReconcilePolicy {
+	policy := ReconcilePolicy{
+		Repair:      make(map[model.DriftKind]bool),
+		StaleAfter:  2 * time.Hour,
+		GracePeriod: 24 * time.Hour,
+	}
+
+	if kinds, err := ParseDriftKinds(os.Getenv("RECONCILE_REPAIR")); err != nil {
+		log.Printf("ERROR: Ignoring RECONCILE_REPAIR: %v", err)
+	} else {
+		for _, kind := range kinds {
+			policy.Repair[kind] = true
+		}
+	}
+	if value := os.Getenv("RECONCILE_STALE_AFTER"); value != "" {
+		if parsed, err := service.ParseWindow(value); err == nil {
+			policy.StaleAfter = parsed
+		}
+	}
+	if value := os.Getenv("RECONCILE_GRACE_PERIOD"); value != "" {
+		if parsed, err := service.ParseWindow(value); err == nil {
+			policy.GracePeriod = parsed
+		}
+	}
+	return policy
+}
+
+// ParseDriftKinds parses a comma separated list of drift kinds; "all" selects every kind
+func ParseDriftKinds(value string) ([]model.DriftKind, error) {
+	if strings.TrimSpace(value) == "all" {
+		return model.DriftKinds, nil
+	}
+
+	var kinds []model.DriftKind
+	for _, item := range strings.Split(value, ",") {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			continue
+		}
+		kind := model.DriftKind(item)
+		if !kind.IsValid() {
+			return nil, fmt.Errorf("unknown drift kind %q, expected one of %v or all", item, model.DriftKinds)
+		}
+		kinds = append(kinds, kind)
+	}
+	return kinds, nil
+}
+
+// ReconcileSchedule returns the cron schedule of the periodic reconciliation from RECONCILE_SCHEDULE,
+// defaulting to every 6 hours. It is empty when the schedule is "off".
+func ReconcileSchedule() string {
+	schedule := os.Getenv("RECONCILE_SCHEDULE")
+	switch schedule {
+	case "":
+		return "@every 6h"
+	case "off":
+		return ""
+	}
+	return schedule
+}
+
+// HandleReconcileStorageTask compares the documents of every corpus (or one) with the files in object
+// storage and the RAG corpora, stores the drift report and repairs the kinds of drift the policy allows
+func HandleReconcileStorageTask(ctx context.Context, t *asynq.Task) error {
+	startTime := time.Now()
+
+	var payload ReconcilePayload
+	if err := json.Unmarshal(t.Payload(), &payload); err != nil {
+		log.Printf("ERROR: Failed to unmarshal reconcile payload: %v", err)
+		return fmt.Errorf("failed to unmarshal reconcile payload: %v: %w", err, asynq.SkipRetry)
+	}
+
+	policy := LoadReconcilePolicy()
+	if payload.Repair != nil {
+		policy.Repair = make(map[model.DriftKind]bool)
+		for _, kind := range payload.Repair {
+			policy.Repair[kind] = true
+		}
+	}
+
+	log.Printf("INFO: Starting reconciliation (corpus=%q, requested_by=%q)", payload.CorpusName, payload.RequestedBy)
+
+	report, err := Reconcile(ctx, payload.CorpusName, policy)
+	if err != nil {
+		log.Printf("ERROR: Reconciliation failed: %v", err)
+		if metricsCollector := GetMetricsCollector(); metricsCollector != nil {
+			metricsCollector.RecordTaskFailure(ctx, TypeReconcileStorage, time.Since(startTime))
+		}
+		return err
+	}
+	report.TaskID, _ = asynq.GetTaskID(ctx)
+
+	if err := repository.NewReconciliationRepository().Create(ctx, report); err != nil {
+		log.Printf("ERROR: Failed to store reconciliation report: %v", err)
+		return err
+	}
+
+	log.Printf("INFO: Reconciliation report %s: %d corpora, %d documents, %d drifts (%d repaired), %d checks failed - counts=%v",
+		report.ID.Hex(), report.Corpora, report.Documents, len(report.Drifts), report.Repaired, len(report.Errors), report.Counts)
+	if metricsCollector := GetMetricsCollector(); metricsCollector != nil {
+		metricsCollector.RecordTaskSuccess(ctx, TypeReconcileStorage, time.Since(startTime))
+	}
+	return nil
+}
+
+// reconciler carries the state of one reconciliation run
+type reconciler struct {
+	policy   ReconcilePolicy
+	docRepo  *repository.DocumentRepository
+	gcs      *service.GCSService // nil when object storage is unavailable
+	vertexAI *service.VertexAIService
+	report   *model.ReconciliationReport
+	now      time.Time
+
+	objects    map[string]*storage.ObjectAttrs // Document files in storage; nil when they could not be listed
+	referenced map[string]bool                 // Object names documents refer to
+}
+
+// Reconcile detects the drift between documents, object storage and RAG corpora and repairs it as the
+// policy allows. Only corpusName is reconciled when set; storage objects without documents are only
+// looked for across every corpus. Checks that cannot run are listed in the report's errors; an error is
+// returned only when the corpora to reconcile cannot be determined.
+func Reconcile(ctx context.Context, corpusName string, policy ReconcilePolicy) (*model.ReconciliationReport, error) {
+	r := &reconciler{
+		policy:     policy,
+		docRepo:    repository.NewDocumentRepository(),
+		vertexAI:   service.NewVertexAIService(),
+		now:        time.Now(),
+		referenced: make(map[string]bool),
+		report: &model.ReconciliationReport{
+			Corpus:    corpusName,
+			Repair:    []model.DriftKind{},
+			Counts:    make(map[model.DriftKind]int),
+			Drifts:    []model.Drift{},
+			StartedAt: time.Now(),
+		},
+	}
+	for _, kind := range model.DriftKinds {
+		if policy.Repair[kind] {
+			r.report.Repair = append(r.report.Repair, kind)
+		}
+	}
+
+	corpora := []string{corpusName}
+	if corpusName == "" {
+		var err error
+		if corpora, err = r.corpusNames(ctx); err != nil {
+			return nil, err
+		}
+	}
+	r.report.Corpora = len(corpora)
+
+	gcsService, err := service.NewGCSService()
+	if err != nil {
+		r.addError("object storage unavailable: %v", err)
+	} else {
+		defer gcsService.Close()
+		r.gcs = gcsService
+		r.listObjects(ctx)
+	}
+
+	allDocumentsListed := true
+	for _, corpus := range corpora {
+		documents, err := r.docRepo.GetDocumentsByCorpus(ctx, corpus)
+		if err != nil {
+			r.addError("corpus %s: failed to list documents: %v", corpus, err)
+			allDocumentsListed = false
+			continue
+		}
+		r.report.Documents += len(documents)
+		r.reconcileCorpus(ctx, corpus, documents)
+	}
+
+	// An object is only orphaned if no document of any corpus refers to it
+	if corpusName == "" && allDocumentsListed {
+		r.checkOrphanedObjects(ctx)
+	}
+
+	r.report.FinishedAt = time.Now()
+	return r.report, nil
+}
+
+// corpusNames returns the corpora with a record or with documents, which includes corpora
+// created before corpus records existed
+func (r *reconciler) corpusNames(ctx context.Context) ([]string, error) {
+	names, err := r.docRepo.CorpusNames(ctx)
+	if err != nil {
+		return nil, err
+	}
+	corpora, err := repository.NewCorpusRepository().ListCorpora(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list corpora: %w", err)
+	}
+
+	seen := make(map[string]bool, len(names))
+	for _, name := range names {
+		seen[name] = true
+	}
+	for _, corpus := range corpora {
+		if !seen[corpus.Name] {
+			seen[corpus.Name] = true
+			names = append(names, corpus.Name)
+		}
+	}
+	sort.Strings(names)
+	return names, nil
+}
+
+// listObjects loads the document files in storage
+func (r *reconciler) listObjects(ctx context.Context) {
+	objects := make(map[string]*storage.ObjectAttrs)
+	for _, prefix := range documentObjectPrefixes {
+		attrs, err := r.gcs.ListObjects(ctx, prefix)
+		if err != nil {
+			r.addError("object storage: %v", err)
+			return
+		}
+		for _, object := range attrs {
+			objects[object.Name] = object
+		}
+	}
+	r.objects = objects
+}
+
+// reconcileCorpus checks the documents of a corpus and its RAG files
+func (r *reconciler) reconcileCorpus(ctx context.Context, corpus string, documents []model.Document) {
+	for i := range documents {
+		document := &documents[i]
+		r.referenced[document.GCSObject] = true
+		r.referenced[document.TempObjectName] = true
+
+		if r.checkMissingObject(ctx, document) {
+			continue
+		}
+		r.checkStaleStatus(ctx, document)
+	}
+	r.checkRAGFiles(ctx, corpus, documents)
+}
+
+// checkMissingObject reports a document whose file is in neither its temp nor its final location.
+// Failed documents are skipped: abandoned ingestions have their upload removed on purpose. It returns
+// true when the document was marked failed.
+func (r *reconciler) checkMissingObject(ctx context.Context, document *model.Document) bool {
+	status := document.Status.Normalize()
+	if r.gcs == nil || r.objects == nil || status == model.DocumentStatusFailed {
+		return false
+	}
+	// A processing document may be between the copy and the update of its object name
+	if status != model.DocumentStatusReady && r.now.Sub(document.UpdatedAt) < r.policy.StaleAfter {
+		return false
+	}
+
+	for _, name := range []string{document.GCSObject, document.TempObjectName} {
+		exists, err := r.objectExists(ctx, name)
+		if err != nil {
+			r.addError("document %s: %v", document.FileID, err)
+			return false
+		}
+		if exists {
+			return false
+		}
+	}
+
+	drift := model.Drift{
+		Kind:   model.DriftMissingObject,
+		Corpus: document.CorpusName,
+		FileID: document.FileID,
+		Target: document.GCSObject,
+		Detail: fmt.Sprintf("%s document has no stored file", status),
+	}
+	if status == model.DocumentStatusReady {
+		// Nothing to repair automatically: the RAG file still serves queries but the file cannot be viewed
+		drift.Detail += "; it stays searchable but has to be uploaded again to be viewed"
+		r.report.AddDrift(drift)
+		return false
+	}
+
+	marked := false
+	if r.policy.Repair[model.DriftMissingObject] {
+		drift.Repair = repairMarkedFailed
+		if err := r.markFailed(ctx, document, "stored file is missing; upload the document again"); err != nil {
+			drift.RepairError = err.Error()
+		} else {
+			marked = true
+		}
+	}
+	r.report.AddDrift(drift)
+	return marked
+}
+
+// checkStaleStatus reports a document stuck in a processing step, which happens when its task was
+// lost. Repairing marks it failed so it can be reprocessed from that step.
+func (r *reconciler) checkStaleStatus(ctx context.Context, document *model.Document) {
+	status := document.Status.Normalize()
+	if status != model.DocumentStatusUploaded && !status.IsProcessingStep() {
+		return
+	}
+	idle := r.now.Sub(document.UpdatedAt)
+	if idle < r.policy.StaleAfter {
+		return
+	}
+
+	drift := model.Drift{
+		Kind:   model.DriftStaleStatus,
+		Corpus: document.CorpusName,
+		FileID: document.FileID,
+		Detail: fmt.Sprintf("%s for %s without progress", status, idle.Round(time.Minute)),
+	}
+	if r.policy.Repair[model.DriftStaleStatus] {
+		drift.Repair = repairMarkedFailed
+		if err := r.markFailed(ctx, document, fmt.Sprintf("no progress in %s step since %s", status, document.UpdatedAt.UTC().Format(time.RFC3339))); err != nil {
+			drift.RepairError = err.Error()
+		}
+	}
+	r.report.AddDrift(drift)
+}
+
+// checkRAGFiles reports RAG files of a corpus no document refers to. A file matching a document
+// without RAG file ID is linked to it when repaired; any other one is deleted.
+func (r *reconciler) checkRAGFiles(ctx context.Context, corpus string, documents []model.Document) {
+	files, err := r.vertexAI.ListRAGFiles(ctx, corpus)
+	if errors.Is(err, service.ErrCorpusNotFound) {
+		if len(documents) > 0 {
+			r.addError("corpus %s: not found in Vertex AI", corpus)
+		}
+		return
+	}
+	if err != nil {
+		r.addError("corpus %s: failed to list RAG files: %v", corpus, err)
+		return
+	}
+
+	known := make(map[string]bool, len(documents))
+	for _, document := range documents {
+		if document.RAGFileID != "" {
+			known[document.RAGFileID] = true
+		}
+	}
+
+	for _, file := range files {
+		// Imports record the RAG file ID once the operation completes
+		if known[file.ID] || r.now.Sub(file.CreateTime) < r.policy.GracePeriod {
+			continue
+		}
+
+		drift := model.Drift{
+			Kind:   model.DriftOrphanedRAGFile,
+			Corpus: corpus,
+			Target: file.ID,
+			Detail: fmt.Sprintf("RAG file %q is not referenced by any document", file.DisplayName),
+		}
+
+		var document *model.Document
+		for i := range documents {
+			if documents[i].RAGFileID == "" && documents[i].MatchesRAGFile(file.DisplayName) {
+				document = &documents[i]
+				break
+			}
+		}
+		if document != nil {
+			drift.FileID = document.FileID
+			drift.Detail = fmt.Sprintf("RAG file %q matches document %s, which has no RAG file ID", file.DisplayName, document.FileID)
+		}
+
+		if r.policy.Repair[model.DriftOrphanedRAGFile] {
+			if document != nil {
+				drift.Repair = repairLinked
+				if err := r.docRepo.UpdateFields(ctx, document.FileID, bson.M{"ragFileId": file.ID}); err != nil {
+					drift.RepairError = err.Error()
+				} else {
+					document.RAGFileID = file.ID
+				}
+			} else {
+				drift.Repair = repairDeleted
+				if err := r.vertexAI.DeleteRAGFile(ctx, corpus, file.ID); err != nil {
+					drift.RepairError = err.Error()
+				}
+			}
+		}
+		r.report.AddDrift(drift)
+	}
+}
+
+// checkOrphanedObjects reports document files in storage no document refers to. Repairing deletes them.
+func (r *reconciler) checkOrphanedObjects(ctx context.Context) {
+	if r.objects == nil {
+		return
+	}
+
+	names := make([]string, 0, len(r.objects))
+	for name := range r.objects {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		object := r.objects[name]
+		// Uploads store the file before creating the document
+		if r.referenced[name] || r.now.Sub(object.Created) < r.policy.GracePeriod {
+			continue
+		}
+
+		drift := model.Drift{
+			Kind:   model.DriftOrphanedObject,
+			Target: name,
+			Detail: fmt.Sprintf("%d bytes stored %s, not referenced by any document", object.Size, object.Created.UTC().Format(time.RFC3339)),
+		}
+		if r.policy.Repair[model.DriftOrphanedObject] {
+			drift.Repair = repairDeleted
+			if err := r.gcs.DeleteObject(ctx, name); err != nil {
+				drift.RepairError = err.Error()
+			}
+		}
+		r.report.AddDrift(drift)
+	}
+}
+
+// objectExists looks a document file up in the listing, asking storage for objects outside the listed prefixes
+func (r *reconciler) objectExists(ctx context.Context, name string) (bool, error) {
+	if name == "" {
+		return false, nil
+	}
+	for _, prefix := range documentObjectPrefixes {
+		if strings.HasPrefix(name, prefix) {
+			_, ok := r.objects[name]
+			return ok, nil
+		}
+	}
+	return r.gcs.ObjectExists(ctx, name)
+}
+
+// markFailed moves a document to failed at the step it should resume from and notifies its listeners
+func (r *reconciler) markFailed(ctx context.Context, document *model.Document, message string) error {
+	step := document.ResumeStep()
+	if err := r.docRepo.MarkFailed(ctx, document.FileID, step, message); err != nil {
+		return err
+	}
+	PublishDocumentStatus(ctx, document, model.DocumentStatusFailed, step, message)
+	return nil
+}
+
+// addError records a check that could not run
+func (r *reconciler) addError(format string, args ...interface{}) {
+	message := fmt.Sprintf(format, args...)
+	log.Printf("ERROR: Reconciliation: %s", message)
+	r.report.Errors = append(r.report.Errors, message)
+}
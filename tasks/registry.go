@@ -0,0 +1,234 @@
+package tasks
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"sync"
+	"time"
+
+	"lumenslate/internal/metrics"
+	"lumenslate/internal/model"
+	"lumenslate/internal/service"
+	"lumenslate/internal/utils"
+
+	"github.com/hibiken/asynq"
+)
+
+// TaskDefinition describes a task type: the handler processing it and the options every task
+// of the type is enqueued with
+type TaskDefinition struct {
+	Handler  asynq.HandlerFunc
+	Queue    string
+	MaxRetry int
+	Timeout  time.Duration
+	// TaskID derives the task ID from the payload, so the same work is queued at most once at a
+	// time; when nil asynq assigns a random ID
+	TaskID func(payload []byte) (string, error)
+	// Unique keeps a task of the type with the same payload from being enqueued again for this long
+	Unique time.Duration
+	// OnArchived cleans up after a task whose retries are exhausted and returns the actions taken,
+	// which are recorded on its dead letter
+	OnArchived func(ctx context.Context, payload []byte, err error) []model.DeadLetterCleanupAction
+}
+
+// definitions maps every task type to its definition. Web and worker processes share it, so a task
+// enqueued by one is always known to the other. It is filled in init because handlers enqueue
+// follow-up tasks through it.
+var definitions map[string]TaskDefinition
+
+func init() {
+	definitions = map[string]TaskDefinition{
+		TypeAddDocumentToCorpus: {
+			Handler:  HandleAddDocumentToCorpusTask,
+			Queue:    service.QueueDefault,
+			MaxRetry: 3,
+			Timeout:  5 * time.Minute, // RAG processing
+			TaskID: taskIDFrom(func(p DocumentTaskPayload) string {
+				return DocumentTaskID(p.FileID, p.Attempt)
+			}),
+			OnArchived: cleanupArchivedIngestion,
+		},
+		TypeCheckRAGOperation: {
+			Handler:  HandleCheckRAGOperationTask,
+			Queue:    service.QueueDefault,
+			MaxRetry: 5,
+			Timeout:  5 * time.Minute, // Covers the remaining steps once the operation is done
+			TaskID: taskIDFrom(func(p RAGOperationCheckPayload) string {
+				return RAGOperationCheckTaskID(p.Document.FileID, p.Attempt, p.Check)
+			}),
+			OnArchived: cleanupArchivedRAGOperationCheck,
+		},
+		TypeDeleteCorpus: {
+			Handler:  HandleDeleteCorpusTask,
+			Queue:    service.QueueLow,
+			MaxRetry: 3,
+			Timeout:  15 * time.Minute, // Large corpora hold many GCS objects
+			TaskID: taskIDFrom(func(p DeleteCorpusPayload) string {
+				return DeleteCorpusTaskID(p.CorpusName)
+			}),
+		},
+		TypeAuthorQuestion: {
+			Handler:  HandleAuthorQuestionTask,
+			Queue:    service.QueueCritical, // The teacher is waiting for the draft
+			MaxRetry: 3,
+			Timeout:  5 * time.Minute, // Three AI calls, each with its own RPC deadline
+			TaskID: taskIDFrom(func(p AuthorQuestionPayload) string {
+				return AuthorQuestionTaskID(p.AuthoringID)
+			}),
+		},
+		TypeReconcileStorage: {
+			Handler:  HandleReconcileStorageTask,
+			Queue:    service.QueueLow,
+			MaxRetry: 1, // The next scheduled run catches up anyway
+			Timeout:  30 * time.Minute,
+			Unique:   time.Hour, // Keeps runs with the same options from overlapping; scheduled runs are deduplicated per period by the scheduler
+		},
+	}
+}
+
+// taskIDFrom adapts a task ID derived from a typed payload to TaskDefinition.TaskID
+func taskIDFrom[P any](taskID func(P) string) func([]byte) (string, error) {
+	return func(payload []byte) (string, error) {
+		var p P
+		if err := json.Unmarshal(payload, &p); err != nil {
+			return "", fmt.Errorf("failed to decode payload: %w", err)
+		}
+		return taskID(p), nil
+	}
+}
+
+// RegisterHandlers registers the handler of every task type with server, and the dead-letter
+// handling of tasks whose retries are exhausted
+func RegisterHandlers(server *service.AsynqServer) error {
+	for taskType, definition := range definitions {
+		if err := server.RegisterTaskHandler(taskType, observeTask(definition.Handler)); err != nil {
+			return fmt.Errorf("failed to register handler for %s: %w", taskType, err)
+		}
+	}
+	server.OnArchived(HandleArchivedTask)
+	return nil
+}
+
+// RegisterPeriodicTasks schedules the periodic tasks with scheduler. The reconciliation runs on
+// RECONCILE_SCHEDULE and is not scheduled when it is "off".
+func RegisterPeriodicTasks(scheduler *service.AsynqScheduler) error {
+	schedule := ReconcileSchedule()
+	if schedule == "" {
+		log.Printf("INFO: Periodic reconciliation disabled by RECONCILE_SCHEDULE")
+		return nil
+	}
+
+	return scheduler.Register(schedule, func(time.Time) (*asynq.Task, error) {
+		return NewReconcileStorageTask(ReconcilePayload{})
+	})
+}
+
+// observeTask records the processing time and outcome of every run of handler
+func observeTask(handler asynq.HandlerFunc) asynq.HandlerFunc {
+	return func(ctx context.Context, t *asynq.Task) error {
+		start := time.Now()
+		err := handler(ctx, t)
+		queue, _ := asynq.GetQueueName(ctx)
+		metrics.ObserveTask(t.Type(), queue, err == nil, time.Since(start))
+		return err
+	}
+}
+
+// newTask creates a task of a registered type with the options of its definition
+func newTask(taskType string, payload interface{}) (*asynq.Task, error) {
+	definition, ok := definitions[taskType]
+	if !ok {
+		return nil, fmt.Errorf("unknown task type %s", taskType)
+	}
+
+	payloadBytes, err := json.Marshal(payload)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal %s payload: %w", taskType, err)
+	}
+
+	opts := []asynq.Option{
+		asynq.Queue(definition.Queue),
+		asynq.MaxRetry(definition.MaxRetry),
+		asynq.Timeout(definition.Timeout),
+	}
+	if definition.TaskID != nil {
+		taskID, err := definition.TaskID(payloadBytes)
+		if err != nil {
+			return nil, fmt.Errorf("failed to derive %s task ID: %w", taskType, err)
+		}
+		opts = append(opts, asynq.TaskID(taskID))
+	}
+	if definition.Unique > 0 {
+		opts = append(opts, asynq.Unique(definition.Unique))
+	}
+
+	return asynq.NewTask(taskType, payloadBytes, opts...), nil
+}
+
+// Enqueue logs and enqueues a task created by one of the New*Task functions, counting the outcome.
+// subject identifies what the task works on (file ID, corpus, draft) in the logs. When a task with
+// the same ID is already queued nothing is enqueued and the ID of the queued task is returned.
+func Enqueue(ctx context.Context, task *asynq.Task, subject string, metadata map[string]string, opts ...asynq.Option) (string, error) {
+	definition, ok := definitions[task.Type()]
+	if !ok {
+		return "", fmt.Errorf("unknown task type %s", task.Type())
+	}
+
+	utils.LogTaskEnqueue(ctx, task.Type(), subject, metadata)
+
+	info, err := enqueueClient().EnqueueContext(ctx, task, opts...)
+	if errors.Is(err, asynq.ErrTaskIDConflict) && definition.TaskID != nil {
+		metrics.RecordTaskEnqueue(task.Type(), definition.Queue, metrics.EnqueueDuplicate)
+		taskID, _ := definition.TaskID(task.Payload())
+		log.Printf("INFO: Task %s is already queued, not enqueuing it again", taskID)
+		return taskID, nil
+	}
+	if errors.Is(err, asynq.ErrDuplicateTask) {
+		metrics.RecordTaskEnqueue(task.Type(), definition.Queue, metrics.EnqueueDuplicate)
+		return "", fmt.Errorf("failed to enqueue %s task: %w", task.Type(), err)
+	}
+	if err != nil {
+		metrics.RecordTaskEnqueue(task.Type(), definition.Queue, metrics.EnqueueFailed)
+		return "", fmt.Errorf("failed to enqueue %s task: %w", task.Type(), err)
+	}
+
+	metrics.RecordTaskEnqueue(task.Type(), info.Queue, metrics.EnqueueEnqueued)
+	return info.ID, nil
+}
+
+var (
+	clientMu sync.Mutex
+	client   *asynq.Client
+)
+
+// enqueueClient returns the client shared by every enqueue, connecting on first use
+func enqueueClient() *asynq.Client {
+	clientMu.Lock()
+	defer clientMu.Unlock()
+
+	if client == nil {
+		redisAddr := os.Getenv("REDIS_ADDR")
+		if redisAddr == "" {
+			redisAddr = "localhost:6379" // Default Redis address
+		}
+		client = asynq.NewClient(asynq.RedisClientOpt{Addr: redisAddr})
+	}
+	return client
+}
+
+// CloseClient closes the client shared by enqueues, if one was opened
+func CloseClient() error {
+	clientMu.Lock()
+	defer clientMu.Unlock()
+
+	if client == nil {
+		return nil
+	}
+	err := client.Close()
+	client = nil
+	return err
+}